@@ -7,6 +7,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	batchinformers "k8s.io/client-go/informers/batch/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
@@ -60,6 +61,7 @@ type Controller struct {
 // ControllerConfig describes the data required to instantiate a new analysis controller
 type ControllerConfig struct {
 	KubeClientSet        kubernetes.Interface
+	DynamicClientSet     dynamic.Interface
 	ArgoProjClientset    clientset.Interface
 	AnalysisRunInformer  informers.AnalysisRunInformer
 	JobInformer          batchinformers.JobInformer
@@ -92,8 +94,9 @@ func NewController(cfg ControllerConfig) *Controller {
 	}
 
 	providerFactory := metricproviders.ProviderFactory{
-		KubeClient: controller.kubeclientset,
-		JobLister:  cfg.JobInformer.Lister(),
+		KubeClient:    controller.kubeclientset,
+		DynamicClient: cfg.DynamicClientSet,
+		JobLister:     cfg.JobInformer.Lister(),
 	}
 	controller.newProvider = providerFactory.NewProvider
 
@@ -11,6 +11,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	kubeinformers "k8s.io/client-go/informers"
@@ -24,13 +25,21 @@ import (
 	"github.com/argoproj/argo-rollouts/controller"
 	"github.com/argoproj/argo-rollouts/controller/metrics"
 	jobprovider "github.com/argoproj/argo-rollouts/metricproviders/job"
+	metricplugin "github.com/argoproj/argo-rollouts/metricproviders/plugin"
+	"github.com/argoproj/argo-rollouts/notifications"
 	clientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned"
 	"github.com/argoproj/argo-rollouts/pkg/signals"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/alb"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/ambassador"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/gatewayapi"
+	trafficrouterplugin "github.com/argoproj/argo-rollouts/rollout/trafficrouting/plugin"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
 	controllerutil "github.com/argoproj/argo-rollouts/utils/controller"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
 	istioutil "github.com/argoproj/argo-rollouts/utils/istio"
 	kubeclientmetrics "github.com/argoproj/argo-rollouts/utils/kubeclientmetrics"
 	"github.com/argoproj/argo-rollouts/utils/tolerantinformer"
+	"github.com/argoproj/argo-rollouts/webhook"
 )
 
 const (
@@ -38,27 +47,45 @@ const (
 	cliName                    = "argo-rollouts"
 	defaultIstioVersion        = "v1alpha3"
 	defaultTrafficSplitVersion = "v1alpha1"
+	defaultAmbassadorVersion   = "v2"
+	defaultGatewayAPIVersion   = "v1beta1"
 )
 
+// webhookOptions configures the validating admission webhook
+type webhookOptions struct {
+	enabled           bool
+	port              int
+	serviceName       string
+	secretName        string
+	configurationName string
+}
+
 func newCommand() *cobra.Command {
 	var (
-		clientConfig        clientcmd.ClientConfig
-		rolloutResyncPeriod int64
-		logLevel            string
-		glogLevel           int
-		metricsPort         int
-		instanceID          string
-		rolloutThreads      int
-		experimentThreads   int
-		analysisThreads     int
-		serviceThreads      int
-		ingressThreads      int
-		istioVersion        string
-		trafficSplitVersion string
-		albIngressClasses   []string
-		nginxIngressClasses []string
-		albVerifyWeight     bool
-		namespaced          bool
+		clientConfig         clientcmd.ClientConfig
+		rolloutResyncPeriod  int64
+		logLevel             string
+		glogLevel            int
+		metricsPort          int
+		instanceID           string
+		rolloutThreads       int
+		experimentThreads    int
+		analysisThreads      int
+		serviceThreads       int
+		ingressThreads       int
+		istioVersion         string
+		trafficSplitVersion  string
+		albIngressClasses    []string
+		nginxIngressClasses  []string
+		albVerifyWeight      bool
+		ambassadorVersion    string
+		gatewayAPIVersion    string
+		trafficRouterPlugins []string
+		metricPlugins        []string
+		metricResources      []string
+		namespaced           bool
+		electOpts            = controller.NewLeaderElectionOptions()
+		webhookOpts          = webhookOptions{}
 	)
 	var command = cobra.Command{
 		Use:   cliName,
@@ -75,6 +102,11 @@ func newCommand() *cobra.Command {
 			stopCh := signals.SetupSignalHandler()
 
 			alb.SetDefaultVerifyWeight(albVerifyWeight)
+			ambassador.SetDefaultAPIVersion(ambassadorVersion)
+			gatewayapi.SetDefaultAPIVersion(gatewayAPIVersion)
+			checkError(trafficrouterplugin.RegisterPlugins(trafficRouterPlugins))
+			checkError(metricplugin.RegisterPlugins(metricPlugins))
+			analysisutil.SetAllowedMetricResources(metricResources)
 
 			config, err := clientConfig.ClientConfig()
 			checkError(err)
@@ -85,6 +117,9 @@ func newCommand() *cobra.Command {
 				namespace = configNS
 				log.Infof("Using namespace %s", namespace)
 			}
+			if electOpts.LeaderElectionNamespace == "" {
+				electOpts.LeaderElectionNamespace = configNS
+			}
 			k8sRequestProvider := &metrics.K8sRequestsCountProvider{}
 			kubeclientmetrics.AddMetricsTransportWrapper(config, k8sRequestProvider.IncKubernetesRequest)
 
@@ -111,6 +146,14 @@ func newCommand() *cobra.Command {
 				kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
 					options.LabelSelector = jobprovider.AnalysisRunUIDLabelKey
 				}))
+			// the notification ConfigMap is in the controller namespace, which may not be managed
+			notificationInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(
+				kubeClient,
+				resyncDuration,
+				kubeinformers.WithNamespace(defaults.Namespace()),
+				kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+					options.FieldSelector = fields.OneTermEqualSelector("metadata.name", notifications.NotificationConfigMapName).String()
+				}))
 			istioGVR := istioutil.GetIstioGVR(istioVersion)
 			// We need three dynamic informer factories:
 			// 1. The first is the dynamic informer for rollouts, analysisruns, analysistemplates, experiments
@@ -123,6 +166,8 @@ func newCommand() *cobra.Command {
 			// 3. We finally need an istio dynamic informer factory which uses different resync
 			// period and does not use a tweakListFunc.
 			istioDynamicInformerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicClient, 0, namespace, nil)
+			analysisTemplateInformer := tolerantinformer.NewTolerantAnalysisTemplateInformer(dynamicInformerFactory)
+			clusterAnalysisTemplateInformer := tolerantinformer.NewTolerantClusterAnalysisTemplateInformer(clusterDynamicInformerFactory)
 			cm := controller.NewManager(
 				namespace,
 				kubeClient,
@@ -132,12 +177,15 @@ func newCommand() *cobra.Command {
 				kubeInformerFactory.Apps().V1().ReplicaSets(),
 				kubeInformerFactory.Core().V1().Services(),
 				kubeInformerFactory.Extensions().V1beta1().Ingresses(),
+				kubeInformerFactory.Apps().V1().Deployments(),
+				kubeInformerFactory.Core().V1().PodTemplates(),
+				notificationInformerFactory.Core().V1().ConfigMaps(),
 				jobInformerFactory.Batch().V1().Jobs(),
 				tolerantinformer.NewTolerantRolloutInformer(dynamicInformerFactory),
 				tolerantinformer.NewTolerantExperimentInformer(dynamicInformerFactory),
 				tolerantinformer.NewTolerantAnalysisRunInformer(dynamicInformerFactory),
-				tolerantinformer.NewTolerantAnalysisTemplateInformer(dynamicInformerFactory),
-				tolerantinformer.NewTolerantClusterAnalysisTemplateInformer(clusterDynamicInformerFactory),
+				analysisTemplateInformer,
+				clusterAnalysisTemplateInformer,
 				istioDynamicInformerFactory.ForResource(istioGVR).Informer(),
 				resyncDuration,
 				instanceID,
@@ -147,6 +195,30 @@ func newCommand() *cobra.Command {
 				trafficSplitVersion,
 				nginxIngressClasses,
 				albIngressClasses)
+			if webhookOpts.enabled {
+				cert, err := webhook.EnsureCertificate(webhook.CertificateConfig{
+					KubeClientSet:            kubeClient,
+					Namespace:                defaults.Namespace(),
+					SecretName:               webhookOpts.secretName,
+					ServiceName:              webhookOpts.serviceName,
+					WebhookConfigurationName: webhookOpts.configurationName,
+				})
+				checkError(err)
+				webhookServer := webhook.NewServer(webhook.ServerConfig{
+					Addr:                          fmt.Sprintf("0.0.0.0:%d", webhookOpts.port),
+					Certificate:                   cert,
+					DynamicClientSet:              dynamicClient,
+					ServicesLister:                kubeInformerFactory.Core().V1().Services().Lister(),
+					IngressesLister:               kubeInformerFactory.Extensions().V1beta1().Ingresses().Lister(),
+					AnalysisTemplateLister:        analysisTemplateInformer.Lister(),
+					ClusterAnalysisTemplateLister: clusterAnalysisTemplateInformer.Lister(),
+					DefaultIstioVersion:           istioVersion,
+				})
+				go func() {
+					log.Infof("Starting Validating Webhook at %s", webhookServer.Addr)
+					checkError(webhookServer.ListenAndServe())
+				}()
+			}
 			// notice that there is no need to run Start methods in a separate goroutine. (i.e. go kubeInformerFactory.Start(stopCh)
 			// Start method is non-blocking and runs all registered informers in a dedicated goroutine.
 			dynamicInformerFactory.Start(stopCh)
@@ -155,13 +227,14 @@ func newCommand() *cobra.Command {
 			}
 			kubeInformerFactory.Start(stopCh)
 			jobInformerFactory.Start(stopCh)
+			notificationInformerFactory.Start(stopCh)
 
 			// Check if Istio installed on cluster before starting dynamicInformerFactory
 			if istioutil.DoesIstioExist(dynamicClient, namespace, istioVersion) {
 				istioDynamicInformerFactory.Start(stopCh)
 			}
 
-			if err = cm.Run(rolloutThreads, serviceThreads, ingressThreads, experimentThreads, analysisThreads, stopCh); err != nil {
+			if err = cm.Run(rolloutThreads, serviceThreads, ingressThreads, experimentThreads, analysisThreads, electOpts, stopCh); err != nil {
 				log.Fatalf("Error running controller: %s", err.Error())
 			}
 			return nil
@@ -188,6 +261,21 @@ func newCommand() *cobra.Command {
 	command.Flags().StringArrayVar(&albIngressClasses, "alb-ingress-classes", defaultALBIngressClass, "Defines all the ingress class annotations that the alb ingress controller operates on. Defaults to alb")
 	command.Flags().StringArrayVar(&nginxIngressClasses, "nginx-ingress-classes", defaultNGINXIngressClass, "Defines all the ingress class annotations that the nginx ingress controller operates on. Defaults to nginx")
 	command.Flags().BoolVar(&albVerifyWeight, "alb-verify-weight", false, "Verify ALB target group weights before progressing through steps (requires AWS privileges)")
+	command.Flags().StringVar(&ambassadorVersion, "ambassador-api-version", defaultAmbassadorVersion, "Set the getambassador.io apiVersion (v2 or v3alpha1) that controller uses when managing Ambassador Mappings.")
+	command.Flags().StringVar(&gatewayAPIVersion, "gateway-api-version", defaultGatewayAPIVersion, "Set the gateway.networking.k8s.io apiVersion that controller uses when managing Gateway API HTTPRoutes.")
+	command.Flags().StringArrayVar(&trafficRouterPlugins, "trafficrouter-plugin", nil, "Registers a traffic router plugin as name=address, where the address is host:port or unix:///path/to/socket. Can be repeated")
+	command.Flags().StringArrayVar(&metricPlugins, "metric-plugin", nil, "Registers a metric provider plugin as name=address, where the address is host:port or unix:///path/to/socket. Can be repeated")
+	command.Flags().StringArrayVar(&metricResources, "metric-resource", nil, "Allows resource metrics to create objects of the resource, as resource.group, e.g. workflows.argoproj.io. Can be repeated. No resource is allowed by default")
+	command.Flags().BoolVar(&webhookOpts.enabled, "validating-webhook", false, "Serve a validating admission webhook which rejects invalid Rollouts, AnalysisTemplates and Experiments")
+	command.Flags().IntVar(&webhookOpts.port, "webhook-port", webhook.DefaultPort, "Set the port the validating webhook should be served over")
+	command.Flags().StringVar(&webhookOpts.serviceName, "webhook-service-name", webhook.DefaultServiceName, "Name of the Service in front of the validating webhook, used for its serving certificate")
+	command.Flags().StringVar(&webhookOpts.secretName, "webhook-secret-name", webhook.DefaultSecretName, "Name of the Secret the self-signed certificates of the validating webhook are stored in")
+	command.Flags().StringVar(&webhookOpts.configurationName, "webhook-configuration-name", webhook.DefaultWebhookConfigurationName, "Name of the ValidatingWebhookConfiguration whose CA bundle is kept up to date")
+	command.Flags().BoolVar(&electOpts.LeaderElect, "leader-elect", controller.DefaultLeaderElect, "If true, controller will perform leader election between instances to ensure no more than one instance of controller operates at a time")
+	command.Flags().StringVar(&electOpts.LeaderElectionNamespace, "leader-election-namespace", "", "Namespace of the Lease used for leader election. Defaults to the namespace the controller runs in")
+	command.Flags().DurationVar(&electOpts.LeaderElectionLeaseDuration, "leader-election-lease-duration", controller.DefaultLeaderElectionLeaseDuration, "The duration that non-leader candidates will wait after observing a leadership renewal until attempting to acquire leadership of a led but unrenewed leader slot. This is effectively the maximum duration that a leader can be stopped before it is replaced by another candidate")
+	command.Flags().DurationVar(&electOpts.LeaderElectionRenewDeadline, "leader-election-renew-deadline", controller.DefaultLeaderElectionRenewDeadline, "The interval between attempts by the acting master to renew a leadership slot before it stops leading. This must be less than or equal to the lease duration")
+	command.Flags().DurationVar(&electOpts.LeaderElectionRetryPeriod, "leader-election-retry-period", controller.DefaultLeaderElectionRetryPeriod, "The duration the clients should wait between attempting acquisition and renewal of a leadership")
 	return &command
 }
 
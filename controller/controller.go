@@ -1,7 +1,10 @@
 package controller
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"os"
 	"time"
 
 	"github.com/pkg/errors"
@@ -11,6 +14,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	appsinformers "k8s.io/client-go/informers/apps/v1"
@@ -21,6 +25,8 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 
@@ -28,11 +34,13 @@ import (
 	"github.com/argoproj/argo-rollouts/controller/metrics"
 	"github.com/argoproj/argo-rollouts/experiments"
 	"github.com/argoproj/argo-rollouts/ingress"
+	"github.com/argoproj/argo-rollouts/notifications"
 	clientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned"
 	rolloutscheme "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/scheme"
 	informers "github.com/argoproj/argo-rollouts/pkg/client/informers/externalversions/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/rollout"
 	"github.com/argoproj/argo-rollouts/service"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
 	istioutil "github.com/argoproj/argo-rollouts/utils/istio"
 )
 
@@ -59,8 +67,47 @@ const (
 
 	// DefaultIngressThreads is the default number of ingress worker threads to start with the controller
 	DefaultIngressThreads = 10
+
+	// DefaultLeaderElect is whether leader election is enabled by default
+	DefaultLeaderElect = true
+
+	// DefaultLeaderElectionLeaseDuration is the default duration that non-leader candidates will wait to force acquire leadership
+	DefaultLeaderElectionLeaseDuration = 15 * time.Second
+
+	// DefaultLeaderElectionRenewDeadline is the default duration that the acting leader will retry refreshing leadership before giving up
+	DefaultLeaderElectionRenewDeadline = 10 * time.Second
+
+	// DefaultLeaderElectionRetryPeriod is the default duration the leader election clients should wait between tries of actions
+	DefaultLeaderElectionRetryPeriod = 2 * time.Second
+
+	// defaultLeaderElectionLeaseLockName is the name of the Lease used to elect the active controller
+	defaultLeaderElectionLeaseLockName = "argo-rollouts-controller-lock"
+
+	// leaderElectionHealthzTimeout is how long the leader may fail to renew its lease, beyond the lease
+	// duration, before the /healthz endpoint starts failing
+	leaderElectionHealthzTimeout = 20 * time.Second
 )
 
+// LeaderElectionOptions configures how the controller elects the replica which runs the controllers
+type LeaderElectionOptions struct {
+	LeaderElect                 bool
+	LeaderElectionNamespace     string
+	LeaderElectionLeaseDuration time.Duration
+	LeaderElectionRenewDeadline time.Duration
+	LeaderElectionRetryPeriod   time.Duration
+}
+
+// NewLeaderElectionOptions returns the default leader election options
+func NewLeaderElectionOptions() *LeaderElectionOptions {
+	return &LeaderElectionOptions{
+		LeaderElect:                 DefaultLeaderElect,
+		LeaderElectionNamespace:     "",
+		LeaderElectionLeaseDuration: DefaultLeaderElectionLeaseDuration,
+		LeaderElectionRenewDeadline: DefaultLeaderElectionRenewDeadline,
+		LeaderElectionRetryPeriod:   DefaultLeaderElectionRetryPeriod,
+	}
+}
+
 // Manager is the controller implementation for Argo-Rollout resources
 type Manager struct {
 	metricsServer        *metrics.MetricsServer
@@ -69,6 +116,7 @@ type Manager struct {
 	analysisController   *analysis.Controller
 	serviceController    *service.Controller
 	ingressController    *ingress.Controller
+	notifier             *notifications.WebhookNotifier
 
 	rolloutSynced                 cache.InformerSynced
 	experimentSynced              cache.InformerSynced
@@ -79,6 +127,9 @@ type Manager struct {
 	ingressSynced                 cache.InformerSynced
 	jobSynced                     cache.InformerSynced
 	replicasSetSynced             cache.InformerSynced
+	deploymentSynced              cache.InformerSynced
+	podTemplateSynced             cache.InformerSynced
+	configMapSynced               cache.InformerSynced
 	istioVirtualServiceSynced     cache.InformerSynced
 
 	rolloutWorkqueue     workqueue.RateLimitingInterface
@@ -90,8 +141,11 @@ type Manager struct {
 	defaultIstioVersion        string
 	defaultTrafficSplitVersion string
 
+	kubeClientSet    kubernetes.Interface
 	dynamicClientSet dynamic.Interface
 
+	leaderElectionHealthz *leaderelection.HealthzAdaptor
+
 	namespace string
 }
 
@@ -105,6 +159,9 @@ func NewManager(
 	replicaSetInformer appsinformers.ReplicaSetInformer,
 	servicesInformer coreinformers.ServiceInformer,
 	ingressesInformer extensionsinformers.IngressInformer,
+	deploymentInformer appsinformers.DeploymentInformer,
+	podTemplateInformer coreinformers.PodTemplateInformer,
+	configMapInformer coreinformers.ConfigMapInformer,
 	jobInformer batchinformers.JobInformer,
 	rolloutsInformer informers.RolloutInformer,
 	experimentsInformer informers.ExperimentInformer,
@@ -132,6 +189,23 @@ func NewManager(
 	eventBroadcaster.StartLogging(log.Infof)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
+
+	// The leader election health check only starts failing once leader election is running and the
+	// lease could not be renewed, so it is safe to register it even when leader election is disabled
+	leaderElectionHealthz := leaderelection.NewLeaderHealthzAdaptor(leaderElectionHealthzTimeout)
+	cachesSynced := []cache.InformerSynced{
+		servicesInformer.Informer().HasSynced,
+		ingressesInformer.Informer().HasSynced,
+		jobInformer.Informer().HasSynced,
+		rolloutsInformer.Informer().HasSynced,
+		experimentsInformer.Informer().HasSynced,
+		analysisRunInformer.Informer().HasSynced,
+		analysisTemplateInformer.Informer().HasSynced,
+		replicaSetInformer.Informer().HasSynced,
+		deploymentInformer.Informer().HasSynced,
+		podTemplateInformer.Informer().HasSynced,
+		configMapInformer.Informer().HasSynced,
+	}
 	metricsAddr := fmt.Sprintf("0.0.0.0:%d", metricsPort)
 	metricsServer := metrics.NewMetricsServer(metrics.ServerConfig{
 		Addr:               metricsAddr,
@@ -139,6 +213,8 @@ func NewManager(
 		AnalysisRunLister:  analysisRunInformer.Lister(),
 		ExperimentLister:   experimentsInformer.Lister(),
 		K8SRequestProvider: k8sRequestProvider,
+		HealthChecks:       []metrics.HealthChecker{leaderElectionHealthz.Check},
+		ReadyChecks:        []metrics.HealthChecker{informersSyncedCheck(cachesSynced)},
 	})
 
 	rolloutWorkqueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Rollouts")
@@ -147,6 +223,12 @@ func NewManager(
 	serviceWorkqueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Services")
 	ingressWorkqueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Ingresses")
 
+	notifier := notifications.NewNotifier(notifications.NotifierConfig{
+		KubeClientSet:   kubeclientset,
+		ConfigMapLister: configMapInformer.Lister(),
+		Namespace:       defaults.Namespace(),
+	})
+
 	rolloutController := rollout.NewController(rollout.ControllerConfig{
 		Namespace:                       namespace,
 		KubeClientSet:                   kubeclientset,
@@ -161,6 +243,8 @@ func NewManager(
 		ReplicaSetInformer:              replicaSetInformer,
 		ServicesInformer:                servicesInformer,
 		IngressInformer:                 ingressesInformer,
+		DeploymentInformer:              deploymentInformer,
+		PodTemplateInformer:             podTemplateInformer,
 		RolloutsInformer:                rolloutsInformer,
 		ResyncPeriod:                    resyncPeriod,
 		RolloutWorkQueue:                rolloutWorkqueue,
@@ -168,6 +252,7 @@ func NewManager(
 		IngressWorkQueue:                ingressWorkqueue,
 		MetricsServer:                   metricsServer,
 		Recorder:                        recorder,
+		Notifier:                        notifier,
 		DefaultIstioVersion:             defaultIstioVersion,
 		DefaultTrafficSplitVersion:      defaultTrafficSplitVersion,
 	})
@@ -189,6 +274,7 @@ func NewManager(
 
 	analysisController := analysis.NewController(analysis.ControllerConfig{
 		KubeClientSet:        kubeclientset,
+		DynamicClientSet:     dynamicclientset,
 		ArgoProjClientset:    argoprojclientset,
 		AnalysisRunInformer:  analysisRunInformer,
 		JobInformer:          jobInformer,
@@ -234,6 +320,9 @@ func NewManager(
 		analysisTemplateSynced:        analysisTemplateInformer.Informer().HasSynced,
 		clusterAnalysisTemplateSynced: clusterAnalysisTemplateInformer.Informer().HasSynced,
 		replicasSetSynced:             replicaSetInformer.Informer().HasSynced,
+		deploymentSynced:              deploymentInformer.Informer().HasSynced,
+		podTemplateSynced:             podTemplateInformer.Informer().HasSynced,
+		configMapSynced:               configMapInformer.Informer().HasSynced,
 		istioVirtualServiceSynced:     istioVirtualServiceInformer.HasSynced,
 		rolloutWorkqueue:              rolloutWorkqueue,
 		experimentWorkqueue:           experimentWorkqueue,
@@ -245,20 +334,116 @@ func NewManager(
 		ingressController:             ingressController,
 		experimentController:          experimentController,
 		analysisController:            analysisController,
+		notifier:                      notifier,
 		defaultIstioVersion:           defaultIstioVersion,
 		defaultTrafficSplitVersion:    defaultTrafficSplitVersion,
+		kubeClientSet:                 kubeclientset,
 		dynamicClientSet:              dynamicclientset,
+		leaderElectionHealthz:         leaderElectionHealthz,
 		namespace:                     namespace,
 	}
 
 	return cm
 }
 
-// Run will set up the event handlers for types we are interested in, as well
-// as syncing informer caches and starting workers. It will block until stopCh
-// is closed, at which point it will shutdown the workqueue and wait for
-// workers to finish processing their current work items.
-func (c *Manager) Run(rolloutThreadiness, serviceThreadiness, ingressThreadiness, experimentThreadiness, analysisThreadiness int, stopCh <-chan struct{}) error {
+// informersSyncedCheck returns a health check which fails until all the informer caches have synced
+func informersSyncedCheck(cachesSynced []cache.InformerSynced) metrics.HealthChecker {
+	return func(req *http.Request) error {
+		for _, synced := range cachesSynced {
+			if !synced() {
+				return fmt.Errorf("informer caches have not synced")
+			}
+		}
+		return nil
+	}
+}
+
+// Run starts the metrics server and, once this replica is elected leader (or immediately when
+// leader election is disabled), sets up the event handlers for types we are interested in, syncs
+// the informer caches and starts workers. It will block until stopCh is closed, at which point it
+// will shutdown the workqueue and wait for workers to finish processing their current work items.
+func (c *Manager) Run(rolloutThreadiness, serviceThreadiness, ingressThreadiness, experimentThreadiness, analysisThreadiness int, electOpts *LeaderElectionOptions, stopCh <-chan struct{}) error {
+	defer runtime.HandleCrash()
+
+	// The metrics server is started before leader election so that standby replicas also serve
+	// their health endpoints
+	go func() {
+		log.Infof("Starting Metric Server at %s", c.metricsServer.Addr)
+		err := c.metricsServer.ListenAndServe()
+		if err != nil {
+			err = errors.Wrap(err, "Starting Metric Server")
+			log.Fatal(err)
+		}
+	}()
+
+	if !electOpts.LeaderElect {
+		log.Info("Leader election is turned off. Running in single-instance mode")
+		return c.startLeading(rolloutThreadiness, serviceThreadiness, ingressThreadiness, experimentThreadiness, analysisThreadiness, stopCh)
+	}
+
+	id, err := os.Hostname()
+	if err != nil {
+		return errors.Wrap(err, "failed to determine leader election identity")
+	}
+	// add a uniquifier so that two processes on the same host don't accidentally both become active
+	id = id + "_" + string(uuid.NewUUID())
+	log.Infof("Participating in leader election as %s", id)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      defaultLeaderElectionLeaseLockName,
+			Namespace: electOpts.LeaderElectionNamespace,
+		},
+		Client: c.kubeClientSet.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: id,
+		},
+	}
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		ReleaseOnCancel: true,
+		LeaseDuration:   electOpts.LeaderElectionLeaseDuration,
+		RenewDeadline:   electOpts.LeaderElectionRenewDeadline,
+		RetryPeriod:     electOpts.LeaderElectionRetryPeriod,
+		WatchDog:        c.leaderElectionHealthz,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				err := c.startLeading(rolloutThreadiness, serviceThreadiness, ingressThreadiness, experimentThreadiness, analysisThreadiness, ctx.Done())
+				if err != nil {
+					log.Fatalf("Error running controller: %s", err.Error())
+				}
+			},
+			OnStoppedLeading: func() {
+				select {
+				case <-stopCh:
+					log.Info("Stopped leading after receiving shutdown signal")
+				default:
+					// the controllers cannot be stopped and restarted safely, so exit and let the
+					// replica rejoin the election as a standby
+					log.Fatalf("Lost leader election lease %s/%s", electOpts.LeaderElectionNamespace, defaultLeaderElectionLeaseLockName)
+				}
+			},
+			OnNewLeader: func(identity string) {
+				if identity == id {
+					return
+				}
+				log.Infof("New leader elected: %s", identity)
+			},
+		},
+	})
+	return nil
+}
+
+// startLeading syncs the informer caches and starts the workers of every controller. It blocks
+// until stopCh is closed.
+func (c *Manager) startLeading(rolloutThreadiness, serviceThreadiness, ingressThreadiness, experimentThreadiness, analysisThreadiness int, stopCh <-chan struct{}) error {
 	defer runtime.HandleCrash()
 	defer c.serviceWorkqueue.ShutDown()
 	defer c.ingressWorkqueue.ShutDown()
@@ -267,7 +452,7 @@ func (c *Manager) Run(rolloutThreadiness, serviceThreadiness, ingressThreadiness
 	defer c.analysisRunWorkqueue.ShutDown()
 	// Wait for the caches to be synced before starting workers
 	log.Info("Waiting for controller's informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.serviceSynced, c.ingressSynced, c.jobSynced, c.rolloutSynced, c.experimentSynced, c.analysisRunSynced, c.analysisTemplateSynced, c.replicasSetSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, c.serviceSynced, c.ingressSynced, c.jobSynced, c.rolloutSynced, c.experimentSynced, c.analysisRunSynced, c.analysisTemplateSynced, c.replicasSetSynced, c.deploymentSynced, c.podTemplateSynced, c.configMapSynced); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 	// only wait for cluster scoped informers to sync if we are running in cluster-wide mode
@@ -291,16 +476,9 @@ func (c *Manager) Run(rolloutThreadiness, serviceThreadiness, ingressThreadiness
 	go wait.Until(func() { c.ingressController.Run(ingressThreadiness, stopCh) }, time.Second, stopCh)
 	go wait.Until(func() { c.experimentController.Run(experimentThreadiness, stopCh) }, time.Second, stopCh)
 	go wait.Until(func() { c.analysisController.Run(analysisThreadiness, stopCh) }, time.Second, stopCh)
+	go c.notifier.Run(notifications.DefaultWorkers, stopCh)
 	log.Info("Started controller")
 
-	go func() {
-		log.Infof("Starting Metric Server at %s", c.metricsServer.Addr)
-		err := c.metricsServer.ListenAndServe()
-		if err != nil {
-			err = errors.Wrap(err, "Starting Metric Server")
-			log.Fatal(err)
-		}
-	}()
 	<-stopCh
 	log.Info("Shutting down workers")
 
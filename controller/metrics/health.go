@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// HealthzPath is the endpoint used as the liveness check of the controller
+	HealthzPath = "/healthz"
+	// ReadyzPath is the endpoint used as the readiness check of the controller
+	ReadyzPath = "/readyz"
+)
+
+// HealthChecker returns an error when the component it checks is not healthy
+type HealthChecker func(req *http.Request) error
+
+// healthHandler returns a handler which responds with 200 when all the checks pass and 503 otherwise
+func healthHandler(path string, checks []HealthChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, check := range checks {
+			if err := check(r); err != nil {
+				log.Warnf("%s check failed: %v", path, err)
+				http.Error(w, fmt.Sprintf("%s check failed: %v", path, err), http.StatusServiceUnavailable)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	}
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testHealthResponse(t *testing.T, handler http.Handler, path string, expectedCode int) {
+	req, err := http.NewRequest("GET", path, nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, expectedCode, rr.Code)
+}
+
+func newHealthTestServer(healthChecks, readyChecks []HealthChecker) *MetricsServer {
+	return NewMetricsServer(ServerConfig{
+		RolloutLister:      fakeRolloutLister{},
+		ExperimentLister:   fakeExperimentLister{},
+		AnalysisRunLister:  fakeAnalysisRunLister{},
+		K8SRequestProvider: &K8sRequestsCountProvider{},
+		HealthChecks:       healthChecks,
+		ReadyChecks:        readyChecks,
+	})
+}
+
+func TestHealthzNoChecks(t *testing.T) {
+	metricsServ := newHealthTestServer(nil, nil)
+	testHealthResponse(t, metricsServ.Handler, HealthzPath, http.StatusOK)
+	testHealthResponse(t, metricsServ.Handler, ReadyzPath, http.StatusOK)
+}
+
+func TestHealthzFailingCheck(t *testing.T) {
+	passing := func(req *http.Request) error { return nil }
+	failing := func(req *http.Request) error { return fmt.Errorf("leader election lease expired") }
+	metricsServ := newHealthTestServer([]HealthChecker{passing, failing}, []HealthChecker{passing})
+	testHealthResponse(t, metricsServ.Handler, HealthzPath, http.StatusServiceUnavailable)
+	testHealthResponse(t, metricsServ.Handler, ReadyzPath, http.StatusOK)
+}
+
+func TestReadyzFailingCheck(t *testing.T) {
+	synced := false
+	cachesSynced := func(req *http.Request) error {
+		if !synced {
+			return fmt.Errorf("informer caches not synced")
+		}
+		return nil
+	}
+	metricsServ := newHealthTestServer(nil, []HealthChecker{cachesSynced})
+	testHealthResponse(t, metricsServ.Handler, ReadyzPath, http.StatusServiceUnavailable)
+	synced = true
+	testHealthResponse(t, metricsServ.Handler, ReadyzPath, http.StatusOK)
+}
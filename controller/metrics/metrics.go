@@ -48,6 +48,10 @@ type ServerConfig struct {
 	AnalysisRunLister  rolloutlister.AnalysisRunLister
 	ExperimentLister   rolloutlister.ExperimentLister
 	K8SRequestProvider *K8sRequestsCountProvider
+	// HealthChecks are evaluated by the /healthz endpoint
+	HealthChecks []HealthChecker
+	// ReadyChecks are evaluated by the /readyz endpoint
+	ReadyChecks []HealthChecker
 }
 
 // NewMetricsServer returns a new prometheus server which collects rollout metrics
@@ -123,6 +127,8 @@ func NewMetricsServer(cfg ServerConfig) *MetricsServer {
 		// contains process, golang and controller workqueues metrics
 		registry.DefaultGatherer,
 	}, promhttp.HandlerOpts{}))
+	mux.Handle(HealthzPath, healthHandler(HealthzPath, cfg.HealthChecks))
+	mux.Handle(ReadyzPath, healthHandler(ReadyzPath, cfg.ReadyChecks))
 	return &MetricsServer{
 		Server: &http.Server{
 			Addr:    cfg.Addr,
@@ -34,7 +34,7 @@ var crdPaths = map[string]string{
 	"AnalysisRun":             "manifests/crds/analysis-run-crd.yaml",
 }
 
-func removeValidation(un *unstructured.Unstructured, path string) {
+func getSchemaPath(path string) []string {
 	schemaPath := []string{"spec", "validation", "openAPIV3Schema"}
 	for _, part := range strings.Split(path, ".") {
 		if strings.HasSuffix(part, "[]") {
@@ -44,6 +44,11 @@ func removeValidation(un *unstructured.Unstructured, path string) {
 			schemaPath = append(schemaPath, "properties", part)
 		}
 	}
+	return schemaPath
+}
+
+func removeValidation(un *unstructured.Unstructured, path string) {
+	schemaPath := getSchemaPath(path)
 	_, ok, err := unstructured.NestedFieldNoCopy(un.Object, schemaPath...)
 	checkErr(err)
 	if !ok {
@@ -52,6 +57,38 @@ func removeValidation(un *unstructured.Unstructured, path string) {
 	unstructured.RemoveNestedField(un.Object, schemaPath...)
 }
 
+// setNullable allows the field at the path to be null
+func setNullable(un *unstructured.Unstructured, path string) {
+	schemaPath := getSchemaPath(path)
+	_, ok, err := unstructured.NestedFieldNoCopy(un.Object, schemaPath...)
+	checkErr(err)
+	if !ok {
+		panic(fmt.Sprintf("%s not found for kind %s", schemaPath, crdKind(un)))
+	}
+	checkErr(unstructured.SetNestedField(un.Object, true, append(schemaPath, "nullable")...))
+}
+
+// removeRequired removes a field from the required fields of the object at the path
+func removeRequired(un *unstructured.Unstructured, path string, field string) {
+	requiredPath := append(getSchemaPath(path), "required")
+	required, ok, err := unstructured.NestedStringSlice(un.Object, requiredPath...)
+	checkErr(err)
+	if !ok {
+		panic(fmt.Sprintf("%s not found for kind %s", requiredPath, crdKind(un)))
+	}
+	var newRequired []string
+	for _, r := range required {
+		if r != field {
+			newRequired = append(newRequired, r)
+		}
+	}
+	if len(newRequired) == 0 {
+		unstructured.RemoveNestedField(un.Object, requiredPath...)
+		return
+	}
+	checkErr(unstructured.SetNestedStringSlice(un.Object, newRequired, requiredPath...))
+}
+
 func NewCustomResourceDefinition() []*extensionsobj.CustomResourceDefinition {
 	crdYamlBytes, err := exec.Command(
 		"controller-gen",
@@ -236,6 +273,10 @@ func removeK8S118Fields(un *unstructured.Unstructured) {
 		removeValidation(un, "spec.template.spec.initContainers[].resources.requests")
 		removeValidation(un, "spec.template.spec.ephemeralContainers[].resources.limits")
 		removeValidation(un, "spec.template.spec.ephemeralContainers[].resources.requests")
+		// the template of a rollout referencing a workload is empty, it is resolved from the workload.
+		// The empty template is serialized with null containers.
+		removeRequired(un, "spec.template.spec", "containers")
+		setNullable(un, "spec.template.spec.containers")
 		validation, _, _ := unstructured.NestedMap(un.Object, "spec", "validation", "openAPIV3Schema")
 		removeFieldHelper(validation, "x-kubernetes-list-type")
 		removeFieldHelper(validation, "x-kubernetes-list-map-keys")
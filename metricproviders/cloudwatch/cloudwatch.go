@@ -0,0 +1,219 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+)
+
+const (
+	//ProviderType indicates the provider is cloudwatch
+	ProviderType = "CloudWatch"
+	// defaultInterval is the length of the queried time window when the metric does not set one
+	defaultInterval = 5 * time.Minute
+)
+
+// CloudWatchClientAPI is the subset of the CloudWatch API used by the provider, which enables
+// mocking of the API
+type CloudWatchClientAPI interface {
+	GetMetricData(ctx context.Context, params *cloudwatch.GetMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error)
+}
+
+// Provider contains all the required components to run a CloudWatch query
+type Provider struct {
+	api    CloudWatchClientAPI
+	logCtx log.Entry
+}
+
+// metricDataResult is the result of a query, as exposed to the conditions of the metric
+type metricDataResult struct {
+	ID         string      `json:"id"`
+	Label      string      `json:"label"`
+	StatusCode string      `json:"statusCode"`
+	Timestamps []time.Time `json:"timestamps"`
+	Values     []float64   `json:"values"`
+}
+
+// Type indicates provider is a CloudWatch provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run queries CloudWatch for the metric
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	newMeasurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+
+	interval := defaultInterval
+	if metric.Provider.CloudWatch.Interval != "" {
+		expDuration, err := metric.Provider.CloudWatch.Interval.Duration()
+		if err != nil {
+			return metricutil.MarkMeasurementError(newMeasurement, err)
+		}
+		interval = expDuration
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	endTime := startTime.Time
+	input := &cloudwatch.GetMetricDataInput{
+		StartTime:         aws.Time(endTime.Add(-interval)),
+		EndTime:           aws.Time(endTime),
+		MetricDataQueries: toMetricDataQueries(metric.Provider.CloudWatch.MetricDataQueries),
+	}
+	var results []types.MetricDataResult
+	for {
+		response, err := p.api.GetMetricData(ctx, input)
+		if err != nil {
+			return metricutil.MarkMeasurementError(newMeasurement, err)
+		}
+		results = append(results, response.MetricDataResults...)
+		if response.NextToken == nil || *response.NextToken == "" {
+			break
+		}
+		input.NextToken = response.NextToken
+	}
+
+	newValue, newStatus, err := p.processResponse(metric, results)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newMeasurement.Value = newValue
+	newMeasurement.Phase = newStatus
+	finishedTime := metav1.Now()
+	newMeasurement.FinishedAt = &finishedTime
+	return newMeasurement
+}
+
+// Resume should not be used the CloudWatch provider since all the work should occur in the Run method
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("CloudWatch provider should not execute the Resume method")
+	return measurement
+}
+
+// Terminate should not be used the CloudWatch provider since all the work should occur in the Run method
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("CloudWatch provider should not execute the Terminate method")
+	return measurement
+}
+
+// GarbageCollect is a no-op for the CloudWatch provider
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	return nil
+}
+
+// processResponse merges the results of the pages of the response by query id, and evaluates the
+// list of results
+func (p *Provider) processResponse(metric v1alpha1.Metric, response []types.MetricDataResult) (string, v1alpha1.AnalysisPhase, error) {
+	results := []*metricDataResult{}
+	resultsByID := map[string]*metricDataResult{}
+	for _, r := range response {
+		id := aws.ToString(r.Id)
+		result, ok := resultsByID[id]
+		if !ok {
+			result = &metricDataResult{
+				ID:         id,
+				Label:      aws.ToString(r.Label),
+				Timestamps: []time.Time{},
+				Values:     []float64{},
+			}
+			resultsByID[id] = result
+			results = append(results, result)
+		}
+		result.StatusCode = string(r.StatusCode)
+		result.Timestamps = append(result.Timestamps, r.Timestamps...)
+		result.Values = append(result.Values, r.Values...)
+	}
+
+	// the conditions evaluate the results as plain JSON values, so that they refer to the fields
+	// by their JSON names
+	valueBytes, err := json.Marshal(results)
+	if err != nil {
+		return "", v1alpha1.AnalysisPhaseError, err
+	}
+	var result interface{}
+	if err := json.Unmarshal(valueBytes, &result); err != nil {
+		return "", v1alpha1.AnalysisPhaseError, err
+	}
+	newStatus := evaluate.EvaluateResult(result, metric, p.logCtx)
+	return string(valueBytes), newStatus, nil
+}
+
+func toMetricDataQueries(queries []v1alpha1.CloudWatchMetricDataQuery) []types.MetricDataQuery {
+	metricDataQueries := make([]types.MetricDataQuery, 0, len(queries))
+	for _, q := range queries {
+		query := types.MetricDataQuery{
+			Id:         aws.String(q.Id),
+			Expression: q.Expression,
+			Label:      q.Label,
+			Period:     q.Period,
+			ReturnData: q.ReturnData,
+		}
+		if q.MetricStat != nil {
+			dimensions := make([]types.Dimension, 0, len(q.MetricStat.Metric.Dimensions))
+			for _, d := range q.MetricStat.Metric.Dimensions {
+				dimensions = append(dimensions, types.Dimension{
+					Name:  aws.String(d.Name),
+					Value: aws.String(d.Value),
+				})
+			}
+			query.MetricStat = &types.MetricStat{
+				Metric: &types.Metric{
+					Dimensions: dimensions,
+					MetricName: aws.String(q.MetricStat.Metric.MetricName),
+					Namespace:  q.MetricStat.Metric.Namespace,
+				},
+				Period: aws.Int32(q.MetricStat.Period),
+				Stat:   aws.String(q.MetricStat.Stat),
+				Unit:   types.StandardUnit(q.MetricStat.Unit),
+			}
+		}
+		metricDataQueries = append(metricDataQueries, query)
+	}
+	return metricDataQueries
+}
+
+// NewCloudWatchProvider creates a new CloudWatch provider
+func NewCloudWatchProvider(api CloudWatchClientAPI, logCtx log.Entry) *Provider {
+	return &Provider{
+		logCtx: logCtx,
+		api:    api,
+	}
+}
+
+// NewCloudWatchAPI creates a CloudWatch client, whose credentials and region are resolved from the
+// default AWS configuration of the controller. The endpoint of the metric overrides the one of the
+// region.
+func NewCloudWatchAPI(metric v1alpha1.Metric) (CloudWatchClientAPI, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	var optFns []func(*cloudwatch.Options)
+	if endpoint := metric.Provider.CloudWatch.Endpoint; endpoint != "" {
+		optFns = append(optFns, func(o *cloudwatch.Options) {
+			o.EndpointResolver = cloudwatch.EndpointResolverFunc(func(region string, options cloudwatch.EndpointResolverOptions) (aws.Endpoint, error) {
+				return aws.Endpoint{
+					URL:               endpoint,
+					SigningRegion:     region,
+					HostnameImmutable: true,
+				}, nil
+			})
+		})
+	}
+	return cloudwatch.NewFromConfig(cfg, optFns...), nil
+}
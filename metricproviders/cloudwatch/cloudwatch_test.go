@@ -0,0 +1,294 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func newAnalysisRun() *v1alpha1.AnalysisRun {
+	return &v1alpha1.AnalysisRun{}
+}
+
+func newMetric(successCondition string) v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "foo",
+		SuccessCondition: successCondition,
+		Provider: v1alpha1.MetricProvider{
+			CloudWatch: &v1alpha1.CloudWatchMetric{
+				Interval: "10m",
+				MetricDataQueries: []v1alpha1.CloudWatchMetricDataQuery{
+					{
+						Id: "errors",
+						MetricStat: &v1alpha1.CloudWatchMetricStat{
+							Metric: v1alpha1.CloudWatchMetricStatMetric{
+								Dimensions: []v1alpha1.CloudWatchMetricStatMetricDimension{
+									{Name: "LoadBalancer", Value: "app/my-lb/1234"},
+								},
+								MetricName: "HTTPCode_Target_5XX_Count",
+								Namespace:  aws.String("AWS/ApplicationELB"),
+							},
+							Period: 300,
+							Stat:   "Sum",
+						},
+						ReturnData: aws.Bool(false),
+					},
+					{
+						Id:         "rate",
+						Expression: aws.String("errors / 100"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func newProvider(api CloudWatchClientAPI) *Provider {
+	e := log.NewEntry(log.New())
+	return NewCloudWatchProvider(api, *e)
+}
+
+func TestType(t *testing.T) {
+	p := newProvider(&mockAPI{})
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestRunSuccessfully(t *testing.T) {
+	api := &mockAPI{
+		responses: []*cloudwatch.GetMetricDataOutput{{
+			MetricDataResults: []types.MetricDataResult{{
+				Id:         aws.String("rate"),
+				Label:      aws.String("rate"),
+				StatusCode: types.StatusCodeComplete,
+				Timestamps: []time.Time{time.Unix(0, 0).UTC()},
+				Values:     []float64{0.01},
+			}},
+		}},
+	}
+	p := newProvider(api)
+	measurement := p.Run(newAnalysisRun(), newMetric("result[0].values[0] < 0.05"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.Equal(t, `[{"id":"rate","label":"rate","statusCode":"Complete","timestamps":["1970-01-01T00:00:00Z"],"values":[0.01]}]`, measurement.Value)
+	assert.NotNil(t, measurement.StartedAt)
+	assert.NotNil(t, measurement.FinishedAt)
+
+	assert.Len(t, api.inputs, 1)
+	input := api.inputs[0]
+	assert.Equal(t, 10*time.Minute, input.EndTime.Sub(*input.StartTime))
+	assert.Len(t, input.MetricDataQueries, 2)
+	assert.Equal(t, "errors", *input.MetricDataQueries[0].Id)
+	assert.Equal(t, "HTTPCode_Target_5XX_Count", *input.MetricDataQueries[0].MetricStat.Metric.MetricName)
+	assert.Equal(t, "LoadBalancer", *input.MetricDataQueries[0].MetricStat.Metric.Dimensions[0].Name)
+	assert.Equal(t, int32(300), *input.MetricDataQueries[0].MetricStat.Period)
+	assert.False(t, *input.MetricDataQueries[0].ReturnData)
+	assert.Equal(t, "errors / 100", *input.MetricDataQueries[1].Expression)
+	assert.Nil(t, input.MetricDataQueries[1].MetricStat)
+}
+
+func TestRunMergesPages(t *testing.T) {
+	api := &mockAPI{
+		responses: []*cloudwatch.GetMetricDataOutput{
+			{
+				MetricDataResults: []types.MetricDataResult{{
+					Id:         aws.String("rate"),
+					StatusCode: types.StatusCodePartialData,
+					Timestamps: []time.Time{time.Unix(60, 0)},
+					Values:     []float64{0.01},
+				}},
+				NextToken: aws.String("next"),
+			},
+			{
+				MetricDataResults: []types.MetricDataResult{{
+					Id:         aws.String("rate"),
+					StatusCode: types.StatusCodeComplete,
+					Timestamps: []time.Time{time.Unix(0, 0)},
+					Values:     []float64{0.2},
+				}},
+			},
+		},
+	}
+	p := newProvider(api)
+	measurement := p.Run(newAnalysisRun(), newMetric("all(result[0].values, {# < 0.05})"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase)
+	assert.Len(t, api.inputs, 2)
+	assert.Equal(t, "next", *api.inputs[1].NextToken)
+	assert.Contains(t, measurement.Value, `"statusCode":"Complete"`)
+	assert.Contains(t, measurement.Value, `"values":[0.01,0.2]`)
+}
+
+func TestRunWithQueryError(t *testing.T) {
+	p := newProvider(&mockAPI{err: fmt.Errorf("bad big bug :(")})
+	measurement := p.Run(newAnalysisRun(), newMetric("true"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "bad big bug :(", measurement.Message)
+	assert.NotNil(t, measurement.FinishedAt)
+}
+
+func TestRunWithInvalidInterval(t *testing.T) {
+	api := &mockAPI{}
+	p := newProvider(api)
+	metric := newMetric("true")
+	metric.Provider.CloudWatch.Interval = "invalid"
+	measurement := p.Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Len(t, api.inputs, 0)
+}
+
+func TestRunWithDefaultInterval(t *testing.T) {
+	api := &mockAPI{
+		responses: []*cloudwatch.GetMetricDataOutput{{}},
+	}
+	p := newProvider(api)
+	metric := newMetric("len(result) == 0")
+	metric.Provider.CloudWatch.Interval = ""
+	measurement := p.Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.Len(t, api.inputs, 1)
+	assert.Equal(t, defaultInterval, api.inputs[0].EndTime.Sub(*api.inputs[0].StartTime))
+}
+
+func TestResume(t *testing.T) {
+	p := newProvider(&mockAPI{})
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseInconclusive,
+	}
+	measurement := p.Resume(newAnalysisRun(), newMetric("true"), previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestTerminate(t *testing.T) {
+	p := newProvider(&mockAPI{})
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseRunning,
+	}
+	measurement := p.Terminate(newAnalysisRun(), newMetric("true"), previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestGarbageCollect(t *testing.T) {
+	p := newProvider(&mockAPI{})
+	err := p.GarbageCollect(nil, v1alpha1.Metric{}, 0)
+	assert.NoError(t, err)
+}
+
+func TestProcessResponseKeepsQueriesApart(t *testing.T) {
+	p := newProvider(&mockAPI{})
+	response := []types.MetricDataResult{
+		{
+			Id:         aws.String("rate"),
+			Label:      aws.String("rate"),
+			StatusCode: types.StatusCodeComplete,
+			Values:     []float64{0.01},
+		},
+		{
+			Id:         aws.String("latency"),
+			Label:      aws.String("latency"),
+			StatusCode: types.StatusCodeComplete,
+			Values:     []float64{250},
+		},
+	}
+	value, status, err := p.processResponse(newMetric("result[0].values[0] < 0.05 && result[1].values[0] < 300"), response)
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, status)
+	assert.Equal(t, `[{"id":"rate","label":"rate","statusCode":"Complete","timestamps":[],"values":[0.01]},{"id":"latency","label":"latency","statusCode":"Complete","timestamps":[],"values":[250]}]`, value)
+}
+
+func TestProcessResponseKeepsLastStatusCode(t *testing.T) {
+	p := newProvider(&mockAPI{})
+	response := []types.MetricDataResult{
+		{
+			Id:         aws.String("rate"),
+			StatusCode: types.StatusCodePartialData,
+			Values:     []float64{0.01},
+		},
+		{
+			Id:         aws.String("rate"),
+			StatusCode: types.StatusCodeInternalError,
+		},
+	}
+	value, status, err := p.processResponse(newMetric(`result[0].statusCode == "Complete"`), response)
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, status)
+	assert.Equal(t, `[{"id":"rate","label":"","statusCode":"InternalError","timestamps":[],"values":[0.01]}]`, value)
+}
+
+func TestProcessEmptyResponse(t *testing.T) {
+	p := newProvider(&mockAPI{})
+	value, status, err := p.processResponse(newMetric("len(result) == 0"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, status)
+	assert.Equal(t, "[]", value)
+}
+
+const getMetricDataResponse = `<GetMetricDataResponse xmlns="http://monitoring.amazonaws.com/doc/2010-08-01/">
+  <GetMetricDataResult>
+    <MetricDataResults>
+      <member>
+        <Id>rate</Id>
+        <Label>rate</Label>
+        <StatusCode>Complete</StatusCode>
+        <Timestamps>
+          <member>2021-01-01T00:00:00Z</member>
+        </Timestamps>
+        <Values>
+          <member>0.01</member>
+        </Values>
+      </member>
+    </MetricDataResults>
+  </GetMetricDataResult>
+  <ResponseMetadata>
+    <RequestId>request-id</RequestId>
+  </ResponseMetadata>
+</GetMetricDataResponse>`
+
+func TestNewCloudWatchAPIWithEndpoint(t *testing.T) {
+	for key, value := range map[string]string{
+		"AWS_ACCESS_KEY_ID":     "access-key-id",
+		"AWS_SECRET_ACCESS_KEY": "secret-access-key",
+		"AWS_REGION":            "us-west-2",
+	} {
+		prev, ok := os.LookupEnv(key)
+		os.Setenv(key, value)
+		if ok {
+			defer os.Setenv(key, prev)
+		} else {
+			defer os.Unsetenv(key)
+		}
+	}
+
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bodyBytes, _ := ioutil.ReadAll(r.Body)
+		body = string(bodyBytes)
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprint(w, getMetricDataResponse)
+	}))
+	defer server.Close()
+
+	metric := newMetric("result[0].values[0] < 0.05")
+	metric.Provider.CloudWatch.Endpoint = server.URL
+	api, err := NewCloudWatchAPI(metric)
+	assert.NoError(t, err)
+	p := newProvider(api)
+	measurement := p.Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+	assert.Contains(t, body, "Action=GetMetricData")
+	assert.Contains(t, body, "MetricDataQueries.member.2.Expression=errors")
+}
@@ -0,0 +1,23 @@
+package cloudwatch
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+type mockAPI struct {
+	responses []*cloudwatch.GetMetricDataOutput
+	inputs    []cloudwatch.GetMetricDataInput
+	err       error
+}
+
+func (m *mockAPI) GetMetricData(ctx context.Context, params *cloudwatch.GetMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error) {
+	m.inputs = append(m.inputs, *params)
+	if m.err != nil {
+		return nil, m.err
+	}
+	response := m.responses[0]
+	m.responses = m.responses[1:]
+	return response, nil
+}
@@ -0,0 +1,230 @@
+package dynatrace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+)
+
+const (
+	//ProviderType indicates the provider is dynatrace
+	ProviderType                      = "Dynatrace"
+	DefaultDynatraceProfileSecretName = "dynatrace"
+	// DefaultFrom is the start of the queried time window when the metric does not set one
+	DefaultFrom = "now-5m"
+)
+
+// MetricSeriesCollection is the result of a metric of the query
+type MetricSeriesCollection struct {
+	MetricID string         `json:"metricId"`
+	Data     []MetricSeries `json:"data"`
+}
+
+// MetricSeries is a series of data points of a metric for a set of dimensions. A value is nil when
+// dynatrace has no data for its timestamp.
+type MetricSeries struct {
+	Dimensions []string   `json:"dimensions"`
+	Timestamps []int64    `json:"timestamps"`
+	Values     []*float64 `json:"values"`
+}
+
+// metricDataResponse is a page of the response of the metrics query API
+type metricDataResponse struct {
+	NextPageKey string                   `json:"nextPageKey"`
+	Result      []MetricSeriesCollection `json:"result"`
+}
+
+// API is the dynatrace API used by the provider
+type API interface {
+	Query(ctx context.Context, metricSelector, from, resolution string) ([]MetricSeriesCollection, error)
+}
+
+type dynatraceAPI struct {
+	client         *http.Client
+	environmentURL string
+	apiToken       string
+}
+
+// Query calls the metrics v2 query API of dynatrace, and returns the results of all the pages of
+// the response
+func (api *dynatraceAPI) Query(ctx context.Context, metricSelector, from, resolution string) ([]MetricSeriesCollection, error) {
+	q := url.Values{}
+	q.Set("metricSelector", metricSelector)
+	q.Set("from", from)
+	if resolution != "" {
+		q.Set("resolution", resolution)
+	}
+	var results []MetricSeriesCollection
+	for {
+		response, err := api.do(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, response.Result...)
+		if response.NextPageKey == "" {
+			return results, nil
+		}
+		// the following pages are only selected by their key
+		q = url.Values{}
+		q.Set("nextPageKey", response.NextPageKey)
+	}
+}
+
+func (api *dynatraceAPI) do(ctx context.Context, query url.Values) (*metricDataResponse, error) {
+	u := strings.TrimSuffix(api.environmentURL, "/") + "/api/v2/metrics/query?" + query.Encode()
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Api-Token "+api.apiToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := api.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received non 2xx response code: %v. Body: %s", resp.StatusCode, string(body))
+	}
+	var response metricDataResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("could not parse dynatrace response: %v", err)
+	}
+	return &response, nil
+}
+
+// Provider contains all the required components to run a dynatrace query
+type Provider struct {
+	api    API
+	logCtx log.Entry
+}
+
+// Type indicates provider is a dynatrace provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run queries dynatrace for the metric
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	newMeasurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	from := metric.Provider.Dynatrace.From
+	if from == "" {
+		from = DefaultFrom
+	}
+	response, err := p.api.Query(ctx, metric.Provider.Dynatrace.MetricSelector, from, metric.Provider.Dynatrace.Resolution)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+
+	newValue, newStatus, err := p.processResponse(metric, response)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newMeasurement.Value = newValue
+	newMeasurement.Phase = newStatus
+	finishedTime := metav1.Now()
+	newMeasurement.FinishedAt = &finishedTime
+	return newMeasurement
+}
+
+// Resume should not be used the dynatrace provider since all the work should occur in the Run method
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Dynatrace provider should not execute the Resume method")
+	return measurement
+}
+
+// Terminate should not be used the dynatrace provider since all the work should occur in the Run method
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Dynatrace provider should not execute the Terminate method")
+	return measurement
+}
+
+// GarbageCollect is a no-op for the dynatrace provider
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	return nil
+}
+
+// processResponse evaluates the values of all the series of all the metrics, skipping the values
+// without data. The measurement is inconclusive when there is no value with data.
+func (p *Provider) processResponse(metric v1alpha1.Metric, response []MetricSeriesCollection) (string, v1alpha1.AnalysisPhase, error) {
+	results := []float64{}
+	valueStrs := []string{}
+	for _, collection := range response {
+		for _, series := range collection.Data {
+			for _, value := range series.Values {
+				if value == nil {
+					continue
+				}
+				results = append(results, *value)
+				valueStrs = append(valueStrs, strconv.FormatFloat(*value, 'f', -1, 64))
+			}
+		}
+	}
+	valueStr := "[" + strings.Join(valueStrs, ",") + "]"
+	if len(results) == 0 {
+		return valueStr, v1alpha1.AnalysisPhaseInconclusive, nil
+	}
+	newStatus := evaluate.EvaluateResult(results, metric, p.logCtx)
+	return valueStr, newStatus, nil
+}
+
+// NewDynatraceProvider creates a new dynatrace provider
+func NewDynatraceProvider(api API, logCtx log.Entry) *Provider {
+	return &Provider{
+		logCtx: logCtx,
+		api:    api,
+	}
+}
+
+// NewDynatraceAPI creates a dynatrace API from the profile secret of the metric
+func NewDynatraceAPI(metric v1alpha1.Metric, kubeclientset kubernetes.Interface) (API, error) {
+	ns := defaults.Namespace()
+	profileSecret := DefaultDynatraceProfileSecretName
+	if metric.Provider.Dynatrace.Profile != "" {
+		profileSecret = metric.Provider.Dynatrace.Profile
+	}
+	secret, err := kubeclientset.CoreV1().Secrets(ns).Get(context.TODO(), profileSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	environmentURL := string(secret.Data["environment-url"])
+	apiToken := string(secret.Data["api-token"])
+	if environmentURL != "" && apiToken != "" {
+		return &dynatraceAPI{
+			client:         &http.Client{},
+			environmentURL: environmentURL,
+			apiToken:       apiToken,
+		}, nil
+	} else {
+		return nil, errors.New("environment URL or API token not found")
+	}
+}
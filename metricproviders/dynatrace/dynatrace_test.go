@@ -0,0 +1,253 @@
+package dynatrace
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+)
+
+const firstPage = `{
+  "totalCount": 2,
+  "nextPageKey": "next",
+  "resolution": "1m",
+  "result": [{
+    "metricId": "builtin:service.response.time:avg",
+    "data": [{"dimensions": ["SERVICE-1"], "timestamps": [1600000000000, 1600000060000], "values": [120.5, null]}]
+  }]
+}`
+
+const secondPage = `{
+  "totalCount": 2,
+  "nextPageKey": null,
+  "resolution": "1m",
+  "result": [{
+    "metricId": "builtin:service.response.time:avg",
+    "data": [{"dimensions": ["SERVICE-2"], "timestamps": [1600000000000], "values": [80]}]
+  }]
+}`
+
+// newServer starts a dynatrace server which responds with the pages, and records the queries
+func newServer(t *testing.T, pages []string, queries *[]url.Values) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v2/metrics/query", r.URL.Path)
+		assert.Equal(t, "Api-Token my-token", r.Header.Get("Authorization"))
+		*queries = append(*queries, r.URL.Query())
+		page := pages[0]
+		pages = pages[1:]
+		fmt.Fprint(w, page)
+	}))
+}
+
+func newSecret(environmentURL string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DefaultDynatraceProfileSecretName,
+			Namespace: defaults.Namespace(),
+		},
+		Data: map[string][]byte{
+			"environment-url": []byte(environmentURL),
+			"api-token":       []byte("my-token"),
+		},
+	}
+}
+
+func newAnalysisRun() *v1alpha1.AnalysisRun {
+	return &v1alpha1.AnalysisRun{}
+}
+
+func newMetric(successCondition string) v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "foo",
+		SuccessCondition: successCondition,
+		Provider: v1alpha1.MetricProvider{
+			Dynatrace: &v1alpha1.DynatraceMetric{
+				MetricSelector: "builtin:service.response.time:avg",
+			},
+		},
+	}
+}
+
+func newProvider(t *testing.T, environmentURL string) *Provider {
+	api, err := NewDynatraceAPI(newMetric("true"), k8sfake.NewSimpleClientset(newSecret(environmentURL)))
+	assert.NoError(t, err)
+	return NewDynatraceProvider(api, *log.NewEntry(log.New()))
+}
+
+func TestType(t *testing.T) {
+	p := newProvider(t, "https://dynatrace")
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestRunSuccessfully(t *testing.T) {
+	var queries []url.Values
+	server := newServer(t, []string{firstPage, secondPage}, &queries)
+	defer server.Close()
+
+	metric := newMetric("all(result, {# < 200})")
+	measurement := newProvider(t, server.URL).Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+	assert.Equal(t, "[120.5,80]", measurement.Value)
+	assert.NotNil(t, measurement.StartedAt)
+	assert.NotNil(t, measurement.FinishedAt)
+
+	assert.Len(t, queries, 2)
+	assert.Equal(t, "builtin:service.response.time:avg", queries[0].Get("metricSelector"))
+	assert.Equal(t, DefaultFrom, queries[0].Get("from"))
+	assert.Equal(t, "", queries[0].Get("resolution"))
+	assert.Equal(t, url.Values{"nextPageKey": []string{"next"}}, queries[1])
+}
+
+func TestRunFailed(t *testing.T) {
+	var queries []url.Values
+	server := newServer(t, []string{secondPage}, &queries)
+	defer server.Close()
+
+	metric := newMetric("all(result, {# < 50})")
+	metric.Provider.Dynatrace.From = "now-10m"
+	metric.Provider.Dynatrace.Resolution = "5m"
+	measurement := newProvider(t, server.URL).Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase, measurement.Message)
+	assert.Equal(t, "now-10m", queries[0].Get("from"))
+	assert.Equal(t, "5m", queries[0].Get("resolution"))
+}
+
+func TestRunWithoutData(t *testing.T) {
+	var queries []url.Values
+	server := newServer(t, []string{`{"totalCount": 1, "result": [{"metricId": "m", "data": [{"timestamps": [1600000000000], "values": [null]}]}]}`}, &queries)
+	defer server.Close()
+
+	measurement := newProvider(t, server.URL).Run(newAnalysisRun(), newMetric("true"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseInconclusive, measurement.Phase, measurement.Message)
+	assert.Equal(t, "[]", measurement.Value)
+}
+
+func TestQueryFollowsNextPageKey(t *testing.T) {
+	var queries []url.Values
+	thirdPage := `{"totalCount": 3, "nextPageKey": null, "result": [{"metricId": "builtin:service.errors.total.rate", "data": [{"dimensions": ["SERVICE-1"], "timestamps": [1600000000000], "values": [0.1]}]}]}`
+	middlePage := strings.Replace(secondPage, `"nextPageKey": null`, `"nextPageKey": "last"`, 1)
+	server := newServer(t, []string{firstPage, middlePage, thirdPage}, &queries)
+	defer server.Close()
+
+	api, err := NewDynatraceAPI(newMetric("true"), k8sfake.NewSimpleClientset(newSecret(server.URL)))
+	assert.NoError(t, err)
+	results, err := api.Query(context.TODO(), "builtin:service.response.time:avg", "now-10m", "1m")
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, "builtin:service.errors.total.rate", results[2].MetricID)
+
+	// the following pages are only selected by their key
+	assert.Len(t, queries, 3)
+	assert.Equal(t, "1m", queries[0].Get("resolution"))
+	assert.Equal(t, url.Values{"nextPageKey": []string{"next"}}, queries[1])
+	assert.Equal(t, url.Values{"nextPageKey": []string{"last"}}, queries[2])
+}
+
+func TestQueryWithErrorOnNextPage(t *testing.T) {
+	var queries []url.Values
+	server := newServer(t, []string{firstPage, `not json`}, &queries)
+	defer server.Close()
+
+	measurement := newProvider(t, server.URL).Run(newAnalysisRun(), newMetric("true"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Contains(t, measurement.Message, "could not parse dynatrace response")
+	assert.Equal(t, "", measurement.Value)
+	assert.Len(t, queries, 2)
+}
+
+func TestResume(t *testing.T) {
+	p := newProvider(t, "https://dynatrace")
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseInconclusive,
+	}
+	measurement := p.Resume(newAnalysisRun(), newMetric("true"), previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestTerminate(t *testing.T) {
+	p := newProvider(t, "https://dynatrace")
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseRunning,
+	}
+	measurement := p.Terminate(newAnalysisRun(), newMetric("true"), previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestGarbageCollect(t *testing.T) {
+	p := newProvider(t, "https://dynatrace")
+	err := p.GarbageCollect(nil, v1alpha1.Metric{}, 0)
+	assert.NoError(t, err)
+}
+
+func TestProcessSeveralMetricsResponse(t *testing.T) {
+	p := newProvider(t, "https://dynatrace")
+	value1, value2, value3 := 120.5, 80.0, 0.25
+	response := []MetricSeriesCollection{
+		{
+			MetricID: "builtin:service.response.time:avg",
+			Data: []MetricSeries{
+				{Dimensions: []string{"SERVICE-1"}, Values: []*float64{&value1, nil}},
+				{Dimensions: []string{"SERVICE-2"}, Values: []*float64{&value2}},
+			},
+		},
+		{
+			MetricID: "builtin:service.errors.total.rate",
+			Data:     []MetricSeries{{Values: []*float64{&value3}}},
+		},
+	}
+	value, status, err := p.processResponse(newMetric("len(result) == 3"), response)
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, status)
+	assert.Equal(t, "[120.5,80,0.25]", value)
+}
+
+func TestProcessNullOnlyResponse(t *testing.T) {
+	p := newProvider(t, "https://dynatrace")
+	response := []MetricSeriesCollection{
+		{MetricID: "builtin:service.response.time:avg", Data: []MetricSeries{{Values: []*float64{nil, nil}}}},
+		{MetricID: "builtin:service.errors.total.rate"},
+	}
+	value, status, err := p.processResponse(newMetric("true"), response)
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseInconclusive, status)
+	assert.Equal(t, "[]", value)
+}
+
+func TestNewDynatraceAPI(t *testing.T) {
+	t.Run("with a profile", func(t *testing.T) {
+		secret := newSecret("https://dynatrace")
+		secret.Name = "my-dynatrace"
+		metric := newMetric("true")
+		metric.Provider.Dynatrace.Profile = "my-dynatrace"
+		_, err := NewDynatraceAPI(metric, k8sfake.NewSimpleClientset(secret))
+		assert.NoError(t, err)
+	})
+
+	t.Run("without the secret", func(t *testing.T) {
+		_, err := NewDynatraceAPI(newMetric("true"), k8sfake.NewSimpleClientset())
+		assert.EqualError(t, err, `secrets "dynatrace" not found`)
+	})
+
+	t.Run("with the API token missing", func(t *testing.T) {
+		secret := newSecret("https://dynatrace")
+		delete(secret.Data, "api-token")
+		_, err := NewDynatraceAPI(newMetric("true"), k8sfake.NewSimpleClientset(secret))
+		assert.EqualError(t, err, "environment URL or API token not found")
+	})
+}
@@ -0,0 +1,205 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+)
+
+const (
+	//ProviderType indicates the provider is elasticsearch
+	ProviderType = "Elasticsearch"
+)
+
+// API is the elasticsearch API used by the provider
+type API interface {
+	Search(ctx context.Context, index, query string) (interface{}, error)
+}
+
+type elasticsearchAPI struct {
+	client   *http.Client
+	address  string
+	username string
+	password string
+	apiKey   string
+}
+
+// Search calls the search API of elasticsearch for the index with the query as body, and returns
+// the decoded JSON response
+func (api *elasticsearchAPI) Search(ctx context.Context, index, query string) (interface{}, error) {
+	u := strings.TrimSuffix(api.address, "/") + "/" + url.PathEscape(index) + "/_search"
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewBufferString(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if api.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+api.apiKey)
+	} else if api.username != "" {
+		req.SetBasicAuth(api.username, api.password)
+	}
+
+	resp, err := api.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("received non 2xx response code: %v. Body: %s", resp.StatusCode, string(body))
+	}
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("could not parse elasticsearch response: %v", err)
+	}
+	return data, nil
+}
+
+// Provider contains all the required components to run an elasticsearch search
+type Provider struct {
+	api        API
+	jsonParser *jsonpath.JSONPath
+	logCtx     log.Entry
+}
+
+// Type indicates provider is an elasticsearch provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run searches elasticsearch for the metric
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	newMeasurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	response, err := p.api.Search(ctx, metric.Provider.Elasticsearch.Index, metric.Provider.Elasticsearch.Query)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+
+	newValue, newStatus, err := p.processResponse(metric, response)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newMeasurement.Value = newValue
+	newMeasurement.Phase = newStatus
+	finishedTime := metav1.Now()
+	newMeasurement.FinishedAt = &finishedTime
+	return newMeasurement
+}
+
+// Resume should not be used the elasticsearch provider since all the work should occur in the Run method
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Elasticsearch provider should not execute the Resume method")
+	return measurement
+}
+
+// Terminate should not be used the elasticsearch provider since all the work should occur in the Run method
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Elasticsearch provider should not execute the Terminate method")
+	return measurement
+}
+
+// GarbageCollect is a no-op for the elasticsearch provider
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	return nil
+}
+
+// processResponse selects the value of the response with the JSON path of the metric, and evaluates it
+func (p *Provider) processResponse(metric v1alpha1.Metric, response interface{}) (string, v1alpha1.AnalysisPhase, error) {
+	fullResults, err := p.jsonParser.FindResults(response)
+	if err != nil {
+		return "", v1alpha1.AnalysisPhaseError, fmt.Errorf("could not find JSONPath in response: %s", err)
+	}
+	val, valString, err := getValue(fullResults)
+	if err != nil {
+		return "", v1alpha1.AnalysisPhaseError, err
+	}
+	newStatus := evaluate.EvaluateResult(val, metric, p.logCtx)
+	return valString, newStatus, nil
+}
+
+func getValue(fullResults [][]reflect.Value) (interface{}, string, error) {
+	for _, results := range fullResults {
+		for _, r := range results {
+			val := r.Interface()
+			valBytes, err := json.Marshal(val)
+			return val, string(valBytes), err
+		}
+	}
+	return nil, "", errors.New("JSONPath of the elasticsearch metric produced no value")
+}
+
+// NewElasticsearchProvider creates a new elasticsearch provider
+func NewElasticsearchProvider(api API, jsonParser *jsonpath.JSONPath, logCtx log.Entry) *Provider {
+	return &Provider{
+		api:        api,
+		jsonParser: jsonParser,
+		logCtx:     logCtx,
+	}
+}
+
+// NewElasticsearchJsonParser creates the parser of the JSON path of the metric
+func NewElasticsearchJsonParser(metric v1alpha1.Metric) (*jsonpath.JSONPath, error) {
+	jsonParser := jsonpath.New("metrics")
+	jsonPath := metric.Provider.Elasticsearch.JSONPath
+	if jsonPath == "" {
+		jsonPath = "{$}"
+	}
+	err := jsonParser.Parse(jsonPath)
+	return jsonParser, err
+}
+
+// NewElasticsearchAPI creates an elasticsearch API from the metric configuration. The credentials
+// are read from the profile secret of the metric, which holds either an API key or a username and
+// password.
+func NewElasticsearchAPI(metric v1alpha1.Metric, kubeclientset kubernetes.Interface) (API, error) {
+	if metric.Provider.Elasticsearch.Address == "" {
+		return nil, errors.New("elasticsearch address is not set")
+	}
+	api := &elasticsearchAPI{
+		client:  &http.Client{},
+		address: metric.Provider.Elasticsearch.Address,
+	}
+	if metric.Provider.Elasticsearch.Profile == "" {
+		return api, nil
+	}
+
+	secret, err := kubeclientset.CoreV1().Secrets(defaults.Namespace()).Get(context.TODO(), metric.Provider.Elasticsearch.Profile, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	api.apiKey = string(secret.Data["apiKey"])
+	api.username = string(secret.Data["username"])
+	api.password = string(secret.Data["password"])
+	if api.apiKey == "" && api.username == "" {
+		return nil, errors.New("apiKey or username not found")
+	}
+	return api, nil
+}
@@ -0,0 +1,273 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+)
+
+const query = `{"size": 0, "query": {"match": {"app": "my-app"}}, "aggs": {"errors": {"filter": {"range": {"status": {"gte": 500}}}}}}`
+
+const searchResponse = `{
+  "took": 3,
+  "timed_out": false,
+  "hits": {"total": {"value": 200, "relation": "eq"}, "hits": []},
+  "aggregations": {"errors": {"doc_count": 4}}
+}`
+
+// request is a search request received by the server
+type request struct {
+	path          string
+	body          string
+	authorization string
+}
+
+// newServer starts an elasticsearch server which responds to searches with the response, and
+// records the last request
+func newServer(t *testing.T, status int, response string, req *request) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		bodyBytes, _ := ioutil.ReadAll(r.Body)
+		*req = request{
+			path:          r.URL.EscapedPath(),
+			body:          string(bodyBytes),
+			authorization: r.Header.Get("Authorization"),
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, response)
+	}))
+}
+
+func newAnalysisRun() *v1alpha1.AnalysisRun {
+	return &v1alpha1.AnalysisRun{}
+}
+
+func newMetric(address, jsonPath, successCondition string) v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "foo",
+		SuccessCondition: successCondition,
+		Provider: v1alpha1.MetricProvider{
+			Elasticsearch: &v1alpha1.ElasticsearchMetric{
+				Address:  address,
+				Index:    "logs-*",
+				Query:    query,
+				JSONPath: jsonPath,
+			},
+		},
+	}
+}
+
+func newProvider(t *testing.T, metric v1alpha1.Metric) *Provider {
+	api, err := NewElasticsearchAPI(metric, k8sfake.NewSimpleClientset())
+	assert.NoError(t, err)
+	jsonParser, err := NewElasticsearchJsonParser(metric)
+	assert.NoError(t, err)
+	return NewElasticsearchProvider(api, jsonParser, *log.NewEntry(log.New()))
+}
+
+// newResponse decodes the JSON response like the elasticsearch API
+func newResponse(t *testing.T, response string) interface{} {
+	var data interface{}
+	assert.NoError(t, json.Unmarshal([]byte(response), &data))
+	return data
+}
+
+// newMockProvider creates a provider searching the mock API
+func newMockProvider(t *testing.T, metric v1alpha1.Metric, api API) *Provider {
+	jsonParser, err := NewElasticsearchJsonParser(metric)
+	assert.NoError(t, err)
+	return NewElasticsearchProvider(api, jsonParser, *log.NewEntry(log.New()))
+}
+
+func TestType(t *testing.T) {
+	p := newProvider(t, newMetric("http://elasticsearch", "", "true"))
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestRunSuccessfully(t *testing.T) {
+	var req request
+	server := newServer(t, http.StatusOK, searchResponse, &req)
+	defer server.Close()
+
+	metric := newMetric(server.URL, "{$.aggregations.errors.doc_count}", "result < 5")
+	measurement := newProvider(t, metric).Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+	assert.Equal(t, "4", measurement.Value)
+	assert.NotNil(t, measurement.StartedAt)
+	assert.NotNil(t, measurement.FinishedAt)
+	assert.Equal(t, "/logs-%2A/_search", req.path)
+	assert.Equal(t, query, req.body)
+	assert.Equal(t, "", req.authorization)
+}
+
+func TestRunWithDefaultJSONPath(t *testing.T) {
+	var req request
+	server := newServer(t, http.StatusOK, searchResponse, &req)
+	defer server.Close()
+
+	metric := newMetric(server.URL, "", "result.aggregations.errors.doc_count / result.hits.total.value >= 0.01")
+	measurement := newProvider(t, metric).Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+	assert.Contains(t, measurement.Value, `"doc_count":4`)
+}
+
+func TestRunWithQueryError(t *testing.T) {
+	expectedErr := fmt.Errorf("bad big bug :(")
+	metric := newMetric("http://elasticsearch", "", "true")
+	measurement := newMockProvider(t, metric, mockAPI{err: expectedErr}).Run(newAnalysisRun(), metric)
+	assert.Equal(t, expectedErr.Error(), measurement.Message)
+	assert.NotNil(t, measurement.StartedAt)
+	assert.Equal(t, "", measurement.Value)
+	assert.NotNil(t, measurement.FinishedAt)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+}
+
+func TestResume(t *testing.T) {
+	metric := newMetric("http://elasticsearch", "", "true")
+	p := newMockProvider(t, metric, mockAPI{})
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseInconclusive,
+	}
+	measurement := p.Resume(newAnalysisRun(), metric, previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestTerminate(t *testing.T) {
+	metric := newMetric("http://elasticsearch", "", "true")
+	p := newMockProvider(t, metric, mockAPI{})
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseRunning,
+	}
+	measurement := p.Terminate(newAnalysisRun(), metric, previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestGarbageCollect(t *testing.T) {
+	p := newMockProvider(t, newMetric("http://elasticsearch", "", "true"), mockAPI{})
+	err := p.GarbageCollect(nil, v1alpha1.Metric{}, 0)
+	assert.NoError(t, err)
+}
+
+func TestProcessObjectResponse(t *testing.T) {
+	metric := newMetric("http://elasticsearch", "{$.aggregations.errors}", "result.doc_count < 5")
+	p := newMockProvider(t, metric, mockAPI{})
+	value, status, err := p.processResponse(metric, newResponse(t, searchResponse))
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, status)
+	assert.Equal(t, `{"doc_count":4}`, value)
+}
+
+func TestProcessResponseWithSeveralValues(t *testing.T) {
+	// only the first value selected by the JSON path is evaluated
+	metric := newMetric("http://elasticsearch", "{$.aggregations.latency.buckets[*].value}", "result < 100")
+	p := newMockProvider(t, metric, mockAPI{})
+	response := newResponse(t, `{"aggregations": {"latency": {"buckets": [{"value": 50}, {"value": 500}]}}}`)
+	value, status, err := p.processResponse(metric, response)
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, status)
+	assert.Equal(t, "50", value)
+}
+
+func TestProcessResponseWithoutValue(t *testing.T) {
+	metric := newMetric("http://elasticsearch", "{$.aggregations.latency.buckets[*].value}", "true")
+	p := newMockProvider(t, metric, mockAPI{})
+	response := newResponse(t, `{"aggregations": {"latency": {"buckets": []}}}`)
+	value, status, err := p.processResponse(metric, response)
+	assert.EqualError(t, err, "JSONPath of the elasticsearch metric produced no value")
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, status)
+	assert.Equal(t, "", value)
+}
+
+func TestProcessResponseWithMissingJSONPath(t *testing.T) {
+	metric := newMetric("http://elasticsearch", "{$.aggregations.latency.value}", "true")
+	p := newMockProvider(t, metric, mockAPI{})
+	value, status, err := p.processResponse(metric, newResponse(t, searchResponse))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "could not find JSONPath in response")
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, status)
+	assert.Equal(t, "", value)
+}
+
+func TestNewElasticsearchJsonParserWithInvalidPath(t *testing.T) {
+	_, err := NewElasticsearchJsonParser(newMetric("http://elasticsearch", "{$.aggregations", "true"))
+	assert.Error(t, err)
+}
+
+func TestNewElasticsearchAPI(t *testing.T) {
+	newSecret := func(data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "my-elasticsearch",
+				Namespace: defaults.Namespace(),
+			},
+			Data: data,
+		}
+	}
+
+	t.Run("without an address", func(t *testing.T) {
+		_, err := NewElasticsearchAPI(newMetric("", "", "true"), k8sfake.NewSimpleClientset())
+		assert.EqualError(t, err, "elasticsearch address is not set")
+	})
+
+	t.Run("with basic auth", func(t *testing.T) {
+		var req request
+		server := newServer(t, http.StatusOK, searchResponse, &req)
+		defer server.Close()
+
+		metric := newMetric(server.URL, "", "true")
+		metric.Provider.Elasticsearch.Profile = "my-elasticsearch"
+		secret := newSecret(map[string][]byte{"username": []byte("user"), "password": []byte("pass")})
+		api, err := NewElasticsearchAPI(metric, k8sfake.NewSimpleClientset(secret))
+		assert.NoError(t, err)
+		_, err = api.Search(context.TODO(), "logs", query)
+		assert.NoError(t, err)
+		assert.Equal(t, "Basic dXNlcjpwYXNz", req.authorization)
+	})
+
+	t.Run("with an api key", func(t *testing.T) {
+		var req request
+		server := newServer(t, http.StatusOK, searchResponse, &req)
+		defer server.Close()
+
+		metric := newMetric(server.URL, "", "true")
+		metric.Provider.Elasticsearch.Profile = "my-elasticsearch"
+		secret := newSecret(map[string][]byte{"apiKey": []byte("a2V5")})
+		api, err := NewElasticsearchAPI(metric, k8sfake.NewSimpleClientset(secret))
+		assert.NoError(t, err)
+		_, err = api.Search(context.TODO(), "logs", query)
+		assert.NoError(t, err)
+		assert.Equal(t, "ApiKey a2V5", req.authorization)
+	})
+
+	t.Run("without credentials in the secret", func(t *testing.T) {
+		metric := newMetric("http://elasticsearch", "", "true")
+		metric.Provider.Elasticsearch.Profile = "my-elasticsearch"
+		_, err := NewElasticsearchAPI(metric, k8sfake.NewSimpleClientset(newSecret(nil)))
+		assert.EqualError(t, err, "apiKey or username not found")
+	})
+
+	t.Run("without the secret", func(t *testing.T) {
+		metric := newMetric("http://elasticsearch", "", "true")
+		metric.Provider.Elasticsearch.Profile = "my-elasticsearch"
+		_, err := NewElasticsearchAPI(metric, k8sfake.NewSimpleClientset())
+		assert.EqualError(t, err, `secrets "my-elasticsearch" not found`)
+	})
+}
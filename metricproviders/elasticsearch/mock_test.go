@@ -0,0 +1,18 @@
+package elasticsearch
+
+import (
+	"context"
+)
+
+type mockAPI struct {
+	response interface{}
+	err      error
+}
+
+// Search returns the mocked response
+func (m mockAPI) Search(ctx context.Context, index, query string) (interface{}, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.response, nil
+}
@@ -0,0 +1,191 @@
+package graphite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+)
+
+const (
+	//ProviderType indicates the provider is graphite
+	ProviderType = "Graphite"
+	// DefaultFrom is the start of the queried time window when the metric does not set one
+	DefaultFrom = "-5min"
+	// DefaultUntil is the end of the queried time window when the metric does not set one
+	DefaultUntil = "now"
+)
+
+// Series is a series of datapoints rendered by graphite for a target. The value of a datapoint
+// is nil when graphite has no data for its timestamp.
+type Series struct {
+	Target     string      `json:"target"`
+	Datapoints []Datapoint `json:"datapoints"`
+}
+
+// Datapoint is a [value, timestamp] pair of a series
+type Datapoint [2]*float64
+
+// API is the graphite API used by the provider
+type API interface {
+	Render(ctx context.Context, target, from, until string) ([]Series, error)
+}
+
+type graphiteAPI struct {
+	client  *http.Client
+	address string
+}
+
+// Render calls the /render API of graphite for the target over the time window
+func (api *graphiteAPI) Render(ctx context.Context, target, from, until string) ([]Series, error) {
+	u, err := url.Parse(strings.TrimSuffix(api.address, "/") + "/render")
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	q.Set("target", target)
+	q.Set("from", from)
+	q.Set("until", until)
+	q.Set("format", "json")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := api.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received non 2xx response code: %v. Body: %s", resp.StatusCode, string(body))
+	}
+	var series []Series
+	if err := json.Unmarshal(body, &series); err != nil {
+		return nil, fmt.Errorf("could not parse graphite response: %v", err)
+	}
+	return series, nil
+}
+
+// Provider contains all the required components to run a graphite query
+type Provider struct {
+	api    API
+	logCtx log.Entry
+}
+
+// Type indicates provider is a graphite provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run queries graphite for the metric
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	newMeasurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	from := metric.Provider.Graphite.From
+	if from == "" {
+		from = DefaultFrom
+	}
+	until := metric.Provider.Graphite.Until
+	if until == "" {
+		until = DefaultUntil
+	}
+	response, err := p.api.Render(ctx, metric.Provider.Graphite.Target, from, until)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+
+	newValue, newStatus, err := p.processResponse(metric, response)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newMeasurement.Value = newValue
+	newMeasurement.Phase = newStatus
+	finishedTime := metav1.Now()
+	newMeasurement.FinishedAt = &finishedTime
+	return newMeasurement
+}
+
+// Resume should not be used the graphite provider since all the work should occur in the Run method
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Graphite provider should not execute the Resume method")
+	return measurement
+}
+
+// Terminate should not be used the graphite provider since all the work should occur in the Run method
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Graphite provider should not execute the Terminate method")
+	return measurement
+}
+
+// GarbageCollect is a no-op for the graphite provider
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	return nil
+}
+
+// processResponse evaluates the values of the datapoints of all the series, skipping the datapoints
+// without data. The measurement is inconclusive when there is no datapoint with data.
+func (p *Provider) processResponse(metric v1alpha1.Metric, response []Series) (string, v1alpha1.AnalysisPhase, error) {
+	results := []float64{}
+	valueStrs := []string{}
+	for _, series := range response {
+		for _, datapoint := range series.Datapoints {
+			if datapoint[0] == nil {
+				continue
+			}
+			results = append(results, *datapoint[0])
+			valueStrs = append(valueStrs, strconv.FormatFloat(*datapoint[0], 'f', -1, 64))
+		}
+	}
+	valueStr := "[" + strings.Join(valueStrs, ",") + "]"
+	if len(results) == 0 {
+		return valueStr, v1alpha1.AnalysisPhaseInconclusive, nil
+	}
+	newStatus := evaluate.EvaluateResult(results, metric, p.logCtx)
+	return valueStr, newStatus, nil
+}
+
+// NewGraphiteProvider creates a new graphite provider
+func NewGraphiteProvider(api API, logCtx log.Entry) *Provider {
+	return &Provider{
+		logCtx: logCtx,
+		api:    api,
+	}
+}
+
+// NewGraphiteAPI generates a graphite API from the metric configuration
+func NewGraphiteAPI(metric v1alpha1.Metric) (API, error) {
+	if metric.Provider.Graphite.Address == "" {
+		return nil, fmt.Errorf("graphite address is not set")
+	}
+	if _, err := url.Parse(metric.Provider.Graphite.Address); err != nil {
+		return nil, err
+	}
+	return &graphiteAPI{
+		client:  &http.Client{},
+		address: metric.Provider.Graphite.Address,
+	}, nil
+}
@@ -0,0 +1,223 @@
+package graphite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func newDatapoint(value float64, timestamp float64) Datapoint {
+	return Datapoint{&value, &timestamp}
+}
+
+func newNullDatapoint(timestamp float64) Datapoint {
+	return Datapoint{nil, &timestamp}
+}
+
+func newAnalysisRun() *v1alpha1.AnalysisRun {
+	return &v1alpha1.AnalysisRun{}
+}
+
+func newMetric() v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "foo",
+		SuccessCondition: "all(result, {# < 0.05})",
+		FailureCondition: "any(result, {# >= 0.05})",
+		Provider: v1alpha1.MetricProvider{
+			Graphite: &v1alpha1.GraphiteMetric{
+				Address: "http://graphite",
+				Target:  "asPercent(app.errors, app.requests)",
+			},
+		},
+	}
+}
+
+func TestType(t *testing.T) {
+	e := log.Entry{}
+	p := NewGraphiteProvider(&mockAPI{}, e)
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestRunSuccessfully(t *testing.T) {
+	e := log.Entry{}
+	mock := &mockAPI{
+		series: []Series{{
+			Target:     "asPercent(app.errors,app.requests)",
+			Datapoints: []Datapoint{newDatapoint(0.01, 1600000000), newDatapoint(0.02, 1600000060)},
+		}},
+	}
+	p := NewGraphiteProvider(mock, e)
+	measurement := p.Run(newAnalysisRun(), newMetric())
+	assert.NotNil(t, measurement.StartedAt)
+	assert.Equal(t, "[0.01,0.02]", measurement.Value)
+	assert.NotNil(t, measurement.FinishedAt)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.Equal(t, "asPercent(app.errors, app.requests)", mock.target)
+	assert.Equal(t, DefaultFrom, mock.from)
+	assert.Equal(t, DefaultUntil, mock.until)
+}
+
+func TestRunWithTimeWindow(t *testing.T) {
+	e := log.Entry{}
+	mock := &mockAPI{
+		series: []Series{{Target: "a", Datapoints: []Datapoint{newDatapoint(0.5, 1600000000)}}},
+	}
+	p := NewGraphiteProvider(mock, e)
+	metric := newMetric()
+	metric.Provider.Graphite.From = "-10min"
+	metric.Provider.Graphite.Until = "-1min"
+	measurement := p.Run(newAnalysisRun(), metric)
+	assert.Equal(t, "[0.5]", measurement.Value)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase)
+	assert.Equal(t, "-10min", mock.from)
+	assert.Equal(t, "-1min", mock.until)
+}
+
+func TestRunWithQueryError(t *testing.T) {
+	e := log.NewEntry(log.New())
+	expectedErr := fmt.Errorf("bad big bug :(")
+	mock := &mockAPI{
+		err: expectedErr,
+	}
+	p := NewGraphiteProvider(mock, *e)
+	measurement := p.Run(newAnalysisRun(), newMetric())
+	assert.Equal(t, expectedErr.Error(), measurement.Message)
+	assert.NotNil(t, measurement.StartedAt)
+	assert.Equal(t, "", measurement.Value)
+	assert.NotNil(t, measurement.FinishedAt)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+}
+
+func TestResume(t *testing.T) {
+	e := log.WithField("", "")
+	p := NewGraphiteProvider(&mockAPI{}, *e)
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseInconclusive,
+	}
+	measurement := p.Resume(newAnalysisRun(), newMetric(), previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestTerminate(t *testing.T) {
+	e := log.NewEntry(log.New())
+	p := NewGraphiteProvider(&mockAPI{}, *e)
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseRunning,
+	}
+	measurement := p.Terminate(newAnalysisRun(), newMetric(), previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestGarbageCollect(t *testing.T) {
+	e := log.NewEntry(log.New())
+	p := NewGraphiteProvider(&mockAPI{}, *e)
+	err := p.GarbageCollect(nil, v1alpha1.Metric{}, 0)
+	assert.NoError(t, err)
+}
+
+func TestProcessMultipleSeriesResponse(t *testing.T) {
+	logCtx := log.WithField("test", "test")
+	p := Provider{
+		logCtx: *logCtx,
+	}
+	response := []Series{
+		{Target: "a", Datapoints: []Datapoint{newDatapoint(0.01, 1600000000)}},
+		{Target: "b", Datapoints: []Datapoint{newDatapoint(0.5, 1600000000)}},
+	}
+	value, status, err := p.processResponse(newMetric(), response)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, status)
+	assert.Equal(t, "[0.01,0.5]", value)
+}
+
+func TestProcessResponseSkipsNullDatapoints(t *testing.T) {
+	logCtx := log.WithField("test", "test")
+	p := Provider{
+		logCtx: *logCtx,
+	}
+	response := []Series{{
+		Target:     "a",
+		Datapoints: []Datapoint{newDatapoint(0.01, 1600000000), newNullDatapoint(1600000060), newDatapoint(0.02, 1600000120)},
+	}}
+	value, status, err := p.processResponse(newMetric(), response)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, status)
+	assert.Equal(t, "[0.01,0.02]", value)
+}
+
+func TestProcessNullOnlySeriesResponse(t *testing.T) {
+	logCtx := log.WithField("test", "test")
+	p := Provider{
+		logCtx: *logCtx,
+	}
+	response := []Series{
+		{Target: "a", Datapoints: []Datapoint{newNullDatapoint(1600000000), newNullDatapoint(1600000060)}},
+		{Target: "b", Datapoints: []Datapoint{}},
+	}
+	value, status, err := p.processResponse(newMetric(), response)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseInconclusive, status)
+	assert.Equal(t, "[]", value)
+}
+
+func TestProcessEmptyResponse(t *testing.T) {
+	logCtx := log.WithField("test", "test")
+	p := Provider{
+		logCtx: *logCtx,
+	}
+	value, status, err := p.processResponse(newMetric(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseInconclusive, status)
+	assert.Equal(t, "[]", value)
+}
+
+func TestRender(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/render", r.URL.Path)
+		assert.Equal(t, "app.errors", r.URL.Query().Get("target"))
+		assert.Equal(t, "-10min", r.URL.Query().Get("from"))
+		assert.Equal(t, "now", r.URL.Query().Get("until"))
+		assert.Equal(t, "json", r.URL.Query().Get("format"))
+		fmt.Fprint(w, `[{"target": "app.errors", "datapoints": [[0.01, 1600000000], [null, 1600000060]]}]`)
+	}))
+	defer server.Close()
+
+	metric := newMetric()
+	metric.Provider.Graphite.Address = server.URL + "/"
+	api, err := NewGraphiteAPI(metric)
+	assert.NoError(t, err)
+	series, err := api.Render(context.Background(), "app.errors", "-10min", "now")
+	assert.NoError(t, err)
+	assert.Len(t, series, 1)
+	assert.Equal(t, "app.errors", series[0].Target)
+	assert.Equal(t, 0.01, *series[0].Datapoints[0][0])
+	assert.Nil(t, series[0].Datapoints[1][0])
+	assert.Equal(t, float64(1600000060), *series[0].Datapoints[1][1])
+}
+
+func TestNewGraphiteAPI(t *testing.T) {
+	metric := newMetric()
+	metric.Provider.Graphite.Address = ""
+	_, err := NewGraphiteAPI(metric)
+	assert.EqualError(t, err, "graphite address is not set")
+
+	metric.Provider.Graphite.Address = ":invalid::url"
+	_, err = NewGraphiteAPI(metric)
+	assert.NotNil(t, err)
+
+	metric.Provider.Graphite.Address = "https://www.example.com"
+	_, err = NewGraphiteAPI(metric)
+	assert.Nil(t, err)
+}
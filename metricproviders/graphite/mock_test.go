@@ -0,0 +1,23 @@
+package graphite
+
+import (
+	"context"
+)
+
+type mockAPI struct {
+	series []Series
+	err    error
+
+	target string
+	from   string
+	until  string
+}
+
+// Render records the query and returns the mocked series
+func (m *mockAPI) Render(ctx context.Context, target, from, until string) ([]Series, error) {
+	m.target, m.from, m.until = target, from, until
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.series, nil
+}
@@ -0,0 +1,184 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	influxapi "github.com/influxdata/influxdb-client-go/v2/api"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+)
+
+const (
+	//ProviderType indicates the provider is InfluxDB
+	ProviderType = "Influxdb"
+	// DefaultInfluxdbProfileSecretName is the name of the secret read when the metric does not set a profile
+	DefaultInfluxdbProfileSecretName = "influxdb"
+)
+
+var (
+	clientsLock sync.Mutex
+	// clients are the InfluxDB clients by profile. A client owns an HTTP transport, so it is shared
+	// by the measurements of the profile instead of being created for each of them.
+	clients = map[string]*profileClient{}
+)
+
+// profileClient is the InfluxDB client of a profile, with the credentials it was created with
+type profileClient struct {
+	address   string
+	authToken string
+	client    influxdb2.Client
+}
+
+// Provider contains all the required components to run a Flux query
+type Provider struct {
+	api    influxapi.QueryAPI
+	logCtx log.Entry
+}
+
+// Type indicates provider is an InfluxDB provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run queries InfluxDB for the metric
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	newMeasurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := p.api.Query(ctx, metric.Provider.Influxdb.Query)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	defer result.Close()
+
+	// the records are grouped by the table they belong to, in the order of the response. Tables
+	// sharing their columns are not annotated separately, so the table column of the records tells
+	// them apart
+	tables := [][]map[string]interface{}{}
+	var lastTable interface{}
+	for result.Next() {
+		record := result.Record()
+		table := record.ValueByKey("table")
+		if result.TableChanged() || table != lastTable {
+			tables = append(tables, []map[string]interface{}{})
+			lastTable = table
+		}
+		tables[len(tables)-1] = append(tables[len(tables)-1], record.Values())
+	}
+	if result.Err() != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, result.Err())
+	}
+
+	newValue, newStatus, err := p.processResponse(metric, tables)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newMeasurement.Value = newValue
+	newMeasurement.Phase = newStatus
+	finishedTime := metav1.Now()
+	newMeasurement.FinishedAt = &finishedTime
+	return newMeasurement
+}
+
+// Resume should not be used the InfluxDB provider since all the work should occur in the Run method
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("InfluxDB provider should not execute the Resume method")
+	return measurement
+}
+
+// Terminate should not be used the InfluxDB provider since all the work should occur in the Run method
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("InfluxDB provider should not execute the Terminate method")
+	return measurement
+}
+
+// GarbageCollect is a no-op for the InfluxDB provider
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	return nil
+}
+
+// processResponse evaluates the tables of the response, each of which is the list of the values of
+// its records by column. The measurement is inconclusive when the query returns no record.
+func (p *Provider) processResponse(metric v1alpha1.Metric, tables [][]map[string]interface{}) (string, v1alpha1.AnalysisPhase, error) {
+	// the conditions evaluate the tables as plain JSON values, so that the timestamps of the
+	// records are strings like in the value of the measurement
+	valueBytes, err := json.Marshal(tables)
+	if err != nil {
+		return "", v1alpha1.AnalysisPhaseError, err
+	}
+	if len(tables) == 0 {
+		return string(valueBytes), v1alpha1.AnalysisPhaseInconclusive, nil
+	}
+	var result interface{}
+	if err := json.Unmarshal(valueBytes, &result); err != nil {
+		return "", v1alpha1.AnalysisPhaseError, err
+	}
+	newStatus := evaluate.EvaluateResult(result, metric, p.logCtx)
+	return string(valueBytes), newStatus, nil
+}
+
+// NewInfluxdbProvider creates a new InfluxDB provider
+func NewInfluxdbProvider(api influxapi.QueryAPI, logCtx log.Entry) *Provider {
+	return &Provider{
+		logCtx: logCtx,
+		api:    api,
+	}
+}
+
+// NewInfluxdbAPI creates a query API of InfluxDB from the profile secret of the metric
+func NewInfluxdbAPI(metric v1alpha1.Metric, kubeclientset kubernetes.Interface) (influxapi.QueryAPI, error) {
+	ns := defaults.Namespace()
+	profileSecret := DefaultInfluxdbProfileSecretName
+	if metric.Provider.Influxdb.Profile != "" {
+		profileSecret = metric.Provider.Influxdb.Profile
+	}
+	secret, err := kubeclientset.CoreV1().Secrets(ns).Get(context.TODO(), profileSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	address := string(secret.Data["address"])
+	authToken := string(secret.Data["authToken"])
+	org := string(secret.Data["org"])
+	if address == "" || authToken == "" || org == "" {
+		return nil, errors.New("address, authToken or org not found")
+	}
+	client := getClient(ns+"/"+profileSecret, address, authToken)
+	return client.QueryAPI(org), nil
+}
+
+// getClient returns the client of the profile, creating it the first time, or when the credentials
+// of the profile change. The client replaced by a new one is closed.
+func getClient(profile, address, authToken string) influxdb2.Client {
+	clientsLock.Lock()
+	defer clientsLock.Unlock()
+	if c, ok := clients[profile]; ok {
+		if c.address == address && c.authToken == authToken {
+			return c.client
+		}
+		c.client.Close()
+	}
+	client := influxdb2.NewClient(address, authToken)
+	clients[profile] = &profileClient{
+		address:   address,
+		authToken: authToken,
+		client:    client,
+	}
+	return client
+}
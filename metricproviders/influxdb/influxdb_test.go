@@ -0,0 +1,281 @@
+package influxdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+)
+
+const query = `from(bucket: "iot") |> range(start: -5m) |> filter(fn: (r) => r._measurement == "latency") |> mean()`
+
+const twoTablesResponse = `#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,double,string,string
+#group,false,false,true,true,false,true,true
+#default,_result,,,,,,
+,result,table,_start,_stop,_value,_field,device
+,,0,2021-01-01T00:00:00Z,2021-01-01T00:05:00Z,12.5,latency,a
+,,1,2021-01-01T00:00:00Z,2021-01-01T00:05:00Z,42,latency,b
+,,1,2021-01-01T00:00:00Z,2021-01-01T00:05:00Z,7,latency,b
+
+`
+
+const twoSchemasResponse = `#datatype,string,long,double,string
+#group,false,false,false,true
+#default,_result,,,
+,result,table,_value,_field
+,,0,12.5,latency
+
+#datatype,string,long,long,string
+#group,false,false,false,true
+#default,_result,,,
+,result,table,_value,_field
+,,1,3,errors
+
+`
+
+const emptyResponse = "\r\n"
+
+const errorResponse = `#datatype,string,string
+#group,true,true
+#default,,
+,error,reference
+,failed to execute query,897
+
+`
+
+func newAnalysisRun() *v1alpha1.AnalysisRun {
+	return &v1alpha1.AnalysisRun{}
+}
+
+func newMetric(successCondition string) v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "foo",
+		SuccessCondition: successCondition,
+		Provider: v1alpha1.MetricProvider{
+			Influxdb: &v1alpha1.InfluxdbMetric{
+				Query: query,
+			},
+		},
+	}
+}
+
+// newServer starts an InfluxDB server which responds to queries with the response, and records the
+// body of the last query
+func newServer(t *testing.T, status int, response string, body *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v2/query", r.URL.Path)
+		assert.Equal(t, "my-org", r.URL.Query().Get("org"))
+		assert.Equal(t, "Token my-token", r.Header.Get("Authorization"))
+		bodyBytes, _ := ioutil.ReadAll(r.Body)
+		*body = string(bodyBytes)
+		if status == http.StatusOK {
+			w.Header().Set("Content-Type", "text/csv")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, response)
+	}))
+}
+
+func newProvider(address string) *Provider {
+	api := influxdb2.NewClient(address, "my-token").QueryAPI("my-org")
+	return NewInfluxdbProvider(api, *log.NewEntry(log.New()))
+}
+
+func TestType(t *testing.T) {
+	p := newProvider("http://influxdb")
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestRunSuccessfully(t *testing.T) {
+	var body string
+	server := newServer(t, http.StatusOK, twoTablesResponse, &body)
+	defer server.Close()
+
+	p := newProvider(server.URL)
+	measurement := p.Run(newAnalysisRun(), newMetric("result[0][0]._value < 20 && all(result[1], {#.device == 'b'})"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+	assert.NotNil(t, measurement.StartedAt)
+	assert.NotNil(t, measurement.FinishedAt)
+	assert.Contains(t, measurement.Value, `"_start":"2021-01-01T00:00:00Z"`)
+	assert.Contains(t, measurement.Value, `"_value":42`)
+	assert.Contains(t, body, "range(start: -5m)")
+}
+
+func TestRunGroupsRecordsByTable(t *testing.T) {
+	var body string
+	server := newServer(t, http.StatusOK, twoTablesResponse, &body)
+	defer server.Close()
+
+	// the tables 0 and 1 share their columns, so that only the table column of their records tells
+	// them apart
+	p := newProvider(server.URL)
+	measurement := p.Run(newAnalysisRun(), newMetric("len(result) == 2 && len(result[0]) == 1 && len(result[1]) == 2"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+}
+
+func TestRunGroupsRecordsByTableSchema(t *testing.T) {
+	var body string
+	server := newServer(t, http.StatusOK, twoSchemasResponse, &body)
+	defer server.Close()
+
+	p := newProvider(server.URL)
+	measurement := p.Run(newAnalysisRun(), newMetric("len(result) == 2 && result[0][0]._field == 'latency' && result[1][0]._value == 3"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+}
+
+func TestRunFailed(t *testing.T) {
+	var body string
+	server := newServer(t, http.StatusOK, twoTablesResponse, &body)
+	defer server.Close()
+
+	p := newProvider(server.URL)
+	measurement := p.Run(newAnalysisRun(), newMetric("all(result, {all(#, {#._value < 20})})"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase, measurement.Message)
+}
+
+func TestRunWithoutRecords(t *testing.T) {
+	var body string
+	server := newServer(t, http.StatusOK, emptyResponse, &body)
+	defer server.Close()
+
+	p := newProvider(server.URL)
+	measurement := p.Run(newAnalysisRun(), newMetric("result[0][0]._value < 20"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseInconclusive, measurement.Phase, measurement.Message)
+	assert.Equal(t, "[]", measurement.Value)
+}
+
+func TestRunWithQueryError(t *testing.T) {
+	var body string
+	server := newServer(t, http.StatusBadRequest, `{"code":"invalid","message":"compilation failed"}`, &body)
+	defer server.Close()
+
+	p := newProvider(server.URL)
+	measurement := p.Run(newAnalysisRun(), newMetric("true"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Contains(t, measurement.Message, "compilation failed")
+	assert.NotNil(t, measurement.FinishedAt)
+}
+
+func TestRunWithErrorInResponse(t *testing.T) {
+	var body string
+	server := newServer(t, http.StatusOK, errorResponse, &body)
+	defer server.Close()
+
+	p := newProvider(server.URL)
+	measurement := p.Run(newAnalysisRun(), newMetric("true"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Contains(t, measurement.Message, "failed to execute query")
+}
+
+func TestResume(t *testing.T) {
+	p := newProvider("http://influxdb")
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseInconclusive,
+	}
+	measurement := p.Resume(newAnalysisRun(), newMetric("true"), previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestTerminate(t *testing.T) {
+	p := newProvider("http://influxdb")
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseRunning,
+	}
+	measurement := p.Terminate(newAnalysisRun(), newMetric("true"), previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestGarbageCollect(t *testing.T) {
+	p := newProvider("http://influxdb")
+	err := p.GarbageCollect(nil, v1alpha1.Metric{}, 0)
+	assert.NoError(t, err)
+}
+
+func TestProcessResponse(t *testing.T) {
+	p := newProvider("http://influxdb")
+	tables := [][]map[string]interface{}{
+		{{"_value": 12.5, "device": "a"}},
+		{{"_value": int64(42), "device": "b"}},
+	}
+	value, status, err := p.processResponse(newMetric("result[0][0]._value < 20 && result[1][0]._value == 42"), tables)
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, status)
+	assert.Equal(t, `[[{"_value":12.5,"device":"a"}],[{"_value":42,"device":"b"}]]`, value)
+}
+
+func TestProcessEmptyResponse(t *testing.T) {
+	p := newProvider("http://influxdb")
+	value, status, err := p.processResponse(newMetric("true"), [][]map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseInconclusive, status)
+	assert.Equal(t, "[]", value)
+}
+
+func TestNewInfluxdbAPI(t *testing.T) {
+	var body string
+	server := newServer(t, http.StatusOK, twoTablesResponse, &body)
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-influxdb",
+			Namespace: defaults.Namespace(),
+		},
+		Data: map[string][]byte{
+			"address":   []byte(server.URL),
+			"authToken": []byte("my-token"),
+			"org":       []byte("my-org"),
+		},
+	}
+	metric := newMetric("result[0][0]._value < 20")
+
+	t.Run("with the profile secret", func(t *testing.T) {
+		metric.Provider.Influxdb.Profile = "my-influxdb"
+		api, err := NewInfluxdbAPI(metric, k8sfake.NewSimpleClientset(secret))
+		assert.NoError(t, err)
+		measurement := NewInfluxdbProvider(api, *log.NewEntry(log.New())).Run(newAnalysisRun(), metric)
+		assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+	})
+
+	t.Run("without the default secret", func(t *testing.T) {
+		metric.Provider.Influxdb.Profile = ""
+		_, err := NewInfluxdbAPI(metric, k8sfake.NewSimpleClientset(secret))
+		assert.EqualError(t, err, fmt.Sprintf("secrets \"%s\" not found", DefaultInfluxdbProfileSecretName))
+	})
+
+	t.Run("with the token missing", func(t *testing.T) {
+		metric.Provider.Influxdb.Profile = "my-influxdb"
+		incomplete := secret.DeepCopy()
+		delete(incomplete.Data, "authToken")
+		_, err := NewInfluxdbAPI(metric, k8sfake.NewSimpleClientset(incomplete))
+		assert.EqualError(t, err, "address, authToken or org not found")
+	})
+}
+
+func TestGetClient(t *testing.T) {
+	client := getClient("ns/reused", "http://influxdb", "my-token")
+	assert.True(t, client == getClient("ns/reused", "http://influxdb", "my-token"))
+	assert.False(t, client == getClient("ns/other", "http://influxdb", "my-token"))
+
+	// the client is replaced when the credentials of the profile change
+	rotated := getClient("ns/reused", "http://influxdb", "my-new-token")
+	assert.False(t, client == rotated)
+	assert.True(t, rotated == getClient("ns/reused", "http://influxdb", "my-new-token"))
+}
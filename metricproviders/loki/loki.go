@@ -0,0 +1,286 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+)
+
+const (
+	//ProviderType indicates the provider is loki
+	ProviderType = "Loki"
+	// TenantHeader is the header holding the tenant of multi-tenant loki deployments
+	TenantHeader = "X-Scope-OrgID"
+)
+
+// API is the loki API used by the provider. The values of the queries are the ones of their
+// prometheus counterparts, since loki returns metric queries in the same format.
+type API interface {
+	Query(ctx context.Context, query string, ts time.Time) (model.Value, error)
+	QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (model.Value, error)
+}
+
+type lokiAPI struct {
+	client   *http.Client
+	address  string
+	tenantID string
+	username string
+	password string
+	token    string
+}
+
+// queryResponse is the response of the query APIs of loki
+type queryResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// Query performs an instant query at the time
+func (api *lokiAPI) Query(ctx context.Context, query string, ts time.Time) (model.Value, error) {
+	q := url.Values{}
+	q.Set("query", query)
+	q.Set("time", strconv.FormatInt(ts.UnixNano(), 10))
+	return api.do(ctx, "/loki/api/v1/query", q)
+}
+
+// QueryRange performs a range query over the time window, with the step as resolution when it is set
+func (api *lokiAPI) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (model.Value, error) {
+	q := url.Values{}
+	q.Set("query", query)
+	q.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	q.Set("end", strconv.FormatInt(end.UnixNano(), 10))
+	if step > 0 {
+		q.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+	}
+	return api.do(ctx, "/loki/api/v1/query_range", q)
+}
+
+func (api *lokiAPI) do(ctx context.Context, path string, query url.Values) (model.Value, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(api.address, "/")+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if api.tenantID != "" {
+		req.Header.Set(TenantHeader, api.tenantID)
+	}
+	if api.token != "" {
+		req.Header.Set("Authorization", "Bearer "+api.token)
+	} else if api.username != "" {
+		req.SetBasicAuth(api.username, api.password)
+	}
+
+	resp, err := api.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received non 2xx response code: %v. Body: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var response queryResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("could not parse loki response: %v", err)
+	}
+	var value model.Value
+	switch response.Data.ResultType {
+	case model.ValScalar.String():
+		value = &model.Scalar{}
+	case model.ValVector.String():
+		value = &model.Vector{}
+	case model.ValMatrix.String():
+		value = &model.Matrix{}
+	default:
+		return nil, fmt.Errorf("loki result type '%s' not supported, the query must be a metric query", response.Data.ResultType)
+	}
+	if err := json.Unmarshal(response.Data.Result, value); err != nil {
+		return nil, fmt.Errorf("could not parse loki response: %v", err)
+	}
+	return value, nil
+}
+
+// Provider contains all the required components to run a loki query
+type Provider struct {
+	api    API
+	logCtx log.Entry
+}
+
+// Type indicates provider is a loki provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run queries loki for the metric
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	newMeasurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var response model.Value
+	var err error
+	if metric.Provider.Loki.Range == "" {
+		response, err = p.api.Query(ctx, metric.Provider.Loki.Query, startTime.Time)
+	} else {
+		response, err = p.queryRange(ctx, metric.Provider.Loki, startTime.Time)
+	}
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+
+	newValue, newStatus, err := p.processResponse(metric, response)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newMeasurement.Value = newValue
+	newMeasurement.Phase = newStatus
+	finishedTime := metav1.Now()
+	newMeasurement.FinishedAt = &finishedTime
+	return newMeasurement
+}
+
+func (p *Provider) queryRange(ctx context.Context, metric *v1alpha1.LokiMetric, end time.Time) (model.Value, error) {
+	queryRange, err := metric.Range.Duration()
+	if err != nil {
+		return nil, err
+	}
+	var step time.Duration
+	if metric.Step != "" {
+		step, err = metric.Step.Duration()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p.api.QueryRange(ctx, metric.Query, end.Add(-queryRange), end, step)
+}
+
+// Resume should not be used the loki provider since all the work should occur in the Run method
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Loki provider should not execute the Resume method")
+	return measurement
+}
+
+// Terminate should not be used the loki provider since all the work should occur in the Run method
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Loki provider should not execute the Terminate method")
+	return measurement
+}
+
+// GarbageCollect is a no-op for the loki provider
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	return nil
+}
+
+// processResponse evaluates the value of a scalar, the list of the values of a vector, or the list
+// of the values of all the series of a matrix
+func (p *Provider) processResponse(metric v1alpha1.Metric, response model.Value) (string, v1alpha1.AnalysisPhase, error) {
+	switch value := response.(type) {
+	case *model.Scalar:
+		valueStr := value.Value.String()
+		result := float64(value.Value)
+		if math.IsNaN(result) {
+			return valueStr, v1alpha1.AnalysisPhaseInconclusive, nil
+		}
+		newStatus := evaluate.EvaluateResult(result, metric, p.logCtx)
+		return valueStr, newStatus, nil
+	case *model.Vector:
+		values := make([]model.SampleValue, 0, len(*value))
+		for _, s := range *value {
+			if s != nil {
+				values = append(values, s.Value)
+			}
+		}
+		return p.processValues(metric, values)
+	case *model.Matrix:
+		values := []model.SampleValue{}
+		for _, s := range *value {
+			if s != nil {
+				for _, v := range s.Values {
+					values = append(values, v.Value)
+				}
+			}
+		}
+		return p.processValues(metric, values)
+	default:
+		return "", v1alpha1.AnalysisPhaseError, fmt.Errorf("Loki metric type not supported")
+	}
+}
+
+func (p *Provider) processValues(metric v1alpha1.Metric, values []model.SampleValue) (string, v1alpha1.AnalysisPhase, error) {
+	results := make([]float64, 0, len(values))
+	valueStrs := make([]string, 0, len(values))
+	for _, v := range values {
+		results = append(results, float64(v))
+		valueStrs = append(valueStrs, v.String())
+	}
+	valueStr := "[" + strings.Join(valueStrs, ",") + "]"
+	for _, result := range results {
+		if math.IsNaN(result) {
+			return valueStr, v1alpha1.AnalysisPhaseInconclusive, nil
+		}
+	}
+	newStatus := evaluate.EvaluateResult(results, metric, p.logCtx)
+	return valueStr, newStatus, nil
+}
+
+// NewLokiProvider creates a new loki provider
+func NewLokiProvider(api API, logCtx log.Entry) *Provider {
+	return &Provider{
+		logCtx: logCtx,
+		api:    api,
+	}
+}
+
+// NewLokiAPI creates a loki API from the metric configuration. The tenant and the credentials are
+// read from the profile secret of the metric, which holds either a bearer token or a username and
+// password.
+func NewLokiAPI(metric v1alpha1.Metric, kubeclientset kubernetes.Interface) (API, error) {
+	if metric.Provider.Loki.Address == "" {
+		return nil, errors.New("loki address is not set")
+	}
+	api := &lokiAPI{
+		client:  &http.Client{},
+		address: metric.Provider.Loki.Address,
+	}
+	if metric.Provider.Loki.Profile == "" {
+		return api, nil
+	}
+
+	secret, err := kubeclientset.CoreV1().Secrets(defaults.Namespace()).Get(context.TODO(), metric.Provider.Loki.Profile, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	api.tenantID = string(secret.Data["tenantID"])
+	api.token = string(secret.Data["token"])
+	api.username = string(secret.Data["username"])
+	api.password = string(secret.Data["password"])
+	return api, nil
+}
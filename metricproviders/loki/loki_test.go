@@ -0,0 +1,323 @@
+package loki
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+)
+
+const vectorResponse = `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"pod":"a"},"value":[1600000000,"2"]},{"metric":{"pod":"b"},"value":[1600000000,"3.5"]}]}}`
+
+const matrixResponse = `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"pod":"a"},"values":[[1600000000,"1"],[1600000060,"4"]]},{"metric":{"pod":"b"},"values":[[1600000000,"2"]]}]}}`
+
+const scalarResponse = `{"status":"success","data":{"resultType":"scalar","result":[1600000000,"0.5"]}}`
+
+const streamsResponse = `{"status":"success","data":{"resultType":"streams","result":[{"stream":{"pod":"a"},"values":[["1600000000000000000","error"]]}]}}`
+
+// request is a query request received by the server
+type request struct {
+	path   string
+	query  url.Values
+	header http.Header
+}
+
+// newServer starts a loki server which responds to queries with the response, and records the last
+// request
+func newServer(t *testing.T, status int, response string, req *request) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*req = request{
+			path:   r.URL.Path,
+			query:  r.URL.Query(),
+			header: r.Header,
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, response)
+	}))
+}
+
+func newAnalysisRun() *v1alpha1.AnalysisRun {
+	return &v1alpha1.AnalysisRun{}
+}
+
+func newMetric(address, successCondition string) v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "foo",
+		SuccessCondition: successCondition,
+		Provider: v1alpha1.MetricProvider{
+			Loki: &v1alpha1.LokiMetric{
+				Address: address,
+				Query:   `sum(count_over_time({app="my-app"} |= "error" [1m]))`,
+			},
+		},
+	}
+}
+
+func newProvider(t *testing.T, metric v1alpha1.Metric) *Provider {
+	api, err := NewLokiAPI(metric, k8sfake.NewSimpleClientset())
+	assert.NoError(t, err)
+	return NewLokiProvider(api, *log.NewEntry(log.New()))
+}
+
+func TestType(t *testing.T) {
+	p := newProvider(t, newMetric("http://loki", "true"))
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestRunInstantQuery(t *testing.T) {
+	var req request
+	server := newServer(t, http.StatusOK, vectorResponse, &req)
+	defer server.Close()
+
+	metric := newMetric(server.URL, "all(result, {# < 5})")
+	measurement := newProvider(t, metric).Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+	assert.Equal(t, "[2,3.5]", measurement.Value)
+	assert.NotNil(t, measurement.StartedAt)
+	assert.NotNil(t, measurement.FinishedAt)
+
+	assert.Equal(t, "/loki/api/v1/query", req.path)
+	assert.Equal(t, metric.Provider.Loki.Query, req.query.Get("query"))
+	assert.Equal(t, strconv.FormatInt(measurement.StartedAt.UnixNano(), 10), req.query.Get("time"))
+	assert.Equal(t, "", req.header.Get(TenantHeader))
+}
+
+func TestRunRangeQuery(t *testing.T) {
+	var req request
+	server := newServer(t, http.StatusOK, matrixResponse, &req)
+	defer server.Close()
+
+	metric := newMetric(server.URL, "all(result, {# < 3})")
+	metric.Provider.Loki.Range = "10m"
+	metric.Provider.Loki.Step = "1m"
+	measurement := newProvider(t, metric).Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase, measurement.Message)
+	assert.Equal(t, "[1,4,2]", measurement.Value)
+
+	assert.Equal(t, "/loki/api/v1/query_range", req.path)
+	start, err := strconv.ParseInt(req.query.Get("start"), 10, 64)
+	assert.NoError(t, err)
+	end, err := strconv.ParseInt(req.query.Get("end"), 10, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10*time.Minute), end-start)
+	assert.Equal(t, "60", req.query.Get("step"))
+}
+
+func TestRunRangeQueryWithInvalidRange(t *testing.T) {
+	metric := newMetric("http://loki", "true")
+	metric.Provider.Loki.Range = "invalid"
+	measurement := newProvider(t, metric).Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+
+	metric.Provider.Loki.Range = "5m"
+	metric.Provider.Loki.Step = "invalid"
+	measurement = newProvider(t, metric).Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+}
+
+func TestRunScalarQuery(t *testing.T) {
+	var req request
+	server := newServer(t, http.StatusOK, scalarResponse, &req)
+	defer server.Close()
+
+	metric := newMetric(server.URL, "result < 1")
+	measurement := newProvider(t, metric).Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+	assert.Equal(t, "0.5", measurement.Value)
+}
+
+func TestRunWithLogQuery(t *testing.T) {
+	var req request
+	server := newServer(t, http.StatusOK, streamsResponse, &req)
+	defer server.Close()
+
+	metric := newMetric(server.URL, "true")
+	measurement := newProvider(t, metric).Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "loki result type 'streams' not supported, the query must be a metric query", measurement.Message)
+}
+
+func TestRunWithResolvedArgs(t *testing.T) {
+	var req request
+	server := newServer(t, http.StatusOK, vectorResponse, &req)
+	defer server.Close()
+
+	hash := "5d4b8f6c7"
+	metric := newMetric(server.URL, "all(result, {# < 5})")
+	metric.Provider.Loki.Query = `sum(count_over_time({rollouts_pod_template_hash="{{args.canary-hash}}"} |= "error" [1m]))`
+	resolvedMetric, err := analysisutil.ResolveMetricArgs(metric, []v1alpha1.Argument{{Name: "canary-hash", Value: &hash}})
+	assert.NoError(t, err)
+	measurement := newProvider(t, *resolvedMetric).Run(newAnalysisRun(), *resolvedMetric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+	assert.Equal(t, `sum(count_over_time({rollouts_pod_template_hash="5d4b8f6c7"} |= "error" [1m]))`, req.query.Get("query"))
+}
+
+func TestResume(t *testing.T) {
+	metric := newMetric("http://loki", "true")
+	p := newProvider(t, metric)
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseInconclusive,
+	}
+	measurement := p.Resume(newAnalysisRun(), metric, previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestTerminate(t *testing.T) {
+	metric := newMetric("http://loki", "true")
+	p := newProvider(t, metric)
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseRunning,
+	}
+	measurement := p.Terminate(newAnalysisRun(), metric, previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestGarbageCollect(t *testing.T) {
+	p := newProvider(t, newMetric("http://loki", "true"))
+	err := p.GarbageCollect(nil, v1alpha1.Metric{}, 0)
+	assert.NoError(t, err)
+}
+
+func TestProcessNaNScalarResponse(t *testing.T) {
+	p := newProvider(t, newMetric("http://loki", "true"))
+	response := &model.Scalar{
+		Value:     model.SampleValue(math.NaN()),
+		Timestamp: model.Time(0),
+	}
+	value, status, err := p.processResponse(newMetric("http://loki", "true"), response)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseInconclusive, status)
+	assert.Equal(t, "NaN", value)
+}
+
+func TestProcessNaNVectorResponse(t *testing.T) {
+	p := newProvider(t, newMetric("http://loki", "true"))
+	response := &model.Vector{
+		{Value: model.SampleValue(2), Timestamp: model.Time(0)},
+		{Value: model.SampleValue(math.NaN()), Timestamp: model.Time(0)},
+	}
+	value, status, err := p.processResponse(newMetric("http://loki", "true"), response)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseInconclusive, status)
+	assert.Equal(t, "[2,NaN]", value)
+}
+
+func TestProcessMatrixResponse(t *testing.T) {
+	p := newProvider(t, newMetric("http://loki", "true"))
+	// the values of all the series are flattened in a single list
+	response := &model.Matrix{
+		{Values: []model.SamplePair{{Value: 1}, {Value: 4}}},
+		{Values: []model.SamplePair{}},
+		{Values: []model.SamplePair{{Value: 2}}},
+	}
+	value, status, err := p.processResponse(newMetric("http://loki", "len(result) == 3 && result[1] == 4"), response)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, status)
+	assert.Equal(t, "[1,4,2]", value)
+}
+
+func TestProcessNaNMatrixResponse(t *testing.T) {
+	p := newProvider(t, newMetric("http://loki", "true"))
+	// a series without data in a step of the range has a NaN value, e.g. a division by zero
+	response := &model.Matrix{
+		{Values: []model.SamplePair{{Value: 1}}},
+		{Values: []model.SamplePair{{Value: model.SampleValue(math.NaN())}, {Value: 2}}},
+	}
+	value, status, err := p.processResponse(newMetric("http://loki", "true"), response)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseInconclusive, status)
+	assert.Equal(t, "[1,NaN,2]", value)
+}
+
+func TestProcessEmptyMatrixResponse(t *testing.T) {
+	p := newProvider(t, newMetric("http://loki", "true"))
+	value, status, err := p.processResponse(newMetric("http://loki", "len(result) == 0"), &model.Matrix{})
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, status)
+	assert.Equal(t, "[]", value)
+}
+
+func TestProcessInvalidResponse(t *testing.T) {
+	p := newProvider(t, newMetric("http://loki", "true"))
+	value, status, err := p.processResponse(newMetric("http://loki", "true"), nil)
+	assert.EqualError(t, err, "Loki metric type not supported")
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, status)
+	assert.Equal(t, "", value)
+}
+
+func TestNewLokiAPI(t *testing.T) {
+	newSecret := func(data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "my-loki",
+				Namespace: defaults.Namespace(),
+			},
+			Data: data,
+		}
+	}
+
+	t.Run("without an address", func(t *testing.T) {
+		_, err := NewLokiAPI(newMetric("", "true"), k8sfake.NewSimpleClientset())
+		assert.EqualError(t, err, "loki address is not set")
+	})
+
+	t.Run("with a tenant and basic auth", func(t *testing.T) {
+		var req request
+		server := newServer(t, http.StatusOK, scalarResponse, &req)
+		defer server.Close()
+
+		metric := newMetric(server.URL, "true")
+		metric.Provider.Loki.Profile = "my-loki"
+		secret := newSecret(map[string][]byte{
+			"tenantID": []byte("team-a"),
+			"username": []byte("user"),
+			"password": []byte("pass"),
+		})
+		api, err := NewLokiAPI(metric, k8sfake.NewSimpleClientset(secret))
+		assert.NoError(t, err)
+		NewLokiProvider(api, *log.NewEntry(log.New())).Run(newAnalysisRun(), metric)
+		assert.Equal(t, "team-a", req.header.Get(TenantHeader))
+		assert.Equal(t, "Basic dXNlcjpwYXNz", req.header.Get("Authorization"))
+	})
+
+	t.Run("with a token", func(t *testing.T) {
+		var req request
+		server := newServer(t, http.StatusOK, scalarResponse, &req)
+		defer server.Close()
+
+		metric := newMetric(server.URL, "true")
+		metric.Provider.Loki.Profile = "my-loki"
+		secret := newSecret(map[string][]byte{"token": []byte("my-token")})
+		api, err := NewLokiAPI(metric, k8sfake.NewSimpleClientset(secret))
+		assert.NoError(t, err)
+		NewLokiProvider(api, *log.NewEntry(log.New())).Run(newAnalysisRun(), metric)
+		assert.Equal(t, "", req.header.Get(TenantHeader))
+		assert.Equal(t, "Bearer my-token", req.header.Get("Authorization"))
+	})
+
+	t.Run("without the secret", func(t *testing.T) {
+		metric := newMetric("http://loki", "true")
+		metric.Provider.Loki.Profile = "my-loki"
+		_, err := NewLokiAPI(metric, k8sfake.NewSimpleClientset())
+		assert.EqualError(t, err, `secrets "my-loki" not found`)
+	})
+}
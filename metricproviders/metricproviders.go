@@ -6,16 +6,27 @@ import (
 	"github.com/argoproj/argo-rollouts/metricproviders/newrelic"
 	"github.com/argoproj/argo-rollouts/metricproviders/wavefront"
 
+	"github.com/argoproj/argo-rollouts/metricproviders/cloudwatch"
 	"github.com/argoproj/argo-rollouts/metricproviders/datadog"
+	"github.com/argoproj/argo-rollouts/metricproviders/dynatrace"
+	"github.com/argoproj/argo-rollouts/metricproviders/elasticsearch"
+	"github.com/argoproj/argo-rollouts/metricproviders/graphite"
+	"github.com/argoproj/argo-rollouts/metricproviders/influxdb"
 	"github.com/argoproj/argo-rollouts/metricproviders/kayenta"
+	"github.com/argoproj/argo-rollouts/metricproviders/loki"
 	"github.com/argoproj/argo-rollouts/metricproviders/webmetric"
 
 	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	batchlisters "k8s.io/client-go/listers/batch/v1"
 
 	"github.com/argoproj/argo-rollouts/metricproviders/job"
+	"github.com/argoproj/argo-rollouts/metricproviders/plugin"
 	"github.com/argoproj/argo-rollouts/metricproviders/prometheus"
+	"github.com/argoproj/argo-rollouts/metricproviders/resource"
+	"github.com/argoproj/argo-rollouts/metricproviders/sql"
+	"github.com/argoproj/argo-rollouts/metricproviders/stackdriver"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 )
@@ -36,8 +47,9 @@ type Provider interface {
 }
 
 type ProviderFactory struct {
-	KubeClient kubernetes.Interface
-	JobLister  batchlisters.JobLister
+	KubeClient    kubernetes.Interface
+	DynamicClient dynamic.Interface
+	JobLister     batchlisters.JobLister
 }
 
 type ProviderFactoryFunc func(logCtx log.Entry, metric v1alpha1.Metric) (Provider, error)
@@ -77,6 +89,66 @@ func (f *ProviderFactory) NewProvider(logCtx log.Entry, metric v1alpha1.Metric)
 			return nil, err
 		}
 		return newrelic.NewNewRelicProvider(client, logCtx), nil
+	case plugin.ProviderType:
+		client, err := plugin.NewClient(metric.Provider.Plugin.Name)
+		if err != nil {
+			return nil, err
+		}
+		return plugin.NewPluginProvider(client, logCtx), nil
+	case cloudwatch.ProviderType:
+		api, err := cloudwatch.NewCloudWatchAPI(metric)
+		if err != nil {
+			return nil, err
+		}
+		return cloudwatch.NewCloudWatchProvider(api, logCtx), nil
+	case graphite.ProviderType:
+		api, err := graphite.NewGraphiteAPI(metric)
+		if err != nil {
+			return nil, err
+		}
+		return graphite.NewGraphiteProvider(api, logCtx), nil
+	case influxdb.ProviderType:
+		api, err := influxdb.NewInfluxdbAPI(metric, f.KubeClient)
+		if err != nil {
+			return nil, err
+		}
+		return influxdb.NewInfluxdbProvider(api, logCtx), nil
+	case elasticsearch.ProviderType:
+		api, err := elasticsearch.NewElasticsearchAPI(metric, f.KubeClient)
+		if err != nil {
+			return nil, err
+		}
+		p, err := elasticsearch.NewElasticsearchJsonParser(metric)
+		if err != nil {
+			return nil, err
+		}
+		return elasticsearch.NewElasticsearchProvider(api, p, logCtx), nil
+	case loki.ProviderType:
+		api, err := loki.NewLokiAPI(metric, f.KubeClient)
+		if err != nil {
+			return nil, err
+		}
+		return loki.NewLokiProvider(api, logCtx), nil
+	case stackdriver.ProviderType:
+		api, err := stackdriver.NewStackdriverAPI(metric, f.KubeClient)
+		if err != nil {
+			return nil, err
+		}
+		return stackdriver.NewStackdriverProvider(api, logCtx), nil
+	case dynatrace.ProviderType:
+		api, err := dynatrace.NewDynatraceAPI(metric, f.KubeClient)
+		if err != nil {
+			return nil, err
+		}
+		return dynatrace.NewDynatraceProvider(api, logCtx), nil
+	case sql.ProviderType:
+		api, err := sql.NewSQLAPI(metric, f.KubeClient)
+		if err != nil {
+			return nil, err
+		}
+		return sql.NewSQLProvider(api, logCtx), nil
+	case resource.ProviderType:
+		return resource.NewResourceProvider(logCtx, f.DynamicClient), nil
 	default:
 		return nil, fmt.Errorf("no valid provider in metric '%s'", metric.Name)
 	}
@@ -97,6 +169,26 @@ func Type(metric v1alpha1.Metric) string {
 		return wavefront.ProviderType
 	} else if metric.Provider.NewRelic != nil {
 		return newrelic.ProviderType
+	} else if metric.Provider.Plugin != nil {
+		return plugin.ProviderType
+	} else if metric.Provider.CloudWatch != nil {
+		return cloudwatch.ProviderType
+	} else if metric.Provider.Graphite != nil {
+		return graphite.ProviderType
+	} else if metric.Provider.Influxdb != nil {
+		return influxdb.ProviderType
+	} else if metric.Provider.Elasticsearch != nil {
+		return elasticsearch.ProviderType
+	} else if metric.Provider.Loki != nil {
+		return loki.ProviderType
+	} else if metric.Provider.Stackdriver != nil {
+		return stackdriver.ProviderType
+	} else if metric.Provider.Dynatrace != nil {
+		return dynatrace.ProviderType
+	} else if metric.Provider.SQL != nil {
+		return sql.ProviderType
+	} else if metric.Provider.Resource != nil {
+		return resource.ProviderType
 	}
 	return "Unknown Provider"
 }
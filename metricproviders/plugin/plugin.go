@@ -0,0 +1,129 @@
+package plugin
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+	pluginutil "github.com/argoproj/argo-rollouts/utils/plugin"
+)
+
+const (
+	// ProviderType indicates the provider is a plugin
+	ProviderType = "Plugin"
+	// callTimeout is the maximum duration of a call to a plugin
+	callTimeout = 30 * time.Second
+)
+
+var registry = pluginutil.NewRegistry()
+
+// RegisterPlugins dials the metric provider plugins of name=address pairs and registers them with
+// the controller
+func RegisterPlugins(pairs []string) error {
+	return registry.RegisterAddresses(pairs)
+}
+
+// RegisterPlugin registers the connection to the metric provider plugin with the name
+func RegisterPlugin(name string, conn grpc.ClientConnInterface) {
+	registry.Register(name, conn)
+}
+
+// NewClient returns a client of the registered metric provider plugin with the name
+func NewClient(name string) (MetricProviderClient, error) {
+	conn, err := registry.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewMetricProviderClient(conn), nil
+}
+
+// Provider contains all the required components to run a measurement through a plugin
+type Provider struct {
+	client MetricProviderClient
+	logCtx log.Entry
+}
+
+// Type indicates provider is a plugin provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run asks the plugin to start a new measurement
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	newMeasurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), callTimeout)
+	defer cancel()
+	resp, err := p.client.Run(ctx, &RunRequest{
+		AnalysisRun: run,
+		Metric:      metric,
+		Config:      metric.Provider.Plugin.Config,
+	})
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	if resp.Measurement.StartedAt == nil {
+		resp.Measurement.StartedAt = &startTime
+	}
+	return resp.Measurement
+}
+
+// Resume asks the plugin for the current state of the measurement
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	ctx, cancel := context.WithTimeout(context.TODO(), callTimeout)
+	defer cancel()
+	resp, err := p.client.Resume(ctx, &ResumeRequest{
+		AnalysisRun: run,
+		Metric:      metric,
+		Config:      metric.Provider.Plugin.Config,
+		Measurement: measurement,
+	})
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+	return resp.Measurement
+}
+
+// Terminate asks the plugin to terminate the in-progress measurement
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	ctx, cancel := context.WithTimeout(context.TODO(), callTimeout)
+	defer cancel()
+	resp, err := p.client.Terminate(ctx, &TerminateRequest{
+		AnalysisRun: run,
+		Metric:      metric,
+		Config:      metric.Provider.Plugin.Config,
+		Measurement: measurement,
+	})
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+	return resp.Measurement
+}
+
+// GarbageCollect asks the plugin to garbage collect completed measurements to the specified limit
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	ctx, cancel := context.WithTimeout(context.TODO(), callTimeout)
+	defer cancel()
+	_, err := p.client.GarbageCollect(ctx, &GarbageCollectRequest{
+		AnalysisRun: run,
+		Metric:      metric,
+		Config:      metric.Provider.Plugin.Config,
+		Limit:       limit,
+	})
+	return err
+}
+
+// NewPluginProvider creates a new plugin provider
+func NewPluginProvider(client MetricProviderClient, logCtx log.Entry) *Provider {
+	return &Provider{
+		client: client,
+		logCtx: logCtx,
+	}
+}
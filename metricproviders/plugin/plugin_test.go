@@ -0,0 +1,175 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+// fakePlugin is an in-process metric provider plugin which completes measurements on Resume
+type fakePlugin struct {
+	config string
+	limit  int
+	err    error
+}
+
+func (p *fakePlugin) Run(ctx context.Context, req *RunRequest) (*MeasurementResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.config = req.Config
+	return &MeasurementResponse{
+		Measurement: v1alpha1.Measurement{
+			Phase:    v1alpha1.AnalysisPhaseRunning,
+			Metadata: map[string]string{"run": req.AnalysisRun.Name},
+		},
+	}, nil
+}
+
+func (p *fakePlugin) Resume(ctx context.Context, req *ResumeRequest) (*MeasurementResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	measurement := req.Measurement
+	measurement.Phase = v1alpha1.AnalysisPhaseSuccessful
+	measurement.Value = "1"
+	return &MeasurementResponse{Measurement: measurement}, nil
+}
+
+func (p *fakePlugin) Terminate(ctx context.Context, req *TerminateRequest) (*MeasurementResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	measurement := req.Measurement
+	measurement.Phase = v1alpha1.AnalysisPhaseSuccessful
+	measurement.Message = "Metric Terminated"
+	return &MeasurementResponse{Measurement: measurement}, nil
+}
+
+func (p *fakePlugin) GarbageCollect(ctx context.Context, req *GarbageCollectRequest) (*GarbageCollectResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.limit = req.Limit
+	return &GarbageCollectResponse{}, nil
+}
+
+// startFakePlugin serves the fake plugin over an in-memory listener and returns a connection to
+// it, along with a function which stops the plugin
+func startFakePlugin(t *testing.T, plugin *fakePlugin) (*grpc.ClientConn, func()) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	RegisterMetricProviderServer(server, plugin)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	assert.NoError(t, err)
+	return conn, func() {
+		_ = conn.Close()
+		server.Stop()
+	}
+}
+
+func newProvider(t *testing.T, plugin *fakePlugin) (*Provider, func()) {
+	conn, stop := startFakePlugin(t, plugin)
+	logCtx := log.WithField("test", "test")
+	return NewPluginProvider(NewMetricProviderClient(conn), *logCtx), stop
+}
+
+func newMetric() v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name: "foo",
+		Provider: v1alpha1.MetricProvider{
+			Plugin: &v1alpha1.PluginMetric{
+				Name:   "fake",
+				Config: `{"query": "my-query"}`,
+			},
+		},
+	}
+}
+
+func newAnalysisRun() *v1alpha1.AnalysisRun {
+	run := &v1alpha1.AnalysisRun{}
+	run.Name = "run"
+	return run
+}
+
+func TestType(t *testing.T) {
+	p, stop := newProvider(t, &fakePlugin{})
+	defer stop()
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestRunResumeTerminate(t *testing.T) {
+	plugin := &fakePlugin{}
+	p, stop := newProvider(t, plugin)
+	defer stop()
+	metric := newMetric()
+	run := newAnalysisRun()
+
+	measurement := p.Run(run, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, measurement.Phase)
+	assert.NotNil(t, measurement.StartedAt)
+	assert.Equal(t, "run", measurement.Metadata["run"])
+	assert.Equal(t, `{"query": "my-query"}`, plugin.config)
+
+	resumed := p.Resume(run, metric, measurement)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, resumed.Phase)
+	assert.Equal(t, "1", resumed.Value)
+	assert.Equal(t, "run", resumed.Metadata["run"])
+
+	terminated := p.Terminate(run, metric, measurement)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, terminated.Phase)
+	assert.Equal(t, "Metric Terminated", terminated.Message)
+
+	assert.NoError(t, p.GarbageCollect(run, metric, 2))
+	assert.Equal(t, 2, plugin.limit)
+}
+
+func TestPluginError(t *testing.T) {
+	p, stop := newProvider(t, &fakePlugin{err: fmt.Errorf("intentional error")})
+	defer stop()
+	metric := newMetric()
+	run := newAnalysisRun()
+
+	measurement := p.Run(run, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Contains(t, measurement.Message, "intentional error")
+	assert.NotNil(t, measurement.StartedAt)
+	assert.NotNil(t, measurement.FinishedAt)
+
+	measurement = p.Resume(run, metric, v1alpha1.Measurement{Phase: v1alpha1.AnalysisPhaseRunning})
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+
+	measurement = p.Terminate(run, metric, v1alpha1.Measurement{Phase: v1alpha1.AnalysisPhaseRunning})
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+
+	assert.Error(t, p.GarbageCollect(run, metric, 2))
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient("not-registered")
+	assert.EqualError(t, err, "plugin 'not-registered' is not registered")
+
+	plugin := &fakePlugin{}
+	conn, stop := startFakePlugin(t, plugin)
+	defer stop()
+	RegisterPlugin("registered", conn)
+	client, err := NewClient("registered")
+	assert.NoError(t, err)
+	_, err = client.GarbageCollect(context.TODO(), &GarbageCollectRequest{Limit: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, plugin.limit)
+}
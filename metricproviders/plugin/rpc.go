@@ -0,0 +1,170 @@
+package plugin
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	pluginutil "github.com/argoproj/argo-rollouts/utils/plugin"
+)
+
+// ServiceName is the name of the gRPC service metric provider plugins implement
+const ServiceName = "argoproj.rollouts.MetricProviderPlugin"
+
+// RunRequest asks the plugin to start a new measurement of the metric
+type RunRequest struct {
+	AnalysisRun *v1alpha1.AnalysisRun `json:"analysisRun"`
+	Metric      v1alpha1.Metric       `json:"metric"`
+	Config      string                `json:"config,omitempty"`
+}
+
+// ResumeRequest asks the plugin for the current state of an in-progress measurement
+type ResumeRequest struct {
+	AnalysisRun *v1alpha1.AnalysisRun `json:"analysisRun"`
+	Metric      v1alpha1.Metric       `json:"metric"`
+	Config      string                `json:"config,omitempty"`
+	Measurement v1alpha1.Measurement  `json:"measurement"`
+}
+
+// TerminateRequest asks the plugin to terminate an in-progress measurement
+type TerminateRequest struct {
+	AnalysisRun *v1alpha1.AnalysisRun `json:"analysisRun"`
+	Metric      v1alpha1.Metric       `json:"metric"`
+	Config      string                `json:"config,omitempty"`
+	Measurement v1alpha1.Measurement  `json:"measurement"`
+}
+
+// MeasurementResponse is the response of the plugin to Run, Resume and Terminate requests
+type MeasurementResponse struct {
+	Measurement v1alpha1.Measurement `json:"measurement"`
+}
+
+// GarbageCollectRequest asks the plugin to garbage collect completed measurements to the limit
+type GarbageCollectRequest struct {
+	AnalysisRun *v1alpha1.AnalysisRun `json:"analysisRun"`
+	Metric      v1alpha1.Metric       `json:"metric"`
+	Config      string                `json:"config,omitempty"`
+	Limit       int                   `json:"limit"`
+}
+
+// GarbageCollectResponse is the response of the plugin to a GarbageCollectRequest
+type GarbageCollectResponse struct{}
+
+// MetricProviderClient is the client API of a metric provider plugin
+type MetricProviderClient interface {
+	Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*MeasurementResponse, error)
+	Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*MeasurementResponse, error)
+	Terminate(ctx context.Context, in *TerminateRequest, opts ...grpc.CallOption) (*MeasurementResponse, error)
+	GarbageCollect(ctx context.Context, in *GarbageCollectRequest, opts ...grpc.CallOption) (*GarbageCollectResponse, error)
+}
+
+type metricProviderClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewMetricProviderClient returns a client of the metric provider plugin on the connection
+func NewMetricProviderClient(cc grpc.ClientConnInterface) MetricProviderClient {
+	return &metricProviderClient{cc: cc}
+}
+
+func (c *metricProviderClient) Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*MeasurementResponse, error) {
+	out := new(MeasurementResponse)
+	if err := pluginutil.Invoke(ctx, c.cc, "/"+ServiceName+"/Run", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricProviderClient) Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*MeasurementResponse, error) {
+	out := new(MeasurementResponse)
+	if err := pluginutil.Invoke(ctx, c.cc, "/"+ServiceName+"/Resume", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricProviderClient) Terminate(ctx context.Context, in *TerminateRequest, opts ...grpc.CallOption) (*MeasurementResponse, error) {
+	out := new(MeasurementResponse)
+	if err := pluginutil.Invoke(ctx, c.cc, "/"+ServiceName+"/Terminate", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricProviderClient) GarbageCollect(ctx context.Context, in *GarbageCollectRequest, opts ...grpc.CallOption) (*GarbageCollectResponse, error) {
+	out := new(GarbageCollectResponse)
+	if err := pluginutil.Invoke(ctx, c.cc, "/"+ServiceName+"/GarbageCollect", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MetricProviderServer is the API a metric provider plugin implements
+type MetricProviderServer interface {
+	Run(context.Context, *RunRequest) (*MeasurementResponse, error)
+	Resume(context.Context, *ResumeRequest) (*MeasurementResponse, error)
+	Terminate(context.Context, *TerminateRequest) (*MeasurementResponse, error)
+	GarbageCollect(context.Context, *GarbageCollectRequest) (*GarbageCollectResponse, error)
+}
+
+// RegisterMetricProviderServer registers the metric provider plugin implementation with the gRPC server
+func RegisterMetricProviderServer(s *grpc.Server, srv MetricProviderServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*MetricProviderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Run",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(RunRequest)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return pluginutil.HandleUnary(ctx, srv, "/"+ServiceName+"/Run", in, interceptor, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(MetricProviderServer).Run(ctx, req.(*RunRequest))
+				})
+			},
+		},
+		{
+			MethodName: "Resume",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(ResumeRequest)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return pluginutil.HandleUnary(ctx, srv, "/"+ServiceName+"/Resume", in, interceptor, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(MetricProviderServer).Resume(ctx, req.(*ResumeRequest))
+				})
+			},
+		},
+		{
+			MethodName: "Terminate",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(TerminateRequest)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return pluginutil.HandleUnary(ctx, srv, "/"+ServiceName+"/Terminate", in, interceptor, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(MetricProviderServer).Terminate(ctx, req.(*TerminateRequest))
+				})
+			},
+		},
+		{
+			MethodName: "GarbageCollect",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(GarbageCollectRequest)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return pluginutil.HandleUnary(ctx, srv, "/"+ServiceName+"/GarbageCollect", in, interceptor, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(MetricProviderServer).GarbageCollect(ctx, req.(*GarbageCollectRequest))
+				})
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
@@ -0,0 +1,293 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/argoproj/argo-rollouts/metricproviders/job"
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+)
+
+const (
+	ProviderType = "resource"
+	// ResourceNameKey is the measurement's metadata key holding the name of the object associated with the measurement
+	ResourceNameKey = "resource-name"
+	// ResourceKey is the measurement's metadata key holding the resource of the object associated
+	// with the measurement, in the resource.version.group format
+	ResourceKey = "resource"
+	// resumeDelay is the delay between two checks of the status of the object
+	resumeDelay = 10 * time.Second
+)
+
+var (
+	analysisRunGVK = v1alpha1.SchemeGroupVersion.WithKind("AnalysisRun")
+)
+
+// ResourceProvider creates a kubernetes object for each measurement, and measures its status
+type ResourceProvider struct {
+	dynamicClient dynamic.Interface
+	logCtx        log.Entry
+}
+
+// NewResourceProvider creates a new resource provider
+func NewResourceProvider(logCtx log.Entry, dynamicClient dynamic.Interface) *ResourceProvider {
+	return &ResourceProvider{
+		dynamicClient: dynamicClient,
+		logCtx:        logCtx,
+	}
+}
+
+func (p *ResourceProvider) Type() string {
+	return ProviderType
+}
+
+// newResourceName returns a new object name for the run and metric, following the names of the jobs
+// of the job provider
+func newResourceName(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) string {
+	id := 1
+	if res := analysisutil.GetResult(run, metric.Name); res != nil {
+		id = int(res.Count + res.Error + 1)
+	}
+	return fmt.Sprintf("%s.%s.%d", run.UID, metric.Name, id)
+}
+
+// newMetricResource creates the object of the manifest of the metric, and returns it with the
+// resource to create it with
+func newMetricResource(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) (*unstructured.Unstructured, schema.GroupVersionResource, error) {
+	obj, gvr, err := analysisutil.ParseMetricResource(metric)
+	if err != nil {
+		return nil, schema.GroupVersionResource{}, err
+	}
+
+	obj.SetName(newResourceName(run, metric))
+	obj.SetGenerateName("")
+	obj.SetNamespace(run.Namespace)
+	obj.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(run, analysisRunGVK)})
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[job.AnalysisRunNameAnnotationKey] = run.Name
+	annotations[job.AnalysisRunMetricAnnotationKey] = metric.Name
+	obj.SetAnnotations(annotations)
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[job.AnalysisRunUIDLabelKey] = string(run.UID)
+	obj.SetLabels(labels)
+	return obj, gvr, nil
+}
+
+func (p *ResourceProvider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	ctx := context.TODO()
+	now := metav1.Now()
+	measurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseRunning,
+	}
+	obj, gvr, err := newMetricResource(run, metric)
+	if err != nil {
+		p.logCtx.Errorf("resource initialization failed: %v", err)
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+	resourceIf := p.dynamicClient.Resource(gvr).Namespace(run.Namespace)
+	createdObj, createErr := resourceIf.Create(ctx, obj, metav1.CreateOptions{})
+	if createErr != nil {
+		if !k8serrors.IsAlreadyExists(createErr) {
+			p.logCtx.Errorf("%s create %s failed: %v", gvr.Resource, obj.GetName(), createErr)
+			return metricutil.MarkMeasurementError(measurement, createErr)
+		}
+		existingObj, err := resourceIf.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if err != nil {
+			p.logCtx.Errorf("%s create (verify) %s failed: %v", gvr.Resource, obj.GetName(), createErr)
+			return metricutil.MarkMeasurementError(measurement, createErr)
+		}
+		controllerRef := metav1.GetControllerOf(existingObj)
+		if controllerRef == nil || run.UID != controllerRef.UID {
+			p.logCtx.Errorf("%s create (uid check) %s failed: %v", gvr.Resource, obj.GetName(), createErr)
+			return metricutil.MarkMeasurementError(measurement, createErr)
+		}
+		p.logCtx.Infof("duplicate %s create detected %s", gvr.Resource, obj.GetName())
+		createdObj = existingObj
+	}
+	measurement.Metadata = map[string]string{
+		ResourceNameKey: createdObj.GetName(),
+		ResourceKey:     strings.Join([]string{gvr.Resource, gvr.Version, gvr.Group}, "."),
+	}
+	resumeTime := metav1.NewTime(time.Now().Add(resumeDelay))
+	measurement.ResumeAt = &resumeTime
+	p.logCtx.Infof("%s %s/%s created", gvr.Resource, createdObj.GetNamespace(), createdObj.GetName())
+	return measurement
+}
+
+// Resume gets the object of the measurement, and completes the measurement once the status of the
+// object is completed. Otherwise the measurement is resumed again later.
+func (p *ResourceProvider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	gvr, name, err := getResource(measurement)
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+	obj, err := p.dynamicClient.Resource(gvr).Namespace(run.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+	result, valueStr, found, err := getResult(metric, obj)
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+	completed := false
+	if found {
+		completed, err = isCompleted(metric, result)
+		if err != nil {
+			return metricutil.MarkMeasurementError(measurement, err)
+		}
+	}
+	if !completed {
+		resumeTime := metav1.NewTime(time.Now().Add(resumeDelay))
+		measurement.ResumeAt = &resumeTime
+		return measurement
+	}
+	now := metav1.Now()
+	measurement.Value = valueStr
+	measurement.Phase = evaluate.EvaluateResult(result, metric, p.logCtx)
+	measurement.FinishedAt = &now
+	measurement.ResumeAt = nil
+	p.logCtx.Infof("%s %s/%s completed: %s", gvr.Resource, obj.GetNamespace(), obj.GetName(), measurement.Phase)
+	return measurement
+}
+
+// getResult returns the value of the JSON path of the metric in the object. The value is not found
+// when the JSON path does not match anything yet, e.g. when the object has no status.
+func getResult(metric v1alpha1.Metric, obj *unstructured.Unstructured) (interface{}, string, bool, error) {
+	jsonParser := jsonpath.New("metrics").AllowMissingKeys(true)
+	jsonPath := metric.Provider.Resource.JSONPath
+	if jsonPath == "" {
+		jsonPath = "{$.status}"
+	}
+	if err := jsonParser.Parse(jsonPath); err != nil {
+		return nil, "", false, err
+	}
+	fullResults, err := jsonParser.FindResults(obj.Object)
+	if err != nil {
+		return nil, "", false, err
+	}
+	for _, results := range fullResults {
+		for _, r := range results {
+			if r.Kind() == reflect.Interface && r.IsNil() {
+				continue
+			}
+			val := r.Interface()
+			valBytes, err := json.Marshal(val)
+			return val, string(valBytes), true, err
+		}
+	}
+	return nil, "", false, nil
+}
+
+// isCompleted evaluates the completed condition of the metric, or its success and failure conditions
+// when it does not have one
+func isCompleted(metric v1alpha1.Metric, result interface{}) (bool, error) {
+	if metric.Provider.Resource.CompletedCondition != "" {
+		return evaluate.EvalCondition(result, metric.Provider.Resource.CompletedCondition)
+	}
+	for _, condition := range []string{metric.SuccessCondition, metric.FailureCondition} {
+		if condition == "" {
+			continue
+		}
+		met, err := evaluate.EvalCondition(result, condition)
+		if err != nil || met {
+			return met, err
+		}
+	}
+	return false, nil
+}
+
+// Terminate stops the object of the measurement with the terminate patch of the metric, or deletes
+// it when the metric does not have one
+func (p *ResourceProvider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	gvr, name, err := getResource(measurement)
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+	if patch := metric.Provider.Resource.TerminatePatch; patch != "" {
+		_, err = p.dynamicClient.Resource(gvr).Namespace(run.Namespace).Patch(context.TODO(), name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+		if k8serrors.IsNotFound(err) {
+			err = nil
+		}
+	} else {
+		err = p.deleteResource(gvr, run.Namespace, name)
+	}
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+	now := metav1.Now()
+	measurement.FinishedAt = &now
+	measurement.Phase = v1alpha1.AnalysisPhaseSuccessful
+	measurement.ResumeAt = nil
+	p.logCtx.Infof("%s %s/%s terminated", gvr.Resource, run.Namespace, name)
+	return measurement
+}
+
+func getResource(measurement v1alpha1.Measurement) (schema.GroupVersionResource, string, error) {
+	if measurement.Metadata == nil || measurement.Metadata[ResourceNameKey] == "" || measurement.Metadata[ResourceKey] == "" {
+		return schema.GroupVersionResource{}, "", errors.New("resource metadata reference missing")
+	}
+	gvr, _ := schema.ParseResourceArg(measurement.Metadata[ResourceKey])
+	if gvr == nil {
+		return schema.GroupVersionResource{}, "", fmt.Errorf("invalid resource metadata reference %s", measurement.Metadata[ResourceKey])
+	}
+	return *gvr, measurement.Metadata[ResourceNameKey], nil
+}
+
+func (p *ResourceProvider) deleteResource(gvr schema.GroupVersionResource, namespace, name string) error {
+	foregroundDelete := metav1.DeletePropagationForeground
+	deleteOpts := metav1.DeleteOptions{PropagationPolicy: &foregroundDelete}
+
+	err := p.dynamicClient.Resource(gvr).Namespace(namespace).Delete(context.TODO(), name, deleteOpts)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// GarbageCollect deletes the objects of the old measurements, keeping the ones of the latest
+// measurements up to the limit. The objects are found from the metadata of the measurements, since
+// the manifest of the metric may not be resolved.
+func (p *ResourceProvider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	result := analysisutil.GetResult(run, metric.Name)
+	if result == nil {
+		return nil
+	}
+	totalMeasurements := len(result.Measurements)
+	for i := 0; i < totalMeasurements-limit; i++ {
+		gvr, name, err := getResource(result.Measurements[i])
+		if err != nil {
+			// the object of the measurement was never created
+			continue
+		}
+		err = p.deleteResource(gvr, run.Namespace, name)
+		if err != nil {
+			return err
+		}
+		p.logCtx.Infof("%s %s/%s garbage collected", gvr.Resource, run.Namespace, name)
+	}
+	return nil
+}
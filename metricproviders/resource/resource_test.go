@@ -0,0 +1,336 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"github.com/argoproj/argo-rollouts/metricproviders/job"
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
+)
+
+const workflowManifest = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: integration-tests-
+  labels:
+    app: integration-tests
+spec:
+  entrypoint: main
+  templates:
+  - name: main
+    container:
+      image: integration-tests:latest
+`
+
+var workflowGVR = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "workflows"}
+
+func newTestResourceProvider(objects ...runtime.Object) *ResourceProvider {
+	analysisutil.SetAllowedMetricResources([]string{"workflows.argoproj.io", "pipelineruns.tekton.dev"})
+	logCtx := log.NewEntry(log.New())
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+	return NewResourceProvider(*logCtx, dynamicClient)
+}
+
+func newRunWithResourceMetric() *v1alpha1.AnalysisRun {
+	return &v1alpha1.AnalysisRun{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:       types.UID("dummyuid"),
+			Name:      "dummyrun",
+			Namespace: "dummynamespace",
+		},
+		Spec: v1alpha1.AnalysisRunSpec{
+			Metrics: []v1alpha1.Metric{
+				{
+					Name:             "dummymetric",
+					SuccessCondition: `result.phase == "Succeeded"`,
+					FailureCondition: `result.phase in ["Failed", "Error"]`,
+					Provider: v1alpha1.MetricProvider{
+						Resource: &v1alpha1.ResourceMetric{
+							Manifest: workflowManifest,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newWorkflow(run *v1alpha1.AnalysisRun, name string, status map[string]interface{}) *unstructured.Unstructured {
+	obj, _, err := newMetricResource(run, run.Spec.Metrics[0])
+	if err != nil {
+		panic(err)
+	}
+	obj.SetName(name)
+	if status != nil {
+		obj.Object["status"] = status
+	}
+	return obj
+}
+
+func newRunningMeasurement(name string) v1alpha1.Measurement {
+	now := metav1.Now()
+	return v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseRunning,
+		Metadata: map[string]string{
+			ResourceNameKey: name,
+			ResourceKey:     "workflows.v1alpha1.argoproj.io",
+		},
+	}
+}
+
+func getWorkflow(t *testing.T, p *ResourceProvider, name string) *unstructured.Unstructured {
+	obj, err := p.dynamicClient.Resource(workflowGVR).Namespace("dummynamespace").Get(context.TODO(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	return obj
+}
+
+func TestType(t *testing.T) {
+	p := newTestResourceProvider()
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestRun(t *testing.T) {
+	p := newTestResourceProvider()
+	run := newRunWithResourceMetric()
+	metric := run.Spec.Metrics[0]
+	measurement := p.Run(run, metric)
+
+	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, measurement.Phase)
+	assert.NotNil(t, measurement.StartedAt)
+	assert.NotNil(t, measurement.ResumeAt)
+	assert.Nil(t, measurement.FinishedAt)
+
+	expectedName := fmt.Sprintf("%s.%s.1", run.UID, metric.Name)
+	assert.Equal(t, expectedName, measurement.Metadata[ResourceNameKey])
+	assert.Equal(t, "workflows.v1alpha1.argoproj.io", measurement.Metadata[ResourceKey])
+
+	// Ensure the object was created with the right name in the right namespace with
+	// right ownership reference and right labels and annotations
+	obj := getWorkflow(t, p, expectedName)
+	assert.Equal(t, "", obj.GetGenerateName())
+	assert.Equal(t, map[string]string{
+		"app":                      "integration-tests",
+		job.AnalysisRunUIDLabelKey: string(run.UID),
+	}, obj.GetLabels())
+	assert.Equal(t, run.Name, obj.GetAnnotations()[job.AnalysisRunNameAnnotationKey])
+	assert.Equal(t, metric.Name, obj.GetAnnotations()[job.AnalysisRunMetricAnnotationKey])
+	expectedOwnerRef := []metav1.OwnerReference{*metav1.NewControllerRef(run, analysisRunGVK)}
+	assert.Equal(t, expectedOwnerRef, obj.GetOwnerReferences())
+	entrypoint, _, _ := unstructured.NestedString(obj.Object, "spec", "entrypoint")
+	assert.Equal(t, "main", entrypoint)
+
+	// do it again, this time it should bump up the run ID
+	run.Status.MetricResults = []v1alpha1.MetricResult{
+		{
+			Name:  metric.Name,
+			Count: 1,
+		},
+	}
+	measurement = p.Run(run, metric)
+	expectedName = fmt.Sprintf("%s.%s.2", run.UID, metric.Name)
+	assert.Equal(t, expectedName, measurement.Metadata[ResourceNameKey])
+}
+
+func TestRunWithResource(t *testing.T) {
+	p := newTestResourceProvider()
+	run := newRunWithResourceMetric()
+	run.Spec.Metrics[0].Provider.Resource.Manifest = "apiVersion: tekton.dev/v1beta1\nkind: PipelineRun\nspec: {}"
+	run.Spec.Metrics[0].Provider.Resource.Resource = "pipelineruns"
+	measurement := p.Run(run, run.Spec.Metrics[0])
+	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, measurement.Phase, measurement.Message)
+	assert.Equal(t, "pipelineruns.v1beta1.tekton.dev", measurement.Metadata[ResourceKey])
+}
+
+func TestRunWithInvalidManifest(t *testing.T) {
+	p := newTestResourceProvider()
+	run := newRunWithResourceMetric()
+
+	run.Spec.Metrics[0].Provider.Resource.Manifest = "spec: {}"
+	measurement := p.Run(run, run.Spec.Metrics[0])
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "resource manifest must have an apiVersion and a kind", measurement.Message)
+
+	run.Spec.Metrics[0].Provider.Resource.Manifest = "- not an object"
+	measurement = p.Run(run, run.Spec.Metrics[0])
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Contains(t, measurement.Message, "failed to parse resource manifest")
+}
+
+func TestRunWithResourceNotAllowed(t *testing.T) {
+	p := newTestResourceProvider()
+	run := newRunWithResourceMetric()
+	run.Spec.Metrics[0].Provider.Resource.Manifest = "apiVersion: networking.k8s.io/v1\nkind: Ingress\nspec: {}"
+	measurement := p.Run(run, run.Spec.Metrics[0])
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "resource ingresses.networking.k8s.io is not allowed for resource metrics", measurement.Message)
+	ingresses, err := p.dynamicClient.Resource(schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}).Namespace(run.Namespace).List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, ingresses.Items)
+}
+
+func TestRunCreateFail(t *testing.T) {
+	p := newTestResourceProvider()
+	run := newRunWithResourceMetric()
+	errMsg := "random create failure"
+
+	// The following causes the Create call to fail
+	fakeClient := p.dynamicClient.(*dynamicfake.FakeDynamicClient)
+	fakeClient.PrependReactor("create", "*", func(action kubetesting.Action) (handled bool, ret runtime.Object, err error) {
+		return true, nil, fmt.Errorf(errMsg)
+	})
+
+	measurement := p.Run(run, run.Spec.Metrics[0])
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, errMsg, measurement.Message)
+	assert.NotNil(t, measurement.FinishedAt)
+}
+
+func TestRunCreateCollision(t *testing.T) {
+	run := newRunWithResourceMetric()
+	existing := newWorkflow(run, fmt.Sprintf("%s.%s.1", run.UID, run.Spec.Metrics[0].Name), nil)
+	p := newTestResourceProvider(existing)
+
+	measurement := p.Run(run, run.Spec.Metrics[0])
+	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, measurement.Phase)
+	assert.Nil(t, measurement.FinishedAt)
+
+	// an object which is not owned by the run is not reused
+	otherRun := newRunWithResourceMetric()
+	otherRun.UID = "otheruid"
+	existing = newWorkflow(otherRun, fmt.Sprintf("%s.%s.1", run.UID, run.Spec.Metrics[0].Name), nil)
+	p = newTestResourceProvider(existing)
+	measurement = p.Run(run, run.Spec.Metrics[0])
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+}
+
+func TestResume(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        map[string]interface{}
+		expectedPhase v1alpha1.AnalysisPhase
+		expectedValue string
+	}{
+		{"without status", nil, v1alpha1.AnalysisPhaseRunning, ""},
+		{"running", map[string]interface{}{"phase": "Running"}, v1alpha1.AnalysisPhaseRunning, ""},
+		{"succeeded", map[string]interface{}{"phase": "Succeeded"}, v1alpha1.AnalysisPhaseSuccessful, `{"phase":"Succeeded"}`},
+		{"failed", map[string]interface{}{"phase": "Failed"}, v1alpha1.AnalysisPhaseFailed, `{"phase":"Failed"}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			run := newRunWithResourceMetric()
+			p := newTestResourceProvider(newWorkflow(run, "workflow", test.status))
+			measurement := p.Resume(run, run.Spec.Metrics[0], newRunningMeasurement("workflow"))
+			assert.Equal(t, test.expectedPhase, measurement.Phase, measurement.Message)
+			assert.Equal(t, test.expectedValue, measurement.Value)
+			if test.expectedPhase == v1alpha1.AnalysisPhaseRunning {
+				assert.NotNil(t, measurement.ResumeAt)
+				assert.Nil(t, measurement.FinishedAt)
+			} else {
+				assert.Nil(t, measurement.ResumeAt)
+				assert.NotNil(t, measurement.FinishedAt)
+			}
+		})
+	}
+}
+
+func TestResumeWithCompletedCondition(t *testing.T) {
+	run := newRunWithResourceMetric()
+	metric := run.Spec.Metrics[0]
+	metric.Provider.Resource.JSONPath = "{$.status.succeeded}"
+	metric.Provider.Resource.CompletedCondition = "true"
+	metric.SuccessCondition = "result >= 10"
+	metric.FailureCondition = "result < 5"
+
+	p := newTestResourceProvider(newWorkflow(run, "workflow", map[string]interface{}{"succeeded": int64(7)}))
+	measurement := p.Resume(run, metric, newRunningMeasurement("workflow"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseInconclusive, measurement.Phase, measurement.Message)
+	assert.Equal(t, "7", measurement.Value)
+	assert.NotNil(t, measurement.FinishedAt)
+}
+
+func TestResumeWithError(t *testing.T) {
+	run := newRunWithResourceMetric()
+	p := newTestResourceProvider()
+
+	measurement := p.Resume(run, run.Spec.Metrics[0], newRunningMeasurement("workflow"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, `workflows.argoproj.io "workflow" not found`, measurement.Message)
+
+	measurement = p.Resume(run, run.Spec.Metrics[0], v1alpha1.Measurement{Phase: v1alpha1.AnalysisPhaseRunning})
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "resource metadata reference missing", measurement.Message)
+}
+
+func TestTerminate(t *testing.T) {
+	run := newRunWithResourceMetric()
+	p := newTestResourceProvider(newWorkflow(run, "workflow", map[string]interface{}{"phase": "Running"}))
+
+	measurement := p.Terminate(run, run.Spec.Metrics[0], newRunningMeasurement("workflow"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.NotNil(t, measurement.FinishedAt)
+	_, err := p.dynamicClient.Resource(workflowGVR).Namespace(run.Namespace).Get(context.TODO(), "workflow", metav1.GetOptions{})
+	assert.Error(t, err)
+
+	// the object is already deleted
+	measurement = p.Terminate(run, run.Spec.Metrics[0], newRunningMeasurement("workflow"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+}
+
+func TestTerminateWithPatch(t *testing.T) {
+	run := newRunWithResourceMetric()
+	metric := run.Spec.Metrics[0]
+	metric.Provider.Resource.TerminatePatch = `{"spec": {"shutdown": "Stop"}}`
+	p := newTestResourceProvider(newWorkflow(run, "workflow", map[string]interface{}{"phase": "Running"}))
+
+	measurement := p.Terminate(run, metric, newRunningMeasurement("workflow"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+	shutdown, _, _ := unstructured.NestedString(getWorkflow(t, p, "workflow").Object, "spec", "shutdown")
+	assert.Equal(t, "Stop", shutdown)
+}
+
+func TestGarbageCollect(t *testing.T) {
+	run := newRunWithResourceMetric()
+	run.Status.MetricResults = []v1alpha1.MetricResult{
+		{
+			Name: run.Spec.Metrics[0].Name,
+			Measurements: []v1alpha1.Measurement{
+				newRunningMeasurement("workflow-1"),
+				{Phase: v1alpha1.AnalysisPhaseError},
+				newRunningMeasurement("workflow-2"),
+				newRunningMeasurement("workflow-3"),
+			},
+		},
+	}
+	p := newTestResourceProvider(
+		newWorkflow(run, "workflow-1", nil),
+		newWorkflow(run, "workflow-2", nil),
+		newWorkflow(run, "workflow-3", nil),
+	)
+
+	err := p.GarbageCollect(run, run.Spec.Metrics[0], 2)
+	assert.NoError(t, err)
+	workflows, err := p.dynamicClient.Resource(workflowGVR).Namespace(run.Namespace).List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	var names []string
+	for _, workflow := range workflows.Items {
+		names = append(names, workflow.GetName())
+	}
+	assert.Equal(t, []string{"workflow-2", "workflow-3"}, names)
+
+	// nothing to collect for a metric without measurements
+	assert.NoError(t, p.GarbageCollect(&v1alpha1.AnalysisRun{}, run.Spec.Metrics[0], 2))
+}
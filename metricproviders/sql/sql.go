@@ -0,0 +1,223 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	// register the postgres driver, the mysql driver is registered by its package
+	_ "github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+)
+
+const (
+	//ProviderType indicates the provider is sql
+	ProviderType                = "SQL"
+	DefaultSQLProfileSecretName = "sql"
+	// DSNKey is the key of the profile secret holding the data source name of the database
+	DSNKey = "dsn"
+	// MySQLDriver is the name of the mysql driver
+	MySQLDriver = "mysql"
+)
+
+// ErrNoRows is returned when the query does not return any row
+var ErrNoRows = errors.New("sql query returned no rows")
+
+// API is the database API used by the provider
+type API interface {
+	// QueryRow runs the query with the args bound to its parameters in a read-only transaction, and
+	// returns the columns of the first row of the result by name. The query must be a single
+	// statement.
+	QueryRow(ctx context.Context, query string, args []interface{}) (map[string]interface{}, error)
+}
+
+type sqlAPI struct {
+	driver string
+	dsn    string
+}
+
+// QueryRow opens a connection to the database for the duration of the query, so that no
+// connection is kept open between the measurements
+func (api *sqlAPI) QueryRow(ctx context.Context, query string, args []interface{}) (map[string]interface{}, error) {
+	db, err := sql.Open(api.driver, api.dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	// the transaction is only used to make the query read-only, there is nothing to commit
+	defer tx.Rollback()
+
+	// the query is always prepared, even without args: the drivers run the queries without args
+	// with the simple query protocol, which accepts several statements, e.g. a COMMIT ending the
+	// read-only transaction followed by a write, whereas a prepared statement is a single statement
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoRows
+	}
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		// text columns are returned as bytes by most of the drivers
+		if b, ok := values[i].([]byte); ok {
+			result[column] = string(b)
+		} else {
+			result[column] = values[i]
+		}
+	}
+	return result, nil
+}
+
+// Provider contains all the required components to run a sql query
+type Provider struct {
+	api    API
+	logCtx log.Entry
+}
+
+// Type indicates provider is a sql provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run runs the query of the metric against the database
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	newMeasurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	args := make([]interface{}, len(metric.Provider.SQL.Args))
+	for i, arg := range metric.Provider.SQL.Args {
+		args[i] = arg
+	}
+	result, err := p.api.QueryRow(ctx, metric.Provider.SQL.Query, args)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+
+	newValue, newStatus, err := p.processResponse(metric, result)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newMeasurement.Value = newValue
+	newMeasurement.Phase = newStatus
+	finishedTime := metav1.Now()
+	newMeasurement.FinishedAt = &finishedTime
+	return newMeasurement
+}
+
+// Resume should not be used the sql provider since all the work should occur in the Run method
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("SQL provider should not execute the Resume method")
+	return measurement
+}
+
+// Terminate should not be used the sql provider since all the work should occur in the Run method
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("SQL provider should not execute the Terminate method")
+	return measurement
+}
+
+// GarbageCollect is a no-op for the sql provider
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	return nil
+}
+
+// processResponse evaluates the columns of the first row. They are round tripped through JSON, so
+// that the conditions see the same numbers and strings as the value of the measurement.
+func (p *Provider) processResponse(metric v1alpha1.Metric, row map[string]interface{}) (string, v1alpha1.AnalysisPhase, error) {
+	valueBytes, err := json.Marshal(row)
+	if err != nil {
+		return "", v1alpha1.AnalysisPhaseError, fmt.Errorf("could not marshal sql row: %v", err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(valueBytes, &result); err != nil {
+		return "", v1alpha1.AnalysisPhaseError, err
+	}
+	newStatus := evaluate.EvaluateResult(result, metric, p.logCtx)
+	return string(valueBytes), newStatus, nil
+}
+
+// NewSQLProvider creates a new sql provider
+func NewSQLProvider(api API, logCtx log.Entry) *Provider {
+	return &Provider{
+		logCtx: logCtx,
+		api:    api,
+	}
+}
+
+// NewSQLAPI creates a database API from the driver of the metric and the data source name of its
+// profile secret
+func NewSQLAPI(metric v1alpha1.Metric, kubeclientset kubernetes.Interface) (API, error) {
+	ns := defaults.Namespace()
+	profileSecret := DefaultSQLProfileSecretName
+	if metric.Provider.SQL.Profile != "" {
+		profileSecret = metric.Provider.SQL.Profile
+	}
+	secret, err := kubeclientset.CoreV1().Secrets(ns).Get(context.TODO(), profileSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	dsn := string(secret.Data[DSNKey])
+	if dsn == "" {
+		return nil, fmt.Errorf("%s not found", DSNKey)
+	}
+	if metric.Provider.SQL.Driver == MySQLDriver {
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", DSNKey, err)
+		}
+		// multiple statements would let the query end the read-only transaction
+		if cfg.MultiStatements {
+			return nil, fmt.Errorf("multiStatements is not allowed in the %s", DSNKey)
+		}
+	}
+	return &sqlAPI{
+		driver: metric.Provider.SQL.Driver,
+		dsn:    dsn,
+	}, nil
+}
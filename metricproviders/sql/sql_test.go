@@ -0,0 +1,238 @@
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+)
+
+const errorRateQuery = "SELECT count(*) AS total, sum(failed) AS failed FROM requests WHERE version = $1 AND created_at > now() - $2::interval"
+
+// newMock creates an in-memory database which the sqlmock driver opens by the data source name of
+// the test
+func newMock(t *testing.T) (string, sqlmock.Sqlmock) {
+	dsn := fmt.Sprintf("sqlmock_%s", t.Name())
+	_, mock, err := sqlmock.NewWithDSN(dsn)
+	assert.NoError(t, err)
+	return dsn, mock
+}
+
+func newSecret(dsn string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DefaultSQLProfileSecretName,
+			Namespace: defaults.Namespace(),
+		},
+		Data: map[string][]byte{
+			DSNKey: []byte(dsn),
+		},
+	}
+}
+
+func newAnalysisRun() *v1alpha1.AnalysisRun {
+	return &v1alpha1.AnalysisRun{}
+}
+
+func newMetric(successCondition string) v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "foo",
+		SuccessCondition: successCondition,
+		Provider: v1alpha1.MetricProvider{
+			SQL: &v1alpha1.SQLMetric{
+				Driver: "sqlmock",
+				Query:  errorRateQuery,
+				Args:   []string{"canary-123", "5 minutes"},
+			},
+		},
+	}
+}
+
+func newProvider(t *testing.T, dsn string) *Provider {
+	api, err := NewSQLAPI(newMetric("true"), k8sfake.NewSimpleClientset(newSecret(dsn)))
+	assert.NoError(t, err)
+	return NewSQLProvider(api, *log.NewEntry(log.New()))
+}
+
+func TestType(t *testing.T) {
+	p := newProvider(t, "dsn")
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestRunSuccessfully(t *testing.T) {
+	dsn, mock := newMock(t)
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`SELECT count\(\*\) AS total, sum\(failed\) AS failed FROM requests`).ExpectQuery().
+		WithArgs("canary-123", "5 minutes").
+		WillReturnRows(sqlmock.NewRows([]string{"total", "failed"}).AddRow(200, 3).AddRow(100, 50))
+	mock.ExpectRollback()
+
+	metric := newMetric("result.failed / result.total < 0.05")
+	measurement := newProvider(t, dsn).Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+	assert.Equal(t, `{"failed":3,"total":200}`, measurement.Value)
+	assert.NotNil(t, measurement.StartedAt)
+	assert.NotNil(t, measurement.FinishedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunFailed(t *testing.T) {
+	dsn, mock := newMock(t)
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`SELECT`).ExpectQuery().
+		WithArgs("canary-123", "5 minutes").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow([]byte("degraded")))
+	mock.ExpectRollback()
+
+	metric := newMetric(`result.status == "healthy"`)
+	measurement := newProvider(t, dsn).Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase, measurement.Message)
+	assert.Equal(t, `{"status":"degraded"}`, measurement.Value)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunWithoutRows(t *testing.T) {
+	dsn, mock := newMock(t)
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`SELECT`).ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"total"}))
+	mock.ExpectRollback()
+
+	measurement := newProvider(t, dsn).Run(newAnalysisRun(), newMetric("true"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, ErrNoRows.Error(), measurement.Message)
+	assert.NotNil(t, measurement.FinishedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunWithQueryError(t *testing.T) {
+	dsn, mock := newMock(t)
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`SELECT`).ExpectQuery().WillReturnError(errors.New("relation \"requests\" does not exist"))
+	mock.ExpectRollback()
+
+	measurement := newProvider(t, dsn).Run(newAnalysisRun(), newMetric("true"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, `relation "requests" does not exist`, measurement.Message)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunWithMultipleStatements(t *testing.T) {
+	dsn, mock := newMock(t)
+	mock.ExpectBegin()
+	// the database refuses to prepare several statements
+	mock.ExpectPrepare(`SELECT 1; COMMIT; DELETE FROM requests`).
+		WillReturnError(errors.New("pq: cannot insert multiple commands into a prepared statement"))
+	mock.ExpectRollback()
+
+	metric := newMetric("true")
+	metric.Provider.SQL.Query = "SELECT 1; COMMIT; DELETE FROM requests"
+	metric.Provider.SQL.Args = nil
+	measurement := newProvider(t, dsn).Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "pq: cannot insert multiple commands into a prepared statement", measurement.Message)
+	// the query was never run outside of the prepared statement
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResume(t *testing.T) {
+	p := newProvider(t, "dsn")
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseInconclusive,
+	}
+	measurement := p.Resume(newAnalysisRun(), newMetric("true"), previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestTerminate(t *testing.T) {
+	p := newProvider(t, "dsn")
+	now := metav1.Now()
+	previousMeasurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseRunning,
+	}
+	measurement := p.Terminate(newAnalysisRun(), newMetric("true"), previousMeasurement)
+	assert.Equal(t, previousMeasurement, measurement)
+}
+
+func TestGarbageCollect(t *testing.T) {
+	p := newProvider(t, "dsn")
+	err := p.GarbageCollect(nil, v1alpha1.Metric{}, 0)
+	assert.NoError(t, err)
+}
+
+func TestProcessResponseWithNullColumn(t *testing.T) {
+	p := newProvider(t, "dsn")
+	// the sum of an empty set of rows is NULL
+	row := map[string]interface{}{"total": int64(0), "failed": nil}
+	value, status, err := p.processResponse(newMetric("result.failed == nil"), row)
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, status)
+	assert.Equal(t, `{"failed":null,"total":0}`, value)
+}
+
+func TestProcessResponseWithTimeColumn(t *testing.T) {
+	p := newProvider(t, "dsn")
+	// the conditions see the timestamps as strings, like the value of the measurement
+	row := map[string]interface{}{"last_error": time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	value, status, err := p.processResponse(newMetric(`result.last_error == "2021-01-01T00:00:00Z"`), row)
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, status)
+	assert.Equal(t, `{"last_error":"2021-01-01T00:00:00Z"}`, value)
+}
+
+func TestProcessResponseWithUnsupportedColumn(t *testing.T) {
+	p := newProvider(t, "dsn")
+	row := map[string]interface{}{"ratio": math.Inf(1)}
+	value, status, err := p.processResponse(newMetric("true"), row)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "could not marshal sql row")
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, status)
+	assert.Equal(t, "", value)
+}
+
+func TestNewSQLAPI(t *testing.T) {
+	t.Run("with a profile", func(t *testing.T) {
+		secret := newSecret("dsn")
+		secret.Name = "my-database"
+		metric := newMetric("true")
+		metric.Provider.SQL.Profile = "my-database"
+		_, err := NewSQLAPI(metric, k8sfake.NewSimpleClientset(secret))
+		assert.NoError(t, err)
+	})
+
+	t.Run("without the secret", func(t *testing.T) {
+		_, err := NewSQLAPI(newMetric("true"), k8sfake.NewSimpleClientset())
+		assert.EqualError(t, err, `secrets "sql" not found`)
+	})
+
+	t.Run("with the dsn missing", func(t *testing.T) {
+		_, err := NewSQLAPI(newMetric("true"), k8sfake.NewSimpleClientset(newSecret("")))
+		assert.EqualError(t, err, "dsn not found")
+	})
+
+	t.Run("with a mysql dsn", func(t *testing.T) {
+		metric := newMetric("true")
+		metric.Provider.SQL.Driver = MySQLDriver
+		_, err := NewSQLAPI(metric, k8sfake.NewSimpleClientset(newSecret("analysis:password@tcp(mysql:3306)/app")))
+		assert.NoError(t, err)
+
+		_, err = NewSQLAPI(metric, k8sfake.NewSimpleClientset(newSecret("analysis:password@tcp(mysql:3306)/app?multiStatements=true")))
+		assert.EqualError(t, err, "multiStatements is not allowed in the dsn")
+
+		_, err = NewSQLAPI(metric, k8sfake.NewSimpleClientset(newSecret("analysis:password@tcp(mysql:3306)app")))
+		assert.EqualError(t, err, "invalid dsn: invalid DSN: missing the slash separating the database name")
+	})
+}
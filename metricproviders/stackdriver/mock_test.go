@@ -0,0 +1,47 @@
+package stackdriver
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/api/monitoring/v3"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+type listCall struct {
+	project     string
+	filter      string
+	start       time.Time
+	end         time.Time
+	aggregation *v1alpha1.StackdriverAggregation
+}
+
+type queryCall struct {
+	project string
+	query   string
+}
+
+type mockAPI struct {
+	timeSeries     []*monitoring.TimeSeries
+	timeSeriesData []*monitoring.TimeSeriesData
+	err            error
+	listCalls      []listCall
+	queryCalls     []queryCall
+}
+
+func (m *mockAPI) ListTimeSeries(ctx context.Context, project, filter string, start, end time.Time, aggregation *v1alpha1.StackdriverAggregation) ([]*monitoring.TimeSeries, error) {
+	m.listCalls = append(m.listCalls, listCall{project: project, filter: filter, start: start, end: end, aggregation: aggregation})
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.timeSeries, nil
+}
+
+func (m *mockAPI) QueryTimeSeries(ctx context.Context, project, query string) ([]*monitoring.TimeSeriesData, error) {
+	m.queryCalls = append(m.queryCalls, queryCall{project: project, query: query})
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.timeSeriesData, nil
+}
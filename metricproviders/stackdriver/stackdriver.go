@@ -0,0 +1,237 @@
+package stackdriver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/api/monitoring/v3"
+	"google.golang.org/api/option"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+)
+
+const (
+	//ProviderType indicates the provider is stackdriver
+	ProviderType = "Stackdriver"
+	// ServiceAccountKey is the key of the profile secret holding the JSON service account key
+	ServiceAccountKey = "serviceAccountKey"
+	// defaultInterval is the length of the time window of a filter when the metric does not set one
+	defaultInterval = 5 * time.Minute
+)
+
+// API is the google cloud monitoring API used by the provider
+type API interface {
+	// ListTimeSeries lists the time series of the project selected by the filter over the time window
+	ListTimeSeries(ctx context.Context, project, filter string, start, end time.Time, aggregation *v1alpha1.StackdriverAggregation) ([]*monitoring.TimeSeries, error)
+	// QueryTimeSeries executes the MQL query in the project
+	QueryTimeSeries(ctx context.Context, project, query string) ([]*monitoring.TimeSeriesData, error)
+}
+
+type stackdriverAPI struct {
+	service *monitoring.Service
+}
+
+// ListTimeSeries lists the time series of all the pages of the response
+func (api *stackdriverAPI) ListTimeSeries(ctx context.Context, project, filter string, start, end time.Time, aggregation *v1alpha1.StackdriverAggregation) ([]*monitoring.TimeSeries, error) {
+	call := api.service.Projects.TimeSeries.List("projects/" + project).
+		Filter(filter).
+		IntervalStartTime(start.UTC().Format(time.RFC3339Nano)).
+		IntervalEndTime(end.UTC().Format(time.RFC3339Nano))
+	if aggregation != nil {
+		if aggregation.AlignmentPeriod != "" {
+			alignmentPeriod, err := aggregation.AlignmentPeriod.Duration()
+			if err != nil {
+				return nil, err
+			}
+			call = call.AggregationAlignmentPeriod(strconv.FormatFloat(alignmentPeriod.Seconds(), 'f', -1, 64) + "s")
+		}
+		if aggregation.PerSeriesAligner != "" {
+			call = call.AggregationPerSeriesAligner(aggregation.PerSeriesAligner)
+		}
+		if aggregation.CrossSeriesReducer != "" {
+			call = call.AggregationCrossSeriesReducer(aggregation.CrossSeriesReducer)
+		}
+		if len(aggregation.GroupByFields) > 0 {
+			call = call.AggregationGroupByFields(aggregation.GroupByFields...)
+		}
+	}
+	var timeSeries []*monitoring.TimeSeries
+	err := call.Pages(ctx, func(response *monitoring.ListTimeSeriesResponse) error {
+		timeSeries = append(timeSeries, response.TimeSeries...)
+		return nil
+	})
+	return timeSeries, err
+}
+
+// QueryTimeSeries queries the time series data of all the pages of the response
+func (api *stackdriverAPI) QueryTimeSeries(ctx context.Context, project, query string) ([]*monitoring.TimeSeriesData, error) {
+	call := api.service.Projects.TimeSeries.Query("projects/"+project, &monitoring.QueryTimeSeriesRequest{
+		Query: query,
+	})
+	var timeSeriesData []*monitoring.TimeSeriesData
+	err := call.Pages(ctx, func(response *monitoring.QueryTimeSeriesResponse) error {
+		timeSeriesData = append(timeSeriesData, response.TimeSeriesData...)
+		return nil
+	})
+	return timeSeriesData, err
+}
+
+// Provider contains all the required components to run a google cloud monitoring query
+type Provider struct {
+	api    API
+	logCtx log.Entry
+}
+
+// Type indicates provider is a stackdriver provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run queries google cloud monitoring for the metric
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	newMeasurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stackdriver := metric.Provider.Stackdriver
+	var values []*monitoring.TypedValue
+	switch {
+	case stackdriver.Query != "" && stackdriver.Filter == "":
+		timeSeriesData, err := p.api.QueryTimeSeries(ctx, stackdriver.Project, stackdriver.Query)
+		if err != nil {
+			return metricutil.MarkMeasurementError(newMeasurement, err)
+		}
+		for _, data := range timeSeriesData {
+			for _, point := range data.PointData {
+				values = append(values, point.Values...)
+			}
+		}
+	case stackdriver.Filter != "" && stackdriver.Query == "":
+		interval := defaultInterval
+		if stackdriver.Interval != "" {
+			expDuration, err := stackdriver.Interval.Duration()
+			if err != nil {
+				return metricutil.MarkMeasurementError(newMeasurement, err)
+			}
+			interval = expDuration
+		}
+		timeSeries, err := p.api.ListTimeSeries(ctx, stackdriver.Project, stackdriver.Filter, startTime.Add(-interval), startTime.Time, stackdriver.Aggregation)
+		if err != nil {
+			return metricutil.MarkMeasurementError(newMeasurement, err)
+		}
+		for _, series := range timeSeries {
+			for _, point := range series.Points {
+				values = append(values, point.Value)
+			}
+		}
+	default:
+		return metricutil.MarkMeasurementError(newMeasurement, errors.New("exactly one of query and filter must be set"))
+	}
+
+	newValue, newStatus, err := p.processResponse(metric, values)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newMeasurement.Value = newValue
+	newMeasurement.Phase = newStatus
+	finishedTime := metav1.Now()
+	newMeasurement.FinishedAt = &finishedTime
+	return newMeasurement
+}
+
+// Resume should not be used the stackdriver provider since all the work should occur in the Run method
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Stackdriver provider should not execute the Resume method")
+	return measurement
+}
+
+// Terminate should not be used the stackdriver provider since all the work should occur in the Run method
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Stackdriver provider should not execute the Terminate method")
+	return measurement
+}
+
+// GarbageCollect is a no-op for the stackdriver provider
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	return nil
+}
+
+// processResponse evaluates the list of the numeric values of the points of all the time series.
+// The measurement is inconclusive when there is no point.
+func (p *Provider) processResponse(metric v1alpha1.Metric, values []*monitoring.TypedValue) (string, v1alpha1.AnalysisPhase, error) {
+	results := make([]float64, 0, len(values))
+	for _, value := range values {
+		switch {
+		case value == nil:
+			continue
+		case value.DoubleValue != nil:
+			results = append(results, *value.DoubleValue)
+		case value.Int64Value != nil:
+			results = append(results, float64(*value.Int64Value))
+		case value.DistributionValue != nil:
+			results = append(results, value.DistributionValue.Mean)
+		default:
+			return "", v1alpha1.AnalysisPhaseError, fmt.Errorf("Stackdriver point value type not supported")
+		}
+	}
+	valueBytes, err := json.Marshal(results)
+	if err != nil {
+		return "", v1alpha1.AnalysisPhaseError, err
+	}
+	if len(results) == 0 {
+		return string(valueBytes), v1alpha1.AnalysisPhaseInconclusive, nil
+	}
+	newStatus := evaluate.EvaluateResult(results, metric, p.logCtx)
+	return string(valueBytes), newStatus, nil
+}
+
+// NewStackdriverProvider creates a new stackdriver provider
+func NewStackdriverProvider(api API, logCtx log.Entry) *Provider {
+	return &Provider{
+		logCtx: logCtx,
+		api:    api,
+	}
+}
+
+// NewStackdriverAPI creates a google cloud monitoring API from the metric configuration. The
+// service account key is read from the profile secret of the metric when it is set, otherwise the
+// default credentials of the controller are used.
+func NewStackdriverAPI(metric v1alpha1.Metric, kubeclientset kubernetes.Interface) (API, error) {
+	opts := []option.ClientOption{
+		option.WithScopes(monitoring.MonitoringReadScope),
+	}
+	if metric.Provider.Stackdriver.Profile != "" {
+		secret, err := kubeclientset.CoreV1().Secrets(defaults.Namespace()).Get(context.TODO(), metric.Provider.Stackdriver.Profile, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		key, ok := secret.Data[ServiceAccountKey]
+		if !ok {
+			return nil, fmt.Errorf("%s not found", ServiceAccountKey)
+		}
+		opts = append(opts, option.WithCredentialsJSON(key))
+	}
+	if endpoint := metric.Provider.Stackdriver.Endpoint; endpoint != "" {
+		opts = append(opts, option.WithEndpoint(strings.TrimSuffix(endpoint, "/")+"/"))
+	}
+	service, err := monitoring.NewService(context.TODO(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &stackdriverAPI{service: service}, nil
+}
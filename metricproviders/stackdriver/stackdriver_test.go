@@ -0,0 +1,289 @@
+package stackdriver
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/monitoring/v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+)
+
+func newFilterMetric(successCondition string) v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "foo",
+		SuccessCondition: successCondition,
+		Provider: v1alpha1.MetricProvider{
+			Stackdriver: &v1alpha1.StackdriverMetric{
+				Project: "my-project",
+				Filter:  `metric.type = "loadbalancing.googleapis.com/https/request_count"`,
+				Aggregation: &v1alpha1.StackdriverAggregation{
+					AlignmentPeriod:    "1m",
+					PerSeriesAligner:   "ALIGN_RATE",
+					CrossSeriesReducer: "REDUCE_SUM",
+				},
+			},
+		},
+	}
+}
+
+func newQueryMetric(successCondition string) v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "foo",
+		SuccessCondition: successCondition,
+		Provider: v1alpha1.MetricProvider{
+			Stackdriver: &v1alpha1.StackdriverMetric{
+				Project: "my-project",
+				Query:   "fetch https_lb_rule | metric 'loadbalancing.googleapis.com/https/request_count' | within 5m",
+			},
+		},
+	}
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func newProvider(api API) *Provider {
+	return NewStackdriverProvider(api, *log.NewEntry(log.New()))
+}
+
+func TestType(t *testing.T) {
+	p := newProvider(&mockAPI{})
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestRunWithFilter(t *testing.T) {
+	api := &mockAPI{
+		timeSeries: []*monitoring.TimeSeries{
+			{Points: []*monitoring.Point{
+				{Value: &monitoring.TypedValue{DoubleValue: float64Ptr(0.5)}},
+				{Value: &monitoring.TypedValue{DoubleValue: float64Ptr(1.5)}},
+			}},
+			{Points: []*monitoring.Point{
+				{Value: &monitoring.TypedValue{Int64Value: int64Ptr(2)}},
+			}},
+		},
+	}
+	metric := newFilterMetric("all(result, {# < 5})")
+	measurement := newProvider(api).Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+	assert.Equal(t, "[0.5,1.5,2]", measurement.Value)
+	assert.NotNil(t, measurement.StartedAt)
+	assert.NotNil(t, measurement.FinishedAt)
+
+	assert.Len(t, api.listCalls, 1)
+	call := api.listCalls[0]
+	assert.Equal(t, "my-project", call.project)
+	assert.Equal(t, metric.Provider.Stackdriver.Filter, call.filter)
+	assert.Equal(t, defaultInterval, call.end.Sub(call.start))
+	assert.Equal(t, measurement.StartedAt.Time, call.end)
+	assert.Equal(t, metric.Provider.Stackdriver.Aggregation, call.aggregation)
+}
+
+func TestRunWithFilterAndInterval(t *testing.T) {
+	api := &mockAPI{}
+	metric := newFilterMetric("true")
+	metric.Provider.Stackdriver.Interval = "10m"
+	measurement := newProvider(api).Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseInconclusive, measurement.Phase, measurement.Message)
+	assert.Equal(t, "[]", measurement.Value)
+	assert.Equal(t, 10*time.Minute, api.listCalls[0].end.Sub(api.listCalls[0].start))
+
+	metric.Provider.Stackdriver.Interval = "invalid"
+	measurement = newProvider(api).Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+}
+
+func TestRunWithQuery(t *testing.T) {
+	api := &mockAPI{
+		timeSeriesData: []*monitoring.TimeSeriesData{
+			{PointData: []*monitoring.PointData{
+				{Values: []*monitoring.TypedValue{{DoubleValue: float64Ptr(10)}}},
+				{Values: []*monitoring.TypedValue{{DistributionValue: &monitoring.Distribution{Mean: 20}}}},
+			}},
+		},
+	}
+	metric := newQueryMetric("all(result, {# < 15})")
+	measurement := newProvider(api).Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase, measurement.Message)
+	assert.Equal(t, "[10,20]", measurement.Value)
+	assert.Equal(t, []queryCall{{project: "my-project", query: metric.Provider.Stackdriver.Query}}, api.queryCalls)
+}
+
+func TestRunWithQueryAndFilter(t *testing.T) {
+	api := &mockAPI{}
+	metric := newQueryMetric("true")
+	metric.Provider.Stackdriver.Filter = "metric.type = \"foo\""
+	measurement := newProvider(api).Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "exactly one of query and filter must be set", measurement.Message)
+
+	metric.Provider.Stackdriver.Filter = ""
+	metric.Provider.Stackdriver.Query = ""
+	measurement = newProvider(api).Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Len(t, api.listCalls, 0)
+	assert.Len(t, api.queryCalls, 0)
+}
+
+func TestRunWithUnsupportedValue(t *testing.T) {
+	api := &mockAPI{
+		timeSeriesData: []*monitoring.TimeSeriesData{
+			{PointData: []*monitoring.PointData{
+				{Values: []*monitoring.TypedValue{{StringValue: new(string)}}},
+			}},
+		},
+	}
+	measurement := newProvider(api).Run(&v1alpha1.AnalysisRun{}, newQueryMetric("true"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "Stackdriver point value type not supported", measurement.Message)
+}
+
+func TestRunWithError(t *testing.T) {
+	p := newProvider(&mockAPI{err: fmt.Errorf("bad big bug :(")})
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, newQueryMetric("true"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "bad big bug :(", measurement.Message)
+	assert.NotNil(t, measurement.FinishedAt)
+
+	measurement = p.Run(&v1alpha1.AnalysisRun{}, newFilterMetric("true"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "bad big bug :(", measurement.Message)
+}
+
+func TestResumeTerminateGarbageCollect(t *testing.T) {
+	p := newProvider(&mockAPI{})
+	measurement := v1alpha1.Measurement{Phase: v1alpha1.AnalysisPhaseRunning}
+	assert.Equal(t, measurement, p.Resume(&v1alpha1.AnalysisRun{}, newQueryMetric("true"), measurement))
+	assert.Equal(t, measurement, p.Terminate(&v1alpha1.AnalysisRun{}, newQueryMetric("true"), measurement))
+	assert.NoError(t, p.GarbageCollect(&v1alpha1.AnalysisRun{}, newQueryMetric("true"), 0))
+}
+
+// newServiceAccountKey generates a service account key whose tokens are issued by the token URI
+func newServiceAccountKey(t *testing.T, tokenURI string) []byte {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	key, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "my-project",
+		"private_key_id": "key-id",
+		"private_key":    string(keyPEM),
+		"client_email":   "rollouts@my-project.iam.gserviceaccount.com",
+		"client_id":      "1234",
+		"token_uri":      tokenURI,
+	})
+	assert.NoError(t, err)
+	return key
+}
+
+func TestNewStackdriverAPIWithEndpoint(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/token":
+			fmt.Fprint(w, `{"access_token": "my-token", "token_type": "Bearer", "expires_in": 3600}`)
+		case r.URL.Path == "/v3/projects/my-project/timeSeries" && r.URL.Query().Get("pageToken") == "":
+			fmt.Fprint(w, `{"timeSeries": [{"points": [{"value": {"doubleValue": 0.5}}]}], "nextPageToken": "next"}`)
+		case r.URL.Path == "/v3/projects/my-project/timeSeries":
+			fmt.Fprint(w, `{"timeSeries": [{"points": [{"value": {"int64Value": "3"}}]}]}`)
+		case r.URL.Path == "/v3/projects/my-project/timeSeries:query":
+			fmt.Fprint(w, `{"timeSeriesData": [{"pointData": [{"values": [{"doubleValue": 1.5}]}]}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-stackdriver",
+			Namespace: defaults.Namespace(),
+		},
+		Data: map[string][]byte{
+			ServiceAccountKey: newServiceAccountKey(t, server.URL+"/token"),
+		},
+	}
+	kubeclient := k8sfake.NewSimpleClientset(secret)
+
+	t.Run("with a filter", func(t *testing.T) {
+		requests = nil
+		metric := newFilterMetric("all(result, {# < 5})")
+		metric.Provider.Stackdriver.Profile = "my-stackdriver"
+		metric.Provider.Stackdriver.Endpoint = server.URL
+		api, err := NewStackdriverAPI(metric, kubeclient)
+		assert.NoError(t, err)
+		measurement := newProvider(api).Run(&v1alpha1.AnalysisRun{}, metric)
+		assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+		assert.Equal(t, "[0.5,3]", measurement.Value)
+
+		assert.Len(t, requests, 3)
+		list := requests[1]
+		assert.Equal(t, "Bearer my-token", list.Header.Get("Authorization"))
+		assert.Equal(t, metric.Provider.Stackdriver.Filter, list.URL.Query().Get("filter"))
+		assert.Equal(t, "60s", list.URL.Query().Get("aggregation.alignmentPeriod"))
+		assert.Equal(t, "ALIGN_RATE", list.URL.Query().Get("aggregation.perSeriesAligner"))
+		assert.Equal(t, "REDUCE_SUM", list.URL.Query().Get("aggregation.crossSeriesReducer"))
+		start, err := time.Parse(time.RFC3339Nano, list.URL.Query().Get("interval.startTime"))
+		assert.NoError(t, err)
+		end, err := time.Parse(time.RFC3339Nano, list.URL.Query().Get("interval.endTime"))
+		assert.NoError(t, err)
+		assert.Equal(t, defaultInterval, end.Sub(start))
+		assert.Equal(t, "next", requests[2].URL.Query().Get("pageToken"))
+	})
+
+	t.Run("with a query", func(t *testing.T) {
+		requests = nil
+		bodies = nil
+		metric := newQueryMetric("all(result, {# < 5})")
+		metric.Provider.Stackdriver.Profile = "my-stackdriver"
+		metric.Provider.Stackdriver.Endpoint = server.URL + "/"
+		api, err := NewStackdriverAPI(metric, kubeclient)
+		assert.NoError(t, err)
+		measurement := newProvider(api).Run(&v1alpha1.AnalysisRun{}, metric)
+		assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase, measurement.Message)
+		assert.Equal(t, "[1.5]", measurement.Value)
+		assert.Contains(t, bodies[len(bodies)-1], `"query":"fetch https_lb_rule`)
+	})
+
+	t.Run("without the key in the secret", func(t *testing.T) {
+		incomplete := secret.DeepCopy()
+		incomplete.Data = nil
+		metric := newQueryMetric("true")
+		metric.Provider.Stackdriver.Profile = "my-stackdriver"
+		_, err := NewStackdriverAPI(metric, k8sfake.NewSimpleClientset(incomplete))
+		assert.EqualError(t, err, "serviceAccountKey not found")
+	})
+
+	t.Run("without the secret", func(t *testing.T) {
+		metric := newQueryMetric("true")
+		metric.Provider.Stackdriver.Profile = "my-stackdriver"
+		_, err := NewStackdriverAPI(metric, k8sfake.NewSimpleClientset())
+		assert.EqualError(t, err, `secrets "my-stackdriver" not found`)
+	})
+}
@@ -0,0 +1,190 @@
+package notifications
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+const (
+	// NotificationConfigMapName is the name of the ConfigMap in the controller namespace which defines
+	// the webhooks notifications are delivered to
+	NotificationConfigMapName = "argo-rollouts-notification-configmap"
+	// SubscribeAnnotationPrefix is the prefix of the Rollout annotations which subscribe webhooks to a trigger
+	// (e.g. notifications.rollout.argoproj.io/subscribe.RolloutAborted: "incident-tool,chat")
+	SubscribeAnnotationPrefix = "notifications.rollout.argoproj.io/subscribe."
+
+	webhooksKey      = "webhooks"
+	subscriptionsKey = "subscriptions"
+
+	// DefaultRetryLimit is the number of times a failed delivery is retried
+	DefaultRetryLimit = 3
+	// DefaultRetryBackoff is the delay before the first retry of a failed delivery. The delay doubles
+	// after each retry.
+	DefaultRetryBackoff = time.Second
+	// DefaultTimeout is the timeout of a single delivery attempt
+	DefaultTimeout = 10 * time.Second
+)
+
+// WebhookFormat describes how the payload of a notification is sent
+type WebhookFormat string
+
+const (
+	// WebhookFormatJSON sends the rendered payload as the request body
+	WebhookFormatJSON WebhookFormat = "webhook"
+	// WebhookFormatCloudEvents sends the rendered payload as the data of a CloudEvent in binary content mode
+	WebhookFormatCloudEvents WebhookFormat = "cloudevents"
+)
+
+// WebhookConfig defines an endpoint which notifications are delivered to
+type WebhookConfig struct {
+	// Name is used to reference the webhook from subscriptions
+	Name string `json:"name"`
+	// URL is the endpoint the notification is delivered to
+	URL string `json:"url"`
+	// Format is either webhook (default) or cloudevents
+	Format WebhookFormat `json:"format,omitempty"`
+	// Method is the HTTP method of the request. Defaults to POST
+	Method string `json:"method,omitempty"`
+	// Headers are added to every request
+	Headers map[string]string `json:"headers,omitempty"`
+	// Template is a Go template which renders the payload. Defaults to a JSON document describing the event
+	Template string `json:"template,omitempty"`
+	// HMACSecret references the key of a Secret in the controller namespace which is used to sign the payload
+	HMACSecret *corev1.SecretKeySelector `json:"hmacSecret,omitempty"`
+	// Retry configures how failed deliveries are retried
+	Retry *RetryConfig `json:"retry,omitempty"`
+	// Timeout of a single delivery attempt (e.g. 5s). Defaults to 10s
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// RetryConfig defines how failed deliveries are retried
+type RetryConfig struct {
+	// Limit is the number of retries after the first attempt. Defaults to 3
+	Limit *int `json:"limit,omitempty"`
+	// Backoff is the delay before the first retry (e.g. 1s), which doubles after each retry
+	Backoff string `json:"backoff,omitempty"`
+}
+
+// Subscription subscribes the webhooks to the triggers of every Rollout matching the selector
+type Subscription struct {
+	// Triggers the webhooks are subscribed to
+	Triggers []Trigger `json:"triggers"`
+	// Webhooks are the names of the webhooks notified
+	Webhooks []string `json:"webhooks"`
+	// Selector selects the Rollouts by label. All Rollouts are selected if omitted
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// Config is the parsed notification ConfigMap
+type Config struct {
+	Webhooks      []WebhookConfig
+	Subscriptions []Subscription
+}
+
+// GetConfig reads the notification ConfigMap from the lister of the controller namespace. An empty
+// config is returned if the ConfigMap does not exist.
+func GetConfig(configMapLister corelisters.ConfigMapNamespaceLister) (*Config, error) {
+	cm, err := configMapLister.Get(NotificationConfigMapName)
+	if k8serrors.IsNotFound(err) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(cm)
+}
+
+// ParseConfig parses the webhooks and subscriptions of the notification ConfigMap
+func ParseConfig(cm *corev1.ConfigMap) (*Config, error) {
+	cfg := Config{}
+	if data, ok := cm.Data[webhooksKey]; ok {
+		if err := yaml.Unmarshal([]byte(data), &cfg.Webhooks); err != nil {
+			return nil, fmt.Errorf("failed to parse '%s' of ConfigMap '%s': %v", webhooksKey, cm.Name, err)
+		}
+	}
+	if data, ok := cm.Data[subscriptionsKey]; ok {
+		if err := yaml.Unmarshal([]byte(data), &cfg.Subscriptions); err != nil {
+			return nil, fmt.Errorf("failed to parse '%s' of ConfigMap '%s': %v", subscriptionsKey, cm.Name, err)
+		}
+	}
+	for i, webhook := range cfg.Webhooks {
+		if webhook.Name == "" {
+			return nil, fmt.Errorf("webhook at index %d of ConfigMap '%s' has no name", i, cm.Name)
+		}
+		if webhook.URL == "" {
+			return nil, fmt.Errorf("webhook '%s' of ConfigMap '%s' has no url", webhook.Name, cm.Name)
+		}
+		switch webhook.Format {
+		case "", WebhookFormatJSON, WebhookFormatCloudEvents:
+		default:
+			return nil, fmt.Errorf("webhook '%s' of ConfigMap '%s' has unsupported format '%s'", webhook.Name, cm.Name, webhook.Format)
+		}
+	}
+	return &cfg, nil
+}
+
+// GetWebhook returns the webhook with the given name
+func (c *Config) GetWebhook(name string) *WebhookConfig {
+	for i := range c.Webhooks {
+		if c.Webhooks[i].Name == name {
+			return &c.Webhooks[i]
+		}
+	}
+	return nil
+}
+
+// GetSubscribedWebhooks returns the names of the webhooks subscribed to the trigger for the rollout,
+// either through the subscriptions of the ConfigMap or the subscribe annotations of the rollout
+func (c *Config) GetSubscribedWebhooks(ro *v1alpha1.Rollout, trigger Trigger) ([]string, error) {
+	var names []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, sub := range c.Subscriptions {
+		if !hasTrigger(sub.Triggers, trigger) {
+			continue
+		}
+		if sub.Selector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(sub.Selector)
+			if err != nil {
+				return nil, err
+			}
+			if !selector.Matches(labels.Set(ro.Labels)) {
+				continue
+			}
+		}
+		for _, name := range sub.Webhooks {
+			add(name)
+		}
+	}
+	if value, ok := ro.Annotations[SubscribeAnnotationPrefix+string(trigger)]; ok {
+		for _, name := range strings.Split(value, ",") {
+			add(name)
+		}
+	}
+	return names, nil
+}
+
+func hasTrigger(triggers []Trigger, trigger Trigger) bool {
+	for _, t := range triggers {
+		if t == trigger {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package notifications
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+const (
+	testWebhooks = `
+- name: incident-tool
+  url: https://incident.example.com/hooks/rollouts
+  format: cloudevents
+  hmacSecret:
+    name: notification-secret
+    key: hmac
+- name: chat
+  url: https://chat.example.com/hooks
+  template: '{"text": "{{.Rollout.Name}}: {{.Message}}"}'
+`
+	testSubscriptions = `
+- triggers: [RolloutAborted, AnalysisRunFailed]
+  webhooks: [incident-tool]
+  selector:
+    matchLabels:
+      tier: frontend
+`
+)
+
+func newConfigMap(webhooks, subscriptions string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      NotificationConfigMapName,
+			Namespace: "argo-rollouts",
+		},
+		Data: map[string]string{
+			webhooksKey:      webhooks,
+			subscriptionsKey: subscriptions,
+		},
+	}
+}
+
+func newConfigMapLister(configMaps ...*corev1.ConfigMap) corelisters.ConfigMapLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, cm := range configMaps {
+		_ = indexer.Add(cm)
+	}
+	return corelisters.NewConfigMapLister(indexer)
+}
+
+func TestGetConfigMissingConfigMap(t *testing.T) {
+	cfg, err := GetConfig(newConfigMapLister().ConfigMaps("argo-rollouts"))
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.Webhooks)
+	assert.Empty(t, cfg.Subscriptions)
+}
+
+func TestGetConfig(t *testing.T) {
+	lister := newConfigMapLister(newConfigMap(testWebhooks, testSubscriptions))
+	cfg, err := GetConfig(lister.ConfigMaps("argo-rollouts"))
+	assert.NoError(t, err)
+	assert.Len(t, cfg.Webhooks, 2)
+	assert.Equal(t, WebhookFormatCloudEvents, cfg.GetWebhook("incident-tool").Format)
+	assert.Equal(t, "hmac", cfg.GetWebhook("incident-tool").HMACSecret.Key)
+	assert.Nil(t, cfg.GetWebhook("does-not-exist"))
+	assert.Len(t, cfg.Subscriptions, 1)
+}
+
+func TestParseConfigInvalid(t *testing.T) {
+	tests := []struct {
+		webhooks string
+		err      string
+	}{
+		{"- url: https://example.com", "has no name"},
+		{"- name: foo", "has no url"},
+		{"- name: foo\n  url: https://example.com\n  format: smoke-signals", "unsupported format"},
+		{"not: a: list", "failed to parse 'webhooks'"},
+	}
+	for _, test := range tests {
+		_, err := ParseConfig(newConfigMap(test.webhooks, ""))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), test.err)
+	}
+}
+
+func TestGetSubscribedWebhooks(t *testing.T) {
+	cfg, err := ParseConfig(newConfigMap(testWebhooks, testSubscriptions))
+	assert.NoError(t, err)
+
+	ro := &v1alpha1.Rollout{}
+	ro.Labels = map[string]string{"tier": "frontend"}
+	ro.Annotations = map[string]string{
+		SubscribeAnnotationPrefix + string(TriggerAborted):   "chat, incident-tool",
+		SubscribeAnnotationPrefix + string(TriggerCompleted): "chat",
+	}
+
+	names, err := cfg.GetSubscribedWebhooks(ro, TriggerAborted)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"incident-tool", "chat"}, names)
+
+	names, err = cfg.GetSubscribedWebhooks(ro, TriggerCompleted)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"chat"}, names)
+
+	names, err = cfg.GetSubscribedWebhooks(ro, TriggerStepChanged)
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+
+	// the selector of the ConfigMap subscription no longer matches
+	ro.Labels["tier"] = "backend"
+	names, err = cfg.GetSubscribedWebhooks(ro, TriggerAnalysisRunFailed)
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
@@ -0,0 +1,170 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+const (
+	// DefaultWorkers is the default number of workers delivering notifications
+	DefaultWorkers = 5
+	// queueSize is the number of notifications which can wait for delivery before new ones are dropped
+	queueSize = 1000
+)
+
+// Notifier delivers notifications about rollout state transitions. Delivery is best effort: the
+// notifications wait for delivery in memory, so they are dropped when too many are waiting and lost
+// when the controller restarts or loses the leader election.
+type Notifier interface {
+	// Notify compares the rollout before and after a reconciliation and queues a notification for
+	// every state transition a webhook is subscribed to
+	Notify(old, new *v1alpha1.Rollout)
+}
+
+// NotifierConfig describes the data required to instantiate a new WebhookNotifier
+type NotifierConfig struct {
+	KubeClientSet kubernetes.Interface
+	// ConfigMapLister lists the ConfigMaps of the controller namespace, to read the notification
+	// ConfigMap from
+	ConfigMapLister corelisters.ConfigMapLister
+	// Namespace is the namespace of the notification ConfigMap and HMAC secrets
+	Namespace string
+	// HTTPClient is used to deliver notifications. Defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+type delivery struct {
+	webhook      WebhookConfig
+	notification Notification
+}
+
+// WebhookNotifier delivers notifications to the webhooks defined in the notification ConfigMap
+type WebhookNotifier struct {
+	kubeclientset   kubernetes.Interface
+	configMapLister corelisters.ConfigMapLister
+	namespace       string
+	httpClient      *http.Client
+	queue           chan delivery
+}
+
+// NewNotifier returns a new WebhookNotifier. Notifications are only delivered once Run is called.
+func NewNotifier(cfg NotifierConfig) *WebhookNotifier {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &WebhookNotifier{
+		kubeclientset:   cfg.KubeClientSet,
+		configMapLister: cfg.ConfigMapLister,
+		namespace:       cfg.Namespace,
+		httpClient:      httpClient,
+		queue:           make(chan delivery, queueSize),
+	}
+}
+
+// Notify queues a notification for every state transition between the two versions of the rollout
+// which a webhook is subscribed to. Delivery happens asynchronously so reconciliation is never
+// blocked by a slow or unreachable webhook.
+func (n *WebhookNotifier) Notify(old, new *v1alpha1.Rollout) {
+	events := GetRolloutEvents(old, new)
+	if len(events) == 0 {
+		return
+	}
+	logCtx := logutil.WithRollout(new)
+	cfg, err := GetConfig(n.configMapLister.ConfigMaps(n.namespace))
+	if err != nil {
+		logCtx.Warnf("Failed to load notification config: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, event := range events {
+		names, err := cfg.GetSubscribedWebhooks(new, event.Trigger)
+		if err != nil {
+			logCtx.Warnf("Failed to resolve subscriptions for %s: %v", event.Trigger, err)
+			continue
+		}
+		for _, name := range names {
+			webhook := cfg.GetWebhook(name)
+			if webhook == nil {
+				logCtx.Warnf("Rollout is subscribed to unknown webhook '%s'", name)
+				continue
+			}
+			d := delivery{
+				webhook: *webhook,
+				notification: Notification{
+					Rollout: new.DeepCopy(),
+					Event:   event,
+					Time:    now,
+				},
+			}
+			select {
+			case n.queue <- d:
+				logCtx.Infof("Queued %s notification for webhook '%s'", event.Trigger, name)
+			default:
+				logCtx.Warnf("Notification queue is full, dropping %s notification for webhook '%s'", event.Trigger, name)
+			}
+		}
+	}
+}
+
+// Run starts the workers delivering the queued notifications. It blocks until stopCh is closed.
+func (n *WebhookNotifier) Run(workers int, stopCh <-chan struct{}) {
+	log.Info("Starting Notification workers")
+	for i := 0; i < workers; i++ {
+		go wait.Until(func() { n.runWorker(stopCh) }, time.Second, stopCh)
+	}
+	<-stopCh
+	log.Info("Shutting down notification workers")
+}
+
+func (n *WebhookNotifier) runWorker(stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case d := <-n.queue:
+			n.deliver(d)
+		}
+	}
+}
+
+func (n *WebhookNotifier) deliver(d delivery) {
+	logCtx := logutil.WithRollout(d.notification.Rollout).WithField("webhook", d.webhook.Name).WithField("trigger", d.notification.Event.Trigger)
+	hmacKey, err := n.getHMACKey(&d.webhook)
+	if err != nil {
+		logCtx.Warnf("Failed to deliver notification: %v", err)
+		return
+	}
+	err = Deliver(n.httpClient, &d.webhook, d.notification, hmacKey)
+	if err != nil {
+		logCtx.Warnf("Failed to deliver notification: %v", err)
+		return
+	}
+	logCtx.Info("Delivered notification")
+}
+
+func (n *WebhookNotifier) getHMACKey(webhook *WebhookConfig) ([]byte, error) {
+	if webhook.HMACSecret == nil {
+		return nil, nil
+	}
+	secret, err := n.kubeclientset.CoreV1().Secrets(n.namespace).Get(context.TODO(), webhook.HMACSecret.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	key, ok := secret.Data[webhook.HMACSecret.Key]
+	if !ok {
+		return nil, fmt.Errorf("key '%s' not found in secret '%s'", webhook.HMACSecret.Key, webhook.HMACSecret.Name)
+	}
+	return key, nil
+}
@@ -0,0 +1,112 @@
+package notifications
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+)
+
+// Trigger is a rollout state transition which webhooks can subscribe to
+type Trigger string
+
+const (
+	// TriggerStepChanged is fired when a canary rollout moves to another step
+	TriggerStepChanged Trigger = "RolloutStepChanged"
+	// TriggerPaused is fired when the controller adds a new pause condition to a rollout
+	TriggerPaused Trigger = "RolloutPaused"
+	// TriggerAborted is fired when a rollout is aborted
+	TriggerAborted Trigger = "RolloutAborted"
+	// TriggerAnalysisRunFailed is fired when an AnalysisRun owned by the rollout fails or errors
+	TriggerAnalysisRunFailed Trigger = "AnalysisRunFailed"
+	// TriggerPromoted is fired when the new ReplicaSet of a rollout becomes the stable ReplicaSet
+	TriggerPromoted Trigger = "RolloutPromoted"
+	// TriggerCompleted is fired when all the replicas of a rollout are updated and available
+	TriggerCompleted Trigger = "RolloutCompleted"
+)
+
+// Triggers is the list of every trigger which can be subscribed to
+var Triggers = []Trigger{
+	TriggerStepChanged,
+	TriggerPaused,
+	TriggerAborted,
+	TriggerAnalysisRunFailed,
+	TriggerPromoted,
+	TriggerCompleted,
+}
+
+// Event is a state transition observed between two versions of a rollout
+type Event struct {
+	Trigger Trigger
+	Message string
+}
+
+// GetRolloutEvents compares the status of the rollout before and after a reconciliation and returns
+// the state transitions which happened in between
+func GetRolloutEvents(old, new *v1alpha1.Rollout) []Event {
+	var events []Event
+	oldStatus := old.Status
+	newStatus := new.Status
+
+	if newStatus.CurrentStepIndex != nil && (oldStatus.CurrentStepIndex == nil || *oldStatus.CurrentStepIndex != *newStatus.CurrentStepIndex) {
+		msg := fmt.Sprintf("Rollout step index set to %d", *newStatus.CurrentStepIndex)
+		if oldStatus.CurrentStepIndex != nil {
+			msg = fmt.Sprintf("Rollout step index changed from %d to %d", *oldStatus.CurrentStepIndex, *newStatus.CurrentStepIndex)
+		}
+		events = append(events, Event{Trigger: TriggerStepChanged, Message: msg})
+	}
+
+	oldPauseReasons := map[v1alpha1.PauseReason]bool{}
+	for _, cond := range oldStatus.PauseConditions {
+		oldPauseReasons[cond.Reason] = true
+	}
+	for _, cond := range newStatus.PauseConditions {
+		if !oldPauseReasons[cond.Reason] {
+			events = append(events, Event{Trigger: TriggerPaused, Message: fmt.Sprintf("Rollout paused with reason %s", cond.Reason)})
+		}
+	}
+
+	// the abort is detected from the Progressing condition rather than status.abort, since a user
+	// aborting the rollout sets status.abort before the controller reconciles it
+	newProgressing := conditions.GetRolloutCondition(newStatus, v1alpha1.RolloutProgressing)
+	oldProgressing := conditions.GetRolloutCondition(oldStatus, v1alpha1.RolloutProgressing)
+	if newProgressing != nil && newProgressing.Reason == conditions.RolloutAbortedReason &&
+		(oldProgressing == nil || oldProgressing.Reason != conditions.RolloutAbortedReason) {
+		events = append(events, Event{Trigger: TriggerAborted, Message: newProgressing.Message})
+	}
+
+	analysisRuns := []struct {
+		old, new *v1alpha1.RolloutAnalysisRunStatus
+	}{
+		{oldStatus.Canary.CurrentStepAnalysisRunStatus, newStatus.Canary.CurrentStepAnalysisRunStatus},
+		{oldStatus.Canary.CurrentBackgroundAnalysisRunStatus, newStatus.Canary.CurrentBackgroundAnalysisRunStatus},
+		{oldStatus.BlueGreen.PrePromotionAnalysisRunStatus, newStatus.BlueGreen.PrePromotionAnalysisRunStatus},
+		{oldStatus.BlueGreen.PostPromotionAnalysisRunStatus, newStatus.BlueGreen.PostPromotionAnalysisRunStatus},
+	}
+	for _, ar := range analysisRuns {
+		if ar.new == nil || (ar.new.Status != v1alpha1.AnalysisPhaseFailed && ar.new.Status != v1alpha1.AnalysisPhaseError) {
+			continue
+		}
+		if ar.old != nil && ar.old.Name == ar.new.Name && ar.old.Status == ar.new.Status {
+			continue
+		}
+		msg := fmt.Sprintf("AnalysisRun '%s' completed %s", ar.new.Name, ar.new.Status)
+		if ar.new.Message != "" {
+			msg = fmt.Sprintf("%s: %s", msg, ar.new.Message)
+		}
+		events = append(events, Event{Trigger: TriggerAnalysisRunFailed, Message: msg})
+	}
+
+	if oldStatus.StableRS != "" && newStatus.StableRS != "" && oldStatus.StableRS != newStatus.StableRS {
+		events = append(events, Event{Trigger: TriggerPromoted, Message: fmt.Sprintf("Rollout promoted pod template hash %s to stable", newStatus.StableRS)})
+	}
+
+	if newProgressing != nil && newProgressing.Status == corev1.ConditionTrue && newProgressing.Reason == conditions.NewRSAvailableReason {
+		if oldProgressing == nil || oldProgressing.Reason != conditions.NewRSAvailableReason {
+			events = append(events, Event{Trigger: TriggerCompleted, Message: newProgressing.Message})
+		}
+	}
+	return events
+}
@@ -0,0 +1,127 @@
+package notifications
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+)
+
+func newRollout(status v1alpha1.RolloutStatus) *v1alpha1.Rollout {
+	ro := &v1alpha1.Rollout{}
+	ro.Name = "guestbook"
+	ro.Namespace = "default"
+	ro.Status = status
+	return ro
+}
+
+func triggersOf(events []Event) []Trigger {
+	var triggers []Trigger
+	for _, e := range events {
+		triggers = append(triggers, e.Trigger)
+	}
+	return triggers
+}
+
+func TestGetRolloutEventsNoChange(t *testing.T) {
+	status := v1alpha1.RolloutStatus{
+		CurrentStepIndex: pointer.Int32Ptr(1),
+		StableRS:         "abc",
+	}
+	assert.Empty(t, GetRolloutEvents(newRollout(status), newRollout(status)))
+}
+
+func TestGetRolloutEventsStepChanged(t *testing.T) {
+	old := newRollout(v1alpha1.RolloutStatus{CurrentStepIndex: pointer.Int32Ptr(1)})
+	new := newRollout(v1alpha1.RolloutStatus{CurrentStepIndex: pointer.Int32Ptr(2)})
+	events := GetRolloutEvents(old, new)
+	assert.Equal(t, []Trigger{TriggerStepChanged}, triggersOf(events))
+	assert.Equal(t, "Rollout step index changed from 1 to 2", events[0].Message)
+
+	events = GetRolloutEvents(newRollout(v1alpha1.RolloutStatus{}), new)
+	assert.Equal(t, "Rollout step index set to 2", events[0].Message)
+}
+
+func TestGetRolloutEventsPaused(t *testing.T) {
+	old := newRollout(v1alpha1.RolloutStatus{
+		PauseConditions: []v1alpha1.PauseCondition{{Reason: v1alpha1.PauseReasonCanaryPauseStep}},
+	})
+	new := newRollout(v1alpha1.RolloutStatus{
+		PauseConditions: []v1alpha1.PauseCondition{
+			{Reason: v1alpha1.PauseReasonCanaryPauseStep},
+			{Reason: v1alpha1.PauseReasonInconclusiveAnalysis},
+		},
+	})
+	events := GetRolloutEvents(old, new)
+	assert.Equal(t, []Trigger{TriggerPaused}, triggersOf(events))
+	assert.Contains(t, events[0].Message, string(v1alpha1.PauseReasonInconclusiveAnalysis))
+}
+
+func TestGetRolloutEventsAbortedAndAnalysisFailed(t *testing.T) {
+	old := newRollout(v1alpha1.RolloutStatus{
+		Canary: v1alpha1.CanaryStatus{
+			CurrentBackgroundAnalysisRunStatus: &v1alpha1.RolloutAnalysisRunStatus{Name: "run", Status: v1alpha1.AnalysisPhaseRunning},
+		},
+	})
+	abortCond := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionFalse, conditions.RolloutAbortedReason, "metric \"error-rate\" assessed Failed")
+	new := newRollout(v1alpha1.RolloutStatus{
+		Abort:      true,
+		Conditions: []v1alpha1.RolloutCondition{*abortCond},
+		Canary: v1alpha1.CanaryStatus{
+			CurrentBackgroundAnalysisRunStatus: &v1alpha1.RolloutAnalysisRunStatus{Name: "run", Status: v1alpha1.AnalysisPhaseFailed, Message: "error-rate failed"},
+		},
+	})
+	events := GetRolloutEvents(old, new)
+	assert.Equal(t, []Trigger{TriggerAborted, TriggerAnalysisRunFailed}, triggersOf(events))
+	assert.Equal(t, "metric \"error-rate\" assessed Failed", events[0].Message)
+	assert.Equal(t, "AnalysisRun 'run' completed Failed: error-rate failed", events[1].Message)
+
+	// the failed analysis run is only reported once
+	assert.Empty(t, GetRolloutEvents(new, new))
+}
+
+func TestGetRolloutEventsUserAborted(t *testing.T) {
+	progressing := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionTrue, conditions.ReplicaSetUpdatedReason, "progressing")
+	// kubectl argo rollouts abort sets status.abort, which the controller reconciles afterwards
+	old := newRollout(v1alpha1.RolloutStatus{
+		Abort:      true,
+		Conditions: []v1alpha1.RolloutCondition{*progressing},
+	})
+	abortCond := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionFalse, conditions.RolloutAbortedReason, conditions.RolloutAbortedMessage)
+	new := newRollout(v1alpha1.RolloutStatus{
+		Abort:      true,
+		Conditions: []v1alpha1.RolloutCondition{*abortCond},
+	})
+	events := GetRolloutEvents(old, new)
+	assert.Equal(t, []Trigger{TriggerAborted}, triggersOf(events))
+	assert.Equal(t, conditions.RolloutAbortedMessage, events[0].Message)
+
+	// the abort is only reported once
+	assert.Empty(t, GetRolloutEvents(new, new))
+}
+
+func TestGetRolloutEventsPromotedAndCompleted(t *testing.T) {
+	progressing := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionTrue, conditions.ReplicaSetUpdatedReason, "progressing")
+	completed := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionTrue, conditions.NewRSAvailableReason, "ReplicaSet \"guestbook-def\" has successfully progressed.")
+	old := newRollout(v1alpha1.RolloutStatus{
+		StableRS:   "abc",
+		Conditions: []v1alpha1.RolloutCondition{*progressing},
+	})
+	new := newRollout(v1alpha1.RolloutStatus{
+		StableRS:   "def",
+		Conditions: []v1alpha1.RolloutCondition{*completed},
+	})
+	events := GetRolloutEvents(old, new)
+	assert.Equal(t, []Trigger{TriggerPromoted, TriggerCompleted}, triggersOf(events))
+	assert.Equal(t, completed.Message, events[1].Message)
+}
+
+func TestGetRolloutEventsInitialStableIsNotPromotion(t *testing.T) {
+	old := newRollout(v1alpha1.RolloutStatus{})
+	new := newRollout(v1alpha1.RolloutStatus{StableRS: "abc"})
+	assert.Empty(t, GetRolloutEvents(old, new))
+}
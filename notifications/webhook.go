@@ -0,0 +1,210 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"text/template"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+)
+
+const (
+	// SignatureHeader is the header which holds the HMAC-SHA256 signature of the payload, formatted as sha256=<hex>
+	SignatureHeader = "X-Rollouts-Signature-256"
+
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsTypePrefix  = "io.argoproj.rollout."
+)
+
+// Notification is a single delivery of an event to a webhook
+type Notification struct {
+	Rollout *v1alpha1.Rollout
+	Event   Event
+	Time    time.Time
+}
+
+// templateData is the data the payload template of a webhook is rendered with
+type templateData struct {
+	Rollout *v1alpha1.Rollout
+	Trigger Trigger
+	Message string
+	Time    string
+}
+
+// Payload is the default payload of a notification when the webhook has no template
+type Payload struct {
+	Trigger          Trigger `json:"trigger"`
+	Message          string  `json:"message"`
+	Time             string  `json:"time"`
+	Name             string  `json:"name"`
+	Namespace        string  `json:"namespace"`
+	Revision         string  `json:"revision,omitempty"`
+	CurrentPodHash   string  `json:"currentPodHash,omitempty"`
+	StableRS         string  `json:"stableRS,omitempty"`
+	CurrentStepIndex *int32  `json:"currentStepIndex,omitempty"`
+}
+
+// RenderPayload renders the body of the request for the notification
+func RenderPayload(webhook *WebhookConfig, n Notification) ([]byte, error) {
+	timestamp := n.Time.UTC().Format(time.RFC3339)
+	if webhook.Template == "" {
+		return json.Marshal(Payload{
+			Trigger:          n.Event.Trigger,
+			Message:          n.Event.Message,
+			Time:             timestamp,
+			Name:             n.Rollout.Name,
+			Namespace:        n.Rollout.Namespace,
+			Revision:         n.Rollout.Annotations[annotations.RevisionAnnotation],
+			CurrentPodHash:   n.Rollout.Status.CurrentPodHash,
+			StableRS:         n.Rollout.Status.StableRS,
+			CurrentStepIndex: n.Rollout.Status.CurrentStepIndex,
+		})
+	}
+	tmpl, err := template.New(webhook.Name).Option("missingkey=error").Parse(webhook.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template of webhook '%s': %v", webhook.Name, err)
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, templateData{
+		Rollout: n.Rollout,
+		Trigger: n.Event.Trigger,
+		Message: n.Event.Message,
+		Time:    timestamp,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render template of webhook '%s': %v", webhook.Name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Sign returns the HMAC-SHA256 signature of the payload formatted as sha256=<hex>
+func Sign(key, payload []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newRequest builds the request delivering the payload of a notification to the webhook
+func newRequest(webhook *WebhookConfig, n Notification, id string, payload []byte, hmacKey []byte) (*http.Request, error) {
+	method := webhook.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequest(method, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if webhook.Format == WebhookFormatCloudEvents {
+		req.Header.Set("ce-specversion", cloudEventsSpecVersion)
+		req.Header.Set("ce-id", id)
+		req.Header.Set("ce-type", cloudEventsTypePrefix+string(n.Event.Trigger))
+		req.Header.Set("ce-source", fmt.Sprintf("/apis/argoproj.io/v1alpha1/namespaces/%s/rollouts/%s", n.Rollout.Namespace, n.Rollout.Name))
+		req.Header.Set("ce-subject", n.Rollout.Name)
+		req.Header.Set("ce-time", n.Time.UTC().Format(time.RFC3339))
+	}
+	for k, v := range webhook.Headers {
+		req.Header.Set(k, v)
+	}
+	if len(hmacKey) > 0 {
+		req.Header.Set(SignatureHeader, Sign(hmacKey, payload))
+	}
+	return req, nil
+}
+
+// getBackoff returns the retry backoff of the webhook
+func getBackoff(webhook *WebhookConfig) (wait.Backoff, error) {
+	limit := DefaultRetryLimit
+	duration := DefaultRetryBackoff
+	if webhook.Retry != nil {
+		if webhook.Retry.Limit != nil {
+			limit = *webhook.Retry.Limit
+		}
+		if webhook.Retry.Backoff != "" {
+			var err error
+			duration, err = time.ParseDuration(webhook.Retry.Backoff)
+			if err != nil {
+				return wait.Backoff{}, fmt.Errorf("invalid retry backoff of webhook '%s': %v", webhook.Name, err)
+			}
+		}
+	}
+	return wait.Backoff{
+		Duration: duration,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    limit + 1,
+	}, nil
+}
+
+// getTimeout returns the timeout of a single delivery attempt to the webhook
+func getTimeout(webhook *WebhookConfig) (time.Duration, error) {
+	if webhook.Timeout == "" {
+		return DefaultTimeout, nil
+	}
+	timeout, err := time.ParseDuration(webhook.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout of webhook '%s': %v", webhook.Name, err)
+	}
+	return timeout, nil
+}
+
+// Deliver sends the notification to the webhook, retrying with an exponential backoff while the
+// webhook is unreachable or answers with a server error
+func Deliver(client *http.Client, webhook *WebhookConfig, n Notification, hmacKey []byte) error {
+	payload, err := RenderPayload(webhook, n)
+	if err != nil {
+		return err
+	}
+	backoff, err := getBackoff(webhook)
+	if err != nil {
+		return err
+	}
+	timeout, err := getTimeout(webhook)
+	if err != nil {
+		return err
+	}
+	httpClient := *client
+	httpClient.Timeout = timeout
+
+	// the id identifies the notification, so it must not change between retries
+	id := string(uuid.NewUUID())
+	var lastErr error
+	err = wait.ExponentialBackoff(backoff, func() (bool, error) {
+		req, err := newRequest(webhook, n, id, payload, hmacKey)
+		if err != nil {
+			return false, err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return true, nil
+		}
+		lastErr = fmt.Errorf("webhook '%s' responded with status %d", webhook.Name, resp.StatusCode)
+		// Client errors other than rate limiting will not succeed when retried
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return false, lastErr
+		}
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("failed to deliver notification after %d attempts: %v", backoff.Steps, lastErr)
+	}
+	return err
+}
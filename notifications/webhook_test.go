@@ -0,0 +1,220 @@
+package notifications
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+)
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newTestServer returns a server which answers with the given status codes in order and records
+// every request it receives
+func newTestServer(statusCodes ...int) (*httptest.Server, func() []receivedRequest) {
+	var lock sync.Mutex
+	var requests []receivedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, receivedRequest{header: r.Header, body: body})
+		code := http.StatusOK
+		if len(requests) <= len(statusCodes) {
+			code = statusCodes[len(requests)-1]
+		}
+		w.WriteHeader(code)
+	}))
+	return server, func() []receivedRequest {
+		lock.Lock()
+		defer lock.Unlock()
+		return requests
+	}
+}
+
+func newNotification() Notification {
+	ro := newRollout(v1alpha1.RolloutStatus{StableRS: "abc", CurrentPodHash: "def"})
+	return Notification{
+		Rollout: ro,
+		Event:   Event{Trigger: TriggerAborted, Message: "Rollout is aborted"},
+		Time:    time.Date(2020, 12, 1, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestRenderPayloadDefault(t *testing.T) {
+	payload, err := RenderPayload(&WebhookConfig{Name: "test"}, newNotification())
+	assert.NoError(t, err)
+	var p Payload
+	assert.NoError(t, json.Unmarshal(payload, &p))
+	assert.Equal(t, TriggerAborted, p.Trigger)
+	assert.Equal(t, "guestbook", p.Name)
+	assert.Equal(t, "default", p.Namespace)
+	assert.Equal(t, "def", p.CurrentPodHash)
+	assert.Equal(t, "2020-12-01T10:00:00Z", p.Time)
+}
+
+func TestRenderPayloadTemplate(t *testing.T) {
+	webhook := &WebhookConfig{
+		Name:     "test",
+		Template: `{"text": "{{.Rollout.Namespace}}/{{.Rollout.Name}} {{.Trigger}}: {{.Message}}"}`,
+	}
+	payload, err := RenderPayload(webhook, newNotification())
+	assert.NoError(t, err)
+	assert.Equal(t, `{"text": "default/guestbook RolloutAborted: Rollout is aborted"}`, string(payload))
+
+	webhook.Template = "{{.DoesNotExist}}"
+	_, err = RenderPayload(webhook, newNotification())
+	assert.Error(t, err)
+
+	webhook.Template = "{{"
+	_, err = RenderPayload(webhook, newNotification())
+	assert.Error(t, err)
+}
+
+func TestDeliverSignedCloudEvent(t *testing.T) {
+	server, requests := newTestServer()
+	defer server.Close()
+
+	webhook := &WebhookConfig{
+		Name:    "test",
+		URL:     server.URL,
+		Format:  WebhookFormatCloudEvents,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}
+	err := Deliver(http.DefaultClient, webhook, newNotification(), []byte("secret"))
+	assert.NoError(t, err)
+	assert.Len(t, requests(), 1)
+	req := requests()[0]
+	assert.Equal(t, "1.0", req.header.Get("ce-specversion"))
+	assert.Equal(t, "io.argoproj.rollout.RolloutAborted", req.header.Get("ce-type"))
+	assert.Equal(t, "/apis/argoproj.io/v1alpha1/namespaces/default/rollouts/guestbook", req.header.Get("ce-source"))
+	assert.NotEmpty(t, req.header.Get("ce-id"))
+	assert.Equal(t, "Bearer token", req.header.Get("Authorization"))
+	assert.Equal(t, Sign([]byte("secret"), req.body), req.header.Get(SignatureHeader))
+}
+
+func TestDeliverRetriesServerErrors(t *testing.T) {
+	server, requests := newTestServer(http.StatusServiceUnavailable, http.StatusBadGateway)
+	defer server.Close()
+
+	webhook := &WebhookConfig{
+		Name:   "test",
+		URL:    server.URL,
+		Format: WebhookFormatCloudEvents,
+		Retry:  &RetryConfig{Backoff: "1ms"},
+	}
+	err := Deliver(http.DefaultClient, webhook, newNotification(), nil)
+	assert.NoError(t, err)
+	assert.Len(t, requests(), 3)
+	assert.Empty(t, requests()[0].header.Get(SignatureHeader))
+	// the cloud event id stays the same across retries
+	assert.Equal(t, requests()[0].header.Get("ce-id"), requests()[2].header.Get("ce-id"))
+}
+
+func TestDeliverRetryLimit(t *testing.T) {
+	server, requests := newTestServer(http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	defer server.Close()
+
+	limit := 1
+	webhook := &WebhookConfig{
+		Name:  "test",
+		URL:   server.URL,
+		Retry: &RetryConfig{Limit: &limit, Backoff: "1ms"},
+	}
+	err := Deliver(http.DefaultClient, webhook, newNotification(), nil)
+	assert.EqualError(t, err, "failed to deliver notification after 2 attempts: webhook 'test' responded with status 500")
+	assert.Len(t, requests(), 2)
+}
+
+func TestDeliverDoesNotRetryClientErrors(t *testing.T) {
+	server, requests := newTestServer(http.StatusUnauthorized)
+	defer server.Close()
+
+	webhook := &WebhookConfig{Name: "test", URL: server.URL, Retry: &RetryConfig{Backoff: "1ms"}}
+	err := Deliver(http.DefaultClient, webhook, newNotification(), nil)
+	assert.EqualError(t, err, "webhook 'test' responded with status 401")
+	assert.Len(t, requests(), 1)
+}
+
+func TestDeliverInvalidConfig(t *testing.T) {
+	webhook := &WebhookConfig{Name: "test", URL: "http://localhost", Retry: &RetryConfig{Backoff: "soon"}}
+	assert.Error(t, Deliver(http.DefaultClient, webhook, newNotification(), nil))
+
+	webhook = &WebhookConfig{Name: "test", URL: "http://localhost", Timeout: "later"}
+	assert.Error(t, Deliver(http.DefaultClient, webhook, newNotification(), nil))
+}
+
+func newAbortedRollout() *v1alpha1.Rollout {
+	abortCond := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionFalse, conditions.RolloutAbortedReason, conditions.RolloutAbortedMessage)
+	return newRollout(v1alpha1.RolloutStatus{
+		Abort:      true,
+		Conditions: []v1alpha1.RolloutCondition{*abortCond},
+	})
+}
+
+func TestNotifierDeliversSubscribedEvents(t *testing.T) {
+	server, requests := newTestServer()
+	defer server.Close()
+
+	webhooks := `
+- name: incident-tool
+  url: ` + server.URL + `
+  hmacSecret:
+    name: notification-secret
+    key: hmac
+- name: unused
+  url: ` + server.URL + `
+`
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "notification-secret", Namespace: "argo-rollouts"},
+		Data:       map[string][]byte{"hmac": []byte("secret")},
+	}
+	client := k8sfake.NewSimpleClientset(secret)
+	notifier := NewNotifier(NotifierConfig{
+		KubeClientSet:   client,
+		ConfigMapLister: newConfigMapLister(newConfigMap(webhooks, "")),
+		Namespace:       "argo-rollouts",
+	})
+
+	old := newRollout(v1alpha1.RolloutStatus{})
+	new := newAbortedRollout()
+	new.Annotations = map[string]string{
+		SubscribeAnnotationPrefix + string(TriggerAborted): "incident-tool,does-not-exist",
+	}
+	notifier.Notify(old, new)
+	assert.Len(t, notifier.queue, 1)
+	// the config is read from the lister, not from the API server
+	assert.Empty(t, client.Actions())
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go notifier.Run(1, stopCh)
+	assert.Eventually(t, func() bool { return len(requests()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	req := requests()[0]
+	assert.Equal(t, Sign([]byte("secret"), req.body), req.header.Get(SignatureHeader))
+	var p Payload
+	assert.NoError(t, json.Unmarshal(req.body, &p))
+	assert.Equal(t, TriggerAborted, p.Trigger)
+}
+
+func TestNotifierIgnoresUnsubscribedEvents(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	notifier := NewNotifier(NotifierConfig{KubeClientSet: client, ConfigMapLister: newConfigMapLister(), Namespace: "argo-rollouts"})
+	notifier.Notify(newRollout(v1alpha1.RolloutStatus{}), newAbortedRollout())
+	assert.Len(t, notifier.queue, 0)
+}
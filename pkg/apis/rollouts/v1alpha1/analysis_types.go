@@ -139,6 +139,26 @@ type MetricProvider struct {
 	NewRelic *NewRelicMetric `json:"newRelic,omitempty"`
 	// Job specifies the job metric run
 	Job *JobMetric `json:"job,omitempty"`
+	// Plugin specifies a metric measured by an out-of-process plugin
+	Plugin *PluginMetric `json:"plugin,omitempty"`
+	// CloudWatch specifies the cloudWatch metric to query
+	CloudWatch *CloudWatchMetric `json:"cloudWatch,omitempty"`
+	// Graphite specifies the graphite metric to query
+	Graphite *GraphiteMetric `json:"graphite,omitempty"`
+	// Influxdb specifies the influxdb metric to query
+	Influxdb *InfluxdbMetric `json:"influxdb,omitempty"`
+	// Elasticsearch specifies the elasticsearch search to query
+	Elasticsearch *ElasticsearchMetric `json:"elasticsearch,omitempty"`
+	// Loki specifies the loki metric to query
+	Loki *LokiMetric `json:"loki,omitempty"`
+	// Stackdriver specifies the google cloud monitoring metric to query
+	Stackdriver *StackdriverMetric `json:"stackdriver,omitempty"`
+	// Dynatrace specifies the dynatrace metric to query
+	Dynatrace *DynatraceMetric `json:"dynatrace,omitempty"`
+	// SQL specifies the read-only SQL query to run against a database
+	SQL *SQLMetric `json:"sql,omitempty"`
+	// Resource specifies a kubernetes object to create which acts as a metric
+	Resource *ResourceMetric `json:"resource,omitempty"`
 }
 
 // AnalysisPhase is the overall phase of an AnalysisRun, MetricResult, or Measurement
@@ -187,12 +207,242 @@ type NewRelicMetric struct {
 	Query string `json:"query"`
 }
 
+// GraphiteMetric defines the graphite render query to perform canary analysis
+type GraphiteMetric struct {
+	// Address is the HTTP address and port of the graphite server
+	Address string `json:"address,omitempty"`
+	// Target is the graphite target expression to render
+	Target string `json:"target"`
+	// From is the start of the queried time window, in the graphite time format. Defaults to -5min.
+	// +optional
+	From string `json:"from,omitempty"`
+	// Until is the end of the queried time window, in the graphite time format. Defaults to now.
+	// +optional
+	Until string `json:"until,omitempty"`
+}
+
+// InfluxdbMetric defines the InfluxDB Flux query to perform canary analysis
+type InfluxdbMetric struct {
+	// Profile is the name of the secret holding the address, token and organization of InfluxDB
+	// +optional
+	Profile string `json:"profile,omitempty"`
+	// Query is the Flux query to execute
+	Query string `json:"query"`
+}
+
+// ElasticsearchMetric defines the elasticsearch search to perform canary analysis
+type ElasticsearchMetric struct {
+	// Address is the HTTP address and port of the elasticsearch or opensearch cluster
+	Address string `json:"address"`
+	// Index is the index, alias or index pattern to search
+	Index string `json:"index"`
+	// Query is the JSON body of the search, usually with a query and aggregations
+	Query string `json:"query"`
+	// JSONPath is a JSON Path into the response to use as the result variable (default: "{$}")
+	// +optional
+	JSONPath string `json:"jsonPath,omitempty"`
+	// Profile is the name of the secret holding the credentials of the cluster. The search is not
+	// authenticated when it is not set.
+	// +optional
+	Profile string `json:"profile,omitempty"`
+}
+
+// LokiMetric defines the loki LogQL metric query to perform canary analysis
+type LokiMetric struct {
+	// Address is the HTTP address and port of the loki server
+	Address string `json:"address"`
+	// Query is the LogQL metric query to perform
+	Query string `json:"query"`
+	// Range is the length of the time window of a range query, which ends at the time of the
+	// measurement. The query is an instant query when it is not set.
+	// +optional
+	Range DurationString `json:"range,omitempty"`
+	// Step is the resolution of a range query. Defaults to the one chosen by loki.
+	// +optional
+	Step DurationString `json:"step,omitempty"`
+	// Profile is the name of the secret holding the tenant and the credentials of loki. The query is
+	// not authenticated when it is not set.
+	// +optional
+	Profile string `json:"profile,omitempty"`
+}
+
+// StackdriverMetric defines the google cloud monitoring query to perform canary analysis. Exactly
+// one of the query and the filter is set.
+type StackdriverMetric struct {
+	// Project is the id of the google cloud project whose time series are queried
+	Project string `json:"project"`
+	// Query is a monitoring query language (MQL) query, whose time window is part of the query
+	// +optional
+	Query string `json:"query,omitempty"`
+	// Filter is a time series filter selecting the time series to list
+	// +optional
+	Filter string `json:"filter,omitempty"`
+	// Interval is the length of the time window of the filter, which ends at the time of the
+	// measurement. Defaults to 5m.
+	// +optional
+	Interval DurationString `json:"interval,omitempty"`
+	// Aggregation aligns and reduces the time series selected by the filter
+	// +optional
+	Aggregation *StackdriverAggregation `json:"aggregation,omitempty"`
+	// Profile is the name of the secret holding the service account key used to query. The
+	// credentials of the controller, e.g. from workload identity, are used when it is not set.
+	// +optional
+	Profile string `json:"profile,omitempty"`
+	// Endpoint overrides the endpoint of the google cloud monitoring API
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// StackdriverAggregation defines how the time series selected by a filter are aligned and reduced
+type StackdriverAggregation struct {
+	// AlignmentPeriod is the length of the windows the points of each time series are aligned to
+	// +optional
+	AlignmentPeriod DurationString `json:"alignmentPeriod,omitempty"`
+	// PerSeriesAligner is the aligner of the points of each time series, e.g. ALIGN_RATE
+	// +optional
+	PerSeriesAligner string `json:"perSeriesAligner,omitempty"`
+	// CrossSeriesReducer is the reducer combining the aligned time series, e.g. REDUCE_SUM
+	// +optional
+	CrossSeriesReducer string `json:"crossSeriesReducer,omitempty"`
+	// GroupByFields are the fields preserved when the time series are reduced
+	// +optional
+	GroupByFields []string `json:"groupByFields,omitempty"`
+}
+
+// DynatraceMetric defines the dynatrace metrics query to perform canary analysis
+type DynatraceMetric struct {
+	// Profile is the name of the secret holding the environment URL and the API token of dynatrace
+	// +optional
+	Profile string `json:"profile,omitempty"`
+	// MetricSelector selects the metrics to query, and transforms them
+	MetricSelector string `json:"metricSelector"`
+	// From is the start of the queried time window, in the dynatrace timeframe format. Defaults to now-5m.
+	// +optional
+	From string `json:"from,omitempty"`
+	// Resolution is the resolution of the queried data points, e.g. 1m. Defaults to the one chosen by dynatrace.
+	// +optional
+	Resolution string `json:"resolution,omitempty"`
+}
+
+// SQLMetric defines the read-only SQL query to perform canary analysis
+type SQLMetric struct {
+	// Driver is the name of the database driver, either postgres or mysql
+	Driver string `json:"driver"`
+	// Profile is the name of the secret holding the data source name of the database. Defaults to sql.
+	// +optional
+	Profile string `json:"profile,omitempty"`
+	// Query is the query to run, with the placeholders of the driver for its parameters
+	Query string `json:"query"`
+	// Args are the values bound to the parameters of the query, in order
+	// +optional
+	Args []string `json:"args,omitempty"`
+}
+
+// CloudWatchMetric defines the cloudwatch GetMetricData query to perform canary analysis
+type CloudWatchMetric struct {
+	// Interval is the length of the time window queried, which ends at the time of the measurement.
+	// Defaults to 5m.
+	// +optional
+	Interval DurationString `json:"interval,omitempty"`
+	// MetricDataQueries are the metrics and metric math expressions to query
+	MetricDataQueries []CloudWatchMetricDataQuery `json:"metricDataQueries"`
+	// Endpoint overrides the URL of the CloudWatch API
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// CloudWatchMetricDataQuery is a metric or a metric math expression of a GetMetricData query
+type CloudWatchMetricDataQuery struct {
+	// Id is the short name of the query, which metric math expressions refer to
+	Id string `json:"id"`
+	// Expression is a metric math expression. Either the expression or the metricStat is set.
+	// +optional
+	Expression *string `json:"expression,omitempty"`
+	// Label is a human-readable label for the returned data
+	// +optional
+	Label *string `json:"label,omitempty"`
+	// MetricStat is the metric and statistic to return
+	// +optional
+	MetricStat *CloudWatchMetricStat `json:"metricStat,omitempty"`
+	// Period is the granularity in seconds of the data returned by an expression
+	// +optional
+	Period *int32 `json:"period,omitempty"`
+	// ReturnData indicates whether the data of the query is returned, or only used by expressions.
+	// Defaults to true.
+	// +optional
+	ReturnData *bool `json:"returnData,omitempty"`
+}
+
+// CloudWatchMetricStat defines the metric, the statistic and the period of a query
+type CloudWatchMetricStat struct {
+	// Metric is the metric to return
+	Metric CloudWatchMetricStatMetric `json:"metric"`
+	// Period is the granularity in seconds of the returned data
+	Period int32 `json:"period"`
+	// Stat is the statistic to return, such as Average, Sum or p99
+	Stat string `json:"stat"`
+	// Unit of the returned data
+	// +optional
+	Unit string `json:"unit,omitempty"`
+}
+
+// CloudWatchMetricStatMetric identifies a CloudWatch metric
+type CloudWatchMetricStatMetric struct {
+	// Dimensions of the metric
+	// +optional
+	Dimensions []CloudWatchMetricStatMetricDimension `json:"dimensions,omitempty"`
+	// MetricName is the name of the metric
+	MetricName string `json:"metricName"`
+	// Namespace of the metric
+	// +optional
+	Namespace *string `json:"namespace,omitempty"`
+}
+
+// CloudWatchMetricStatMetricDimension is a name/value pair which is part of the identity of a metric
+type CloudWatchMetricStatMetricDimension struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PluginMetric defines the plugin which measures the metric
+type PluginMetric struct {
+	// Name refers to the name the plugin is registered with on the controller
+	Name string `json:"name"`
+	// Config is an opaque configuration, usually JSON, which is passed as is to the plugin
+	// +optional
+	Config string `json:"config,omitempty"`
+}
+
 // JobMetric defines a job to run which acts as a metric
 type JobMetric struct {
 	Metadata metav1.ObjectMeta `json:"metadata,omitempty"`
 	Spec     batchv1.JobSpec   `json:"spec"`
 }
 
+// ResourceMetric defines a kubernetes object to create which acts as a metric, such as a Workflow
+// or a PipelineRun
+type ResourceMetric struct {
+	// Manifest is the YAML or JSON manifest of the object to create. Its name and namespace are set
+	// by the controller.
+	Manifest string `json:"manifest"`
+	// Resource is the plural name of the resource of the object, e.g. workflows. Defaults to the
+	// lowercase plural of its kind.
+	// +optional
+	Resource string `json:"resource,omitempty"`
+	// JSONPath is a JSON Path into the object to use as the result variable (default: "{$.status}")
+	// +optional
+	JSONPath string `json:"jsonPath,omitempty"`
+	// CompletedCondition is an expression on the result which is true once the object is completed.
+	// When it is not set, the object is completed as soon as the success or the failure condition
+	// of the metric is true.
+	// +optional
+	CompletedCondition string `json:"completedCondition,omitempty"`
+	// TerminatePatch is a JSON merge patch applied to the object to stop it when the measurement
+	// is terminated. The object is deleted when it is not set.
+	// +optional
+	TerminatePatch string `json:"terminatePatch,omitempty"`
+}
+
 // AnalysisRun is an instantiation of an AnalysisTemplate
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
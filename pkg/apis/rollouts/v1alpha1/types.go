@@ -5,6 +5,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -39,7 +40,12 @@ type RolloutSpec struct {
 	// It must match the pod template's labels.
 	Selector *metav1.LabelSelector `json:"selector"`
 	// Template describes the pods that will be created.
+	// +optional
 	Template corev1.PodTemplateSpec `json:"template"`
+	// WorkloadRef references a workload which provides the pod template of the rollout instead of
+	// the inline template
+	// +optional
+	WorkloadRef *WorkloadRef `json:"workloadRef,omitempty"`
 	// Minimum number of seconds for which a newly created pod should be ready
 	// without any of its container crashing, for it to be considered available.
 	// Defaults to 0 (pod will be considered available as soon as it is ready)
@@ -59,8 +65,48 @@ type RolloutSpec struct {
 	// Note that progress will not be estimated during the time a rollout is paused.
 	// Defaults to 600s.
 	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+	// ProgressDeadlineAbort is whether to abort the update when ProgressDeadlineSeconds
+	// is exceeded, which scales down the canary and shifts traffic back to the stable version.
+	// +optional
+	ProgressDeadlineAbort bool `json:"progressDeadlineAbort,omitempty"`
 	// RestartAt indicates when all the pods of a Rollout should be restarted
 	RestartAt *metav1.Time `json:"restartAt,omitempty"`
+	// RollbackWindow defines the window in which rolling back to a previous revision skips all
+	// steps and analysis, and promotes the revision immediately
+	// +optional
+	RollbackWindow *RollbackWindowSpec `json:"rollbackWindow,omitempty"`
+}
+
+// EmptyTemplate returns true if the inline template of the rollout is not set
+func (s *RolloutSpec) EmptyTemplate() bool {
+	if len(s.Template.Labels) > 0 || len(s.Template.Annotations) > 0 {
+		return false
+	}
+	return equality.Semantic.DeepEqual(s.Template.Spec, corev1.PodSpec{})
+}
+
+// WorkloadRef references a workload providing the pod template of a rollout
+type WorkloadRef struct {
+	// APIVersion of the referenced workload: apps/v1 for a Deployment or v1 for a PodTemplate
+	APIVersion string `json:"apiVersion"`
+	// Kind of the referenced workload: Deployment or PodTemplate
+	Kind string `json:"kind"`
+	// Name of the referenced workload in the namespace of the rollout
+	Name string `json:"name"`
+	// ScaleDown scales the referenced Deployment to zero once the rollout is healthy
+	// +optional
+	ScaleDown bool `json:"scaleDown,omitempty"`
+}
+
+// RollbackWindowSpec defines which previous revisions are rolled back to without running steps and analysis.
+// A revision inside either the Revisions or the Duration window is promoted immediately.
+type RollbackWindowSpec struct {
+	// Revisions is the number of revisions older than the stable revision which are inside the window
+	// +optional
+	Revisions int32 `json:"revisions,omitempty"`
+	// Duration is how long after being replaced a revision stays inside the window (e.g. 30m, 1h)
+	// +optional
+	Duration DurationString `json:"duration,omitempty"`
 }
 
 const (
@@ -127,6 +173,24 @@ type BlueGreenStrategy struct {
 	AntiAffinity *AntiAffinity `json:"antiAffinity,omitempty"`
 	// PostPromotionAnalysis configuration to run analysis after a selector switch
 	PostPromotionAnalysis *RolloutAnalysis `json:"postPromotionAnalysis,omitempty"`
+	// TrafficRouting hosts all the supported service meshes supported to enable shifting the traffic
+	// gradually from the active service to the preview service once the rollout is promoted
+	// +optional
+	TrafficRouting *RolloutTrafficRouting `json:"trafficRouting,omitempty"`
+	// TrafficSteps define the order of phases to shift the traffic from the active service to the
+	// preview service before the active service is switched to the new ReplicaSet. Requires TrafficRouting.
+	// +optional
+	TrafficSteps []BlueGreenTrafficStep `json:"trafficSteps,omitempty"`
+}
+
+// BlueGreenTrafficStep defines a step of the traffic shift of a blue-green rollout
+type BlueGreenTrafficStep struct {
+	// SetWeight sets the percentage of the traffic sent to the preview service
+	// +optional
+	SetWeight *int32 `json:"setWeight,omitempty"`
+	// Pause freezes the traffic shift. If no duration is provided, it will freeze until the rollout is promoted
+	// +optional
+	Pause *RolloutPause `json:"pause,omitempty"`
 }
 
 // AntiAffinity defines which inter-pod scheduling rule to use for anti-affinity injection
@@ -196,6 +260,11 @@ type CanaryStrategy struct {
 	// StableMetadata specify labels and annotations which will be attached to the stable pods for
 	// the duration which they act as a canary, and will be removed after
 	StableMetadata *PodTemplateMetadata `json:"stableMetadata,omitempty"`
+	// DynamicStableScale is a traffic routing feature which scales down the stable ReplicaSet in
+	// proportion to the traffic weight it no longer receives, instead of leaving it fully scaled
+	// until the rollout completes. Requires TrafficRouting.
+	// +optional
+	DynamicStableScale bool `json:"dynamicStableScale,omitempty"`
 }
 
 // ALBTrafficRouting configuration for ALB ingress controller to control traffic routing
@@ -221,6 +290,81 @@ type RolloutTrafficRouting struct {
 	ALB *ALBTrafficRouting `json:"alb,omitempty"`
 	// SMI holds TrafficSplit specific configuration to route traffic
 	SMI *SMITrafficRouting `json:"smi,omitempty"`
+	// Ambassador holds specific configuration to use Ambassador to route traffic
+	Ambassador *AmbassadorTrafficRouting `json:"ambassador,omitempty"`
+	// GatewayAPI holds specific configuration to use a Gateway API HTTPRoute to route traffic
+	GatewayAPI *GatewayAPITrafficRouting `json:"gatewayAPI,omitempty"`
+	// Traefik holds specific configuration to use Traefik to route traffic
+	Traefik *TraefikTrafficRouting `json:"traefik,omitempty"`
+	// AppMesh holds specific configuration to use AWS App Mesh to route traffic
+	AppMesh *AppMeshTrafficRouting `json:"appMesh,omitempty"`
+	// Plugin holds specific configuration to use an out-of-process plugin to route traffic
+	Plugin *PluginTrafficRouting `json:"plugin,omitempty"`
+	// ManagedRoutes is the list of routes the controller adds ahead of the user's routes for
+	// setHeaderRoute and setMirrorRoute steps. The order of the list defines the precedence of the routes.
+	// +optional
+	ManagedRoutes []ManagedRoute `json:"managedRoutes,omitempty"`
+}
+
+// ManagedRoute is a route which the controller adds to and removes from the traffic routing resource
+type ManagedRoute struct {
+	// Name of the route
+	Name string `json:"name"`
+}
+
+// AmbassadorTrafficRouting defines the configuration required to use Ambassador as traffic router
+type AmbassadorTrafficRouting struct {
+	// Mappings refer to the name of the Ambassador Mappings used to route traffic to the stable
+	// service. A canary Mapping with the weight of the canary is created next to each of them.
+	Mappings []string `json:"mappings"`
+}
+
+// GatewayAPITrafficRouting defines the configuration required to use a Gateway API HTTPRoute as traffic router
+type GatewayAPITrafficRouting struct {
+	// HTTPRoute refers to the name of the HTTPRoute whose backendRefs weights are adjusted
+	HTTPRoute string `json:"httpRoute"`
+	// Rules refer to the indexes of the HTTPRoute rules whose backendRefs weights are adjusted.
+	// Defaults to all the rules referencing the stable service.
+	// +optional
+	Rules []int32 `json:"rules,omitempty"`
+}
+
+// TraefikTrafficRouting defines the configuration required to use Traefik as traffic router
+type TraefikTrafficRouting struct {
+	// WeightedTraefikServiceName refers to the name of the weighted TraefikService which splits the
+	// traffic between the stable and the canary service
+	WeightedTraefikServiceName string `json:"weightedTraefikServiceName"`
+}
+
+// AppMeshTrafficRouting defines the configuration required to use AWS App Mesh as traffic router
+type AppMeshTrafficRouting struct {
+	// VirtualRouter refers to the VirtualRouter whose routes split the traffic between the VirtualNodes
+	VirtualRouter AppMeshVirtualRouter `json:"virtualRouter"`
+	// StableVirtualNode refers to the name of the VirtualNode of the stable pods
+	StableVirtualNode string `json:"stableVirtualNode"`
+	// CanaryVirtualNode is the name of the VirtualNode of the canary pods, which is created from the
+	// stable VirtualNode. Defaults to the name of the stable VirtualNode with a -canary suffix.
+	// +optional
+	CanaryVirtualNode string `json:"canaryVirtualNode,omitempty"`
+}
+
+// AppMeshVirtualRouter holds the name and the routes of an App Mesh VirtualRouter
+type AppMeshVirtualRouter struct {
+	// Name refers to the name of the VirtualRouter
+	Name string `json:"name"`
+	// Routes refer to the names of the routes whose weighted targets are adjusted. Defaults to all
+	// the routes targeting the stable VirtualNode.
+	// +optional
+	Routes []string `json:"routes,omitempty"`
+}
+
+// PluginTrafficRouting defines the configuration required to use an out-of-process plugin as traffic router
+type PluginTrafficRouting struct {
+	// Name refers to the name the plugin is registered with on the controller
+	Name string `json:"name"`
+	// Config is an opaque configuration, usually JSON, which is passed as is to the plugin
+	// +optional
+	Config string `json:"config,omitempty"`
 }
 
 // SMITrafficRouting configuration for TrafficSplit Custom Resource to control traffic routing
@@ -342,6 +486,75 @@ type CanaryStep struct {
 	// SetCanaryScale defines how to scale the newRS without changing traffic weight
 	// +optional
 	SetCanaryScale *SetCanaryScale `json:"setCanaryScale,omitempty"`
+	// SetHeaderRoute defines a managed route which sends requests with matching headers or cookies to the canary
+	// +optional
+	SetHeaderRoute *SetHeaderRoute `json:"setHeaderRoute,omitempty"`
+	// SetMirrorRoute defines a managed route which mirrors matching requests to the canary
+	// +optional
+	SetMirrorRoute *SetMirrorRoute `json:"setMirrorRoute,omitempty"`
+}
+
+// SetHeaderRoute defines a managed route which sends requests with matching headers or cookies to the canary
+type SetHeaderRoute struct {
+	// Name of the route, which needs to be listed in the managedRoutes of the traffic routing
+	Name string `json:"name"`
+	// Match is the list of conditions of the route. Requests matching any of the conditions are sent
+	// to the canary. The route is removed when no conditions are given.
+	// +optional
+	Match []HeaderRoutingMatch `json:"match,omitempty"`
+}
+
+// HeaderRoutingMatch is a condition on either a header or a cookie of a request
+type HeaderRoutingMatch struct {
+	// HeaderName is the name of the header to match
+	// +optional
+	HeaderName string `json:"headerName,omitempty"`
+	// CookieName is the name of the cookie to match
+	// +optional
+	CookieName string `json:"cookieName,omitempty"`
+	// Value is the value the header or cookie needs to match
+	Value StringMatch `json:"value"`
+}
+
+// SetMirrorRoute defines a managed route which mirrors matching requests to the canary. The requests
+// keep being served by the destinations of the route while the responses of the canary are discarded.
+type SetMirrorRoute struct {
+	// Name of the route, which needs to be listed in the managedRoutes of the traffic routing
+	Name string `json:"name"`
+	// Match is the list of conditions of the route. Requests matching any of the conditions are
+	// mirrored to the canary. The route is removed when no conditions are given.
+	// +optional
+	Match []RouteMatch `json:"match,omitempty"`
+	// Percentage of the matching requests which are mirrored. Defaults to 100.
+	// +optional
+	Percentage *int32 `json:"percentage,omitempty"`
+}
+
+// RouteMatch is a condition on the method, path and headers of a request. All the fields which are
+// set need to match.
+type RouteMatch struct {
+	// Method is the HTTP method to match
+	// +optional
+	Method *StringMatch `json:"method,omitempty"`
+	// Path is the path to match
+	// +optional
+	Path *StringMatch `json:"path,omitempty"`
+	// Headers is a map of header names to the values they need to match
+	// +optional
+	Headers map[string]StringMatch `json:"headers,omitempty"`
+}
+
+// StringMatch matches a string exactly, by prefix or by regular expression. Only one of the fields can be set.
+type StringMatch struct {
+	// Exact matches the exact string
+	// +optional
+	Exact string `json:"exact,omitempty"`
+	// Prefix matches strings starting with the prefix
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Regex matches strings with the RE2 regular expression
+	// +optional
+	Regex string `json:"regex,omitempty"`
 }
 
 // SetCanaryScale defines how to scale the newRS without changing traffic weight
@@ -493,6 +706,8 @@ const (
 	PauseReasonCanaryPauseStep PauseReason = "CanaryPauseStep"
 	// PauseReasonBlueGreenPause pause rollout before promoting rollout
 	PauseReasonBlueGreenPause PauseReason = "BlueGreenPause"
+	// PauseReasonBlueGreenTrafficStep pause rollout for blue-green traffic pause step
+	PauseReasonBlueGreenTrafficStep PauseReason = "BlueGreenTrafficStep"
 )
 
 // PauseCondition the reason for a pause and when it started
@@ -589,6 +804,10 @@ type BlueGreenStatus struct {
 	// ScaleUpPreviewCheckPoint indicates that the Replicaset receiving traffic from the preview service is ready to be scaled up after the rollout is unpaused
 	// +optional
 	ScaleUpPreviewCheckPoint bool `json:"scaleUpPreviewCheckPoint,omitempty"`
+	// CurrentTrafficStepIndex defines the current traffic step of the rollout. It is set once the rollout
+	// is promoted and the traffic starts shifting from the active service to the preview service
+	// +optional
+	CurrentTrafficStepIndex *int32 `json:"currentTrafficStepIndex,omitempty"`
 	// PrePromotionAnalysisRun is the current analysis run running before the active service promotion
 	// TODO(Deprecated): Remove in v0.10
 	PrePromotionAnalysisRun string `json:"prePromotionAnalysisRun,omitempty"`
@@ -615,6 +834,27 @@ type CanaryStatus struct {
 	CurrentBackgroundAnalysisRunStatus *RolloutAnalysisRunStatus `json:"currentBackgroundAnalysisRunStatus,omitempty"`
 	// CurrentExperiment indicates the running experiment
 	CurrentExperiment string `json:"currentExperiment,omitempty"`
+	// Weights records the traffic weights last set by the traffic routing
+	// +optional
+	Weights *TrafficWeights `json:"weights,omitempty"`
+}
+
+// TrafficWeights describes the traffic weights of the canary and the stable
+type TrafficWeights struct {
+	// Canary is the weight of the traffic sent to the canary
+	Canary WeightDestination `json:"canary"`
+	// Stable is the weight of the traffic sent to the stable
+	Stable WeightDestination `json:"stable"`
+}
+
+// WeightDestination is the weight of the traffic sent to a service
+type WeightDestination struct {
+	// Weight is the percentage of the traffic sent to the destination
+	Weight int32 `json:"weight"`
+	// ServiceName is the name of the service of the destination
+	ServiceName string `json:"serviceName,omitempty"`
+	// PodTemplateHash is the pod template hash of the pods selected by the service
+	PodTemplateHash string `json:"podTemplateHash,omitempty"`
 }
 
 type RolloutAnalysisRunStatus struct {
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func TestRolloutPauseDuration(t *testing.T) {
@@ -22,3 +23,18 @@ func TestRolloutPauseDuration(t *testing.T) {
 	rp.Duration = DurationFromString("1z")
 	assert.Equal(t, int32(-1), rp.DurationSeconds())
 }
+
+func TestRolloutSpecEmptyTemplate(t *testing.T) {
+	spec := RolloutSpec{
+		WorkloadRef: &WorkloadRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "guestbook"},
+	}
+	assert.True(t, spec.EmptyTemplate())
+
+	spec.Template.Labels = map[string]string{"app": "guestbook"}
+	assert.False(t, spec.EmptyTemplate())
+
+	spec.Template = corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "guestbook", Image: "guestbook:v1"}}},
+	}
+	assert.False(t, spec.EmptyTemplate())
+}
@@ -42,6 +42,27 @@ func (in *ALBTrafficRouting) DeepCopy() *ALBTrafficRouting {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AmbassadorTrafficRouting) DeepCopyInto(out *AmbassadorTrafficRouting) {
+	*out = *in
+	if in.Mappings != nil {
+		in, out := &in.Mappings, &out.Mappings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AmbassadorTrafficRouting.
+func (in *AmbassadorTrafficRouting) DeepCopy() *AmbassadorTrafficRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(AmbassadorTrafficRouting)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AnalysisRun) DeepCopyInto(out *AnalysisRun) {
 	*out = *in
@@ -297,6 +318,44 @@ func (in *AntiAffinity) DeepCopy() *AntiAffinity {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppMeshTrafficRouting) DeepCopyInto(out *AppMeshTrafficRouting) {
+	*out = *in
+	in.VirtualRouter.DeepCopyInto(&out.VirtualRouter)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppMeshTrafficRouting.
+func (in *AppMeshTrafficRouting) DeepCopy() *AppMeshTrafficRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(AppMeshTrafficRouting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppMeshVirtualRouter) DeepCopyInto(out *AppMeshVirtualRouter) {
+	*out = *in
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppMeshVirtualRouter.
+func (in *AppMeshVirtualRouter) DeepCopy() *AppMeshVirtualRouter {
+	if in == nil {
+		return nil
+	}
+	out := new(AppMeshVirtualRouter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Argument) DeepCopyInto(out *Argument) {
 	*out = *in
@@ -356,6 +415,11 @@ func (in *BlueGreenStatus) DeepCopyInto(out *BlueGreenStatus) {
 		in, out := &in.ScaleDownDelayStartTime, &out.ScaleDownDelayStartTime
 		*out = (*in).DeepCopy()
 	}
+	if in.CurrentTrafficStepIndex != nil {
+		in, out := &in.CurrentTrafficStepIndex, &out.CurrentTrafficStepIndex
+		*out = new(int32)
+		**out = **in
+	}
 	if in.PrePromotionAnalysisRunStatus != nil {
 		in, out := &in.PrePromotionAnalysisRunStatus, &out.PrePromotionAnalysisRunStatus
 		*out = new(RolloutAnalysisRunStatus)
@@ -422,6 +486,18 @@ func (in *BlueGreenStrategy) DeepCopyInto(out *BlueGreenStrategy) {
 		*out = new(RolloutAnalysis)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TrafficRouting != nil {
+		in, out := &in.TrafficRouting, &out.TrafficRouting
+		*out = new(RolloutTrafficRouting)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TrafficSteps != nil {
+		in, out := &in.TrafficSteps, &out.TrafficSteps
+		*out = make([]BlueGreenTrafficStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -435,6 +511,32 @@ func (in *BlueGreenStrategy) DeepCopy() *BlueGreenStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreenTrafficStep) DeepCopyInto(out *BlueGreenTrafficStep) {
+	*out = *in
+	if in.SetWeight != nil {
+		in, out := &in.SetWeight, &out.SetWeight
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Pause != nil {
+		in, out := &in.Pause, &out.Pause
+		*out = new(RolloutPause)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreenTrafficStep.
+func (in *BlueGreenTrafficStep) DeepCopy() *BlueGreenTrafficStep {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreenTrafficStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanaryStatus) DeepCopyInto(out *CanaryStatus) {
 	*out = *in
@@ -448,6 +550,11 @@ func (in *CanaryStatus) DeepCopyInto(out *CanaryStatus) {
 		*out = new(RolloutAnalysisRunStatus)
 		**out = **in
 	}
+	if in.Weights != nil {
+		in, out := &in.Weights, &out.Weights
+		*out = new(TrafficWeights)
+		**out = **in
+	}
 	return
 }
 
@@ -489,6 +596,16 @@ func (in *CanaryStep) DeepCopyInto(out *CanaryStep) {
 		*out = new(SetCanaryScale)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SetHeaderRoute != nil {
+		in, out := &in.SetHeaderRoute, &out.SetHeaderRoute
+		*out = new(SetHeaderRoute)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SetMirrorRoute != nil {
+		in, out := &in.SetMirrorRoute, &out.SetMirrorRoute
+		*out = new(SetMirrorRoute)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -560,6 +677,129 @@ func (in *CanaryStrategy) DeepCopy() *CanaryStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudWatchMetric) DeepCopyInto(out *CloudWatchMetric) {
+	*out = *in
+	if in.MetricDataQueries != nil {
+		in, out := &in.MetricDataQueries, &out.MetricDataQueries
+		*out = make([]CloudWatchMetricDataQuery, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudWatchMetric.
+func (in *CloudWatchMetric) DeepCopy() *CloudWatchMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudWatchMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudWatchMetricDataQuery) DeepCopyInto(out *CloudWatchMetricDataQuery) {
+	*out = *in
+	if in.Expression != nil {
+		in, out := &in.Expression, &out.Expression
+		*out = new(string)
+		**out = **in
+	}
+	if in.Label != nil {
+		in, out := &in.Label, &out.Label
+		*out = new(string)
+		**out = **in
+	}
+	if in.MetricStat != nil {
+		in, out := &in.MetricStat, &out.MetricStat
+		*out = new(CloudWatchMetricStat)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Period != nil {
+		in, out := &in.Period, &out.Period
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReturnData != nil {
+		in, out := &in.ReturnData, &out.ReturnData
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudWatchMetricDataQuery.
+func (in *CloudWatchMetricDataQuery) DeepCopy() *CloudWatchMetricDataQuery {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudWatchMetricDataQuery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudWatchMetricStat) DeepCopyInto(out *CloudWatchMetricStat) {
+	*out = *in
+	in.Metric.DeepCopyInto(&out.Metric)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudWatchMetricStat.
+func (in *CloudWatchMetricStat) DeepCopy() *CloudWatchMetricStat {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudWatchMetricStat)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudWatchMetricStatMetric) DeepCopyInto(out *CloudWatchMetricStatMetric) {
+	*out = *in
+	if in.Dimensions != nil {
+		in, out := &in.Dimensions, &out.Dimensions
+		*out = make([]CloudWatchMetricStatMetricDimension, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespace != nil {
+		in, out := &in.Namespace, &out.Namespace
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudWatchMetricStatMetric.
+func (in *CloudWatchMetricStatMetric) DeepCopy() *CloudWatchMetricStatMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudWatchMetricStatMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudWatchMetricStatMetricDimension) DeepCopyInto(out *CloudWatchMetricStatMetricDimension) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudWatchMetricStatMetricDimension.
+func (in *CloudWatchMetricStatMetricDimension) DeepCopy() *CloudWatchMetricStatMetricDimension {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudWatchMetricStatMetricDimension)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterAnalysisTemplate) DeepCopyInto(out *ClusterAnalysisTemplate) {
 	*out = *in
@@ -636,6 +876,38 @@ func (in *DatadogMetric) DeepCopy() *DatadogMetric {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DynatraceMetric) DeepCopyInto(out *DynatraceMetric) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynatraceMetric.
+func (in *DynatraceMetric) DeepCopy() *DynatraceMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(DynatraceMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchMetric) DeepCopyInto(out *ElasticsearchMetric) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchMetric.
+func (in *ElasticsearchMetric) DeepCopy() *ElasticsearchMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Experiment) DeepCopyInto(out *Experiment) {
 	*out = *in
@@ -844,6 +1116,76 @@ func (in *FieldRef) DeepCopy() *FieldRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayAPITrafficRouting) DeepCopyInto(out *GatewayAPITrafficRouting) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayAPITrafficRouting.
+func (in *GatewayAPITrafficRouting) DeepCopy() *GatewayAPITrafficRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayAPITrafficRouting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GraphiteMetric) DeepCopyInto(out *GraphiteMetric) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GraphiteMetric.
+func (in *GraphiteMetric) DeepCopy() *GraphiteMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(GraphiteMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeaderRoutingMatch) DeepCopyInto(out *HeaderRoutingMatch) {
+	*out = *in
+	out.Value = in.Value
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeaderRoutingMatch.
+func (in *HeaderRoutingMatch) DeepCopy() *HeaderRoutingMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(HeaderRoutingMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InfluxdbMetric) DeepCopyInto(out *InfluxdbMetric) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InfluxdbMetric.
+func (in *InfluxdbMetric) DeepCopy() *InfluxdbMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(InfluxdbMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IstioTrafficRouting) DeepCopyInto(out *IstioTrafficRouting) {
 	*out = *in
@@ -956,6 +1298,38 @@ func (in *KayentaThreshold) DeepCopy() *KayentaThreshold {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LokiMetric) DeepCopyInto(out *LokiMetric) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LokiMetric.
+func (in *LokiMetric) DeepCopy() *LokiMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(LokiMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedRoute) DeepCopyInto(out *ManagedRoute) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedRoute.
+func (in *ManagedRoute) DeepCopy() *ManagedRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Measurement) DeepCopyInto(out *Measurement) {
 	*out = *in
@@ -1066,6 +1440,56 @@ func (in *MetricProvider) DeepCopyInto(out *MetricProvider) {
 		*out = new(JobMetric)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Plugin != nil {
+		in, out := &in.Plugin, &out.Plugin
+		*out = new(PluginMetric)
+		**out = **in
+	}
+	if in.CloudWatch != nil {
+		in, out := &in.CloudWatch, &out.CloudWatch
+		*out = new(CloudWatchMetric)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Graphite != nil {
+		in, out := &in.Graphite, &out.Graphite
+		*out = new(GraphiteMetric)
+		**out = **in
+	}
+	if in.Influxdb != nil {
+		in, out := &in.Influxdb, &out.Influxdb
+		*out = new(InfluxdbMetric)
+		**out = **in
+	}
+	if in.Elasticsearch != nil {
+		in, out := &in.Elasticsearch, &out.Elasticsearch
+		*out = new(ElasticsearchMetric)
+		**out = **in
+	}
+	if in.Loki != nil {
+		in, out := &in.Loki, &out.Loki
+		*out = new(LokiMetric)
+		**out = **in
+	}
+	if in.Stackdriver != nil {
+		in, out := &in.Stackdriver, &out.Stackdriver
+		*out = new(StackdriverMetric)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Dynatrace != nil {
+		in, out := &in.Dynatrace, &out.Dynatrace
+		*out = new(DynatraceMetric)
+		**out = **in
+	}
+	if in.SQL != nil {
+		in, out := &in.SQL, &out.SQL
+		*out = new(SQLMetric)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Resource != nil {
+		in, out := &in.Resource, &out.Resource
+		*out = new(ResourceMetric)
+		**out = **in
+	}
 	return
 }
 
@@ -1158,6 +1582,38 @@ func (in *PauseCondition) DeepCopy() *PauseCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginMetric) DeepCopyInto(out *PluginMetric) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginMetric.
+func (in *PluginMetric) DeepCopy() *PluginMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginTrafficRouting) DeepCopyInto(out *PluginTrafficRouting) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginTrafficRouting.
+func (in *PluginTrafficRouting) DeepCopy() *PluginTrafficRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginTrafficRouting)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodTemplateMetadata) DeepCopyInto(out *PodTemplateMetadata) {
 	*out = *in
@@ -1236,6 +1692,38 @@ func (in *RequiredDuringSchedulingIgnoredDuringExecution) DeepCopy() *RequiredDu
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceMetric) DeepCopyInto(out *ResourceMetric) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceMetric.
+func (in *ResourceMetric) DeepCopy() *ResourceMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollbackWindowSpec) DeepCopyInto(out *RollbackWindowSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollbackWindowSpec.
+func (in *RollbackWindowSpec) DeepCopy() *RollbackWindowSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RollbackWindowSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Rollout) DeepCopyInto(out *Rollout) {
 	*out = *in
@@ -1512,6 +2000,11 @@ func (in *RolloutSpec) DeepCopyInto(out *RolloutSpec) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.Template.DeepCopyInto(&out.Template)
+	if in.WorkloadRef != nil {
+		in, out := &in.WorkloadRef, &out.WorkloadRef
+		*out = new(WorkloadRef)
+		**out = **in
+	}
 	in.Strategy.DeepCopyInto(&out.Strategy)
 	if in.RevisionHistoryLimit != nil {
 		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
@@ -1527,6 +2020,11 @@ func (in *RolloutSpec) DeepCopyInto(out *RolloutSpec) {
 		in, out := &in.RestartAt, &out.RestartAt
 		*out = (*in).DeepCopy()
 	}
+	if in.RollbackWindow != nil {
+		in, out := &in.RollbackWindow, &out.RollbackWindow
+		*out = new(RollbackWindowSpec)
+		**out = **in
+	}
 	return
 }
 
@@ -1639,6 +2137,36 @@ func (in *RolloutTrafficRouting) DeepCopyInto(out *RolloutTrafficRouting) {
 		*out = new(SMITrafficRouting)
 		**out = **in
 	}
+	if in.Ambassador != nil {
+		in, out := &in.Ambassador, &out.Ambassador
+		*out = new(AmbassadorTrafficRouting)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GatewayAPI != nil {
+		in, out := &in.GatewayAPI, &out.GatewayAPI
+		*out = new(GatewayAPITrafficRouting)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Traefik != nil {
+		in, out := &in.Traefik, &out.Traefik
+		*out = new(TraefikTrafficRouting)
+		**out = **in
+	}
+	if in.AppMesh != nil {
+		in, out := &in.AppMesh, &out.AppMesh
+		*out = new(AppMeshTrafficRouting)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Plugin != nil {
+		in, out := &in.Plugin, &out.Plugin
+		*out = new(PluginTrafficRouting)
+		**out = **in
+	}
+	if in.ManagedRoutes != nil {
+		in, out := &in.ManagedRoutes, &out.ManagedRoutes
+		*out = make([]ManagedRoute, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -1652,6 +2180,39 @@ func (in *RolloutTrafficRouting) DeepCopy() *RolloutTrafficRouting {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteMatch) DeepCopyInto(out *RouteMatch) {
+	*out = *in
+	if in.Method != nil {
+		in, out := &in.Method, &out.Method
+		*out = new(StringMatch)
+		**out = **in
+	}
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = new(StringMatch)
+		**out = **in
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]StringMatch, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteMatch.
+func (in *RouteMatch) DeepCopy() *RouteMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SMITrafficRouting) DeepCopyInto(out *SMITrafficRouting) {
 	*out = *in
@@ -1668,6 +2229,27 @@ func (in *SMITrafficRouting) DeepCopy() *SMITrafficRouting {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SQLMetric) DeepCopyInto(out *SQLMetric) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SQLMetric.
+func (in *SQLMetric) DeepCopy() *SQLMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(SQLMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScopeDetail) DeepCopyInto(out *ScopeDetail) {
 	*out = *in
@@ -1726,6 +2308,113 @@ func (in *SetCanaryScale) DeepCopy() *SetCanaryScale {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetHeaderRoute) DeepCopyInto(out *SetHeaderRoute) {
+	*out = *in
+	if in.Match != nil {
+		in, out := &in.Match, &out.Match
+		*out = make([]HeaderRoutingMatch, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetHeaderRoute.
+func (in *SetHeaderRoute) DeepCopy() *SetHeaderRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(SetHeaderRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetMirrorRoute) DeepCopyInto(out *SetMirrorRoute) {
+	*out = *in
+	if in.Match != nil {
+		in, out := &in.Match, &out.Match
+		*out = make([]RouteMatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Percentage != nil {
+		in, out := &in.Percentage, &out.Percentage
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetMirrorRoute.
+func (in *SetMirrorRoute) DeepCopy() *SetMirrorRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(SetMirrorRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StackdriverAggregation) DeepCopyInto(out *StackdriverAggregation) {
+	*out = *in
+	if in.GroupByFields != nil {
+		in, out := &in.GroupByFields, &out.GroupByFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StackdriverAggregation.
+func (in *StackdriverAggregation) DeepCopy() *StackdriverAggregation {
+	if in == nil {
+		return nil
+	}
+	out := new(StackdriverAggregation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StackdriverMetric) DeepCopyInto(out *StackdriverMetric) {
+	*out = *in
+	if in.Aggregation != nil {
+		in, out := &in.Aggregation, &out.Aggregation
+		*out = new(StackdriverAggregation)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StackdriverMetric.
+func (in *StackdriverMetric) DeepCopy() *StackdriverMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(StackdriverMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringMatch) DeepCopyInto(out *StringMatch) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringMatch.
+func (in *StringMatch) DeepCopy() *StringMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(StringMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TemplateSpec) DeepCopyInto(out *TemplateSpec) {
 	*out = *in
@@ -1778,6 +2467,40 @@ func (in *TemplateStatus) DeepCopy() *TemplateStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TraefikTrafficRouting) DeepCopyInto(out *TraefikTrafficRouting) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TraefikTrafficRouting.
+func (in *TraefikTrafficRouting) DeepCopy() *TraefikTrafficRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(TraefikTrafficRouting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficWeights) DeepCopyInto(out *TrafficWeights) {
+	*out = *in
+	out.Canary = in.Canary
+	out.Stable = in.Stable
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficWeights.
+func (in *TrafficWeights) DeepCopy() *TrafficWeights {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficWeights)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ValueFrom) DeepCopyInto(out *ValueFrom) {
 	*out = *in
@@ -1856,3 +2579,35 @@ func (in *WebMetricHeader) DeepCopy() *WebMetricHeader {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightDestination) DeepCopyInto(out *WeightDestination) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightDestination.
+func (in *WeightDestination) DeepCopy() *WeightDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadRef) DeepCopyInto(out *WorkloadRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadRef.
+func (in *WorkloadRef) DeepCopy() *WorkloadRef {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadRef)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -3,6 +3,7 @@ package validation
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strconv"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
@@ -29,12 +30,34 @@ const (
 	InvalidSetWeightMessage = "SetWeight needs to be between 0 and 100"
 	// InvalidSetCanaryScaleTrafficPolicy indicates that TrafficRouting, required for SetCanaryScale, is missing
 	InvalidSetCanaryScaleTrafficPolicy = "SetCanaryScale requires TrafficRouting to be set"
+	// InvalidDynamicStableScaleTrafficPolicy indicates that TrafficRouting, required for DynamicStableScale, is missing
+	InvalidDynamicStableScaleTrafficPolicy = "DynamicStableScale requires TrafficRouting to be set"
+	// InvalidSetHeaderRouteTrafficPolicy indicates that Istio TrafficRouting, required for SetHeaderRoute, is missing
+	InvalidSetHeaderRouteTrafficPolicy = "SetHeaderRoute requires TrafficRouting with Istio to be set"
+	// InvalidSetHeaderRouteNameMessage indicates that the route of a SetHeaderRoute step is not a managed route
+	InvalidSetHeaderRouteNameMessage = "SetHeaderRoute name must be listed in trafficRouting.managedRoutes"
+	// InvalidSetMirrorRouteTrafficPolicy indicates that Istio TrafficRouting, required for SetMirrorRoute, is missing
+	InvalidSetMirrorRouteTrafficPolicy = "SetMirrorRoute requires TrafficRouting with Istio to be set"
+	// InvalidSetMirrorRouteNameMessage indicates that the route of a SetMirrorRoute step is not a managed route
+	InvalidSetMirrorRouteNameMessage = "SetMirrorRoute name must be listed in trafficRouting.managedRoutes"
+	// InvalidSetMirrorRoutePercentageMessage indicates the percentage of mirrored requests needs to be between 0 and 100
+	InvalidSetMirrorRoutePercentageMessage = "SetMirrorRoute percentage needs to be between 0 and 100"
+	// InvalidRouteMatchMessage indicates that a route match needs at least one condition
+	InvalidRouteMatchMessage = "Match must have at least one of the following set: method, path or headers"
+	// InvalidHeaderRoutingMatchMessage indicates that a match must be either on a header or on a cookie
+	InvalidHeaderRoutingMatchMessage = "Match must have exactly one of the following set: headerName or cookieName"
+	// InvalidStringMatchMessage indicates that a string match must use exactly one match type
+	InvalidStringMatchMessage = "Value must have exactly one of the following set: exact, prefix or regex"
+	// InvalidManagedRouteMessage indicates that a managed route is listed twice or conflicts with a route of the user
+	InvalidManagedRouteMessage = "Managed routes must be unique and must not be listed in the routes of the VirtualService"
 	// InvalidDurationMessage indicates the Duration value needs to be greater than 0
 	InvalidDurationMessage = "Duration needs to be greater than 0"
+	// InvalidRollbackWindowMessage indicates that a rollback window must have either revisions or duration set
+	InvalidRollbackWindowMessage = "RollbackWindow must have one of the following set: revisions or duration"
 	// InvalidMaxSurgeMaxUnavailable indicates both maxSurge and MaxUnavailable can not be set to zero
 	InvalidMaxSurgeMaxUnavailable = "MaxSurge and MaxUnavailable both can not be zero"
 	// InvalidStepMessage indicates that a step must have either setWeight or pause set
-	InvalidStepMessage = "Step must have one of the following set: experiment, setWeight, setCanaryScale or pause"
+	InvalidStepMessage = "Step must have one of the following set: experiment, setWeight, setCanaryScale, setHeaderRoute, setMirrorRoute or pause"
 	// InvalidStrategyMessage indicates that multiple strategies can not be listed
 	InvalidStrategyMessage = "Multiple Strategies can not be listed"
 	// DuplicatedServicesBlueGreenMessage the message to indicate that the rollout uses the same service for the active and preview services
@@ -49,21 +72,63 @@ const (
 	ScaleDownLimitLargerThanRevisionLimit = "This rollout's revision history limit can not be smaller than the rollout's scale down limit"
 	// InvalidTrafficRoutingMessage indicates that both canary and stable service must be set to use Traffic Routing
 	InvalidTrafficRoutingMessage = "Canary service and Stable service must to be set to use Traffic Routing"
+	// InvalidBlueGreenTrafficRoutingMessage indicates that the preview service must be set to use Traffic Routing with the blue-green strategy
+	InvalidBlueGreenTrafficRoutingMessage = "Preview service must be set to use Traffic Routing"
+	// InvalidBlueGreenTrafficStepsMessage indicates that TrafficRouting, required for TrafficSteps, is missing
+	InvalidBlueGreenTrafficStepsMessage = "TrafficSteps requires TrafficRouting to be set"
+	// InvalidBlueGreenTrafficStepMessage indicates that a traffic step must have exactly one action
+	InvalidBlueGreenTrafficStepMessage = "Traffic step must have exactly one of the following set: setWeight or pause"
+	// InvalidAmbassadorMappingsMessage indicates that rollout does not have a mapping specified for the Ambassador Traffic Routing
+	InvalidAmbassadorMappingsMessage = "Ambassador must have at least 1 mapping specified"
+	// InvalidGatewayAPIHTTPRouteMessage indicates that rollout does not have an HTTPRoute specified for the Gateway API Traffic Routing
+	InvalidGatewayAPIHTTPRouteMessage = "Gateway API must have an HTTPRoute specified"
+	// InvalidGatewayAPIRuleMessage indicates that an HTTPRoute rule index of the Gateway API Traffic Routing is negative
+	InvalidGatewayAPIRuleMessage = "Gateway API HTTPRoute rule index must be greater than or equal to 0"
+	// InvalidTraefikServiceMessage indicates that rollout does not have a TraefikService specified for the Traefik Traffic Routing
+	InvalidTraefikServiceMessage = "Traefik must have a weighted TraefikService specified"
+	// InvalidAppMeshVirtualRouterMessage indicates that rollout does not have a VirtualRouter specified for the App Mesh Traffic Routing
+	InvalidAppMeshVirtualRouterMessage = "AppMesh must have a VirtualRouter specified"
+	// InvalidAppMeshStableVirtualNodeMessage indicates that rollout does not have a stable VirtualNode specified for the App Mesh Traffic Routing
+	InvalidAppMeshStableVirtualNodeMessage = "AppMesh must have a stable VirtualNode specified"
+	// InvalidAppMeshCanaryVirtualNodeMessage indicates that the canary and stable VirtualNodes of the App Mesh Traffic Routing are the same
+	InvalidAppMeshCanaryVirtualNodeMessage = "AppMesh canary VirtualNode must be different from the stable VirtualNode"
+	// InvalidPluginNameMessage indicates that rollout does not have a plugin name specified for the plugin Traffic Routing
+	InvalidPluginNameMessage = "Plugin must have a name specified"
 	// InvalidIstioRoutesMessage indicates that rollout does not have a route specified for the istio Traffic Routing
 	InvalidIstioRoutesMessage = "Istio virtual service must have at least 1 route specified"
+	// InvalidWorkloadRefMessage indicates that the workload reference is not a Deployment or a PodTemplate
+	InvalidWorkloadRefMessage = "WorkloadRef must reference a Deployment (apps/v1) or a PodTemplate (v1)"
+	// InvalidWorkloadRefTemplateMessage indicates that the inline template and the workload reference can not be both set
+	InvalidWorkloadRefTemplateMessage = "Template must be empty when workloadRef is set"
+	// InvalidWorkloadRefScaleDownMessage indicates that only referenced Deployments can be scaled down
+	InvalidWorkloadRefScaleDownMessage = "ScaleDown is only supported when workloadRef references a Deployment"
 	// InvalidAnalysisArgsMessage indicates that arguments provided in analysis steps are refrencing un-supported metadatafield.
 	//supported fields are "metadata.annotations", "metadata.labels", "metadata.name", "metadata.namespace", "metadata.uid"
 	InvalidAnalysisArgsMessage = "Analyses arguments must refer to valid object metadata supported by downwardAPI"
 )
 
+// ValidateRollout validates the rollout as it is persisted: the template of a rollout referencing a
+// workload must be empty, and is only validated once resolved from the workload by the controller.
 func ValidateRollout(rollout *v1alpha1.Rollout) field.ErrorList {
 	allErrs := field.ErrorList{}
 	allErrs = append(allErrs, ValidateRolloutSpec(rollout, field.NewPath("spec"))...)
 	return allErrs
 }
 
+// ValidateResolvedRollout validates a rollout whose template was resolved from its workload
+// reference, if any
+func ValidateResolvedRollout(rollout *v1alpha1.Rollout) field.ErrorList {
+	allErrs := field.ErrorList{}
+	allErrs = append(allErrs, validateRolloutSpec(rollout, field.NewPath("spec"), true)...)
+	return allErrs
+}
+
 // ValidateRolloutSpec checks for a valid spec otherwise returns a list of errors.
 func ValidateRolloutSpec(rollout *v1alpha1.Rollout, fldPath *field.Path) field.ErrorList {
+	return validateRolloutSpec(rollout, fldPath, false)
+}
+
+func validateRolloutSpec(rollout *v1alpha1.Rollout, fldPath *field.Path, templateResolved bool) field.ErrorList {
 	spec := rollout.Spec
 	allErrs := field.ErrorList{}
 
@@ -80,10 +145,18 @@ func ValidateRolloutSpec(rollout *v1alpha1.Rollout, fldPath *field.Path) field.E
 		}
 	}
 
+	if spec.WorkloadRef != nil {
+		allErrs = append(allErrs, ValidateWorkloadRef(spec.WorkloadRef, fldPath.Child("workloadRef"))...)
+		if !templateResolved && !spec.EmptyTemplate() {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("template"), "<template>", InvalidWorkloadRefTemplateMessage))
+		}
+	}
+
 	selector, err := metav1.LabelSelectorAsSelector(spec.Selector)
 	if err != nil {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("selector"), spec.Selector, "invalid label selector"))
-	} else {
+	} else if spec.WorkloadRef == nil || templateResolved {
+		// The template of a workload reference can only be validated once the controller resolved it
 		// The upstream K8s validation we are using expects the default values of a PodSpec to be set otherwise throwing a validation error.
 		// However, the Rollout does not need to have them set since the ReplicaSet it creates will have the default values set.
 		// As a result, the controller sets the default values before validation to prevent the validation errors due to the lack of these default fields. See #576 for more info.
@@ -121,11 +194,50 @@ func ValidateRolloutSpec(rollout *v1alpha1.Rollout, fldPath *field.Path) field.E
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("progressDeadlineSeconds"), progressDeadlineSeconds, "must be greater than minReadySeconds"))
 	}
 
+	if spec.RollbackWindow != nil {
+		allErrs = append(allErrs, ValidateRollbackWindow(spec.RollbackWindow, fldPath.Child("rollbackWindow"))...)
+	}
+
 	allErrs = append(allErrs, ValidateRolloutStrategy(rollout, fldPath.Child("strategy"))...)
 
 	return allErrs
 }
 
+// ValidateWorkloadRef checks the workload reference points to a supported kind of workload
+func ValidateWorkloadRef(workloadRef *v1alpha1.WorkloadRef, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	isDeployment := workloadRef.Kind == "Deployment" && workloadRef.APIVersion == "apps/v1"
+	isPodTemplate := workloadRef.Kind == "PodTemplate" && workloadRef.APIVersion == "v1"
+	if !isDeployment && !isPodTemplate {
+		allErrs = append(allErrs, field.Invalid(fldPath, fmt.Sprintf("%s/%s", workloadRef.APIVersion, workloadRef.Kind), InvalidWorkloadRefMessage))
+	}
+	if workloadRef.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), fmt.Sprintf(MissingFieldMessage, ".spec.workloadRef.name")))
+	}
+	if workloadRef.ScaleDown && !isDeployment {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("scaleDown"), workloadRef.ScaleDown, InvalidWorkloadRefScaleDownMessage))
+	}
+	return allErrs
+}
+
+// ValidateRollbackWindow checks the rollback window has a valid number of revisions or duration
+func ValidateRollbackWindow(window *v1alpha1.RollbackWindowSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(window.Revisions), fldPath.Child("revisions"))...)
+	if window.Duration != "" {
+		duration, err := window.Duration.Duration()
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("duration"), window.Duration, err.Error()))
+		} else if duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("duration"), window.Duration, InvalidDurationMessage))
+		}
+	}
+	if window.Revisions == 0 && window.Duration == "" {
+		allErrs = append(allErrs, field.Required(fldPath, InvalidRollbackWindowMessage))
+	}
+	return allErrs
+}
+
 // removeSecurityContextPrivileged removes the privileged value on containers for the purposes of
 // validation. This is necessary because the k8s ValidateSecurityContext library which we reuse,
 // calls k8s.io/kubernetes/pkg/capabilities.Get(), which determines the security capabilities at a
@@ -170,6 +282,30 @@ func ValidateRolloutStrategyBlueGreen(rollout *v1alpha1.Rollout, fldPath *field.
 	if blueGreen.ScaleDownDelayRevisionLimit != nil && revisionHistoryLimit < *blueGreen.ScaleDownDelayRevisionLimit {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("scaleDownDelayRevisionLimit"), *blueGreen.ScaleDownDelayRevisionLimit, ScaleDownLimitLargerThanRevisionLimit))
 	}
+	if blueGreen.TrafficRouting != nil {
+		if blueGreen.PreviewService == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("previewService"), blueGreen.PreviewService, InvalidBlueGreenTrafficRoutingMessage))
+		}
+		if blueGreen.TrafficRouting.Istio != nil && len(blueGreen.TrafficRouting.Istio.VirtualService.Routes) == 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("trafficRouting").Child("istio").Child("virtualService").Child("routes"), "[]", InvalidIstioRoutesMessage))
+		}
+	}
+	if blueGreen.TrafficRouting == nil && len(blueGreen.TrafficSteps) > 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("trafficSteps"), len(blueGreen.TrafficSteps), InvalidBlueGreenTrafficStepsMessage))
+	}
+	for i, step := range blueGreen.TrafficSteps {
+		stepFldPath := fldPath.Child("trafficSteps").Index(i)
+		if (step.SetWeight == nil) == (step.Pause == nil) {
+			errVal := fmt.Sprintf("step.SetWeight: %t step.Pause: %t", step.SetWeight != nil, step.Pause != nil)
+			allErrs = append(allErrs, field.Invalid(stepFldPath, errVal, InvalidBlueGreenTrafficStepMessage))
+		}
+		if step.SetWeight != nil && (*step.SetWeight < 0 || *step.SetWeight > 100) {
+			allErrs = append(allErrs, field.Invalid(stepFldPath.Child("setWeight"), *step.SetWeight, InvalidSetWeightMessage))
+		}
+		if step.Pause != nil && step.Pause.DurationSeconds() < 0 {
+			allErrs = append(allErrs, field.Invalid(stepFldPath.Child("pause").Child("duration"), step.Pause.DurationSeconds(), InvalidDurationMessage))
+		}
+	}
 	allErrs = append(allErrs, ValidateRolloutStrategyAntiAffinity(blueGreen.AntiAffinity, fldPath.Child("antiAffinity"))...)
 	return allErrs
 }
@@ -189,16 +325,49 @@ func ValidateRolloutStrategyCanary(rollout *v1alpha1.Rollout, fldPath *field.Pat
 			allErrs = append(allErrs, field.Invalid(fldPath.Child("canaryService"), canary.CanaryService, InvalidTrafficRoutingMessage))
 		}
 	}
+	if canary.TrafficRouting == nil && canary.DynamicStableScale {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("dynamicStableScale"), canary.DynamicStableScale, InvalidDynamicStableScaleTrafficPolicy))
+	}
 	if canary.TrafficRouting != nil && canary.TrafficRouting.Istio != nil && len(canary.TrafficRouting.Istio.VirtualService.Routes) == 0 {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("trafficRouting").Child("istio").Child("virtualService").Child("routes"), "[]", InvalidIstioRoutesMessage))
 
 	}
+	if canary.TrafficRouting != nil && canary.TrafficRouting.Ambassador != nil && len(canary.TrafficRouting.Ambassador.Mappings) == 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("trafficRouting").Child("ambassador").Child("mappings"), "[]", InvalidAmbassadorMappingsMessage))
+	}
+	if canary.TrafficRouting != nil && canary.TrafficRouting.GatewayAPI != nil {
+		gatewayAPIFldPath := fldPath.Child("trafficRouting").Child("gatewayAPI")
+		if canary.TrafficRouting.GatewayAPI.HTTPRoute == "" {
+			allErrs = append(allErrs, field.Invalid(gatewayAPIFldPath.Child("httpRoute"), canary.TrafficRouting.GatewayAPI.HTTPRoute, InvalidGatewayAPIHTTPRouteMessage))
+		}
+		for i, rule := range canary.TrafficRouting.GatewayAPI.Rules {
+			if rule < 0 {
+				allErrs = append(allErrs, field.Invalid(gatewayAPIFldPath.Child("rules").Index(i), rule, InvalidGatewayAPIRuleMessage))
+			}
+		}
+	}
+	if canary.TrafficRouting != nil && canary.TrafficRouting.Traefik != nil && canary.TrafficRouting.Traefik.WeightedTraefikServiceName == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("trafficRouting").Child("traefik").Child("weightedTraefikServiceName"), canary.TrafficRouting.Traefik.WeightedTraefikServiceName, InvalidTraefikServiceMessage))
+	}
+	if canary.TrafficRouting != nil && canary.TrafficRouting.AppMesh != nil {
+		allErrs = append(allErrs, ValidateAppMeshTrafficRouting(canary.TrafficRouting.AppMesh, fldPath.Child("trafficRouting").Child("appMesh"))...)
+	}
+	if canary.TrafficRouting != nil && canary.TrafficRouting.Plugin != nil && canary.TrafficRouting.Plugin.Name == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("trafficRouting").Child("plugin").Child("name"), canary.TrafficRouting.Plugin.Name, InvalidPluginNameMessage))
+	}
+	managedRoutes := map[string]bool{}
+	if canary.TrafficRouting != nil {
+		allErrs = append(allErrs, ValidateManagedRoutes(canary.TrafficRouting, fldPath.Child("trafficRouting").Child("managedRoutes"))...)
+		for _, route := range canary.TrafficRouting.ManagedRoutes {
+			managedRoutes[route.Name] = true
+		}
+	}
 	for i, step := range canary.Steps {
 		stepFldPath := fldPath.Child("steps").Index(i)
 		allErrs = append(allErrs, hasMultipleStepsType(step, stepFldPath)...)
-		if step.Experiment == nil && step.Pause == nil && step.SetWeight == nil && step.Analysis == nil && step.SetCanaryScale == nil {
-			errVal := fmt.Sprintf("step.Experiment: %t step.Pause: %t step.SetWeight: %t step.Analysis: %t step.SetCanaryScale %t",
-				step.Experiment == nil, step.Pause == nil, step.SetWeight == nil, step.Analysis == nil, step.SetCanaryScale == nil)
+		if step.Experiment == nil && step.Pause == nil && step.SetWeight == nil && step.Analysis == nil && step.SetCanaryScale == nil && step.SetHeaderRoute == nil && step.SetMirrorRoute == nil {
+			errVal := fmt.Sprintf("step.Experiment: %t step.Pause: %t step.SetWeight: %t step.Analysis: %t step.SetCanaryScale %t step.SetHeaderRoute %t step.SetMirrorRoute %t",
+				step.Experiment == nil, step.Pause == nil, step.SetWeight == nil, step.Analysis == nil, step.SetCanaryScale == nil, step.SetHeaderRoute == nil, step.SetMirrorRoute == nil)
 			allErrs = append(allErrs, field.Invalid(stepFldPath, errVal, InvalidStepMessage))
 		}
 		if step.SetWeight != nil && (*step.SetWeight < 0 || *step.SetWeight > 100) {
@@ -210,6 +379,12 @@ func ValidateRolloutStrategyCanary(rollout *v1alpha1.Rollout, fldPath *field.Pat
 		if rollout.Spec.Strategy.Canary != nil && rollout.Spec.Strategy.Canary.TrafficRouting == nil && step.SetCanaryScale != nil {
 			allErrs = append(allErrs, field.Invalid(stepFldPath.Child("setCanaryScale"), step.SetCanaryScale, InvalidSetCanaryScaleTrafficPolicy))
 		}
+		if step.SetHeaderRoute != nil {
+			allErrs = append(allErrs, ValidateSetHeaderRoute(rollout, step.SetHeaderRoute, managedRoutes, stepFldPath.Child("setHeaderRoute"))...)
+		}
+		if step.SetMirrorRoute != nil {
+			allErrs = append(allErrs, ValidateSetMirrorRoute(rollout, step.SetMirrorRoute, managedRoutes, stepFldPath.Child("setMirrorRoute"))...)
+		}
 		analysisRunArgs := []v1alpha1.AnalysisRunArgument{}
 		if step.Experiment != nil {
 			for _, analysis := range step.Experiment.Analyses {
@@ -240,6 +415,113 @@ func ValidateRolloutStrategyCanary(rollout *v1alpha1.Rollout, fldPath *field.Pat
 	return allErrs
 }
 
+// ValidateManagedRoutes checks the managed routes are unique and do not conflict with the routes of the user
+func ValidateAppMeshTrafficRouting(appMesh *v1alpha1.AppMeshTrafficRouting, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if appMesh.VirtualRouter.Name == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("virtualRouter").Child("name"), appMesh.VirtualRouter.Name, InvalidAppMeshVirtualRouterMessage))
+	}
+	if appMesh.StableVirtualNode == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("stableVirtualNode"), appMesh.StableVirtualNode, InvalidAppMeshStableVirtualNodeMessage))
+	} else if appMesh.CanaryVirtualNode == appMesh.StableVirtualNode {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("canaryVirtualNode"), appMesh.CanaryVirtualNode, InvalidAppMeshCanaryVirtualNodeMessage))
+	}
+	return allErrs
+}
+
+func ValidateManagedRoutes(trafficRouting *v1alpha1.RolloutTrafficRouting, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	routes := map[string]bool{}
+	if trafficRouting.Istio != nil {
+		for _, route := range trafficRouting.Istio.VirtualService.Routes {
+			routes[route] = true
+		}
+	}
+	for i, route := range trafficRouting.ManagedRoutes {
+		if route.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Index(i).Child("name"), fmt.Sprintf(MissingFieldMessage, "name")))
+			continue
+		}
+		if routes[route.Name] {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("name"), route.Name, InvalidManagedRouteMessage))
+		}
+		routes[route.Name] = true
+	}
+	return allErrs
+}
+
+// ValidateSetHeaderRoute checks a setHeaderRoute step refers to a managed route and has valid match conditions
+func ValidateSetHeaderRoute(rollout *v1alpha1.Rollout, headerRoute *v1alpha1.SetHeaderRoute, managedRoutes map[string]bool, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	trafficRouting := rollout.Spec.Strategy.Canary.TrafficRouting
+	if trafficRouting == nil || trafficRouting.Istio == nil {
+		allErrs = append(allErrs, field.Invalid(fldPath, headerRoute, InvalidSetHeaderRouteTrafficPolicy))
+	}
+	if !managedRoutes[headerRoute.Name] {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), headerRoute.Name, InvalidSetHeaderRouteNameMessage))
+	}
+	for i, match := range headerRoute.Match {
+		matchFldPath := fldPath.Child("match").Index(i)
+		if (match.HeaderName == "") == (match.CookieName == "") {
+			allErrs = append(allErrs, field.Invalid(matchFldPath, match, InvalidHeaderRoutingMatchMessage))
+		}
+		allErrs = append(allErrs, ValidateStringMatch(match.Value, matchFldPath.Child("value"))...)
+	}
+	return allErrs
+}
+
+// ValidateSetMirrorRoute checks a setMirrorRoute step refers to a managed route and has valid match conditions
+func ValidateSetMirrorRoute(rollout *v1alpha1.Rollout, mirrorRoute *v1alpha1.SetMirrorRoute, managedRoutes map[string]bool, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	trafficRouting := rollout.Spec.Strategy.Canary.TrafficRouting
+	if trafficRouting == nil || trafficRouting.Istio == nil {
+		allErrs = append(allErrs, field.Invalid(fldPath, mirrorRoute, InvalidSetMirrorRouteTrafficPolicy))
+	}
+	if !managedRoutes[mirrorRoute.Name] {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), mirrorRoute.Name, InvalidSetMirrorRouteNameMessage))
+	}
+	if mirrorRoute.Percentage != nil && (*mirrorRoute.Percentage < 0 || *mirrorRoute.Percentage > 100) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("percentage"), *mirrorRoute.Percentage, InvalidSetMirrorRoutePercentageMessage))
+	}
+	for i, match := range mirrorRoute.Match {
+		matchFldPath := fldPath.Child("match").Index(i)
+		if match.Method == nil && match.Path == nil && len(match.Headers) == 0 {
+			allErrs = append(allErrs, field.Invalid(matchFldPath, match, InvalidRouteMatchMessage))
+		}
+		if match.Method != nil {
+			allErrs = append(allErrs, ValidateStringMatch(*match.Method, matchFldPath.Child("method"))...)
+		}
+		if match.Path != nil {
+			allErrs = append(allErrs, ValidateStringMatch(*match.Path, matchFldPath.Child("path"))...)
+		}
+		for name, value := range match.Headers {
+			allErrs = append(allErrs, ValidateStringMatch(value, matchFldPath.Child("headers").Key(name))...)
+		}
+	}
+	return allErrs
+}
+
+// ValidateStringMatch checks exactly one match type of the string match is set
+func ValidateStringMatch(match v1alpha1.StringMatch, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	matchTypes := 0
+	for _, value := range []string{match.Exact, match.Prefix, match.Regex} {
+		if value != "" {
+			matchTypes++
+		}
+	}
+	if matchTypes != 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath, match, InvalidStringMatchMessage))
+		return allErrs
+	}
+	if match.Regex != "" {
+		if _, err := regexp.Compile(match.Regex); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("regex"), match.Regex, err.Error()))
+		}
+	}
+	return allErrs
+}
+
 func ValidateRolloutStrategyAntiAffinity(antiAffinity *v1alpha1.AntiAffinity, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	if antiAffinity != nil {
@@ -293,11 +575,13 @@ func hasMultipleStepsType(s v1alpha1.CanaryStep, fldPath *field.Path) field.Erro
 	oneOf = append(oneOf, s.Pause != nil)
 	oneOf = append(oneOf, s.Experiment != nil)
 	oneOf = append(oneOf, s.Analysis != nil)
+	oneOf = append(oneOf, s.SetHeaderRoute != nil)
+	oneOf = append(oneOf, s.SetMirrorRoute != nil)
 	hasMultipleStepTypes := false
 	for i := range oneOf {
 		if oneOf[i] {
 			if hasMultipleStepTypes {
-				errVal := fmt.Sprintf("step.Experiment: %t step.Pause: %t step.SetWeight: %t step.Analysis: %t", s.Experiment != nil, s.Pause != nil, s.SetWeight != nil, s.Analysis != nil)
+				errVal := fmt.Sprintf("step.Experiment: %t step.Pause: %t step.SetWeight: %t step.Analysis: %t step.SetHeaderRoute: %t step.SetMirrorRoute: %t", s.Experiment != nil, s.Pause != nil, s.SetWeight != nil, s.Analysis != nil, s.SetHeaderRoute != nil, s.SetMirrorRoute != nil)
 				allErrs = append(allErrs, field.Invalid(fldPath, errVal, InvalidStepMessage))
 				break
 			}
@@ -6,6 +6,7 @@ import (
 	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
 	"github.com/argoproj/argo-rollouts/utils/conditions"
 	serviceutil "github.com/argoproj/argo-rollouts/utils/service"
+	trafficroutingutil "github.com/argoproj/argo-rollouts/utils/trafficrouting"
 
 	ingressutil "github.com/argoproj/argo-rollouts/utils/ingress"
 	corev1 "k8s.io/api/core/v1"
@@ -14,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/gatewayapi"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/istio"
 )
 
@@ -57,6 +59,7 @@ type ReferencedResources struct {
 	Ingresses                []v1beta1.Ingress
 	ServiceWithType          []ServiceWithType
 	VirtualServices          []unstructured.Unstructured
+	HTTPRoutes               []unstructured.Unstructured
 }
 
 func ValidateRolloutReferencedResources(rollout *v1alpha1.Rollout, referencedResources ReferencedResources) field.ErrorList {
@@ -73,6 +76,9 @@ func ValidateRolloutReferencedResources(rollout *v1alpha1.Rollout, referencedRes
 	for _, vsvc := range referencedResources.VirtualServices {
 		allErrs = append(allErrs, ValidateVirtualService(rollout, vsvc)...)
 	}
+	for _, route := range referencedResources.HTTPRoutes {
+		allErrs = append(allErrs, ValidateHTTPRoute(rollout, route)...)
+	}
 	return allErrs
 }
 
@@ -126,7 +132,8 @@ func ValidateAnalysisTemplateWithType(template AnalysisTemplateWithType) field.E
 
 func ValidateIngress(rollout *v1alpha1.Rollout, ingress v1beta1.Ingress) field.ErrorList {
 	allErrs := field.ErrorList{}
-	fldPath := field.NewPath("spec", "strategy", "canary", "trafficRouting")
+	fldPath := trafficroutingutil.GetTrafficRoutingFieldPath(rollout)
+	rollout = trafficroutingutil.GetTrafficRoutingRollout(rollout)
 	var ingressName string
 	var serviceName string
 	if rollout.Spec.Strategy.Canary.TrafficRouting.Nginx != nil {
@@ -154,7 +161,8 @@ func ValidateIngress(rollout *v1alpha1.Rollout, ingress v1beta1.Ingress) field.E
 func ValidateVirtualService(rollout *v1alpha1.Rollout, obj unstructured.Unstructured) field.ErrorList {
 	allErrs := field.ErrorList{}
 	newObj := obj.DeepCopy()
-	fldPath := field.NewPath("spec", "strategy", "canary", "trafficRouting", "istio", "virtualService", "name")
+	fldPath := trafficroutingutil.GetTrafficRoutingFieldPath(rollout).Child("istio", "virtualService", "name")
+	rollout = trafficroutingutil.GetTrafficRoutingRollout(rollout)
 	vsvcName := rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Name
 	httpRoutesI, err := istio.GetHttpRoutesI(newObj)
 	if err != nil {
@@ -174,6 +182,19 @@ func ValidateVirtualService(rollout *v1alpha1.Rollout, obj unstructured.Unstruct
 	return allErrs
 }
 
+func ValidateHTTPRoute(rollout *v1alpha1.Rollout, obj unstructured.Unstructured) field.ErrorList {
+	allErrs := field.ErrorList{}
+	fldPath := trafficroutingutil.GetTrafficRoutingFieldPath(rollout).Child("gatewayAPI", "httpRoute")
+	rollout = trafficroutingutil.GetTrafficRoutingRollout(rollout)
+	routeName := rollout.Spec.Strategy.Canary.TrafficRouting.GatewayAPI.HTTPRoute
+	err := gatewayapi.ValidateHTTPRoute(rollout, &obj)
+	if err != nil {
+		msg := fmt.Sprintf("Gateway API HTTPRoute has invalid rules. Error: %s", err.Error())
+		allErrs = append(allErrs, field.Invalid(fldPath, routeName, msg))
+	}
+	return allErrs
+}
+
 func GetServiceWithTypeFieldPath(serviceType ServiceType) *field.Path {
 	fldPath := field.NewPath("spec", "strategy")
 	switch serviceType {
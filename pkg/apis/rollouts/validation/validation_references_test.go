@@ -71,6 +71,31 @@ spec:
         host: canary
       weight: 0`
 
+const successCaseHTTPRoute = `apiVersion: gateway.networking.k8s.io/v1beta1
+kind: HTTPRoute
+metadata:
+  name: httproute
+  namespace: default
+spec:
+  rules:
+  - backendRefs:
+    - name: stable
+      port: 80
+    - name: canary
+      port: 80
+      weight: 0`
+
+const failCaseHTTPRoute = `apiVersion: gateway.networking.k8s.io/v1beta1
+kind: HTTPRoute
+metadata:
+  name: httproute
+  namespace: default
+spec:
+  rules:
+  - backendRefs:
+    - name: stable
+      port: 80`
+
 func getAnalysisTemplateWithType() AnalysisTemplateWithType {
 	count := intstr.FromInt(1)
 	return AnalysisTemplateWithType{
@@ -254,6 +279,72 @@ func TestValidateVirtualService(t *testing.T) {
 		assert.Equal(t, expectedErr.Error(), allErrs[0].Error())
 
 	})
+
+	t.Run("validate blueGreen virtualService - failure", func(t *testing.T) {
+		blueGreen := ro.DeepCopy()
+		blueGreen.Spec.Strategy.BlueGreen = &v1alpha1.BlueGreenStrategy{
+			ActiveService:  "stable",
+			PreviewService: "canary",
+			TrafficRouting: ro.Spec.Strategy.Canary.TrafficRouting,
+		}
+		blueGreen.Spec.Strategy.Canary = nil
+		assert.Empty(t, ValidateVirtualService(blueGreen, *unstructured.StrToUnstructuredUnsafe(successCaseVsvc)))
+
+		vsvc := unstructured.StrToUnstructuredUnsafe(failCaseVsvc)
+		allErrs := ValidateVirtualService(blueGreen, *vsvc)
+		assert.Len(t, allErrs, 1)
+		expectedErr := field.Invalid(field.NewPath("spec", "strategy", "blueGreen", "trafficRouting", "istio", "virtualService", "name"), "istio-vsvc-name", "Istio VirtualService has invalid HTTP routes. Error: Stable Service 'stable' not found in route")
+		assert.Equal(t, expectedErr.Error(), allErrs[0].Error())
+	})
+}
+
+func TestValidateHTTPRoute(t *testing.T) {
+	ro := &v1alpha1.Rollout{
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					StableService: "stable",
+					CanaryService: "canary",
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+						GatewayAPI: &v1alpha1.GatewayAPITrafficRouting{
+							HTTPRoute: "httproute",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("validate httpRoute - success", func(t *testing.T) {
+		route := unstructured.StrToUnstructuredUnsafe(successCaseHTTPRoute)
+		allErrs := ValidateHTTPRoute(ro, *route)
+		assert.Empty(t, allErrs)
+	})
+
+	t.Run("validate httpRoute - failure", func(t *testing.T) {
+		route := unstructured.StrToUnstructuredUnsafe(failCaseHTTPRoute)
+		allErrs := ValidateHTTPRoute(ro, *route)
+		assert.Len(t, allErrs, 1)
+		expectedErr := field.Invalid(field.NewPath("spec", "strategy", "canary", "trafficRouting", "gatewayAPI", "httpRoute"), "httproute", "Gateway API HTTPRoute has invalid rules. Error: Canary Service 'canary' not found in HTTPRoute rule 0")
+		assert.Equal(t, expectedErr.Error(), allErrs[0].Error())
+	})
+
+	t.Run("validate blueGreen httpRoute - failure", func(t *testing.T) {
+		blueGreen := ro.DeepCopy()
+		blueGreen.Spec.Strategy.BlueGreen = &v1alpha1.BlueGreenStrategy{
+			ActiveService:  "stable",
+			PreviewService: "canary",
+			TrafficRouting: ro.Spec.Strategy.Canary.TrafficRouting,
+		}
+		blueGreen.Spec.Strategy.Canary = nil
+		assert.Empty(t, ValidateHTTPRoute(blueGreen, *unstructured.StrToUnstructuredUnsafe(successCaseHTTPRoute)))
+
+		route := unstructured.StrToUnstructuredUnsafe(failCaseHTTPRoute)
+		allErrs := ValidateHTTPRoute(blueGreen, *route)
+		assert.Len(t, allErrs, 1)
+		expectedErr := field.Invalid(field.NewPath("spec", "strategy", "blueGreen", "trafficRouting", "gatewayAPI", "httpRoute"), "httproute", "Gateway API HTTPRoute has invalid rules. Error: Canary Service 'canary' not found in HTTPRoute rule 0")
+		assert.Equal(t, expectedErr.Error(), allErrs[0].Error())
+	})
 }
 
 func TestGetAnalysisTemplateWithTypeFieldPath(t *testing.T) {
@@ -76,6 +76,55 @@ func TestValidateRollout(t *testing.T) {
 		assert.Empty(t, allErrs)
 	})
 
+	t.Run("workloadRef with template", func(t *testing.T) {
+		ro := ro.DeepCopy()
+		ro.Spec.WorkloadRef = &v1alpha1.WorkloadRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "foo"}
+		allErrs := ValidateRollout(ro)
+		assert.Len(t, allErrs, 1)
+		assert.Equal(t, "spec.template", allErrs[0].Field)
+		assert.Equal(t, InvalidWorkloadRefTemplateMessage, allErrs[0].Detail)
+	})
+
+	t.Run("unresolved workloadRef", func(t *testing.T) {
+		ro := ro.DeepCopy()
+		ro.Spec.Template = corev1.PodTemplateSpec{}
+		ro.Spec.WorkloadRef = &v1alpha1.WorkloadRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "foo"}
+		allErrs := ValidateRollout(ro)
+		assert.Empty(t, allErrs)
+	})
+
+	t.Run("resolved workloadRef", func(t *testing.T) {
+		ro := ro.DeepCopy()
+		template := ro.Spec.Template
+		ro.Spec.Template = corev1.PodTemplateSpec{}
+		ro.Spec.WorkloadRef = &v1alpha1.WorkloadRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "foo"}
+		allErrs := ValidateRollout(ro)
+		assert.Empty(t, allErrs)
+
+		ro.Spec.Template = template
+		allErrs = ValidateResolvedRollout(ro)
+		assert.Empty(t, allErrs)
+
+		ro.Spec.Template.Spec.Containers = nil
+		allErrs = ValidateResolvedRollout(ro)
+		assert.Len(t, allErrs, 1)
+		assert.Equal(t, "spec.template.spec.containers", allErrs[0].Field)
+	})
+}
+
+func TestValidateWorkloadRef(t *testing.T) {
+	path := field.NewPath("workloadRef")
+	assert.Empty(t, ValidateWorkloadRef(&v1alpha1.WorkloadRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "foo", ScaleDown: true}, path))
+	assert.Empty(t, ValidateWorkloadRef(&v1alpha1.WorkloadRef{APIVersion: "v1", Kind: "PodTemplate", Name: "foo"}, path))
+
+	allErrs := ValidateWorkloadRef(&v1alpha1.WorkloadRef{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "foo"}, path)
+	assert.Equal(t, InvalidWorkloadRefMessage, allErrs[0].Detail)
+
+	allErrs = ValidateWorkloadRef(&v1alpha1.WorkloadRef{APIVersion: "apps/v1", Kind: "Deployment"}, path)
+	assert.Equal(t, "workloadRef.name", allErrs[0].Field)
+
+	allErrs = ValidateWorkloadRef(&v1alpha1.WorkloadRef{APIVersion: "v1", Kind: "PodTemplate", Name: "foo", ScaleDown: true}, path)
+	assert.Equal(t, InvalidWorkloadRefScaleDownMessage, allErrs[0].Detail)
 }
 
 func TestValidateRolloutStrategy(t *testing.T) {
@@ -117,6 +166,54 @@ func TestValidateRolloutStrategyBlueGreen(t *testing.T) {
 	assert.Equal(t, ScaleDownLimitLargerThanRevisionLimit, allErrs[1].Detail)
 }
 
+func TestValidateRolloutStrategyBlueGreenTrafficRouting(t *testing.T) {
+	rollout := v1alpha1.Rollout{
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				BlueGreen: &v1alpha1.BlueGreenStrategy{
+					ActiveService: "active",
+					TrafficSteps: []v1alpha1.BlueGreenTrafficStep{
+						{SetWeight: pointer.Int32Ptr(50)},
+					},
+				},
+			},
+		},
+	}
+	fldPath := field.NewPath("spec", "strategy", "blueGreen")
+
+	t.Run("traffic steps require traffic routing", func(t *testing.T) {
+		allErrs := ValidateRolloutStrategyBlueGreen(&rollout, fldPath)
+		assert.Len(t, allErrs, 1)
+		assert.Equal(t, InvalidBlueGreenTrafficStepsMessage, allErrs[0].Detail)
+	})
+
+	t.Run("traffic routing requires preview service", func(t *testing.T) {
+		ro := rollout.DeepCopy()
+		ro.Spec.Strategy.BlueGreen.TrafficRouting = &v1alpha1.RolloutTrafficRouting{SMI: &v1alpha1.SMITrafficRouting{}}
+		allErrs := ValidateRolloutStrategyBlueGreen(ro, fldPath)
+		assert.Len(t, allErrs, 1)
+		assert.Equal(t, InvalidBlueGreenTrafficRoutingMessage, allErrs[0].Detail)
+
+		ro.Spec.Strategy.BlueGreen.PreviewService = "preview"
+		allErrs = ValidateRolloutStrategyBlueGreen(ro, fldPath)
+		assert.Len(t, allErrs, 0)
+	})
+
+	t.Run("invalid traffic steps", func(t *testing.T) {
+		ro := rollout.DeepCopy()
+		ro.Spec.Strategy.BlueGreen.PreviewService = "preview"
+		ro.Spec.Strategy.BlueGreen.TrafficRouting = &v1alpha1.RolloutTrafficRouting{SMI: &v1alpha1.SMITrafficRouting{}}
+		ro.Spec.Strategy.BlueGreen.TrafficSteps = []v1alpha1.BlueGreenTrafficStep{
+			{},
+			{SetWeight: pointer.Int32Ptr(101)},
+		}
+		allErrs := ValidateRolloutStrategyBlueGreen(ro, fldPath)
+		assert.Len(t, allErrs, 2)
+		assert.Equal(t, InvalidBlueGreenTrafficStepMessage, allErrs[0].Detail)
+		assert.Equal(t, InvalidSetWeightMessage, allErrs[1].Detail)
+	})
+}
+
 func TestValidateRolloutStrategyCanary(t *testing.T) {
 	canaryStrategy := &v1alpha1.CanaryStrategy{
 		CanaryService: "canary",
@@ -167,6 +264,74 @@ func TestValidateRolloutStrategyCanary(t *testing.T) {
 		assert.Equal(t, InvalidTrafficRoutingMessage, allErrs[0].Detail)
 	})
 
+	t.Run("invalid ambassador without mappings", func(t *testing.T) {
+		invalidRo := ro.DeepCopy()
+		invalidRo.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			Ambassador: &v1alpha1.AmbassadorTrafficRouting{},
+		}
+		allErrs := ValidateRolloutStrategyCanary(invalidRo, field.NewPath(""))
+		assert.Equal(t, InvalidAmbassadorMappingsMessage, allErrs[0].Detail)
+	})
+
+	t.Run("invalid gatewayAPI without httpRoute", func(t *testing.T) {
+		invalidRo := ro.DeepCopy()
+		invalidRo.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			GatewayAPI: &v1alpha1.GatewayAPITrafficRouting{},
+		}
+		allErrs := ValidateRolloutStrategyCanary(invalidRo, field.NewPath(""))
+		assert.Equal(t, InvalidGatewayAPIHTTPRouteMessage, allErrs[0].Detail)
+	})
+
+	t.Run("invalid traefik without weightedTraefikServiceName", func(t *testing.T) {
+		invalidRo := ro.DeepCopy()
+		invalidRo.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			Traefik: &v1alpha1.TraefikTrafficRouting{},
+		}
+		allErrs := ValidateRolloutStrategyCanary(invalidRo, field.NewPath(""))
+		assert.Equal(t, InvalidTraefikServiceMessage, allErrs[0].Detail)
+	})
+
+	t.Run("invalid appMesh", func(t *testing.T) {
+		invalidRo := ro.DeepCopy()
+		invalidRo.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			AppMesh: &v1alpha1.AppMeshTrafficRouting{},
+		}
+		allErrs := ValidateRolloutStrategyCanary(invalidRo, field.NewPath(""))
+		assert.Equal(t, InvalidAppMeshVirtualRouterMessage, allErrs[0].Detail)
+		assert.Equal(t, InvalidAppMeshStableVirtualNodeMessage, allErrs[1].Detail)
+
+		invalidRo.Spec.Strategy.Canary.TrafficRouting.AppMesh = &v1alpha1.AppMeshTrafficRouting{
+			VirtualRouter:     v1alpha1.AppMeshVirtualRouter{Name: "virtual-router"},
+			StableVirtualNode: "virtual-node",
+			CanaryVirtualNode: "virtual-node",
+		}
+		allErrs = ValidateRolloutStrategyCanary(invalidRo, field.NewPath(""))
+		assert.Equal(t, InvalidAppMeshCanaryVirtualNodeMessage, allErrs[0].Detail)
+	})
+
+	t.Run("invalid plugin without name", func(t *testing.T) {
+		invalidRo := ro.DeepCopy()
+		invalidRo.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			Plugin: &v1alpha1.PluginTrafficRouting{Config: `{"route": "my-route"}`},
+		}
+		allErrs := ValidateRolloutStrategyCanary(invalidRo, field.NewPath(""))
+		assert.Equal(t, InvalidPluginNameMessage, allErrs[0].Detail)
+		assert.Equal(t, "[].trafficRouting.plugin.name", allErrs[0].Field)
+	})
+
+	t.Run("invalid gatewayAPI rule index", func(t *testing.T) {
+		invalidRo := ro.DeepCopy()
+		invalidRo.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			GatewayAPI: &v1alpha1.GatewayAPITrafficRouting{
+				HTTPRoute: "httproute",
+				Rules:     []int32{0, -1},
+			},
+		}
+		allErrs := ValidateRolloutStrategyCanary(invalidRo, field.NewPath(""))
+		assert.Equal(t, InvalidGatewayAPIRuleMessage, allErrs[0].Detail)
+		assert.Equal(t, "[].trafficRouting.gatewayAPI.rules[1]", allErrs[0].Field)
+	})
+
 	t.Run("invalid setCanaryScale without trafficRouting", func(t *testing.T) {
 		invalidRo := ro.DeepCopy()
 		invalidRo.Spec.Strategy.Canary.Steps[0].SetCanaryScale = &v1alpha1.SetCanaryScale{}
@@ -175,6 +340,16 @@ func TestValidateRolloutStrategyCanary(t *testing.T) {
 		assert.Equal(t, InvalidSetCanaryScaleTrafficPolicy, allErrs[0].Detail)
 	})
 
+	t.Run("invalid dynamicStableScale without trafficRouting", func(t *testing.T) {
+		invalidRo := ro.DeepCopy()
+		invalidRo.Spec.Strategy.Canary.Steps[0].SetWeight = pointer.Int32Ptr(10)
+		invalidRo.Spec.Strategy.Canary.DynamicStableScale = true
+		invalidRo.Spec.Strategy.Canary.TrafficRouting = nil
+		allErrs := ValidateRolloutStrategyCanary(invalidRo, field.NewPath(""))
+		assert.Len(t, allErrs, 1)
+		assert.Equal(t, InvalidDynamicStableScaleTrafficPolicy, allErrs[0].Detail)
+	})
+
 	t.Run("invalid canary step", func(t *testing.T) {
 		invalidRo := ro.DeepCopy()
 		allErrs := ValidateRolloutStrategyCanary(invalidRo, field.NewPath(""))
@@ -229,6 +404,148 @@ func TestValidateRolloutStrategyAntiAffinity(t *testing.T) {
 	assert.Equal(t, InvalidAntiAffinityWeightMessage, allErrs[0].Detail)
 }
 
+func TestValidateRollbackWindow(t *testing.T) {
+	path := field.NewPath("rollbackWindow")
+	assert.Empty(t, ValidateRollbackWindow(&v1alpha1.RollbackWindowSpec{Revisions: 2}, path))
+	assert.Empty(t, ValidateRollbackWindow(&v1alpha1.RollbackWindowSpec{Duration: "1h"}, path))
+
+	allErrs := ValidateRollbackWindow(&v1alpha1.RollbackWindowSpec{}, path)
+	assert.Equal(t, InvalidRollbackWindowMessage, allErrs[0].Detail)
+
+	allErrs = ValidateRollbackWindow(&v1alpha1.RollbackWindowSpec{Revisions: -1}, path)
+	assert.Equal(t, "rollbackWindow.revisions", allErrs[0].Field)
+
+	allErrs = ValidateRollbackWindow(&v1alpha1.RollbackWindowSpec{Duration: "0s"}, path)
+	assert.Equal(t, InvalidDurationMessage, allErrs[0].Detail)
+
+	allErrs = ValidateRollbackWindow(&v1alpha1.RollbackWindowSpec{Duration: "an hour"}, path)
+	assert.Equal(t, "rollbackWindow.duration", allErrs[0].Field)
+}
+
+func TestValidateSetHeaderRoute(t *testing.T) {
+	ro := &v1alpha1.Rollout{
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+						Istio: &v1alpha1.IstioTrafficRouting{
+							VirtualService: v1alpha1.IstioVirtualService{Name: "vsvc", Routes: []string{"primary"}},
+						},
+						ManagedRoutes: []v1alpha1.ManagedRoute{{Name: "header-route"}},
+					},
+				},
+			},
+		},
+	}
+	path := field.NewPath("setHeaderRoute")
+	managedRoutes := map[string]bool{"header-route": true}
+	headerRoute := &v1alpha1.SetHeaderRoute{
+		Name: "header-route",
+		Match: []v1alpha1.HeaderRoutingMatch{
+			{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Exact: "true"}},
+			{CookieName: "beta", Value: v1alpha1.StringMatch{Regex: "^yes$"}},
+		},
+	}
+	assert.Empty(t, ValidateSetHeaderRoute(ro, headerRoute, managedRoutes, path))
+
+	allErrs := ValidateSetHeaderRoute(ro, &v1alpha1.SetHeaderRoute{Name: "unmanaged"}, managedRoutes, path)
+	assert.Equal(t, InvalidSetHeaderRouteNameMessage, allErrs[0].Detail)
+
+	allErrs = ValidateSetHeaderRoute(ro, &v1alpha1.SetHeaderRoute{
+		Name:  "header-route",
+		Match: []v1alpha1.HeaderRoutingMatch{{HeaderName: "x-canary", CookieName: "beta", Value: v1alpha1.StringMatch{Exact: "true"}}},
+	}, managedRoutes, path)
+	assert.Equal(t, InvalidHeaderRoutingMatchMessage, allErrs[0].Detail)
+
+	ro.Spec.Strategy.Canary.TrafficRouting.Istio = nil
+	allErrs = ValidateSetHeaderRoute(ro, headerRoute, managedRoutes, path)
+	assert.Equal(t, InvalidSetHeaderRouteTrafficPolicy, allErrs[0].Detail)
+}
+
+func TestValidateSetMirrorRoute(t *testing.T) {
+	ro := &v1alpha1.Rollout{
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+						Istio: &v1alpha1.IstioTrafficRouting{
+							VirtualService: v1alpha1.IstioVirtualService{Name: "vsvc", Routes: []string{"primary"}},
+						},
+						ManagedRoutes: []v1alpha1.ManagedRoute{{Name: "mirror-route"}},
+					},
+				},
+			},
+		},
+	}
+	path := field.NewPath("setMirrorRoute")
+	managedRoutes := map[string]bool{"mirror-route": true}
+	mirrorRoute := &v1alpha1.SetMirrorRoute{
+		Name: "mirror-route",
+		Match: []v1alpha1.RouteMatch{{
+			Method:  &v1alpha1.StringMatch{Exact: "GET"},
+			Headers: map[string]v1alpha1.StringMatch{"x-tenant": {Prefix: "internal"}},
+		}},
+		Percentage: pointer.Int32Ptr(50),
+	}
+	assert.Empty(t, ValidateSetMirrorRoute(ro, mirrorRoute, managedRoutes, path))
+
+	allErrs := ValidateSetMirrorRoute(ro, &v1alpha1.SetMirrorRoute{Name: "unmanaged"}, managedRoutes, path)
+	assert.Equal(t, InvalidSetMirrorRouteNameMessage, allErrs[0].Detail)
+
+	allErrs = ValidateSetMirrorRoute(ro, &v1alpha1.SetMirrorRoute{Name: "mirror-route", Percentage: pointer.Int32Ptr(101)}, managedRoutes, path)
+	assert.Equal(t, InvalidSetMirrorRoutePercentageMessage, allErrs[0].Detail)
+
+	allErrs = ValidateSetMirrorRoute(ro, &v1alpha1.SetMirrorRoute{Name: "mirror-route", Match: []v1alpha1.RouteMatch{{}}}, managedRoutes, path)
+	assert.Equal(t, InvalidRouteMatchMessage, allErrs[0].Detail)
+
+	allErrs = ValidateSetMirrorRoute(ro, &v1alpha1.SetMirrorRoute{
+		Name:  "mirror-route",
+		Match: []v1alpha1.RouteMatch{{Path: &v1alpha1.StringMatch{}}},
+	}, managedRoutes, path)
+	assert.Equal(t, "setMirrorRoute.match[0].path", allErrs[0].Field)
+
+	ro.Spec.Strategy.Canary.TrafficRouting.Istio = nil
+	allErrs = ValidateSetMirrorRoute(ro, mirrorRoute, managedRoutes, path)
+	assert.Equal(t, InvalidSetMirrorRouteTrafficPolicy, allErrs[0].Detail)
+}
+
+func TestValidateManagedRoutes(t *testing.T) {
+	path := field.NewPath("managedRoutes")
+	trafficRouting := &v1alpha1.RolloutTrafficRouting{
+		Istio: &v1alpha1.IstioTrafficRouting{
+			VirtualService: v1alpha1.IstioVirtualService{Name: "vsvc", Routes: []string{"primary"}},
+		},
+		ManagedRoutes: []v1alpha1.ManagedRoute{{Name: "header-route"}},
+	}
+	assert.Empty(t, ValidateManagedRoutes(trafficRouting, path))
+
+	trafficRouting.ManagedRoutes = []v1alpha1.ManagedRoute{{Name: "header-route"}, {Name: "header-route"}}
+	allErrs := ValidateManagedRoutes(trafficRouting, path)
+	assert.Equal(t, InvalidManagedRouteMessage, allErrs[0].Detail)
+
+	trafficRouting.ManagedRoutes = []v1alpha1.ManagedRoute{{Name: "primary"}}
+	allErrs = ValidateManagedRoutes(trafficRouting, path)
+	assert.Equal(t, InvalidManagedRouteMessage, allErrs[0].Detail)
+
+	trafficRouting.ManagedRoutes = []v1alpha1.ManagedRoute{{}}
+	allErrs = ValidateManagedRoutes(trafficRouting, path)
+	assert.Equal(t, "managedRoutes[0].name", allErrs[0].Field)
+}
+
+func TestValidateStringMatch(t *testing.T) {
+	path := field.NewPath("value")
+	assert.Empty(t, ValidateStringMatch(v1alpha1.StringMatch{Prefix: "Mozilla"}, path))
+
+	allErrs := ValidateStringMatch(v1alpha1.StringMatch{}, path)
+	assert.Equal(t, InvalidStringMatchMessage, allErrs[0].Detail)
+
+	allErrs = ValidateStringMatch(v1alpha1.StringMatch{Exact: "a", Prefix: "b"}, path)
+	assert.Equal(t, InvalidStringMatchMessage, allErrs[0].Detail)
+
+	allErrs = ValidateStringMatch(v1alpha1.StringMatch{Regex: "(unclosed"}, path)
+	assert.Equal(t, "value.regex", allErrs[0].Field)
+}
+
 func TestInvalidMaxSurgeMaxUnavailable(t *testing.T) {
 	r := func(maxSurge, maxUnavailable intstr.IntOrString) *v1alpha1.Rollout {
 		return &v1alpha1.Rollout{
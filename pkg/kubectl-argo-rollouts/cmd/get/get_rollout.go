@@ -118,6 +118,9 @@ func (o *GetOptions) PrintRollout(roInfo *info.RolloutInfo) {
 		fmt.Fprintf(o.Out, tableFormat, "  SetWeight:", roInfo.SetWeight)
 		fmt.Fprintf(o.Out, tableFormat, "  ActualWeight:", roInfo.ActualWeight)
 	}
+	if roInfo.WorkloadRef != "" {
+		fmt.Fprintf(o.Out, tableFormat, "WorkloadRef:", roInfo.WorkloadRef)
+	}
 	images := roInfo.Images()
 	if len(images) > 0 {
 		fmt.Fprintf(o.Out, tableFormat, "Images:", o.formatImage(images[0]))
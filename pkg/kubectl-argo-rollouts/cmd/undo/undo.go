@@ -71,6 +71,10 @@ func RunUndoRollout(rolloutIf dynamic.ResourceInterface, c kubernetes.Interface,
 	if err != nil {
 		return "", err
 	}
+	// the template of a rollout referencing a workload is owned by the workload
+	if _, found, _ := unstructured.NestedMap(ro.Object, "spec", "workloadRef"); found {
+		return "", fmt.Errorf("rollout '%s' references a workload, undo the change of the workload instead", name)
+	}
 	rsForRevision, err := rolloutRevision(ro, c, toRevision)
 	if err != nil {
 		return "", err
@@ -199,3 +199,21 @@ func TestUndoCmdError(t *testing.T) {
 	assert.Empty(t, stdout)
 	assert.Equal(t, "Error: rollouts.argoproj.io \"doesnotexist\" not found\n", stderr)
 }
+
+func TestUndoCmdWorkloadRefError(t *testing.T) {
+	rolloutObjs := testdata.NewCanaryRollout()
+	ro := rolloutObjs.Rollouts[0]
+	ro.Spec.Template = corev1.PodTemplateSpec{}
+	ro.Spec.WorkloadRef = &v1alpha1.WorkloadRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "rollouts-demo"}
+	tf, o := options.NewFakeArgoRolloutsOptions(rolloutObjs.AllObjects()...)
+	o.RESTClientGetter = tf.WithNamespace(ro.Namespace)
+	defer tf.Cleanup()
+
+	cmd := NewCmdUndo(o)
+	cmd.PersistentPreRunE = o.PersistentPreRunE
+	cmd.SetArgs([]string{ro.Name})
+	err := cmd.Execute()
+	assert.Error(t, err)
+	stderr := o.ErrOut.(*bytes.Buffer).String()
+	assert.Equal(t, fmt.Sprintf("Error: rollout '%s' references a workload, undo the change of the workload instead\n", ro.Name), stderr)
+}
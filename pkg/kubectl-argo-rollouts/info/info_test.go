@@ -6,11 +6,13 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/pkg/kubectl-argo-rollouts/info/testdata"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
 )
 
 func newCanaryRollout() *v1alpha1.Rollout {
@@ -97,6 +99,24 @@ func TestCanaryRolloutInfo(t *testing.T) {
 			Tags:  []string{InfoTagStable},
 		},
 	})
+	assert.Empty(t, roInfo.WorkloadRef)
+}
+
+func TestWorkloadRefRolloutInfo(t *testing.T) {
+	rolloutObjs := testdata.NewCanaryRollout()
+	ro := rolloutObjs.Rollouts[0].DeepCopy()
+	ro.Spec.Template = corev1.PodTemplateSpec{}
+	ro.Spec.WorkloadRef = &v1alpha1.WorkloadRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "rollouts-demo"}
+	roInfo := NewRolloutInfo(ro, rolloutObjs.ReplicaSets, rolloutObjs.Pods, rolloutObjs.Experiments, rolloutObjs.AnalysisRuns)
+	assert.Equal(t, "Deployment/rollouts-demo", roInfo.WorkloadRef)
+	// the images are read from the ReplicaSets, so they do not depend on the inline template
+	assert.Len(t, roInfo.Images(), 2)
+	assert.Empty(t, roInfo.Containers)
+
+	resolved := replicasetutil.RolloutWithTemplate(ro, &rolloutObjs.Rollouts[0].Spec.Template)
+	roInfo = NewRolloutInfo(resolved, rolloutObjs.ReplicaSets, rolloutObjs.Pods, rolloutObjs.Experiments, rolloutObjs.AnalysisRuns)
+	assert.Equal(t, []ContainerInfo{{Name: "canary-demo", Image: "argoproj/rollouts-demo:does-not-exist"}}, roInfo.Containers)
+	assert.True(t, ro.Spec.EmptyTemplate())
 }
 
 func TestBlueGreenRolloutInfo(t *testing.T) {
@@ -24,6 +24,10 @@ type RolloutInfo struct {
 	Step         string
 	SetWeight    string
 	ActualWeight string
+	// WorkloadRef is the kind and name of the workload providing the pod template, if any
+	WorkloadRef string
+	// Containers are the containers of the desired pod template
+	Containers []ContainerInfo
 
 	Ready     int32
 	Current   int32
@@ -36,6 +40,14 @@ type RolloutInfo struct {
 	AnalysisRuns []AnalysisRunInfo
 }
 
+// ContainerInfo is the name and image of a container of the desired pod template
+type ContainerInfo struct {
+	Name  string
+	Image string
+}
+
+// NewRolloutInfo returns the info of the rollout. The template of a rollout referencing a workload
+// must be resolved from the workload, e.g. with replicasetutil.RolloutWithTemplate.
 func NewRolloutInfo(
 	ro *v1alpha1.Rollout,
 	allReplicaSets []*appsv1.ReplicaSet,
@@ -83,6 +95,12 @@ func NewRolloutInfo(
 	} else if ro.Spec.Strategy.BlueGreen != nil {
 		roInfo.Strategy = "BlueGreen"
 	}
+	if ro.Spec.WorkloadRef != nil {
+		roInfo.WorkloadRef = fmt.Sprintf("%s/%s", ro.Spec.WorkloadRef.Kind, ro.Spec.WorkloadRef.Name)
+	}
+	for _, c := range ro.Spec.Template.Spec.Containers {
+		roInfo.Containers = append(roInfo.Containers, ContainerInfo{Name: c.Name, Image: c.Image})
+	}
 	roInfo.Status, roInfo.Message = RolloutStatusString(ro)
 	roInfo.Icon = rolloutIcon(roInfo.Status)
 
@@ -20,6 +20,7 @@ import (
 	rolloutinformers "github.com/argoproj/argo-rollouts/pkg/client/informers/externalversions"
 	rolloutlisters "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/pkg/kubectl-argo-rollouts/info"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
 )
 
 // viewController is a mini controller which allows printing of live updates to rollouts
@@ -36,6 +37,8 @@ type viewController struct {
 	rolloutLister     rolloutlisters.RolloutNamespaceLister
 	experimentLister  rolloutlisters.ExperimentNamespaceLister
 	analysisRunLister rolloutlisters.AnalysisRunNamespaceLister
+	deploymentLister  appslisters.DeploymentLister
+	podTemplateLister corelisters.PodTemplateLister
 
 	cacheSyncs []cache.InformerSynced
 
@@ -62,6 +65,8 @@ func NewRolloutViewController(namespace string, name string, kubeClient kubernet
 	vc.cacheSyncs = append(
 		vc.cacheSyncs,
 		vc.rolloutsInformerFactory.Argoproj().V1alpha1().Rollouts().Informer().HasSynced,
+		vc.kubeInformerFactory.Apps().V1().Deployments().Informer().HasSynced,
+		vc.kubeInformerFactory.Core().V1().PodTemplates().Informer().HasSynced,
 	)
 	rvc := RolloutViewController{
 		viewController: vc,
@@ -97,6 +102,8 @@ func newViewController(namespace string, name string, kubeClient kubernetes.Inte
 		rolloutLister:           rolloutsInformerFactory.Argoproj().V1alpha1().Rollouts().Lister().Rollouts(namespace),
 		experimentLister:        rolloutsInformerFactory.Argoproj().V1alpha1().Experiments().Lister().Experiments(namespace),
 		analysisRunLister:       rolloutsInformerFactory.Argoproj().V1alpha1().AnalysisRuns().Lister().AnalysisRuns(namespace),
+		deploymentLister:        kubeInformerFactory.Apps().V1().Deployments().Lister(),
+		podTemplateLister:       kubeInformerFactory.Core().V1().PodTemplates().Lister(),
 		workqueue:               workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
 	}
 
@@ -122,6 +129,8 @@ func newViewController(namespace string, name string, kubeClient kubernetes.Inte
 	// changes to any of these resources will enqueue the rollout for refreshing
 	kubeInformerFactory.Apps().V1().ReplicaSets().Informer().AddEventHandler(enqueueRolloutHandlerFuncs)
 	kubeInformerFactory.Core().V1().Pods().Informer().AddEventHandler(enqueueRolloutHandlerFuncs)
+	kubeInformerFactory.Apps().V1().Deployments().Informer().AddEventHandler(enqueueRolloutHandlerFuncs)
+	kubeInformerFactory.Core().V1().PodTemplates().Informer().AddEventHandler(enqueueRolloutHandlerFuncs)
 	rolloutsInformerFactory.Argoproj().V1alpha1().Rollouts().Informer().AddEventHandler(enqueueRolloutHandlerFuncs)
 	rolloutsInformerFactory.Argoproj().V1alpha1().Experiments().Informer().AddEventHandler(enqueueRolloutHandlerFuncs)
 	rolloutsInformerFactory.Argoproj().V1alpha1().AnalysisRuns().Informer().AddEventHandler(enqueueRolloutHandlerFuncs)
@@ -191,6 +200,14 @@ func (c *RolloutViewController) GetRolloutInfo() (*info.RolloutInfo, error) {
 		return nil, err
 	}
 
+	// the template of a rollout referencing a workload is resolved from the workload, like the
+	// controller does. The rollout is shown as is when the workload cannot be resolved.
+	template, err := replicasetutil.ResolveWorkloadRef(ro, c.deploymentLister, c.podTemplateLister)
+	if err != nil {
+		log.Warnf("Failed to resolve the workload of rollout %s: %v", ro.Name, err)
+	}
+	ro = replicasetutil.RolloutWithTemplate(ro, template)
+
 	roInfo := info.NewRolloutInfo(ro, allReplicaSets, allPods, allExps, allAnalysisRuns)
 	return roInfo, nil
 }
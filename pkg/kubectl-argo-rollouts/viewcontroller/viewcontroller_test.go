@@ -8,6 +8,8 @@ import (
 	rolloutsfake "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
 	"github.com/argoproj/argo-rollouts/pkg/kubectl-argo-rollouts/info"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
@@ -44,6 +46,40 @@ func TestRolloutController(t *testing.T) {
 	assert.Equal(t, roInfo.Name, "foo")
 }
 
+func TestRolloutControllerWorkloadRef(t *testing.T) {
+	ro := &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+		Spec: v1alpha1.RolloutSpec{
+			WorkloadRef: &v1alpha1.WorkloadRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "foo-deployment"},
+		},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo-deployment",
+			Namespace: "test",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "foo", Image: "foo:v2"}},
+				},
+			},
+		},
+	}
+	c := NewRolloutViewController(ro.Namespace, ro.Name, k8sfake.NewSimpleClientset(deployment), rolloutsfake.NewSimpleClientset(ro))
+	ctx := context.Background()
+	ctx, cancel := context.WithCancel(ctx)
+	c.Start(ctx)
+	cancel()
+	roInfo, err := c.GetRolloutInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, "Deployment/foo-deployment", roInfo.WorkloadRef)
+	assert.Equal(t, []info.ContainerInfo{{Name: "foo", Image: "foo:v2"}}, roInfo.Containers)
+}
+
 func TestRolloutControllerCallback(t *testing.T) {
 	ro := &v1alpha1.Rollout{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1,6 +1,7 @@
 package rollout
 
 import (
+	"fmt"
 	"sort"
 	"time"
 
@@ -8,8 +9,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kubernetes/pkg/controller"
+	"k8s.io/utils/pointer"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
 	"github.com/argoproj/argo-rollouts/utils/defaults"
 	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
 	serviceutil "github.com/argoproj/argo-rollouts/utils/service"
@@ -51,6 +54,11 @@ func (c *rolloutContext) rolloutBlueGreen() error {
 
 	c.reconcileBlueGreenPause(activeSvc, previewSvc)
 
+	err = c.reconcileBlueGreenTrafficRouting(activeSvc)
+	if err != nil {
+		return err
+	}
+
 	err = c.reconcileActiveService(previewSvc, activeSvc)
 	if err != nil {
 		return err
@@ -123,6 +131,10 @@ func (c *rolloutContext) skipPause(activeSvc *corev1.Service) bool {
 	if c.rollout.Status.PromoteFull {
 		return true
 	}
+	if c.rollout.Status.BlueGreen.CurrentTrafficStepIndex != nil {
+		// the rollout was promoted and the traffic is shifting to the preview service
+		return true
+	}
 
 	// If a rollout has a PrePromotionAnalysis, the controller only skips the pause after the analysis passes
 	if defaults.GetAutoPromotionEnabledOrDefault(c.rollout) && c.completedPrePromotionAnalysis() {
@@ -176,6 +188,122 @@ func (c *rolloutContext) reconcileBlueGreenPause(activeSvc, previewSvc *corev1.S
 	}
 }
 
+// reconcileBlueGreenTrafficRouting shifts the traffic from the active service to the preview
+// service through the traffic steps once the rollout is promoted. The active service is switched to
+// the new ReplicaSet only after all the traffic steps completed.
+func (c *rolloutContext) reconcileBlueGreenTrafficRouting(activeSvc *corev1.Service) error {
+	reconciler, err := c.newTrafficRoutingReconciler(c)
+	if err != nil {
+		return err
+	}
+	if reconciler == nil {
+		return nil
+	}
+	c.log.Infof("Reconciling TrafficRouting with type '%s'", reconciler.Type())
+
+	steps := c.rollout.Spec.Strategy.BlueGreen.TrafficSteps
+	index := c.rollout.Status.BlueGreen.CurrentTrafficStepIndex
+	desiredWeight := int32(0)
+	shifting := false
+	switch {
+	case c.pauseContext.IsAborted():
+		// all the traffic is sent back to the active service
+	case activeSvc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey] == replicasetutil.GetPodTemplateHash(c.newRS):
+		// the active service already serves the new ReplicaSet
+	case c.skipBlueGreenTrafficSteps(activeSvc):
+	case index == nil:
+		if replicasetutil.ReadyForPause(c.rollout, c.newRS, c.allRSs) && annotations.IsSaturated(c.rollout, c.newRS) &&
+			(c.skipPause(activeSvc) || (c.pauseContext.CompletedBlueGreenPause() && c.completedPrePromotionAnalysis())) {
+			c.log.Info("Rollout promoted, starting the traffic steps")
+			c.newStatus.BlueGreen.CurrentTrafficStepIndex = pointer.Int32Ptr(0)
+		}
+	default:
+		shifting = true
+		for i := int32(0); i <= *index && int(i) < len(steps); i++ {
+			if steps[i].SetWeight != nil {
+				desiredWeight = *steps[i].SetWeight
+			}
+		}
+	}
+
+	err = reconciler.SetWeight(desiredWeight)
+	if err != nil {
+		c.recorder.Event(c.rollout, corev1.EventTypeWarning, "TrafficRoutingError", err.Error())
+		return err
+	}
+	if !shifting || int(*index) >= len(steps) {
+		return nil
+	}
+
+	completedStep := false
+	step := steps[*index]
+	if step.SetWeight != nil {
+		weightVerified, err := reconciler.VerifyWeight(desiredWeight)
+		if err != nil {
+			return err
+		}
+		if !weightVerified {
+			c.log.Infof("Desired weight (trafficStepIdx: %d) %d not yet verified", *index, desiredWeight)
+			c.enqueueRolloutAfter(c.rollout, 10*time.Second)
+		}
+		completedStep = weightVerified
+	} else if step.Pause != nil {
+		completedStep = c.reconcileBlueGreenTrafficPause(*step.Pause)
+	}
+	if completedStep {
+		nextIndex := *index + 1
+		c.log.Infof("Incrementing the Current Traffic Step Index to %d", nextIndex)
+		c.recorder.Eventf(c.rollout, corev1.EventTypeNormal, "SetTrafficStepIndex", "Set Traffic Step Index to %d", nextIndex)
+		c.newStatus.BlueGreen.CurrentTrafficStepIndex = &nextIndex
+	}
+	return nil
+}
+
+// reconcileBlueGreenTrafficPause pauses the rollout at a traffic pause step and returns true once
+// the pause completed
+func (c *rolloutContext) reconcileBlueGreenTrafficPause(pause v1alpha1.RolloutPause) bool {
+	if c.pauseContext.CompletedBlueGreenTrafficPauseStep(pause) {
+		c.pauseContext.RemovePauseCondition(v1alpha1.PauseReasonBlueGreenTrafficStep)
+		return true
+	}
+	cond := getPauseCondition(c.rollout, v1alpha1.PauseReasonBlueGreenTrafficStep)
+	if cond == nil {
+		if !c.rollout.Status.ControllerPause {
+			c.pauseContext.AddPauseCondition(v1alpha1.PauseReasonBlueGreenTrafficStep)
+		}
+		return false
+	}
+	if pause.Duration != nil {
+		c.checkEnqueueRolloutDuringWait(cond.StartTime, pause.DurationSeconds())
+	}
+	return false
+}
+
+// skipBlueGreenTrafficSteps returns true if the active service is switched to the new ReplicaSet at
+// once instead of shifting the traffic through the traffic steps
+func (c *rolloutContext) skipBlueGreenTrafficSteps(activeSvc *corev1.Service) bool {
+	blueGreen := c.rollout.Spec.Strategy.BlueGreen
+	if blueGreen.TrafficRouting == nil || len(blueGreen.TrafficSteps) == 0 {
+		return true
+	}
+	if c.rollout.Status.PromoteFull || replicasetutil.HasScaleDownDeadline(c.newRS) {
+		return true
+	}
+	// the initial deployment has no active ReplicaSet to shift the traffic from
+	_, ok := activeSvc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey]
+	return !ok
+}
+
+// completedBlueGreenTrafficSteps returns true if the active service can be switched to the new
+// ReplicaSet since all the traffic steps completed
+func (c *rolloutContext) completedBlueGreenTrafficSteps(activeSvc *corev1.Service) bool {
+	if c.skipBlueGreenTrafficSteps(activeSvc) {
+		return true
+	}
+	index := c.rollout.Status.BlueGreen.CurrentTrafficStepIndex
+	return index != nil && int(*index) >= len(c.rollout.Spec.Strategy.BlueGreen.TrafficSteps)
+}
+
 // scaleDownOldReplicaSetsForBlueGreen scales down old replica sets when rollout strategy is "Blue Green".
 func (c *rolloutContext) scaleDownOldReplicaSetsForBlueGreen(oldRSs []*appsv1.ReplicaSet) (bool, error) {
 	if getPauseCondition(c.rollout, v1alpha1.PauseReasonInconclusiveAnalysis) != nil {
@@ -247,13 +375,24 @@ func (c *rolloutContext) syncRolloutStatusBlueGreen(previewSvc *corev1.Service,
 		c.SetRestartedAt()
 		newStatus.BlueGreen.PrePromotionAnalysisRunStatus = nil
 		newStatus.BlueGreen.PostPromotionAnalysisRunStatus = nil
+		newStatus.BlueGreen.CurrentTrafficStepIndex = nil
 		newStatus.PromoteFull = false
+		if c.isRollbackWithinWindow() {
+			msg := fmt.Sprintf("Skipping pause and analysis because ReplicaSet '%s' is within the rollback window", c.newRS.Name)
+			c.log.Info(msg)
+			c.recorder.Event(c.rollout, corev1.EventTypeNormal, "RollbackWithinWindow", msg)
+			newStatus.PromoteFull = true
+		}
 	}
 
 	if c.rollout.Status.PromoteFull {
 		c.pauseContext.ClearPauseConditions()
 		c.pauseContext.RemoveAbort()
 	}
+	if c.pauseContext.IsAborted() {
+		// the traffic steps restart once the rollout is retried
+		newStatus.BlueGreen.CurrentTrafficStepIndex = nil
+	}
 
 	previewSelector := serviceutil.GetRolloutSelectorLabel(previewSvc)
 	if previewSelector != c.rollout.Status.BlueGreen.PreviewSelector {
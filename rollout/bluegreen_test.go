@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	core "k8s.io/client-go/testing"
@@ -1245,3 +1247,105 @@ func TestBlueGreenAbort(t *testing.T) {
 	patch := f.getPatchedRollout(patchIndex)
 	assert.Equal(t, calculatePatch(r2, expectedPatch), patch)
 }
+
+func TestBlueGreenTrafficRouting(t *testing.T) {
+	newTrafficRoutingFixture := func(t *testing.T, currentTrafficStepIndex *int32) (*fixture, *v1alpha1.Rollout, *appsv1.ReplicaSet, *corev1.Service, string) {
+		f := newFixture(t)
+
+		r1 := newBlueGreenRollout("foo", 1, nil, "active", "preview")
+		r1.Spec.Strategy.BlueGreen.TrafficRouting = &v1alpha1.RolloutTrafficRouting{SMI: &v1alpha1.SMITrafficRouting{}}
+		r1.Spec.Strategy.BlueGreen.TrafficSteps = []v1alpha1.BlueGreenTrafficStep{
+			{SetWeight: pointer.Int32Ptr(50)},
+			{Pause: &v1alpha1.RolloutPause{}},
+		}
+		r2 := bumpVersion(r1)
+		rs1 := newReplicaSetWithStatus(r1, 1, 1)
+		rs2 := newReplicaSetWithStatus(r2, 1, 1)
+		rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+		rs2PodHash := rs2.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+
+		r2 = updateBlueGreenRolloutStatus(r2, rs2PodHash, rs1PodHash, rs1PodHash, 1, 1, 2, 1, false, true)
+		r2.Status.BlueGreen.CurrentTrafficStepIndex = currentTrafficStepIndex
+		previewSvc := newService("preview", 80, map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs2PodHash}, r2)
+		activeSvc := newService("active", 80, map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs1PodHash}, r2)
+
+		f.objects = append(f.objects, r2)
+		f.kubeobjects = append(f.kubeobjects, previewSvc, activeSvc, rs1, rs2)
+		f.rolloutLister = append(f.rolloutLister, r2)
+		f.replicaSetLister = append(f.replicaSetLister, rs1, rs2)
+		f.serviceLister = append(f.serviceLister, activeSvc, previewSvc)
+		f.fakeTrafficRouting = newUnmockedFakeTrafficRoutingReconciler()
+		return f, r2, rs1, activeSvc, rs2PodHash
+	}
+
+	t.Run("StartTrafficStepsOnPromotion", func(t *testing.T) {
+		f, ro, _, _, _ := newTrafficRoutingFixture(t, nil)
+		defer f.Close()
+		f.fakeTrafficRouting.On("SetWeight", int32(0)).Return(nil)
+
+		patchIndex := f.expectPatchRolloutAction(ro)
+		f.run(getKey(ro, t))
+
+		patch := f.getPatchedRolloutAsObject(patchIndex)
+		assert.Equal(t, pointer.Int32Ptr(0), patch.Status.BlueGreen.CurrentTrafficStepIndex)
+		f.fakeTrafficRouting.AssertExpectations(t)
+	})
+
+	t.Run("SetWeightStep", func(t *testing.T) {
+		f, ro, _, _, _ := newTrafficRoutingFixture(t, pointer.Int32Ptr(0))
+		defer f.Close()
+		f.fakeTrafficRouting.On("SetWeight", int32(50)).Return(nil)
+		f.fakeTrafficRouting.On("VerifyWeight", int32(50)).Return(true, nil)
+
+		patchIndex := f.expectPatchRolloutAction(ro)
+		f.run(getKey(ro, t))
+
+		patch := f.getPatchedRolloutAsObject(patchIndex)
+		assert.Equal(t, pointer.Int32Ptr(1), patch.Status.BlueGreen.CurrentTrafficStepIndex)
+		f.fakeTrafficRouting.AssertExpectations(t)
+	})
+
+	t.Run("PauseStep", func(t *testing.T) {
+		f, ro, _, _, _ := newTrafficRoutingFixture(t, pointer.Int32Ptr(1))
+		defer f.Close()
+		f.fakeTrafficRouting.On("SetWeight", int32(50)).Return(nil)
+
+		patchIndex := f.expectPatchRolloutAction(ro)
+		f.run(getKey(ro, t))
+
+		patch := f.getPatchedRolloutAsObject(patchIndex)
+		assert.True(t, patch.Status.ControllerPause)
+		assert.Len(t, patch.Status.PauseConditions, 1)
+		assert.Equal(t, v1alpha1.PauseReasonBlueGreenTrafficStep, patch.Status.PauseConditions[0].Reason)
+		assert.Nil(t, patch.Status.BlueGreen.CurrentTrafficStepIndex)
+		f.fakeTrafficRouting.AssertNotCalled(t, "VerifyWeight", mock.Anything)
+	})
+
+	t.Run("SwitchActiveServiceAfterTrafficSteps", func(t *testing.T) {
+		f, ro, rs1, activeSvc, rs2PodHash := newTrafficRoutingFixture(t, pointer.Int32Ptr(2))
+		defer f.Close()
+		f.fakeTrafficRouting.On("SetWeight", int32(50)).Return(nil)
+
+		servicePatchIndex := f.expectPatchServiceAction(activeSvc, rs2PodHash)
+		f.expectPatchReplicaSetAction(rs1)
+		f.expectPatchRolloutAction(ro)
+		f.run(getKey(ro, t))
+
+		f.verifyPatchedService(servicePatchIndex, rs2PodHash, "")
+		f.fakeTrafficRouting.AssertExpectations(t)
+	})
+
+	t.Run("AbortResetsTrafficSteps", func(t *testing.T) {
+		f, ro, _, _, _ := newTrafficRoutingFixture(t, pointer.Int32Ptr(1))
+		defer f.Close()
+		ro.Status.Abort = true
+		f.fakeTrafficRouting.On("SetWeight", int32(0)).Return(nil)
+
+		patchIndex := f.expectPatchRolloutAction(ro)
+		f.run(getKey(ro, t))
+
+		patch := f.getPatchedRollout(patchIndex)
+		assert.Contains(t, patch, `"currentTrafficStepIndex":null`)
+		f.fakeTrafficRouting.AssertExpectations(t)
+	})
+}
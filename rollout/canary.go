@@ -214,6 +214,9 @@ func (c *rolloutContext) completedCurrentCanaryStep() bool {
 		return c.pauseContext.CompletedPauseStep(*currentStep.Pause)
 	case currentStep.SetCanaryScale != nil:
 		return replicasetutil.AtDesiredReplicaCountsForCanary(c.rollout, c.newRS, c.stableRS, c.otherRSs)
+	case currentStep.SetHeaderRoute != nil, currentStep.SetMirrorRoute != nil:
+		// the managed route is set when reconciling the traffic routing, which happens before this check
+		return true
 	case currentStep.SetWeight != nil:
 		if !replicasetutil.AtDesiredReplicaCountsForCanary(c.rollout, c.newRS, c.stableRS, c.otherRSs) {
 			return false
@@ -261,6 +264,15 @@ func (c *rolloutContext) syncRolloutStatusCanary() error {
 		newStatus.Canary.CurrentStepAnalysisRunStatus = nil
 		newStatus.Canary.CurrentBackgroundAnalysisRunStatus = nil
 		newStatus.PromoteFull = false
+		if c.isRollbackWithinWindow() {
+			msg := fmt.Sprintf("Skipping all steps and analysis because ReplicaSet '%s' is within the rollback window", c.newRS.Name)
+			c.log.Info(msg)
+			c.recorder.Event(c.rollout, corev1.EventTypeNormal, "RollbackWithinWindow", msg)
+			if newStatus.CurrentStepIndex != nil {
+				newStatus.CurrentStepIndex = pointer.Int32Ptr(stepCount)
+			}
+			newStatus.PromoteFull = true
+		}
 		return c.persistRolloutStatus(&newStatus)
 	}
 
@@ -715,6 +715,9 @@ func TestRollBackToStable(t *testing.T) {
 	expectedRS1.Annotations[annotations.RevisionAnnotation] = "3"
 	expectedRS1.Annotations[annotations.RevisionHistoryAnnotation] = "1"
 	firstUpdatedRS1 := f.getUpdatedReplicaSet(updatedRSIndex)
+	// the time of the rollback is recorded for the rollback window
+	assert.NotEmpty(t, firstUpdatedRS1.Annotations[annotations.RevisionTimestampAnnotation])
+	expectedRS1.Annotations[annotations.RevisionTimestampAnnotation] = firstUpdatedRS1.Annotations[annotations.RevisionTimestampAnnotation]
 	assert.Equal(t, expectedRS1, firstUpdatedRS1)
 
 	expectedPatchWithoutSub := `{
@@ -821,6 +824,175 @@ func TestRollBackToStableAndStepChange(t *testing.T) {
 	assert.Equal(t, calculatePatch(r2, expectedPatch), patch)
 }
 
+// rollbackToFirstRevision runs a rollout which rolls back from the stable third revision to the
+// first revision and returns the patched rollout
+func rollbackToFirstRevision(t *testing.T, window *v1alpha1.RollbackWindowSpec) *v1alpha1.Rollout {
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{{
+		SetWeight: int32Ptr(10),
+	}, {
+		Pause: &v1alpha1.RolloutPause{},
+	}}
+	r1 := newCanaryRollout("foo", 10, nil, steps, int32Ptr(2), intstr.FromInt(1), intstr.FromInt(0))
+	r2 := bumpVersion(r1)
+	r3 := bumpVersion(r2)
+
+	now := time.Now()
+	rs1 := newReplicaSetWithStatus(r1, 0, 0)
+	rs1.CreationTimestamp = metav1.NewTime(now.Add(-3 * time.Hour))
+	rs2 := newReplicaSetWithStatus(r2, 0, 0)
+	rs2.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Hour))
+	rs3 := newReplicaSetWithStatus(r3, 10, 10)
+	rs3.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+	rs3PodHash := rs3.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	f.kubeobjects = append(f.kubeobjects, rs1, rs2, rs3)
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2, rs3)
+
+	r3 = updateCanaryRolloutStatus(r3, rs3PodHash, 10, 10, 10, false)
+	r3.Spec.Template = r1.Spec.Template
+	r3.Spec.RollbackWindow = window
+	f.rolloutLister = append(f.rolloutLister, r3)
+	f.objects = append(f.objects, r3)
+
+	f.expectUpdateReplicaSetAction(rs1)
+	f.expectUpdateReplicaSetAction(rs1)
+	patchIndex := f.expectPatchRolloutAction(r3)
+	f.run(getKey(r3, t))
+
+	return f.getPatchedRolloutAsObject(patchIndex)
+}
+
+func TestRollbackWithinRevisionWindow(t *testing.T) {
+	patched := rollbackToFirstRevision(t, &v1alpha1.RollbackWindowSpec{Revisions: 2})
+	assert.True(t, patched.Status.PromoteFull)
+	// the step index remains at the end of the steps
+	assert.Nil(t, patched.Status.CurrentStepIndex)
+}
+
+func TestRollbackOutsideRevisionWindow(t *testing.T) {
+	patched := rollbackToFirstRevision(t, &v1alpha1.RollbackWindowSpec{Revisions: 1})
+	assert.False(t, patched.Status.PromoteFull)
+	assert.Equal(t, int32(0), *patched.Status.CurrentStepIndex)
+}
+
+func TestRollbackWithinDurationWindow(t *testing.T) {
+	// the first revision was replaced by the second revision two hours ago
+	patched := rollbackToFirstRevision(t, &v1alpha1.RollbackWindowSpec{Duration: "3h"})
+	assert.True(t, patched.Status.PromoteFull)
+
+	patched = rollbackToFirstRevision(t, &v1alpha1.RollbackWindowSpec{Duration: "1h"})
+	assert.False(t, patched.Status.PromoteFull)
+}
+
+func TestRollbackAfterRollbackAndRollForward(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{{
+		SetWeight: int32Ptr(10),
+	}, {
+		Pause: &v1alpha1.RolloutPause{},
+	}}
+	r1 := newCanaryRollout("foo", 10, nil, steps, int32Ptr(2), intstr.FromInt(1), intstr.FromInt(0))
+	r2 := bumpVersion(r1)
+	r3 := bumpVersion(r2)
+
+	now := time.Now()
+	rs1 := newReplicaSetWithStatus(r1, 0, 0)
+	rs1.CreationTimestamp = metav1.NewTime(now.Add(-3 * time.Hour))
+	rs2 := newReplicaSetWithStatus(r2, 0, 0)
+	rs2.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Hour))
+	rs3 := newReplicaSetWithStatus(r3, 10, 10)
+	rs3.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+	// the rollout was rolled back from the third to the first revision, then rolled forward to the
+	// third revision again
+	rs1.Annotations[annotations.RevisionAnnotation] = "4"
+	rs1.Annotations[annotations.RevisionHistoryAnnotation] = "1"
+	rs3.Annotations[annotations.RevisionAnnotation] = "5"
+	rs3.Annotations[annotations.RevisionHistoryAnnotation] = "3"
+	rs3PodHash := rs3.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	f.kubeobjects = append(f.kubeobjects, rs1, rs2, rs3)
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2, rs3)
+
+	r3 = updateCanaryRolloutStatus(r3, rs3PodHash, 10, 10, 10, false)
+	annotations.SetRolloutRevision(r3, "5")
+	r3.Spec.Template = r1.Spec.Template
+	// the first revision was replaced a single revision ago, even though the second revision was
+	// created after it
+	r3.Spec.RollbackWindow = &v1alpha1.RollbackWindowSpec{Revisions: 1}
+	f.rolloutLister = append(f.rolloutLister, r3)
+	f.objects = append(f.objects, r3)
+
+	updateIndex := f.expectUpdateReplicaSetAction(rs1)
+	f.expectUpdateReplicaSetAction(rs1)
+	patchIndex := f.expectPatchRolloutAction(r3)
+	f.run(getKey(r3, t))
+
+	updatedRS := f.getUpdatedReplicaSet(updateIndex)
+	assert.Equal(t, "6", updatedRS.Annotations[annotations.RevisionAnnotation])
+	assert.Equal(t, "1,4", updatedRS.Annotations[annotations.RevisionHistoryAnnotation])
+	patched := f.getPatchedRolloutAsObject(patchIndex)
+	assert.True(t, patched.Status.PromoteFull)
+}
+
+func TestRollbackWithinDurationWindowAfterRepromotion(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{{
+		SetWeight: int32Ptr(10),
+	}, {
+		Pause: &v1alpha1.RolloutPause{},
+	}}
+	r1 := newCanaryRollout("foo", 10, nil, steps, int32Ptr(2), intstr.FromInt(1), intstr.FromInt(0))
+	r2 := bumpVersion(r1)
+	r3 := bumpVersion(r2)
+
+	now := time.Now()
+	rs1 := newReplicaSetWithStatus(r1, 0, 0)
+	rs1.CreationTimestamp = metav1.NewTime(now.Add(-4 * time.Hour))
+	rs2 := newReplicaSetWithStatus(r2, 0, 0)
+	rs2.CreationTimestamp = metav1.NewTime(now.Add(-5 * time.Hour))
+	rs3 := newReplicaSetWithStatus(r3, 10, 10)
+	rs3.CreationTimestamp = metav1.NewTime(now.Add(-10 * time.Minute))
+	// the second ReplicaSet was created first, replaced by the first one, and re-promoted by rolling
+	// back to it half an hour ago, which replaced the first one
+	rs2.Annotations[annotations.RevisionAnnotation] = "3"
+	rs2.Annotations[annotations.RevisionHistoryAnnotation] = "1"
+	rs2.Annotations[annotations.RevisionTimestampAnnotation] = now.Add(-30 * time.Minute).UTC().Format(time.RFC3339)
+	rs1.Annotations[annotations.RevisionAnnotation] = "2"
+	rs3.Annotations[annotations.RevisionAnnotation] = "4"
+	rs3PodHash := rs3.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	f.kubeobjects = append(f.kubeobjects, rs1, rs2, rs3)
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2, rs3)
+
+	r3 = updateCanaryRolloutStatus(r3, rs3PodHash, 10, 10, 10, false)
+	annotations.SetRolloutRevision(r3, "4")
+	r3.Spec.Template = r1.Spec.Template
+	r3.Spec.RollbackWindow = &v1alpha1.RollbackWindowSpec{Duration: "1h"}
+	f.rolloutLister = append(f.rolloutLister, r3)
+	f.objects = append(f.objects, r3)
+
+	updateIndex := f.expectUpdateReplicaSetAction(rs1)
+	f.expectUpdateReplicaSetAction(rs1)
+	patchIndex := f.expectPatchRolloutAction(r3)
+	f.run(getKey(r3, t))
+
+	updatedRS := f.getUpdatedReplicaSet(updateIndex)
+	assert.Equal(t, "5", updatedRS.Annotations[annotations.RevisionAnnotation])
+	assert.NotEmpty(t, updatedRS.Annotations[annotations.RevisionTimestampAnnotation])
+	patched := f.getPatchedRolloutAsObject(patchIndex)
+	assert.True(t, patched.Status.PromoteFull)
+}
+
+func TestRollbackWithoutWindow(t *testing.T) {
+	patched := rollbackToFirstRevision(t, nil)
+	assert.False(t, patched.Status.PromoteFull)
+	assert.Equal(t, int32(0), *patched.Status.CurrentStepIndex)
+}
+
 func TestCanaryRolloutIncrementStepIfSetWeightsAreCorrect(t *testing.T) {
 	f := newFixture(t)
 	defer f.Close()
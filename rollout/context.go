@@ -5,6 +5,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
@@ -19,6 +20,9 @@ type rolloutContext struct {
 	rollout *v1alpha1.Rollout
 	// newRollout is the rollout after reconciliation. used to write back to informer
 	newRollout *v1alpha1.Rollout
+	// resolvedTemplate is the template resolved from the workload referenced by the rollout, set on
+	// rollout but never persisted. nil when the rollout does not reference a workload.
+	resolvedTemplate *corev1.PodTemplateSpec
 	// newRS is the "new" ReplicaSet. Also referred to as current, or desired.
 	// newRS will be nil when the pod template spec changes.
 	newRS *appsv1.ReplicaSet
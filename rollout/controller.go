@@ -37,12 +37,14 @@ import (
 	"k8s.io/utils/pointer"
 
 	"github.com/argoproj/argo-rollouts/controller/metrics"
+	"github.com/argoproj/argo-rollouts/notifications"
 	register "github.com/argoproj/argo-rollouts/pkg/apis/rollouts"
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/validation"
 	clientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned"
 	informers "github.com/argoproj/argo-rollouts/pkg/client/informers/externalversions/rollouts/v1alpha1"
 	listers "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/gatewayapi"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/istio"
 	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
 	"github.com/argoproj/argo-rollouts/utils/conditions"
@@ -53,11 +55,13 @@ import (
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
 	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
 	serviceutil "github.com/argoproj/argo-rollouts/utils/service"
+	trafficroutingutil "github.com/argoproj/argo-rollouts/utils/trafficrouting"
 	unstructuredutil "github.com/argoproj/argo-rollouts/utils/unstructured"
 )
 
 const (
 	virtualServiceIndexName = "byVirtualService"
+	workloadRefIndexName    = "byWorkloadRef"
 )
 
 // Controller is the controller implementation for Rollout resources
@@ -95,6 +99,8 @@ type ControllerConfig struct {
 	ReplicaSetInformer              appsinformers.ReplicaSetInformer
 	ServicesInformer                coreinformers.ServiceInformer
 	IngressInformer                 extensionsinformers.IngressInformer
+	DeploymentInformer              appsinformers.DeploymentInformer
+	PodTemplateInformer             coreinformers.PodTemplateInformer
 	RolloutsInformer                informers.RolloutInformer
 	IstioVirtualServiceInformer     cache.SharedIndexInformer
 	ResyncPeriod                    time.Duration
@@ -103,6 +109,7 @@ type ControllerConfig struct {
 	IngressWorkQueue                workqueue.RateLimitingInterface
 	MetricsServer                   *metrics.MetricsServer
 	Recorder                        record.EventRecorder
+	Notifier                        notifications.Notifier
 	DefaultIstioVersion             string
 	DefaultTrafficSplitVersion      string
 }
@@ -129,6 +136,8 @@ type reconcilerBase struct {
 	rolloutsIndexer               cache.Indexer
 	servicesLister                v1.ServiceLister
 	ingressesLister               extensionslisters.IngressLister
+	deploymentLister              appslisters.DeploymentLister
+	podTemplateLister             v1.PodTemplateLister
 	experimentsLister             listers.ExperimentLister
 	analysisRunLister             listers.AnalysisRunLister
 	analysisTemplateLister        listers.AnalysisTemplateLister
@@ -146,7 +155,9 @@ type reconcilerBase struct {
 	newTrafficRoutingReconciler func(roCtx *rolloutContext) (TrafficRoutingReconciler, error) //nolint:structcheck
 
 	// recorder is an event recorder for recording Event resources to the Kubernetes API.
-	recorder     record.EventRecorder
+	recorder record.EventRecorder
+	// notifier delivers notifications about rollout state transitions. Optional.
+	notifier     notifications.Notifier
 	resyncPeriod time.Duration
 }
 
@@ -181,6 +192,8 @@ func NewController(cfg ControllerConfig) *Controller {
 		rolloutsSynced:                cfg.RolloutsInformer.Informer().HasSynced,
 		servicesLister:                cfg.ServicesInformer.Lister(),
 		ingressesLister:               cfg.IngressInformer.Lister(),
+		deploymentLister:              cfg.DeploymentInformer.Lister(),
+		podTemplateLister:             cfg.PodTemplateInformer.Lister(),
 		experimentsLister:             cfg.ExperimentInformer.Lister(),
 		analysisRunLister:             cfg.AnalysisRunInformer.Lister(),
 		analysisTemplateLister:        cfg.AnalysisTemplateInformer.Lister(),
@@ -188,6 +201,7 @@ func NewController(cfg ControllerConfig) *Controller {
 		istioVirtualServiceLister:     dynamiclister.New(cfg.IstioVirtualServiceInformer.GetIndexer(), istioutil.GetIstioGVR(cfg.DefaultIstioVersion)),
 		istioVirtualServiceInformer:   cfg.IstioVirtualServiceInformer,
 		recorder:                      cfg.Recorder,
+		notifier:                      cfg.Notifier,
 		resyncPeriod:                  cfg.ResyncPeriod,
 		podRestarter:                  podRestarter,
 	}
@@ -244,6 +258,16 @@ func NewController(cfg ControllerConfig) *Controller {
 		},
 	}))
 
+	// Indexer to enqueue Rollouts when the workload they reference changes
+	util.CheckErr(cfg.RolloutsInformer.Informer().AddIndexers(cache.Indexers{
+		workloadRefIndexName: func(obj interface{}) (strings []string, e error) {
+			if ro := unstructuredutil.ObjectToRollout(obj); ro != nil && ro.Spec.WorkloadRef != nil {
+				return []string{workloadRefKey(ro.Namespace, ro.Spec.WorkloadRef.Kind, ro.Spec.WorkloadRef.Name)}, nil
+			}
+			return
+		},
+	}))
+
 	cfg.ReplicaSetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			controllerutil.EnqueueParentObject(obj, register.RolloutKind, controller.enqueueRollout)
@@ -263,6 +287,16 @@ func NewController(cfg ControllerConfig) *Controller {
 		},
 	})
 
+	workloadHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueWorkloadRef,
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueueWorkloadRef(new)
+		},
+		DeleteFunc: controller.enqueueWorkloadRef,
+	}
+	cfg.DeploymentInformer.Informer().AddEventHandler(workloadHandler)
+	cfg.PodTemplateInformer.Informer().AddEventHandler(workloadHandler)
+
 	cfg.AnalysisRunInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			controllerutil.EnqueueParentObject(obj, register.RolloutKind, controller.enqueueRollout)
@@ -401,15 +435,27 @@ func (c *Controller) syncHandler(key string) error {
 		logCtx.WithField("time_ms", duration.Seconds()*1e3).Info("Reconciliation completed")
 	}()
 
+	template, resolveErr := c.resolveWorkloadRef(r)
+	if template != nil {
+		r.Spec.Template = *template
+	}
 	roCtx, err := c.newRolloutContext(r)
 	if err != nil {
 		return err
 	}
+	roCtx.resolvedTemplate = template
+	if resolveErr != nil {
+		// the workload might not exist yet, the rollout is enqueued again once it is created
+		return roCtx.createInvalidRolloutCondition(resolveErr, r)
+	}
 	err = roCtx.reconcile()
 	if roCtx.newRollout != nil {
 		c.writeBackToInformer(roCtx.newRollout)
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	return roCtx.scaleDownWorkload()
 }
 
 // writeBackToInformer writes a just recently updated Rollout back into the informer cache.
@@ -479,7 +525,7 @@ func (c *Controller) newRolloutContext(rollout *v1alpha1.Rollout) (*rolloutConte
 }
 
 func (c *rolloutContext) getRolloutValidationErrors() error {
-	rolloutValidationErrors := validation.ValidateRollout(c.rollout)
+	rolloutValidationErrors := validation.ValidateResolvedRollout(c.rollout)
 	if len(rolloutValidationErrors) > 0 {
 		return rolloutValidationErrors[0]
 	}
@@ -545,6 +591,12 @@ func (c *rolloutContext) getRolloutReferencedResources() (*validation.Referenced
 	}
 	refResources.VirtualServices = *virtualServices
 
+	httpRoutes, err := c.getReferencedHTTPRoutes()
+	if err != nil {
+		return nil, err
+	}
+	refResources.HTTPRoutes = *httpRoutes
+
 	return &refResources, nil
 }
 
@@ -702,8 +754,8 @@ func (c *rolloutContext) getReferencedAnalysisTemplate(template v1alpha1.Rollout
 
 func (c *rolloutContext) getReferencedIngresses() (*[]v1beta1.Ingress, error) {
 	ingresses := []v1beta1.Ingress{}
-	canary := c.rollout.Spec.Strategy.Canary
-	fldPath := field.NewPath("spec", "strategy", "canary", "trafficRouting")
+	canary := trafficroutingutil.GetTrafficRoutingRollout(c.rollout).Spec.Strategy.Canary
+	fldPath := trafficroutingutil.GetTrafficRoutingFieldPath(c.rollout)
 	if canary != nil && canary.TrafficRouting != nil {
 		if canary.TrafficRouting.ALB != nil {
 			ingress, err := c.ingressesLister.Ingresses(c.rollout.Namespace).Get(canary.TrafficRouting.ALB.Ingress)
@@ -731,9 +783,8 @@ func (c *rolloutContext) getReferencedIngresses() (*[]v1beta1.Ingress, error) {
 func (c *rolloutContext) getReferencedVirtualServices() (*[]unstructured.Unstructured, error) {
 	ctx := context.TODO()
 	virtualServices := []unstructured.Unstructured{}
-	fldPath := field.NewPath("spec", "strategy", "canary", "trafficRouting", "istio", "virtualService", "name")
-	if c.rollout.Spec.Strategy.Canary != nil {
-		canary := c.rollout.Spec.Strategy.Canary
+	fldPath := trafficroutingutil.GetTrafficRoutingFieldPath(c.rollout).Child("istio", "virtualService", "name")
+	if canary := trafficroutingutil.GetTrafficRoutingRollout(c.rollout).Spec.Strategy.Canary; canary != nil {
 		if canary.TrafficRouting != nil && canary.TrafficRouting.Istio != nil {
 			var vsvc *unstructured.Unstructured
 			var err error
@@ -756,6 +807,25 @@ func (c *rolloutContext) getReferencedVirtualServices() (*[]unstructured.Unstruc
 	return &virtualServices, nil
 }
 
+func (c *rolloutContext) getReferencedHTTPRoutes() (*[]unstructured.Unstructured, error) {
+	httpRoutes := []unstructured.Unstructured{}
+	fldPath := trafficroutingutil.GetTrafficRoutingFieldPath(c.rollout).Child("gatewayAPI", "httpRoute")
+	if canary := trafficroutingutil.GetTrafficRoutingRollout(c.rollout).Spec.Strategy.Canary; canary != nil {
+		if canary.TrafficRouting != nil && canary.TrafficRouting.GatewayAPI != nil {
+			routeName := canary.TrafficRouting.GatewayAPI.HTTPRoute
+			route, err := c.dynamicclientset.Resource(gatewayapi.GetHTTPRouteGVR()).Namespace(c.rollout.Namespace).Get(context.TODO(), routeName, metav1.GetOptions{})
+			if k8serrors.IsNotFound(err) {
+				return nil, field.Invalid(fldPath, routeName, err.Error())
+			}
+			if err != nil {
+				return nil, err
+			}
+			httpRoutes = append(httpRoutes, *route)
+		}
+	}
+	return &httpRoutes, nil
+}
+
 func remarshalRollout(r *v1alpha1.Rollout) *v1alpha1.Rollout {
 	rolloutBytes, err := json.Marshal(r)
 	if err != nil {
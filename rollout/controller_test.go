@@ -91,6 +91,8 @@ type fixture struct {
 	replicaSetLister              []*appsv1.ReplicaSet
 	serviceLister                 []*corev1.Service
 	ingressLister                 []*extensionsv1beta1.Ingress
+	deploymentLister              []*appsv1.Deployment
+	podTemplateLister             []*corev1.PodTemplate
 	// Actions expected to happen on the client.
 	kubeactions []core.Action
 	actions     []core.Action
@@ -439,6 +441,8 @@ func (f *fixture) newController(resync resyncFunc) (*Controller, informers.Share
 		ReplicaSetInformer:              k8sI.Apps().V1().ReplicaSets(),
 		ServicesInformer:                k8sI.Core().V1().Services(),
 		IngressInformer:                 k8sI.Extensions().V1beta1().Ingresses(),
+		DeploymentInformer:              k8sI.Apps().V1().Deployments(),
+		PodTemplateInformer:             k8sI.Core().V1().PodTemplates(),
 		RolloutsInformer:                i.Argoproj().V1alpha1().Rollouts(),
 		IstioVirtualServiceInformer:     istioVirtualServiceInformer,
 		ResyncPeriod:                    resync(),
@@ -492,6 +496,12 @@ func (f *fixture) newController(resync resyncFunc) (*Controller, informers.Share
 	for _, i := range f.ingressLister {
 		k8sI.Extensions().V1beta1().Ingresses().Informer().GetIndexer().Add(i)
 	}
+	for _, d := range f.deploymentLister {
+		k8sI.Apps().V1().Deployments().Informer().GetIndexer().Add(d)
+	}
+	for _, pt := range f.podTemplateLister {
+		k8sI.Core().V1().PodTemplates().Informer().GetIndexer().Add(pt)
+	}
 	for _, at := range f.analysisTemplateLister {
 		i.Argoproj().V1alpha1().AnalysisTemplates().Informer().GetIndexer().Add(at)
 	}
@@ -608,7 +618,11 @@ func filterInformerActions(actions []core.Action) []core.Action {
 			action.Matches("list", "services") ||
 			action.Matches("watch", "services") ||
 			action.Matches("list", "ingresses") ||
-			action.Matches("watch", "ingresses") {
+			action.Matches("watch", "ingresses") ||
+			action.Matches("list", "deployments") ||
+			action.Matches("watch", "deployments") ||
+			action.Matches("list", "podtemplates") ||
+			action.Matches("watch", "podtemplates") {
 			continue
 		}
 		ret = append(ret, action)
@@ -1489,6 +1503,81 @@ func TestGetReferencedVirtualServices(t *testing.T) {
 	})
 }
 
+func TestGetReferencedHTTPRoutes(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	r := newCanaryRollout("rollout", 1, nil, nil, nil, intstr.FromInt(0), intstr.FromInt(1))
+	r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+		GatewayAPI: &v1alpha1.GatewayAPITrafficRouting{
+			HTTPRoute: "httproute",
+		},
+	}
+	r.Namespace = metav1.NamespaceDefault
+
+	t.Run("get referenced httpRoute - fail", func(t *testing.T) {
+		c, _, _ := f.newController(noResyncPeriodFunc)
+		c.dynamicclientset = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		roCtx, err := c.newRolloutContext(r)
+		assert.NoError(t, err)
+		_, err = roCtx.getReferencedHTTPRoutes()
+		expectedErr := field.Invalid(field.NewPath("spec", "strategy", "canary", "trafficRouting", "gatewayAPI", "httpRoute"), "httproute", "httproutes.gateway.networking.k8s.io \"httproute\" not found")
+		assert.Equal(t, expectedErr.Error(), err.Error())
+	})
+
+	t.Run("get referenced httpRoute - success", func(t *testing.T) {
+		c, _, _ := f.newController(noResyncPeriodFunc)
+		route := &unstructured.Unstructured{}
+		route.SetAPIVersion("gateway.networking.k8s.io/v1beta1")
+		route.SetKind("HTTPRoute")
+		route.SetName("httproute")
+		route.SetNamespace(metav1.NamespaceDefault)
+		c.dynamicclientset = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), route)
+		roCtx, err := c.newRolloutContext(r)
+		assert.NoError(t, err)
+		httpRoutes, err := roCtx.getReferencedHTTPRoutes()
+		assert.NoError(t, err)
+		assert.Len(t, *httpRoutes, 1)
+	})
+}
+
+func TestGetReferencedBlueGreenTrafficRouting(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	r := newBlueGreenRollout("rollout", 1, nil, "active", "preview")
+	r.Spec.Strategy.BlueGreen.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+		Nginx: &v1alpha1.NginxTrafficRouting{
+			StableIngress: "nginx-ingress-name",
+		},
+		Istio: &v1alpha1.IstioTrafficRouting{
+			VirtualService: v1alpha1.IstioVirtualService{
+				Name: "istio-vsvc-name",
+			},
+		},
+		GatewayAPI: &v1alpha1.GatewayAPITrafficRouting{
+			HTTPRoute: "httproute",
+		},
+	}
+	r.Namespace = metav1.NamespaceDefault
+	fldPath := field.NewPath("spec", "strategy", "blueGreen", "trafficRouting")
+
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	c.dynamicclientset = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	roCtx, err := c.newRolloutContext(r)
+	assert.NoError(t, err)
+
+	_, err = roCtx.getReferencedIngresses()
+	expectedErr := field.Invalid(fldPath.Child("nginx", "stableIngress"), "nginx-ingress-name", "ingress.extensions \"nginx-ingress-name\" not found")
+	assert.Equal(t, expectedErr.Error(), err.Error())
+
+	_, err = roCtx.getReferencedVirtualServices()
+	expectedErr = field.Invalid(fldPath.Child("istio", "virtualService", "name"), "istio-vsvc-name", "virtualservices.networking.istio.io \"istio-vsvc-name\" not found")
+	assert.Equal(t, expectedErr.Error(), err.Error())
+
+	_, err = roCtx.getReferencedHTTPRoutes()
+	expectedErr = field.Invalid(fldPath.Child("gatewayAPI", "httpRoute"), "httproute", "httproutes.gateway.networking.k8s.io \"httproute\" not found")
+	assert.Equal(t, expectedErr.Error(), err.Error())
+}
+
 func TestRolloutStrategyNotSet(t *testing.T) {
 	f := newFixture(t)
 	defer f.Close()
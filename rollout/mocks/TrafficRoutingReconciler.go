@@ -2,13 +2,59 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	mock "github.com/stretchr/testify/mock"
+
+	v1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
 
 // TrafficRoutingReconciler is an autogenerated mock type for the TrafficRoutingReconciler type
 type TrafficRoutingReconciler struct {
 	mock.Mock
 }
 
+// RemoveManagedRoutes provides a mock function with given fields:
+func (_m *TrafficRoutingReconciler) RemoveManagedRoutes() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetHeaderRoute provides a mock function with given fields: headerRoute
+func (_m *TrafficRoutingReconciler) SetHeaderRoute(headerRoute *v1alpha1.SetHeaderRoute) error {
+	ret := _m.Called(headerRoute)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*v1alpha1.SetHeaderRoute) error); ok {
+		r0 = rf(headerRoute)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetMirrorRoute provides a mock function with given fields: mirrorRoute
+func (_m *TrafficRoutingReconciler) SetMirrorRoute(mirrorRoute *v1alpha1.SetMirrorRoute) error {
+	ret := _m.Called(mirrorRoute)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*v1alpha1.SetMirrorRoute) error); ok {
+		r0 = rf(mirrorRoute)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetWeight provides a mock function with given fields: desiredWeight
 func (_m *TrafficRoutingReconciler) SetWeight(desiredWeight int32) error {
 	ret := _m.Called(desiredWeight)
@@ -172,8 +172,16 @@ func (pCtx *pauseContext) CompletedBlueGreenPause() bool {
 }
 
 func (pCtx *pauseContext) CompletedPauseStep(pause v1alpha1.RolloutPause) bool {
+	return pCtx.completedPause(pause, v1alpha1.PauseReasonCanaryPauseStep)
+}
+
+func (pCtx *pauseContext) CompletedBlueGreenTrafficPauseStep(pause v1alpha1.RolloutPause) bool {
+	return pCtx.completedPause(pause, v1alpha1.PauseReasonBlueGreenTrafficStep)
+}
+
+func (pCtx *pauseContext) completedPause(pause v1alpha1.RolloutPause, reason v1alpha1.PauseReason) bool {
 	rollout := pCtx.rollout
-	pauseCondition := getPauseCondition(rollout, v1alpha1.PauseReasonCanaryPauseStep)
+	pauseCondition := getPauseCondition(rollout, reason)
 
 	if pause.Duration != nil {
 		now := metav1.Now()
@@ -94,6 +94,10 @@ func (c *rolloutContext) reconcileActiveService(previewSvc, activeSvc *corev1.Se
 	if c.pauseContext.CompletedBlueGreenPause() && c.completedPrePromotionAnalysis() {
 		newPodHash = c.newRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
 	}
+	if newPodHash != activeSvc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey] && !c.completedBlueGreenTrafficSteps(activeSvc) {
+		c.log.Info("Waiting for the traffic steps to complete before switching the active service")
+		newPodHash = activeSvc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey]
+	}
 
 	if c.rollout.Status.Abort {
 		newPodHash = c.rollout.Status.StableRS
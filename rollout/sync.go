@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -86,12 +87,12 @@ func (c *rolloutContext) syncReplicaSetRevision() (*appsv1.ReplicaSet, error) {
 
 	// Should use the revision in existingNewRS's annotation, since it set by before
 	if annotations.SetRolloutRevision(c.rollout, rsCopy.Annotations[annotations.RevisionAnnotation]) {
-		updatedRollout, err := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(c.rollout.Namespace).Update(ctx, c.rollout, metav1.UpdateOptions{})
+		updatedRollout, err := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(c.rollout.Namespace).Update(ctx, c.persistedRollout(), metav1.UpdateOptions{})
 		if err != nil {
 			c.log.WithError(err).Error("Error: updating rollout revision")
 			return nil, err
 		}
-		c.rollout = updatedRollout
+		c.setUpdatedRollout(updatedRollout)
 		c.newRollout = updatedRollout
 		c.log.Infof("Updated rollout revision annotation to %s", rsCopy.Annotations[annotations.RevisionAnnotation])
 	}
@@ -104,12 +105,12 @@ func (c *rolloutContext) syncReplicaSetRevision() (*appsv1.ReplicaSet, error) {
 		msg := fmt.Sprintf(conditions.FoundNewRSMessage, rsCopy.Name)
 		condition := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionTrue, conditions.FoundNewRSReason, msg)
 		conditions.SetRolloutCondition(&c.rollout.Status, *condition)
-		updatedRollout, err := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(c.rollout.Namespace).UpdateStatus(ctx, c.rollout, metav1.UpdateOptions{})
+		updatedRollout, err := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(c.rollout.Namespace).UpdateStatus(ctx, c.persistedRollout(), metav1.UpdateOptions{})
 		if err != nil {
 			c.log.WithError(err).Error("Error: updating rollout revision")
 			return nil, err
 		}
-		c.rollout = updatedRollout
+		c.setUpdatedRollout(updatedRollout)
 		c.newRollout = updatedRollout
 		c.log.Infof("Initialized Progressing condition: %v", condition)
 	}
@@ -205,7 +206,7 @@ func (c *rolloutContext) createDesiredReplicaSet() (*appsv1.ReplicaSet, error) {
 		*c.rollout.Status.CollisionCount++
 		// Update the collisionCount for the Rollout and let it requeue by returning the original
 		// error.
-		_, roErr := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(c.rollout.Namespace).UpdateStatus(ctx, c.rollout, metav1.UpdateOptions{})
+		_, roErr := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(c.rollout.Namespace).UpdateStatus(ctx, c.persistedRollout(), metav1.UpdateOptions{})
 		if roErr == nil {
 			c.log.Warnf("Found a hash collision - bumped collisionCount (%d->%d) to resolve it", preCollisionCount, *c.rollout.Status.CollisionCount)
 		}
@@ -229,22 +230,22 @@ func (c *rolloutContext) createDesiredReplicaSet() (*appsv1.ReplicaSet, error) {
 	}
 
 	if annotations.SetRolloutRevision(c.rollout, newRevision) {
-		updatedRollout, err := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(c.rollout.Namespace).Update(ctx, c.rollout, metav1.UpdateOptions{})
+		updatedRollout, err := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(c.rollout.Namespace).Update(ctx, c.persistedRollout(), metav1.UpdateOptions{})
 		if err != nil {
 			return nil, err
 		}
-		c.rollout = updatedRollout
+		c.setUpdatedRollout(updatedRollout)
 		c.log.Infof("Updated rollout revision to %s", c.rollout.Annotations[annotations.RevisionAnnotation])
 	}
 	if !alreadyExists {
 		msg := fmt.Sprintf(conditions.NewReplicaSetMessage, createdRS.Name)
 		condition := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionTrue, conditions.NewReplicaSetReason, msg)
 		conditions.SetRolloutCondition(&c.rollout.Status, *condition)
-		updatedRollout, err := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(c.rollout.Namespace).UpdateStatus(ctx, c.rollout, metav1.UpdateOptions{})
+		updatedRollout, err := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(c.rollout.Namespace).UpdateStatus(ctx, c.persistedRollout(), metav1.UpdateOptions{})
 		if err != nil {
 			return nil, err
 		}
-		c.rollout = updatedRollout
+		c.setUpdatedRollout(updatedRollout)
 		c.newRollout = updatedRollout
 		c.log.Infof("Set rollout condition: %v", condition)
 	}
@@ -409,6 +410,13 @@ func (c *rolloutContext) calculateBaseStatus() v1alpha1.RolloutStatus {
 	newStatus.CollisionCount = c.rollout.Status.CollisionCount
 	newStatus.Conditions = prevStatus.Conditions
 	newStatus.RestartedAt = c.newStatus.RestartedAt
+	if newStatus.Canary.Weights == nil && c.rollout.Spec.Strategy.Canary != nil && c.rollout.Spec.Strategy.Canary.DynamicStableScale {
+		// the traffic routing is not reconciled on every sync, the weights last set are kept
+		newStatus.Canary.Weights = prevStatus.Canary.Weights
+	}
+	if newStatus.BlueGreen.CurrentTrafficStepIndex == nil {
+		newStatus.BlueGreen.CurrentTrafficStepIndex = prevStatus.BlueGreen.CurrentTrafficStepIndex
+	}
 	newStatus.PromoteFull = (newStatus.CurrentPodHash != newStatus.StableRS) && prevStatus.PromoteFull
 	return newStatus
 }
@@ -469,6 +477,74 @@ func (c *rolloutContext) cleanupRollouts(oldRSs []*appsv1.ReplicaSet) error {
 	return nil
 }
 
+// isRollbackWithinWindow returns true if the newRS is a revision older than the stable RS which is
+// still inside the rollback window of the rollout. The ReplicaSets whose revision is between the last
+// revision of the newRS and the revision of the stable RS determine how many revisions ago the newRS
+// was replaced, and the first of them how long ago.
+func (c *rolloutContext) isRollbackWithinWindow() bool {
+	window := c.rollout.Spec.RollbackWindow
+	if window == nil || c.newRS == nil || c.stableRS == nil {
+		return false
+	}
+	if replicasetutil.GetPodTemplateHash(c.newRS) == c.rollout.Status.StableRS {
+		return false
+	}
+	stableRevision := replicasetutil.GetReplicaSetRevision(c.rollout, c.stableRS)
+	newRevision := replicasetutil.GetReplicaSetRevision(c.rollout, c.newRS)
+	if newRevision > stableRevision {
+		// the revision of a ReplicaSet is bumped when rolling back to it, the revision it last served
+		// is kept in its revision history
+		newRevision = lastHistoryRevision(c.newRS)
+	}
+	if newRevision < 0 || newRevision >= stableRevision {
+		// moving forward to a newer revision is not a rollback
+		return false
+	}
+	revisions := int32(0)
+	// the newRS was replaced when the revision following it was rolled out, which for a ReplicaSet
+	// rolled back to is when its revision was bumped rather than when it was created
+	replacedBy := c.stableRS
+	replacedByRevision := stableRevision
+	for _, rs := range c.allRSs {
+		revision := replicasetutil.GetReplicaSetRevision(c.rollout, rs)
+		if revision > newRevision && revision <= stableRevision {
+			revisions++
+			if revision < replacedByRevision {
+				replacedBy = rs
+				replacedByRevision = revision
+			}
+		}
+	}
+	replacedAt := annotations.GetRevisionTimestamp(replacedBy)
+	if window.Revisions > 0 && revisions <= window.Revisions {
+		return true
+	}
+	if window.Duration != "" {
+		duration, err := window.Duration.Duration()
+		if err != nil {
+			c.log.Warnf("Invalid rollback window duration '%s': %v", window.Duration, err)
+			return false
+		}
+		return time.Since(replacedAt.Time) <= duration
+	}
+	return false
+}
+
+// lastHistoryRevision returns the last revision of the revision history of the ReplicaSet, or -1 if
+// the ReplicaSet has no revision history
+func lastHistoryRevision(rs *appsv1.ReplicaSet) int {
+	history := rs.Annotations[annotations.RevisionHistoryAnnotation]
+	if history == "" {
+		return -1
+	}
+	revisions := strings.Split(history, ",")
+	revision, err := strconv.Atoi(revisions[len(revisions)-1])
+	if err != nil {
+		return -1
+	}
+	return revision
+}
+
 // checkPausedConditions checks if the given rollout is paused or not and adds an appropriate condition.
 // These conditions are needed so that we won't accidentally report lack of progress for resumed rollouts
 // that were paused for longer than progressDeadlineSeconds.
@@ -604,8 +680,18 @@ func (c *rolloutContext) calculateRolloutConditions(newStatus v1alpha1.RolloutSt
 			if c.newRS != nil {
 				msg = fmt.Sprintf(conditions.ReplicaSetTimeOutMessage, c.newRS.Name)
 			}
-			condition := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionFalse, conditions.TimedOutReason, msg)
-			conditions.SetRolloutCondition(&newStatus, *condition)
+			if c.rollout.Spec.ProgressDeadlineAbort {
+				// Abort the update like a failed analysis does, so the canary is scaled down and
+				// traffic is shifted back to the stable version without waiting for a human
+				msg = fmt.Sprintf(conditions.RolloutAbortedTimeOutMessage, msg)
+				c.pauseContext.AddAbort(msg)
+				c.recorder.Event(c.rollout, corev1.EventTypeWarning, conditions.RolloutAbortedReason, msg)
+				condition := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionFalse, conditions.RolloutAbortedReason, msg)
+				conditions.SetRolloutCondition(&newStatus, *condition)
+			} else {
+				condition := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionFalse, conditions.TimedOutReason, msg)
+				conditions.SetRolloutCondition(&newStatus, *condition)
+			}
 		}
 	}
 
@@ -661,6 +747,9 @@ func (c *rolloutContext) persistRolloutStatus(newStatus *v1alpha1.RolloutStatus)
 	}
 	logCtx.Infof("Patched: %s", patch)
 	c.newRollout = newRollout
+	if c.notifier != nil {
+		c.notifier.Notify(c.rollout, newRollout)
+	}
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package rollout
 
 import (
+	"fmt"
 	"strconv"
 	"testing"
 	"time"
@@ -14,9 +15,11 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 
+	"github.com/argoproj/argo-rollouts/notifications"
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
 	"github.com/argoproj/argo-rollouts/utils/annotations"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
 	"github.com/stretchr/testify/assert"
 )
@@ -322,3 +325,102 @@ func TestBlueGreenPromoteFull(t *testing.T) {
 	assert.Equal(t, rs2PodHash, patchedRollout.Status.BlueGreen.ActiveSelector)
 	assert.False(t, patchedRollout.Status.PromoteFull)
 }
+
+type fakeNotifier struct {
+	events []notifications.Event
+}
+
+func (n *fakeNotifier) Notify(old, new *v1alpha1.Rollout) {
+	n.events = append(n.events, notifications.GetRolloutEvents(old, new)...)
+}
+
+func TestPersistRolloutStatusNotifies(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{
+		{
+			Pause: &v1alpha1.RolloutPause{},
+		},
+	}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r2 := bumpVersion(r1)
+
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	rs2 := newReplicaSetWithStatus(r2, 0, 0)
+	f.kubeobjects = append(f.kubeobjects, rs1, rs2)
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2)
+
+	r2 = updateCanaryRolloutStatus(r2, rs1PodHash, 10, 0, 10, false)
+
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.objects = append(f.objects, r2)
+
+	f.expectPatchRolloutAction(r2)
+	c, i, k8sI := f.newController(noResyncPeriodFunc)
+	notifier := &fakeNotifier{}
+	c.notifier = notifier
+	f.runController(getKey(r2, t), true, false, c, i, k8sI)
+
+	assert.Len(t, notifier.events, 1)
+	assert.Equal(t, notifications.TriggerPaused, notifier.events[0].Trigger)
+}
+
+func TestProgressDeadlineAbort(t *testing.T) {
+	newTimedOutRollout := func(progressDeadlineAbort bool) *v1alpha1.Rollout {
+		steps := []v1alpha1.CanaryStep{{SetWeight: pointer.Int32Ptr(10)}}
+		r := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+		r.Spec.ProgressDeadlineSeconds = pointer.Int32Ptr(10)
+		r.Spec.ProgressDeadlineAbort = progressDeadlineAbort
+		r.Status.Conditions = []v1alpha1.RolloutCondition{{
+			Type:           v1alpha1.RolloutProgressing,
+			Status:         corev1.ConditionTrue,
+			Reason:         conditions.ReplicaSetUpdatedReason,
+			LastUpdateTime: metav1.NewTime(metav1.Now().Add(-time.Minute)),
+		}}
+		return r
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		f := newFixture(t)
+		defer f.Close()
+		c, _, _ := f.newController(noResyncPeriodFunc)
+		r := newTimedOutRollout(false)
+		roCtx, err := c.newRolloutContext(r)
+		assert.NoError(t, err)
+
+		newStatus := roCtx.calculateRolloutConditions(r.Status)
+		cond := conditions.GetRolloutCondition(newStatus, v1alpha1.RolloutProgressing)
+		assert.Equal(t, conditions.TimedOutReason, cond.Reason)
+		assert.False(t, roCtx.pauseContext.IsAborted())
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		f := newFixture(t)
+		defer f.Close()
+		c, _, _ := f.newController(noResyncPeriodFunc)
+		r := newTimedOutRollout(true)
+		roCtx, err := c.newRolloutContext(r)
+		assert.NoError(t, err)
+
+		newStatus := roCtx.calculateRolloutConditions(r.Status)
+		cond := conditions.GetRolloutCondition(newStatus, v1alpha1.RolloutProgressing)
+		expectedMsg := fmt.Sprintf(conditions.RolloutAbortedTimeOutMessage, fmt.Sprintf(conditions.RolloutTimeOutMessage, r.Name))
+		assert.Equal(t, conditions.RolloutAbortedReason, cond.Reason)
+		assert.Equal(t, corev1.ConditionFalse, cond.Status)
+		assert.Equal(t, expectedMsg, cond.Message)
+		assert.True(t, roCtx.pauseContext.IsAborted())
+		roCtx.pauseContext.CalculatePauseStatus(&newStatus)
+		assert.True(t, newStatus.Abort)
+
+		// the reason is preserved by the following reconciliations of the aborted rollout
+		r.Status = newStatus
+		roCtx, err = c.newRolloutContext(r)
+		assert.NoError(t, err)
+		newStatus = roCtx.calculateRolloutConditions(r.Status)
+		cond = conditions.GetRolloutCondition(newStatus, v1alpha1.RolloutProgressing)
+		assert.Equal(t, conditions.RolloutAbortedReason, cond.Reason)
+		assert.Equal(t, expectedMsg, cond.Message)
+	})
+}
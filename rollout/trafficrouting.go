@@ -5,12 +5,20 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/alb"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/ambassador"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/appmesh"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/gatewayapi"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/istio"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/nginx"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/plugin"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/smi"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/traefik"
 
+	"github.com/argoproj/argo-rollouts/utils/defaults"
 	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+	trafficroutingutil "github.com/argoproj/argo-rollouts/utils/trafficrouting"
 )
 
 // TrafficRoutingReconciler common function across all TrafficRouting implementation
@@ -19,14 +27,22 @@ type TrafficRoutingReconciler interface {
 	SetWeight(desiredWeight int32) error
 	// VerifyWeight returns true if the canary is at the desired weight
 	VerifyWeight(desiredWeight int32) (bool, error)
+	// SetHeaderRoute adds or updates the managed route sending requests with matching headers or
+	// cookies to the canary, or removes the route when it has no match conditions
+	SetHeaderRoute(headerRoute *v1alpha1.SetHeaderRoute) error
+	// SetMirrorRoute adds or updates the managed route mirroring matching requests to the canary, or
+	// removes the route when it has no match conditions
+	SetMirrorRoute(mirrorRoute *v1alpha1.SetMirrorRoute) error
+	// RemoveManagedRoutes removes all the routes managed by the controller
+	RemoveManagedRoutes() error
 	// Type returns the type of the traffic routing reconciler
 	Type() string
 }
 
 // NewTrafficRoutingReconciler identifies return the TrafficRouting Plugin that the rollout wants to modify
 func (c *Controller) NewTrafficRoutingReconciler(roCtx *rolloutContext) (TrafficRoutingReconciler, error) {
-	rollout := roCtx.rollout
-	if rollout.Spec.Strategy.Canary.TrafficRouting == nil {
+	rollout := trafficroutingutil.GetTrafficRoutingRollout(roCtx.rollout)
+	if rollout.Spec.Strategy.Canary == nil || rollout.Spec.Strategy.Canary.TrafficRouting == nil {
 		return nil, nil
 	}
 	if rollout.Spec.Strategy.Canary.TrafficRouting.Istio != nil {
@@ -63,6 +79,53 @@ func (c *Controller) NewTrafficRoutingReconciler(roCtx *rolloutContext) (Traffic
 			ApiVersion:     c.defaultTrafficSplitVersion,
 		})
 	}
+	if rollout.Spec.Strategy.Canary.TrafficRouting.Ambassador != nil {
+		return ambassador.NewReconciler(ambassador.ReconcilerConfig{
+			Rollout:        rollout,
+			Client:         c.dynamicclientset,
+			Recorder:       c.recorder,
+			ControllerKind: controllerKind,
+		}), nil
+	}
+	if rollout.Spec.Strategy.Canary.TrafficRouting.GatewayAPI != nil {
+		return gatewayapi.NewReconciler(gatewayapi.ReconcilerConfig{
+			Rollout:  rollout,
+			Client:   c.dynamicclientset,
+			Recorder: c.recorder,
+		}), nil
+	}
+	if rollout.Spec.Strategy.Canary.TrafficRouting.Traefik != nil {
+		return traefik.NewReconciler(traefik.ReconcilerConfig{
+			Rollout:  rollout,
+			Client:   c.dynamicclientset,
+			Recorder: c.recorder,
+		}), nil
+	}
+	if rollout.Spec.Strategy.Canary.TrafficRouting.AppMesh != nil {
+		var canaryHash string
+		if roCtx.newRS != nil {
+			canaryHash = replicasetutil.GetPodTemplateHash(roCtx.newRS)
+		}
+		return appmesh.NewReconciler(appmesh.ReconcilerConfig{
+			Rollout:        rollout,
+			Client:         c.dynamicclientset,
+			Recorder:       c.recorder,
+			ControllerKind: controllerKind,
+			StableHash:     rollout.Status.StableRS,
+			CanaryHash:     canaryHash,
+		}), nil
+	}
+	if rollout.Spec.Strategy.Canary.TrafficRouting.Plugin != nil {
+		client, err := plugin.NewClient(rollout.Spec.Strategy.Canary.TrafficRouting.Plugin.Name)
+		if err != nil {
+			return nil, err
+		}
+		return plugin.NewReconciler(plugin.ReconcilerConfig{
+			Rollout:  rollout,
+			Client:   client,
+			Recorder: c.recorder,
+		}), nil
+	}
 	return nil, nil
 }
 
@@ -80,6 +143,9 @@ func (c *rolloutContext) reconcileTrafficRouting() error {
 	desiredWeight := int32(0)
 	if c.rollout.Status.StableRS == c.rollout.Status.CurrentPodHash {
 		// when we are fully promoted. desired canary weight should be 0
+	} else if c.pauseContext.IsAborted() && c.rollout.Spec.Strategy.Canary.DynamicStableScale {
+		// when aborted, only shift as much traffic back to the stable as it can handle
+		desiredWeight = c.calculateDesiredWeightOnAbort()
 	} else if index != nil {
 		atDesiredReplicaCount := replicasetutil.AtDesiredReplicaCountsForCanary(c.rollout, c.newRS, c.stableRS, c.otherRSs)
 		if !atDesiredReplicaCount {
@@ -99,14 +165,37 @@ func (c *rolloutContext) reconcileTrafficRouting() error {
 			// weight of the traffic routing service should be at the value of the
 			// last setWeight step, which is set by GetCurrentSetWeight.
 			desiredWeight = replicasetutil.GetCurrentSetWeight(c.rollout)
+		} else if c.rollout.Spec.Strategy.Canary.DynamicStableScale {
+			// With dynamic stable scaling, the stable may already be scaled down. All traffic is
+			// kept on the canary until the stable is switched to the new RS, otherwise the traffic
+			// would be sent back to a stable which cannot handle it.
+			desiredWeight = 100
 		}
 	}
 
+	trafficRouting := c.rollout.Spec.Strategy.Canary.TrafficRouting
+	hasManagedRoutes := trafficRouting != nil && len(trafficRouting.ManagedRoutes) > 0
+	if hasManagedRoutes && (c.rollout.Status.StableRS == c.rollout.Status.CurrentPodHash || c.pauseContext.IsAborted()) {
+		// managed routes only exist while an update is in progress
+		err = reconciler.RemoveManagedRoutes()
+	} else if currentStep != nil && currentStep.SetHeaderRoute != nil {
+		err = reconciler.SetHeaderRoute(currentStep.SetHeaderRoute)
+	} else if currentStep != nil && currentStep.SetMirrorRoute != nil {
+		err = reconciler.SetMirrorRoute(currentStep.SetMirrorRoute)
+	}
+	if err != nil {
+		c.recorder.Event(c.rollout, corev1.EventTypeWarning, "TrafficRoutingError", err.Error())
+		return err
+	}
+
 	err = reconciler.SetWeight(desiredWeight)
 	if err != nil {
 		c.recorder.Event(c.rollout, corev1.EventTypeWarning, "TrafficRoutingError", err.Error())
 		return err
 	}
+	if c.rollout.Spec.Strategy.Canary.DynamicStableScale {
+		c.setTrafficWeights(desiredWeight)
+	}
 
 	// If we are at a setWeight step, also perform weight verification. Note that we don't do this
 	// every reconciliation because weight verification typically involves API calls to the cloud
@@ -127,3 +216,43 @@ func (c *rolloutContext) reconcileTrafficRouting() error {
 
 	return nil
 }
+
+// setTrafficWeights records the weights set by the traffic routing, which are used to scale the
+// stable dynamically
+func (c *rolloutContext) setTrafficWeights(desiredWeight int32) {
+	var canaryHash string
+	if c.newRS != nil {
+		canaryHash = replicasetutil.GetPodTemplateHash(c.newRS)
+	}
+	c.newStatus.Canary.Weights = &v1alpha1.TrafficWeights{
+		Canary: v1alpha1.WeightDestination{
+			Weight:          desiredWeight,
+			ServiceName:     c.rollout.Spec.Strategy.Canary.CanaryService,
+			PodTemplateHash: canaryHash,
+		},
+		Stable: v1alpha1.WeightDestination{
+			Weight:          100 - desiredWeight,
+			ServiceName:     c.rollout.Spec.Strategy.Canary.StableService,
+			PodTemplateHash: c.rollout.Status.StableRS,
+		},
+	}
+}
+
+// calculateDesiredWeightOnAbort returns the canary weight of an aborted rollout using dynamic stable
+// scaling. The stable is scaled down during the update, so the traffic is shifted back to it only
+// as its replicas become available. The canary weight never increases.
+func (c *rolloutContext) calculateDesiredWeightOnAbort() int32 {
+	replicas := defaults.GetReplicasOrDefault(c.rollout.Spec.Replicas)
+	if c.stableRS == nil || replicas == 0 {
+		return 0
+	}
+	availableStableWeight := c.stableRS.Status.AvailableReplicas * 100 / replicas
+	if availableStableWeight > 100 {
+		availableStableWeight = 100
+	}
+	desiredWeight := 100 - availableStableWeight
+	if weights := c.rollout.Status.Canary.Weights; weights != nil && weights.Canary.Weight < desiredWeight {
+		desiredWeight = weights.Canary.Weight
+	}
+	return desiredWeight
+}
@@ -220,3 +220,18 @@ func getDesiredAnnotations(current *extensionsv1beta1.Ingress, r *v1alpha1.Rollo
 	desired[ingressutil.ManagedActionsAnnotation] = m.String()
 	return desired, nil
 }
+
+// SetHeaderRoute is a no-op since header routes are not supported by the AWS Load Balancer Controller
+func (r *Reconciler) SetHeaderRoute(headerRoute *v1alpha1.SetHeaderRoute) error {
+	return nil
+}
+
+// SetMirrorRoute is a no-op since mirror routes are not supported by the AWS Load Balancer Controller
+func (r *Reconciler) SetMirrorRoute(mirrorRoute *v1alpha1.SetMirrorRoute) error {
+	return nil
+}
+
+// RemoveManagedRoutes is a no-op since managed routes are not supported by the AWS Load Balancer Controller
+func (r *Reconciler) RemoveManagedRoutes() error {
+	return nil
+}
@@ -0,0 +1,241 @@
+package ambassador
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+const (
+	// Type holds this controller type
+	Type = "Ambassador"
+
+	// canaryMappingSuffix is appended to the name of a Mapping to name its canary Mapping
+	canaryMappingSuffix = "-canary"
+)
+
+var (
+	defaultAPIVersion = "v2"
+)
+
+// SetDefaultAPIVersion sets the apiVersion of the getambassador.io group used to manage Mappings.
+// Both v2 and v3alpha1 are supported.
+func SetDefaultAPIVersion(version string) {
+	defaultAPIVersion = version
+}
+
+// GetMappingGVR returns the GroupVersionResource of the Ambassador Mappings
+func GetMappingGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "getambassador.io",
+		Version:  defaultAPIVersion,
+		Resource: "mappings",
+	}
+}
+
+// ReconcilerConfig describes static configuration data for the Ambassador reconciler
+type ReconcilerConfig struct {
+	Rollout        *v1alpha1.Rollout
+	Client         dynamic.Interface
+	Recorder       record.EventRecorder
+	ControllerKind schema.GroupVersionKind
+}
+
+// Reconciler holds required fields to reconcile Ambassador resources
+type Reconciler struct {
+	cfg ReconcilerConfig
+	log *logrus.Entry
+}
+
+// NewReconciler returns a reconciler struct that brings the canary Mappings into the desired state
+func NewReconciler(cfg ReconcilerConfig) *Reconciler {
+	return &Reconciler{
+		cfg: cfg,
+		log: logutil.WithRollout(cfg.Rollout),
+	}
+}
+
+// Type indicates this reconciler is an Ambassador reconciler
+func (r *Reconciler) Type() string {
+	return Type
+}
+
+func (r *Reconciler) mappingClient() dynamic.ResourceInterface {
+	return r.cfg.Client.Resource(GetMappingGVR()).Namespace(r.cfg.Rollout.Namespace)
+}
+
+// SetWeight creates or updates a canary Mapping with the desired weight for each of the Mappings of
+// the rollout. The canary Mappings are deleted once the desired weight is 0, which happens when the
+// rollout is fully promoted or aborted.
+func (r *Reconciler) SetWeight(desiredWeight int32) error {
+	ctx := context.TODO()
+	for _, mappingName := range r.cfg.Rollout.Spec.Strategy.Canary.TrafficRouting.Ambassador.Mappings {
+		err := r.reconcileCanaryMapping(ctx, mappingName, desiredWeight)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) reconcileCanaryMapping(ctx context.Context, mappingName string, desiredWeight int32) error {
+	client := r.mappingClient()
+	canaryMappingName := mappingName + canaryMappingSuffix
+	canaryMapping, err := client.Get(ctx, canaryMappingName, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if desiredWeight == 0 {
+		if !exists {
+			return nil
+		}
+		msg := fmt.Sprintf("Deleting canary Mapping `%s`", canaryMappingName)
+		r.log.Info(msg)
+		r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeNormal, "DeletingCanaryMapping", msg)
+		err = client.Delete(ctx, canaryMappingName, metav1.DeleteOptions{})
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if exists {
+		if weight, ok := mappingWeight(canaryMapping); ok && weight == int64(desiredWeight) {
+			return nil
+		}
+		canaryMapping = canaryMapping.DeepCopy()
+		err = unstructured.SetNestedField(canaryMapping.Object, int64(desiredWeight), "spec", "weight")
+		if err != nil {
+			return err
+		}
+		msg := fmt.Sprintf("Updating canary Mapping `%s` to desiredWeight '%d'", canaryMappingName, desiredWeight)
+		r.log.Info(msg)
+		r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeNormal, "UpdatingCanaryMapping", msg)
+		_, err = client.Update(ctx, canaryMapping, metav1.UpdateOptions{})
+		return err
+	}
+
+	baseMapping, err := client.Get(ctx, mappingName, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			msg := fmt.Sprintf("Mapping `%s` not found", mappingName)
+			r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeWarning, "MappingNotFound", msg)
+		}
+		return err
+	}
+	canaryMapping, err = r.canaryMapping(baseMapping, canaryMappingName, desiredWeight)
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("Creating canary Mapping `%s` with desiredWeight '%d'", canaryMappingName, desiredWeight)
+	r.log.Info(msg)
+	r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeNormal, "CreatingCanaryMapping", msg)
+	_, err = client.Create(ctx, canaryMapping, metav1.CreateOptions{})
+	return err
+}
+
+// canaryMapping returns the desired state of the canary Mapping, which is a copy of the base Mapping
+// sending the desired weight of the traffic to the canary service
+func (r *Reconciler) canaryMapping(baseMapping *unstructured.Unstructured, name string, desiredWeight int32) (*unstructured.Unstructured, error) {
+	spec, found, err := unstructured.NestedMap(baseMapping.Object, "spec")
+	if err != nil {
+		return nil, err
+	}
+	service, ok := spec["service"].(string)
+	if !found || !ok || service == "" {
+		return nil, fmt.Errorf("Mapping `%s` does not define a service", baseMapping.GetName())
+	}
+	spec["service"] = canaryService(service, r.cfg.Rollout.Spec.Strategy.Canary.CanaryService)
+	spec["weight"] = int64(desiredWeight)
+
+	canaryMapping := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	canaryMapping.SetGroupVersionKind(GetMappingGVR().GroupVersion().WithKind("Mapping"))
+	canaryMapping.SetName(name)
+	canaryMapping.SetNamespace(baseMapping.GetNamespace())
+	canaryMapping.SetLabels(baseMapping.GetLabels())
+	canaryMapping.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(r.cfg.Rollout, r.cfg.ControllerKind)})
+	return canaryMapping, nil
+}
+
+// canaryService replaces the service name of a Mapping service, which has the format
+// [scheme://]service[.namespace][:port], with the canary service
+func canaryService(service, canaryServiceName string) string {
+	scheme := ""
+	if i := strings.Index(service, "://"); i >= 0 {
+		scheme = service[:i+3]
+		service = service[i+3:]
+	}
+	suffix := ""
+	if i := strings.IndexAny(service, ".:"); i >= 0 {
+		suffix = service[i:]
+	}
+	return scheme + canaryServiceName + suffix
+}
+
+// VerifyWeight returns true if all the canary Mappings have the desired weight
+func (r *Reconciler) VerifyWeight(desiredWeight int32) (bool, error) {
+	ctx := context.TODO()
+	for _, mappingName := range r.cfg.Rollout.Spec.Strategy.Canary.TrafficRouting.Ambassador.Mappings {
+		canaryMapping, err := r.mappingClient().Get(ctx, mappingName+canaryMappingSuffix, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			if desiredWeight != 0 {
+				return false, nil
+			}
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if weight, ok := mappingWeight(canaryMapping); !ok || weight != int64(desiredWeight) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// mappingWeight returns the weight of a Mapping, which is decoded as an integer or a float
+// depending on where the Mapping comes from
+func mappingWeight(mapping *unstructured.Unstructured) (int64, bool) {
+	weight, found, err := unstructured.NestedFieldNoCopy(mapping.Object, "spec", "weight")
+	if err != nil || !found {
+		return 0, false
+	}
+	switch w := weight.(type) {
+	case int64:
+		return w, true
+	case int:
+		return int64(w), true
+	case float64:
+		return int64(w), true
+	}
+	return 0, false
+}
+
+// SetHeaderRoute is a no-op since header routes are not supported by the Ambassador reconciler
+func (r *Reconciler) SetHeaderRoute(headerRoute *v1alpha1.SetHeaderRoute) error {
+	return nil
+}
+
+// SetMirrorRoute is a no-op since mirror routes are not supported by the Ambassador reconciler
+func (r *Reconciler) SetMirrorRoute(mirrorRoute *v1alpha1.SetMirrorRoute) error {
+	return nil
+}
+
+// RemoveManagedRoutes is a no-op since managed routes are not supported by the Ambassador reconciler
+func (r *Reconciler) RemoveManagedRoutes() error {
+	return nil
+}
@@ -0,0 +1,218 @@
+package ambassador
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+const baseMapping = `
+apiVersion: getambassador.io/v2
+kind: Mapping
+metadata:
+  name: myapp-mapping
+  namespace: default
+  labels:
+    app: myapp
+spec:
+  prefix: /myapp/
+  rewrite: /
+  service: stable-service:8080
+`
+
+const canaryMapping = `
+apiVersion: getambassador.io/v2
+kind: Mapping
+metadata:
+  name: myapp-mapping-canary
+  namespace: default
+spec:
+  prefix: /myapp/
+  rewrite: /
+  service: canary-service:8080
+  weight: 20
+`
+
+func strToUnstructured(yamlStr string) *unstructured.Unstructured {
+	obj := make(map[string]interface{})
+	yamlStr = strings.ReplaceAll(yamlStr, "\t", "    ")
+	err := yaml.Unmarshal([]byte(yamlStr), &obj)
+	if err != nil {
+		panic(err)
+	}
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func rollout(mappings ...string) *v1alpha1.Rollout {
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rollout",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					StableService: "stable-service",
+					CanaryService: "canary-service",
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+						Ambassador: &v1alpha1.AmbassadorTrafficRouting{
+							Mappings: mappings,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newReconciler(client *fake.FakeDynamicClient, ro *v1alpha1.Rollout) *Reconciler {
+	return NewReconciler(ReconcilerConfig{
+		Rollout:        ro,
+		Client:         client,
+		Recorder:       &record.FakeRecorder{},
+		ControllerKind: schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"},
+	})
+}
+
+func getMapping(t *testing.T, client *fake.FakeDynamicClient, name string) *unstructured.Unstructured {
+	mapping, err := client.Resource(GetMappingGVR()).Namespace("default").Get(context.TODO(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	return mapping
+}
+
+func TestType(t *testing.T) {
+	r := newReconciler(fake.NewSimpleDynamicClient(runtime.NewScheme()), rollout("myapp-mapping"))
+	assert.Equal(t, Type, r.Type())
+}
+
+func TestSetWeightCreatesCanaryMapping(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(baseMapping))
+	ro := rollout("myapp-mapping")
+	r := newReconciler(client, ro)
+
+	err := r.SetWeight(20)
+	assert.NoError(t, err)
+
+	canary := getMapping(t, client, "myapp-mapping-canary")
+	service, _, _ := unstructured.NestedString(canary.Object, "spec", "service")
+	assert.Equal(t, "canary-service:8080", service)
+	prefix, _, _ := unstructured.NestedString(canary.Object, "spec", "prefix")
+	assert.Equal(t, "/myapp/", prefix)
+	weight, ok := mappingWeight(canary)
+	assert.True(t, ok)
+	assert.Equal(t, int64(20), weight)
+	assert.Equal(t, map[string]string{"app": "myapp"}, canary.GetLabels())
+	assert.True(t, metav1.IsControlledBy(canary, ro))
+
+	verified, err := r.VerifyWeight(20)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+}
+
+func TestSetWeightUpdatesCanaryMapping(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(baseMapping), strToUnstructured(canaryMapping))
+	r := newReconciler(client, rollout("myapp-mapping"))
+
+	verified, err := r.VerifyWeight(50)
+	assert.NoError(t, err)
+	assert.False(t, verified)
+
+	err = r.SetWeight(50)
+	assert.NoError(t, err)
+	weight, _ := mappingWeight(getMapping(t, client, "myapp-mapping-canary"))
+	assert.Equal(t, int64(50), weight)
+
+	// no update when the canary Mapping is at the desired weight
+	actions := len(client.Actions())
+	err = r.SetWeight(50)
+	assert.NoError(t, err)
+	assert.Len(t, client.Actions(), actions+1)
+	assert.Equal(t, "get", client.Actions()[actions].GetVerb())
+}
+
+func TestSetWeightDeletesCanaryMapping(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(baseMapping), strToUnstructured(canaryMapping))
+	r := newReconciler(client, rollout("myapp-mapping"))
+
+	err := r.SetWeight(0)
+	assert.NoError(t, err)
+	_, err = client.Resource(GetMappingGVR()).Namespace("default").Get(context.TODO(), "myapp-mapping-canary", metav1.GetOptions{})
+	assert.True(t, k8serrors.IsNotFound(err))
+	getMapping(t, client, "myapp-mapping")
+
+	verified, err := r.VerifyWeight(0)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+
+	// nothing to clean up
+	err = r.SetWeight(0)
+	assert.NoError(t, err)
+}
+
+func TestSetWeightMappingNotFound(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	recorder := &record.FakeRecorder{Events: make(chan string, 1)}
+	r := newReconciler(client, rollout("myapp-mapping"))
+	r.cfg.Recorder = recorder
+
+	err := r.SetWeight(20)
+	assert.True(t, k8serrors.IsNotFound(err))
+	assert.Contains(t, <-recorder.Events, "MappingNotFound")
+
+	verified, err := r.VerifyWeight(20)
+	assert.NoError(t, err)
+	assert.False(t, verified)
+}
+
+func TestSetWeightMappingWithoutService(t *testing.T) {
+	mapping := strToUnstructured(baseMapping)
+	unstructured.RemoveNestedField(mapping.Object, "spec", "service")
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), mapping)
+	r := newReconciler(client, rollout("myapp-mapping"))
+
+	err := r.SetWeight(20)
+	assert.EqualError(t, err, "Mapping `myapp-mapping` does not define a service")
+}
+
+func TestSetWeightV3alpha1(t *testing.T) {
+	SetDefaultAPIVersion("v3alpha1")
+	defer SetDefaultAPIVersion("v2")
+	mapping := strToUnstructured(baseMapping)
+	mapping.SetAPIVersion("getambassador.io/v3alpha1")
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), mapping)
+	r := newReconciler(client, rollout("myapp-mapping"))
+
+	err := r.SetWeight(20)
+	assert.NoError(t, err)
+	canary := getMapping(t, client, "myapp-mapping-canary")
+	assert.Equal(t, "getambassador.io/v3alpha1", canary.GetAPIVersion())
+	assert.Equal(t, "v3alpha1", GetMappingGVR().Version)
+}
+
+func TestCanaryService(t *testing.T) {
+	assert.Equal(t, "canary", canaryService("stable", "canary"))
+	assert.Equal(t, "canary:8080", canaryService("stable:8080", "canary"))
+	assert.Equal(t, "canary.default:8080", canaryService("stable.default:8080", "canary"))
+	assert.Equal(t, "https://canary.default.svc.cluster.local:443", canaryService("https://stable.default.svc.cluster.local:443", "canary"))
+}
+
+func TestManagedRoutesNoOp(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	r := newReconciler(client, rollout("myapp-mapping"))
+	assert.NoError(t, r.SetHeaderRoute(&v1alpha1.SetHeaderRoute{}))
+	assert.NoError(t, r.SetMirrorRoute(&v1alpha1.SetMirrorRoute{}))
+	assert.NoError(t, r.RemoveManagedRoutes())
+	assert.Len(t, client.Actions(), 0)
+}
@@ -0,0 +1,491 @@
+package appmesh
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+const (
+	// Type holds this controller type
+	Type = "AppMesh"
+
+	// canaryVirtualNodeSuffix is appended to the name of the stable VirtualNode to name the canary VirtualNode
+	canaryVirtualNodeSuffix = "-canary"
+)
+
+// routeTypes are the protocols of a VirtualRouter route which hold weighted targets
+var routeTypes = []string{"httpRoute", "http2Route", "grpcRoute", "tcpRoute"}
+
+// GetVirtualNodeGVR returns the GroupVersionResource of the App Mesh VirtualNodes
+func GetVirtualNodeGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "appmesh.k8s.aws",
+		Version:  "v1beta2",
+		Resource: "virtualnodes",
+	}
+}
+
+// GetVirtualRouterGVR returns the GroupVersionResource of the App Mesh VirtualRouters
+func GetVirtualRouterGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "appmesh.k8s.aws",
+		Version:  "v1beta2",
+		Resource: "virtualrouters",
+	}
+}
+
+// ReconcilerConfig describes static configuration data for the App Mesh reconciler
+type ReconcilerConfig struct {
+	Rollout        *v1alpha1.Rollout
+	Client         dynamic.Interface
+	Recorder       record.EventRecorder
+	ControllerKind schema.GroupVersionKind
+	// StableHash is the pod template hash of the stable ReplicaSet
+	StableHash string
+	// CanaryHash is the pod template hash of the canary ReplicaSet
+	CanaryHash string
+}
+
+// Reconciler holds required fields to reconcile App Mesh resources
+type Reconciler struct {
+	cfg ReconcilerConfig
+	log *logrus.Entry
+}
+
+// NewReconciler returns a reconciler struct that brings the VirtualNodes and the VirtualRouter into
+// the desired state
+func NewReconciler(cfg ReconcilerConfig) *Reconciler {
+	return &Reconciler{
+		cfg: cfg,
+		log: logutil.WithRollout(cfg.Rollout),
+	}
+}
+
+// Type indicates this reconciler is an App Mesh reconciler
+func (r *Reconciler) Type() string {
+	return Type
+}
+
+func (r *Reconciler) appMesh() *v1alpha1.AppMeshTrafficRouting {
+	return r.cfg.Rollout.Spec.Strategy.Canary.TrafficRouting.AppMesh
+}
+
+func (r *Reconciler) stableVirtualNodeName() string {
+	return r.appMesh().StableVirtualNode
+}
+
+func (r *Reconciler) canaryVirtualNodeName() string {
+	if r.appMesh().CanaryVirtualNode != "" {
+		return r.appMesh().CanaryVirtualNode
+	}
+	return r.appMesh().StableVirtualNode + canaryVirtualNodeSuffix
+}
+
+// hasCanary returns true while the rollout is updating, which is when the canary pods differ from the
+// stable pods
+func (r *Reconciler) hasCanary() bool {
+	return r.cfg.CanaryHash != "" && r.cfg.CanaryHash != r.cfg.StableHash
+}
+
+func (r *Reconciler) virtualNodeClient() dynamic.ResourceInterface {
+	return r.cfg.Client.Resource(GetVirtualNodeGVR()).Namespace(r.cfg.Rollout.Namespace)
+}
+
+func (r *Reconciler) virtualRouterClient() dynamic.ResourceInterface {
+	return r.cfg.Client.Resource(GetVirtualRouterGVR()).Namespace(r.cfg.Rollout.Namespace)
+}
+
+// SetWeight selects the stable and canary pods in their VirtualNodes, creating the canary VirtualNode
+// from the stable one, and sets the weights of the weighted targets of the VirtualRouter routes. Once
+// the rollout is fully promoted, the canary VirtualNode is removed from the routes and deleted.
+func (r *Reconciler) SetWeight(desiredWeight int32) error {
+	ctx := context.TODO()
+	stableNode, err := r.getVirtualNode(ctx, r.stableVirtualNodeName())
+	if err != nil {
+		return err
+	}
+	if r.cfg.StableHash != "" {
+		err = r.updatePodSelector(ctx, stableNode, r.cfg.StableHash)
+		if err != nil {
+			return err
+		}
+	}
+	if r.hasCanary() {
+		err = r.reconcileCanaryVirtualNode(ctx, stableNode)
+		if err != nil {
+			return err
+		}
+		return r.reconcileVirtualRouter(ctx, desiredWeight)
+	}
+	err = r.reconcileVirtualRouter(ctx, 0)
+	if err != nil {
+		return err
+	}
+	return r.deleteCanaryVirtualNode(ctx)
+}
+
+func (r *Reconciler) getVirtualNode(ctx context.Context, name string) (*unstructured.Unstructured, error) {
+	virtualNode, err := r.virtualNodeClient().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			msg := fmt.Sprintf("VirtualNode `%s` not found", name)
+			r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeWarning, "VirtualNodeNotFound", msg)
+		}
+		return nil, err
+	}
+	return virtualNode, nil
+}
+
+// updatePodSelector updates the VirtualNode to only select the pods with the pod template hash
+func (r *Reconciler) updatePodSelector(ctx context.Context, virtualNode *unstructured.Unstructured, podTemplateHash string) error {
+	matchLabels, found, err := unstructured.NestedStringMap(virtualNode.Object, "spec", "podSelector", "matchLabels")
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("VirtualNode `%s` does not define a podSelector", virtualNode.GetName())
+	}
+	if matchLabels[v1alpha1.DefaultRolloutUniqueLabelKey] == podTemplateHash {
+		return nil
+	}
+	matchLabels[v1alpha1.DefaultRolloutUniqueLabelKey] = podTemplateHash
+	virtualNode = virtualNode.DeepCopy()
+	err = unstructured.SetNestedStringMap(virtualNode.Object, matchLabels, "spec", "podSelector", "matchLabels")
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("Updating VirtualNode `%s` to select pods with hash '%s'", virtualNode.GetName(), podTemplateHash)
+	r.log.Info(msg)
+	r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeNormal, "UpdatingVirtualNode", msg)
+	_, err = r.virtualNodeClient().Update(ctx, virtualNode, metav1.UpdateOptions{})
+	return err
+}
+
+func (r *Reconciler) reconcileCanaryVirtualNode(ctx context.Context, stableNode *unstructured.Unstructured) error {
+	canaryNode, err := r.virtualNodeClient().Get(ctx, r.canaryVirtualNodeName(), metav1.GetOptions{})
+	if err == nil {
+		return r.updatePodSelector(ctx, canaryNode, r.cfg.CanaryHash)
+	}
+	if !k8serrors.IsNotFound(err) {
+		return err
+	}
+	canaryNode, err = r.canaryVirtualNode(stableNode)
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("Creating canary VirtualNode `%s` selecting pods with hash '%s'", canaryNode.GetName(), r.cfg.CanaryHash)
+	r.log.Info(msg)
+	r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeNormal, "CreatingVirtualNode", msg)
+	_, err = r.virtualNodeClient().Create(ctx, canaryNode, metav1.CreateOptions{})
+	return err
+}
+
+// canaryVirtualNode returns the desired state of the canary VirtualNode, which is a copy of the stable
+// VirtualNode selecting the canary pods
+func (r *Reconciler) canaryVirtualNode(stableNode *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	spec, _, err := unstructured.NestedMap(stableNode.Object, "spec")
+	if err != nil {
+		return nil, err
+	}
+	canaryNode := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	canaryNode.SetGroupVersionKind(GetVirtualNodeGVR().GroupVersion().WithKind("VirtualNode"))
+	canaryNode.SetName(r.canaryVirtualNodeName())
+	canaryNode.SetNamespace(stableNode.GetNamespace())
+	canaryNode.SetLabels(stableNode.GetLabels())
+	canaryNode.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(r.cfg.Rollout, r.cfg.ControllerKind)})
+	// the name of the VirtualNode in App Mesh has to be unique, so it is generated from the name of
+	// the canary VirtualNode
+	unstructured.RemoveNestedField(canaryNode.Object, "spec", "awsName")
+	err = unstructured.SetNestedField(canaryNode.Object, r.cfg.CanaryHash, "spec", "podSelector", "matchLabels", v1alpha1.DefaultRolloutUniqueLabelKey)
+	if err != nil {
+		return nil, err
+	}
+	hostname, found, err := unstructured.NestedString(canaryNode.Object, "spec", "serviceDiscovery", "dns", "hostname")
+	if err == nil && found {
+		hostname = canaryHostname(hostname, r.cfg.Rollout.Spec.Strategy.Canary.StableService, r.cfg.Rollout.Spec.Strategy.Canary.CanaryService)
+		err = unstructured.SetNestedField(canaryNode.Object, hostname, "spec", "serviceDiscovery", "dns", "hostname")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return canaryNode, nil
+}
+
+// canaryHostname replaces the stable service of a DNS hostname, which has the format
+// service[.namespace.svc.cluster.local], with the canary service
+func canaryHostname(hostname, stableService, canaryService string) string {
+	parts := strings.SplitN(hostname, ".", 2)
+	if parts[0] != stableService {
+		return hostname
+	}
+	parts[0] = canaryService
+	return strings.Join(parts, ".")
+}
+
+func (r *Reconciler) deleteCanaryVirtualNode(ctx context.Context) error {
+	name := r.canaryVirtualNodeName()
+	_, err := r.virtualNodeClient().Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("Deleting canary VirtualNode `%s`", name)
+	r.log.Info(msg)
+	r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeNormal, "DeletingVirtualNode", msg)
+	err = r.virtualNodeClient().Delete(ctx, name, metav1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *Reconciler) getVirtualRouter(ctx context.Context) (*unstructured.Unstructured, error) {
+	name := r.appMesh().VirtualRouter.Name
+	virtualRouter, err := r.virtualRouterClient().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			msg := fmt.Sprintf("VirtualRouter `%s` not found", name)
+			r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeWarning, "VirtualRouterNotFound", msg)
+		}
+		return nil, err
+	}
+	return virtualRouter, nil
+}
+
+// reconcileVirtualRouter sets the weights of the stable and canary targets of the VirtualRouter
+// routes. The canary target is added to the routes while the rollout has a canary, and removed
+// otherwise.
+func (r *Reconciler) reconcileVirtualRouter(ctx context.Context, desiredWeight int32) error {
+	virtualRouter, err := r.getVirtualRouter(ctx)
+	if err != nil {
+		return err
+	}
+	routes, err := getRoutes(virtualRouter)
+	if err != nil {
+		return err
+	}
+	routeTargets, err := r.getRouteTargets(routes)
+	if err != nil {
+		return err
+	}
+	stableNode := r.stableVirtualNodeName()
+	canaryNode := r.canaryVirtualNodeName()
+	modified := false
+	for _, rt := range routeTargets {
+		targets := rt.targets()
+		stableTarget := findTarget(targets, stableNode)
+		canaryTarget := findTarget(targets, canaryNode)
+		if !r.hasCanary() {
+			if canaryTarget != nil || getWeight(stableTarget) != 100 {
+				stableTarget["weight"] = int64(100)
+				rt.setTargets(removeTarget(targets, canaryNode))
+				modified = true
+			}
+			continue
+		}
+		if canaryTarget == nil {
+			canaryTarget = map[string]interface{}{
+				"virtualNodeRef": map[string]interface{}{"name": canaryNode},
+			}
+			rt.setTargets(append(targets, canaryTarget))
+			modified = true
+		}
+		if getWeight(canaryTarget) != int64(desiredWeight) || getWeight(stableTarget) != int64(100-desiredWeight) {
+			canaryTarget["weight"] = int64(desiredWeight)
+			stableTarget["weight"] = int64(100 - desiredWeight)
+			modified = true
+		}
+	}
+	if !modified {
+		return nil
+	}
+	virtualRouter = virtualRouter.DeepCopy()
+	err = unstructured.SetNestedSlice(virtualRouter.Object, routes, "spec", "routes")
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("Updating VirtualRouter `%s` to desiredWeight '%d'", virtualRouter.GetName(), desiredWeight)
+	r.log.Info(msg)
+	r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeNormal, "UpdatingVirtualRouter", msg)
+	_, err = r.virtualRouterClient().Update(ctx, virtualRouter, metav1.UpdateOptions{})
+	return err
+}
+
+// VerifyWeight returns true if the weighted targets of the VirtualRouter routes have the desired weights
+func (r *Reconciler) VerifyWeight(desiredWeight int32) (bool, error) {
+	virtualRouter, err := r.getVirtualRouter(context.TODO())
+	if err != nil {
+		return false, err
+	}
+	routes, err := getRoutes(virtualRouter)
+	if err != nil {
+		return false, err
+	}
+	routeTargets, err := r.getRouteTargets(routes)
+	if err != nil {
+		return false, err
+	}
+	for _, rt := range routeTargets {
+		targets := rt.targets()
+		if getWeight(findTarget(targets, r.stableVirtualNodeName())) != int64(100-desiredWeight) {
+			return false, nil
+		}
+		canaryTarget := findTarget(targets, r.canaryVirtualNodeName())
+		if canaryTarget == nil && desiredWeight != 0 {
+			return false, nil
+		}
+		if canaryTarget != nil && getWeight(canaryTarget) != int64(desiredWeight) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// SetHeaderRoute is a no-op since header routes are not supported by the App Mesh reconciler
+func (r *Reconciler) SetHeaderRoute(headerRoute *v1alpha1.SetHeaderRoute) error {
+	return nil
+}
+
+// SetMirrorRoute is a no-op since mirror routes are not supported by the App Mesh reconciler
+func (r *Reconciler) SetMirrorRoute(mirrorRoute *v1alpha1.SetMirrorRoute) error {
+	return nil
+}
+
+// RemoveManagedRoutes is a no-op since managed routes are not supported by the App Mesh reconciler
+func (r *Reconciler) RemoveManagedRoutes() error {
+	return nil
+}
+
+// routeTargets holds the action of a VirtualRouter route, whose weighted targets can be modified in place
+type routeTargets struct {
+	action map[string]interface{}
+}
+
+func (rt routeTargets) targets() []interface{} {
+	targets, _ := rt.action["weightedTargets"].([]interface{})
+	return targets
+}
+
+func (rt routeTargets) setTargets(targets []interface{}) {
+	rt.action["weightedTargets"] = targets
+}
+
+func getRoutes(virtualRouter *unstructured.Unstructured) ([]interface{}, error) {
+	routes, found, err := unstructured.NestedSlice(virtualRouter.Object, "spec", "routes")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf(".spec.routes is not defined")
+	}
+	return routes, nil
+}
+
+// getRouteTargets returns the weighted targets of the routes listed in the rollout, or of all the
+// routes targeting the stable VirtualNode if the rollout does not list any
+func (r *Reconciler) getRouteTargets(routes []interface{}) ([]routeTargets, error) {
+	stableNode := r.stableVirtualNodeName()
+	routeNames := map[string]bool{}
+	for _, name := range r.appMesh().VirtualRouter.Routes {
+		routeNames[name] = false
+	}
+	allRouteTargets := []routeTargets{}
+	for _, routeI := range routes {
+		route, ok := routeI.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := route["name"].(string)
+		rt := routeTargets{getRouteAction(route)}
+		hasStableTarget := findTarget(rt.targets(), stableNode) != nil
+		if len(routeNames) > 0 {
+			if _, ok := routeNames[name]; !ok {
+				continue
+			}
+			routeNames[name] = true
+			if !hasStableTarget {
+				return nil, fmt.Errorf("Stable VirtualNode '%s' not found in route '%s'", stableNode, name)
+			}
+		} else if !hasStableTarget {
+			continue
+		}
+		allRouteTargets = append(allRouteTargets, rt)
+	}
+	for name, found := range routeNames {
+		if !found {
+			return nil, fmt.Errorf("Route '%s' is not found", name)
+		}
+	}
+	if len(allRouteTargets) == 0 {
+		return nil, fmt.Errorf("Stable VirtualNode '%s' not found in VirtualRouter routes", stableNode)
+	}
+	return allRouteTargets, nil
+}
+
+// getRouteAction returns the action of the route holding the weighted targets
+func getRouteAction(route map[string]interface{}) map[string]interface{} {
+	for _, routeType := range routeTypes {
+		action, found, err := unstructured.NestedFieldNoCopy(route, routeType, "action")
+		if err != nil || !found {
+			continue
+		}
+		if action, ok := action.(map[string]interface{}); ok {
+			return action
+		}
+	}
+	return nil
+}
+
+// findTarget returns the weighted target referencing the VirtualNode
+func findTarget(targets []interface{}, virtualNode string) map[string]interface{} {
+	for _, targetI := range targets {
+		target, ok := targetI.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(target, "virtualNodeRef", "name")
+		if name == virtualNode {
+			return target
+		}
+	}
+	return nil
+}
+
+func removeTarget(targets []interface{}, virtualNode string) []interface{} {
+	remaining := []interface{}{}
+	for _, target := range targets {
+		if target, ok := target.(map[string]interface{}); ok && findTarget([]interface{}{target}, virtualNode) != nil {
+			continue
+		}
+		remaining = append(remaining, target)
+	}
+	return remaining
+}
+
+// getWeight returns the weight of a weighted target
+func getWeight(target map[string]interface{}) int64 {
+	switch w := target["weight"].(type) {
+	case int64:
+		return w
+	case int:
+		return int64(w)
+	case float64:
+		return int64(w)
+	}
+	return 0
+}
@@ -0,0 +1,321 @@
+package appmesh
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	unstructuredutil "github.com/argoproj/argo-rollouts/utils/unstructured"
+)
+
+const stableVirtualNode = `
+apiVersion: appmesh.k8s.aws/v1beta2
+kind: VirtualNode
+metadata:
+  name: my-vn
+  namespace: default
+  labels:
+    app: my-app
+spec:
+  awsName: my-vn_default
+  podSelector:
+    matchLabels:
+      app: my-app
+  listeners:
+  - portMapping:
+      port: 8080
+      protocol: http
+  serviceDiscovery:
+    dns:
+      hostname: stable.default.svc.cluster.local`
+
+const canaryVirtualNode = `
+apiVersion: appmesh.k8s.aws/v1beta2
+kind: VirtualNode
+metadata:
+  name: my-vn-canary
+  namespace: default
+spec:
+  podSelector:
+    matchLabels:
+      app: my-app
+      rollouts-pod-template-hash: old
+  serviceDiscovery:
+    dns:
+      hostname: canary.default.svc.cluster.local`
+
+const virtualRouter = `
+apiVersion: appmesh.k8s.aws/v1beta2
+kind: VirtualRouter
+metadata:
+  name: my-vr
+  namespace: default
+spec:
+  listeners:
+  - portMapping:
+      port: 8080
+      protocol: http
+  routes:
+  - name: primary
+    httpRoute:
+      match:
+        prefix: /
+      action:
+        weightedTargets:
+        - virtualNodeRef:
+            name: my-vn
+          weight: 100
+  - name: grpc
+    grpcRoute:
+      match:
+        serviceName: my-service
+      action:
+        weightedTargets:
+        - virtualNodeRef:
+            name: my-vn
+          weight: 100
+        - virtualNodeRef:
+            name: my-vn-canary
+          weight: 0
+  - name: other
+    httpRoute:
+      match:
+        prefix: /other
+      action:
+        weightedTargets:
+        - virtualNodeRef:
+            name: other-vn
+          weight: 100`
+
+func rollout(routes ...string) *v1alpha1.Rollout {
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rollout",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					StableService: "stable",
+					CanaryService: "canary",
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+						AppMesh: &v1alpha1.AppMeshTrafficRouting{
+							VirtualRouter: v1alpha1.AppMeshVirtualRouter{
+								Name:   "my-vr",
+								Routes: routes,
+							},
+							StableVirtualNode: "my-vn",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newReconciler(client *fake.FakeDynamicClient, ro *v1alpha1.Rollout, stableHash, canaryHash string) *Reconciler {
+	return NewReconciler(ReconcilerConfig{
+		Rollout:        ro,
+		Client:         client,
+		Recorder:       &record.FakeRecorder{},
+		ControllerKind: schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"},
+		StableHash:     stableHash,
+		CanaryHash:     canaryHash,
+	})
+}
+
+func newClient(objs ...string) *fake.FakeDynamicClient {
+	objects := []runtime.Object{}
+	for _, obj := range objs {
+		objects = append(objects, unstructuredutil.StrToUnstructuredUnsafe(obj))
+	}
+	return fake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+}
+
+func getVirtualNode(t *testing.T, client *fake.FakeDynamicClient, name string) *unstructured.Unstructured {
+	virtualNode, err := client.Resource(GetVirtualNodeGVR()).Namespace("default").Get(context.TODO(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	return virtualNode
+}
+
+// targetWeights returns the weights of the targets of each route by VirtualNode
+func targetWeights(t *testing.T, client *fake.FakeDynamicClient) map[string]map[string]int64 {
+	virtualRouter, err := client.Resource(GetVirtualRouterGVR()).Namespace("default").Get(context.TODO(), "my-vr", metav1.GetOptions{})
+	assert.NoError(t, err)
+	routes, err := getRoutes(virtualRouter)
+	assert.NoError(t, err)
+	weights := map[string]map[string]int64{}
+	for _, routeI := range routes {
+		route := routeI.(map[string]interface{})
+		routeWeights := map[string]int64{}
+		for _, targetI := range (routeTargets{getRouteAction(route)}).targets() {
+			target := targetI.(map[string]interface{})
+			name, _, _ := unstructured.NestedString(target, "virtualNodeRef", "name")
+			routeWeights[name] = getWeight(target)
+		}
+		weights[route["name"].(string)] = routeWeights
+	}
+	return weights
+}
+
+func TestType(t *testing.T) {
+	r := newReconciler(newClient(), rollout(), "stable-hash", "canary-hash")
+	assert.Equal(t, Type, r.Type())
+}
+
+func TestSetWeightCreatesCanaryVirtualNode(t *testing.T) {
+	client := newClient(stableVirtualNode, virtualRouter)
+	ro := rollout()
+	r := newReconciler(client, ro, "stable-hash", "canary-hash")
+
+	err := r.SetWeight(20)
+	assert.NoError(t, err)
+
+	stableNode := getVirtualNode(t, client, "my-vn")
+	matchLabels, _, _ := unstructured.NestedStringMap(stableNode.Object, "spec", "podSelector", "matchLabels")
+	assert.Equal(t, map[string]string{"app": "my-app", v1alpha1.DefaultRolloutUniqueLabelKey: "stable-hash"}, matchLabels)
+
+	canaryNode := getVirtualNode(t, client, "my-vn-canary")
+	matchLabels, _, _ = unstructured.NestedStringMap(canaryNode.Object, "spec", "podSelector", "matchLabels")
+	assert.Equal(t, map[string]string{"app": "my-app", v1alpha1.DefaultRolloutUniqueLabelKey: "canary-hash"}, matchLabels)
+	hostname, _, _ := unstructured.NestedString(canaryNode.Object, "spec", "serviceDiscovery", "dns", "hostname")
+	assert.Equal(t, "canary.default.svc.cluster.local", hostname)
+	_, found, _ := unstructured.NestedString(canaryNode.Object, "spec", "awsName")
+	assert.False(t, found)
+	assert.Equal(t, map[string]string{"app": "my-app"}, canaryNode.GetLabels())
+	assert.True(t, metav1.IsControlledBy(canaryNode, ro))
+
+	assert.Equal(t, map[string]map[string]int64{
+		"primary": {"my-vn": 80, "my-vn-canary": 20},
+		"grpc":    {"my-vn": 80, "my-vn-canary": 20},
+		"other":   {"other-vn": 100},
+	}, targetWeights(t, client))
+
+	verified, err := r.VerifyWeight(20)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+	verified, err = r.VerifyWeight(40)
+	assert.NoError(t, err)
+	assert.False(t, verified)
+}
+
+func TestSetWeightUpdatesCanaryVirtualNode(t *testing.T) {
+	client := newClient(stableVirtualNode, canaryVirtualNode, virtualRouter)
+	r := newReconciler(client, rollout("grpc"), "stable-hash", "canary-hash")
+
+	err := r.SetWeight(50)
+	assert.NoError(t, err)
+
+	canaryNode := getVirtualNode(t, client, "my-vn-canary")
+	hash, _, _ := unstructured.NestedString(canaryNode.Object, "spec", "podSelector", "matchLabels", v1alpha1.DefaultRolloutUniqueLabelKey)
+	assert.Equal(t, "canary-hash", hash)
+
+	assert.Equal(t, map[string]map[string]int64{
+		"primary": {"my-vn": 100},
+		"grpc":    {"my-vn": 50, "my-vn-canary": 50},
+		"other":   {"other-vn": 100},
+	}, targetWeights(t, client))
+
+	// no update when the VirtualNodes and the VirtualRouter are in the desired state
+	actions := len(client.Actions())
+	err = r.SetWeight(50)
+	assert.NoError(t, err)
+	for _, action := range client.Actions()[actions:] {
+		assert.Equal(t, "get", action.GetVerb())
+	}
+}
+
+func TestSetWeightDeletesCanaryVirtualNode(t *testing.T) {
+	client := newClient(stableVirtualNode, canaryVirtualNode, virtualRouter)
+	r := newReconciler(client, rollout(), "canary-hash", "canary-hash")
+
+	err := r.SetWeight(0)
+	assert.NoError(t, err)
+
+	_, err = client.Resource(GetVirtualNodeGVR()).Namespace("default").Get(context.TODO(), "my-vn-canary", metav1.GetOptions{})
+	assert.True(t, k8serrors.IsNotFound(err))
+	assert.Equal(t, map[string]map[string]int64{
+		"primary": {"my-vn": 100},
+		"grpc":    {"my-vn": 100},
+		"other":   {"other-vn": 100},
+	}, targetWeights(t, client))
+
+	verified, err := r.VerifyWeight(0)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+}
+
+func TestSetWeightInvalidRoutes(t *testing.T) {
+	client := newClient(stableVirtualNode, virtualRouter)
+
+	r := newReconciler(client, rollout("missing"), "stable-hash", "canary-hash")
+	err := r.SetWeight(10)
+	assert.EqualError(t, err, "Route 'missing' is not found")
+
+	r = newReconciler(client, rollout("other"), "stable-hash", "canary-hash")
+	_, err = r.VerifyWeight(10)
+	assert.EqualError(t, err, "Stable VirtualNode 'my-vn' not found in route 'other'")
+
+	ro := rollout()
+	ro.Spec.Strategy.Canary.TrafficRouting.AppMesh.StableVirtualNode = "missing-vn"
+	r = newReconciler(client, ro, "stable-hash", "canary-hash")
+	_, err = r.VerifyWeight(10)
+	assert.EqualError(t, err, "Stable VirtualNode 'missing-vn' not found in VirtualRouter routes")
+}
+
+func TestSetWeightNotFound(t *testing.T) {
+	recorder := &record.FakeRecorder{Events: make(chan string, 1)}
+	r := newReconciler(newClient(), rollout(), "stable-hash", "canary-hash")
+	r.cfg.Recorder = recorder
+	err := r.SetWeight(10)
+	assert.True(t, k8serrors.IsNotFound(err))
+	assert.Contains(t, <-recorder.Events, "VirtualNodeNotFound")
+
+	r = newReconciler(newClient(stableVirtualNode), rollout(), "stable-hash", "canary-hash")
+	r.cfg.Recorder = recorder
+	_, err = r.VerifyWeight(10)
+	assert.True(t, k8serrors.IsNotFound(err))
+	assert.Contains(t, <-recorder.Events, "VirtualRouterNotFound")
+}
+
+func TestSetWeightWithoutPodSelector(t *testing.T) {
+	virtualNode := unstructuredutil.StrToUnstructuredUnsafe(stableVirtualNode)
+	unstructured.RemoveNestedField(virtualNode.Object, "spec", "podSelector")
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), virtualNode)
+	r := newReconciler(client, rollout(), "stable-hash", "canary-hash")
+
+	err := r.SetWeight(10)
+	assert.EqualError(t, err, "VirtualNode `my-vn` does not define a podSelector")
+}
+
+func TestCanaryVirtualNodeName(t *testing.T) {
+	ro := rollout()
+	r := newReconciler(newClient(), ro, "", "")
+	assert.Equal(t, "my-vn-canary", r.canaryVirtualNodeName())
+	ro.Spec.Strategy.Canary.TrafficRouting.AppMesh.CanaryVirtualNode = "canary-vn"
+	assert.Equal(t, "canary-vn", r.canaryVirtualNodeName())
+}
+
+func TestCanaryHostname(t *testing.T) {
+	assert.Equal(t, "canary", canaryHostname("stable", "stable", "canary"))
+	assert.Equal(t, "canary.default.svc.cluster.local", canaryHostname("stable.default.svc.cluster.local", "stable", "canary"))
+	assert.Equal(t, "other.default.svc.cluster.local", canaryHostname("other.default.svc.cluster.local", "stable", "canary"))
+}
+
+func TestManagedRoutesNoOp(t *testing.T) {
+	client := newClient()
+	r := newReconciler(client, rollout(), "stable-hash", "canary-hash")
+	assert.NoError(t, r.SetHeaderRoute(&v1alpha1.SetHeaderRoute{}))
+	assert.NoError(t, r.SetMirrorRoute(&v1alpha1.SetMirrorRoute{}))
+	assert.NoError(t, r.RemoveManagedRoutes())
+	assert.Len(t, client.Actions(), 0)
+}
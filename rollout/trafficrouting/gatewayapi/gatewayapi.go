@@ -0,0 +1,281 @@
+package gatewayapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+const (
+	// Type holds this controller type
+	Type = "GatewayAPI"
+)
+
+var (
+	defaultAPIVersion = "v1beta1"
+)
+
+// SetDefaultAPIVersion sets the apiVersion of the gateway.networking.k8s.io group used to manage HTTPRoutes
+func SetDefaultAPIVersion(version string) {
+	defaultAPIVersion = version
+}
+
+// GetHTTPRouteGVR returns the GroupVersionResource of the Gateway API HTTPRoutes
+func GetHTTPRouteGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "gateway.networking.k8s.io",
+		Version:  defaultAPIVersion,
+		Resource: "httproutes",
+	}
+}
+
+// ReconcilerConfig describes static configuration data for the Gateway API reconciler
+type ReconcilerConfig struct {
+	Rollout  *v1alpha1.Rollout
+	Client   dynamic.Interface
+	Recorder record.EventRecorder
+}
+
+// Reconciler holds required fields to reconcile Gateway API resources
+type Reconciler struct {
+	cfg ReconcilerConfig
+	log *logrus.Entry
+}
+
+// NewReconciler returns a reconciler struct that brings the HTTPRoute into the desired state
+func NewReconciler(cfg ReconcilerConfig) *Reconciler {
+	return &Reconciler{
+		cfg: cfg,
+		log: logutil.WithRollout(cfg.Rollout),
+	}
+}
+
+// Type indicates this reconciler is a Gateway API reconciler
+func (r *Reconciler) Type() string {
+	return Type
+}
+
+func (r *Reconciler) httpRouteClient() dynamic.ResourceInterface {
+	return r.cfg.Client.Resource(GetHTTPRouteGVR()).Namespace(r.cfg.Rollout.Namespace)
+}
+
+func (r *Reconciler) getHTTPRoute(ctx context.Context) (*unstructured.Unstructured, error) {
+	routeName := r.cfg.Rollout.Spec.Strategy.Canary.TrafficRouting.GatewayAPI.HTTPRoute
+	route, err := r.httpRouteClient().Get(ctx, routeName, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			msg := fmt.Sprintf("HTTPRoute `%s` not found", routeName)
+			r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeWarning, "HTTPRouteNotFound", msg)
+		}
+		return nil, err
+	}
+	return route, nil
+}
+
+// SetWeight sets the weight of the canary backendRef of the HTTPRoute rules to the desired weight, and
+// the weight of the stable backendRef to the remaining weight
+func (r *Reconciler) SetWeight(desiredWeight int32) error {
+	ctx := context.TODO()
+	route, err := r.getHTTPRoute(ctx)
+	if err != nil {
+		return err
+	}
+	rules, err := getRules(route)
+	if err != nil {
+		return err
+	}
+	backendRefs, err := getBackendRefs(r.cfg.Rollout, rules)
+	if err != nil {
+		return err
+	}
+	modified := false
+	for _, refs := range backendRefs {
+		if getWeight(refs.canary) != int64(desiredWeight) || getWeight(refs.stable) != int64(100-desiredWeight) {
+			refs.canary["weight"] = int64(desiredWeight)
+			refs.stable["weight"] = int64(100 - desiredWeight)
+			modified = true
+		}
+	}
+	if !modified {
+		return nil
+	}
+	route = route.DeepCopy()
+	err = unstructured.SetNestedSlice(route.Object, rules, "spec", "rules")
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("Updating HTTPRoute `%s` to desiredWeight '%d'", route.GetName(), desiredWeight)
+	r.log.Info(msg)
+	r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeNormal, "UpdatingHTTPRoute", msg)
+	_, err = r.httpRouteClient().Update(ctx, route, metav1.UpdateOptions{})
+	return err
+}
+
+// VerifyWeight returns true if the backendRefs of the HTTPRoute rules have the desired weights
+func (r *Reconciler) VerifyWeight(desiredWeight int32) (bool, error) {
+	route, err := r.getHTTPRoute(context.TODO())
+	if err != nil {
+		return false, err
+	}
+	rules, err := getRules(route)
+	if err != nil {
+		return false, err
+	}
+	backendRefs, err := getBackendRefs(r.cfg.Rollout, rules)
+	if err != nil {
+		return false, err
+	}
+	for _, refs := range backendRefs {
+		if getWeight(refs.canary) != int64(desiredWeight) || getWeight(refs.stable) != int64(100-desiredWeight) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// SetHeaderRoute is a no-op since header routes are not supported by the Gateway API reconciler
+func (r *Reconciler) SetHeaderRoute(headerRoute *v1alpha1.SetHeaderRoute) error {
+	return nil
+}
+
+// SetMirrorRoute is a no-op since mirror routes are not supported by the Gateway API reconciler
+func (r *Reconciler) SetMirrorRoute(mirrorRoute *v1alpha1.SetMirrorRoute) error {
+	return nil
+}
+
+// RemoveManagedRoutes is a no-op since managed routes are not supported by the Gateway API reconciler
+func (r *Reconciler) RemoveManagedRoutes() error {
+	return nil
+}
+
+// ruleBackendRefs holds the stable and canary backendRefs of an HTTPRoute rule
+type ruleBackendRefs struct {
+	stable map[string]interface{}
+	canary map[string]interface{}
+}
+
+// getBackendRefs returns the stable and canary backendRefs of the HTTPRoute rules managed by the
+// rollout. The returned maps belong to the rules so they can be modified in place.
+func getBackendRefs(ro *v1alpha1.Rollout, rules []interface{}) ([]ruleBackendRefs, error) {
+	canary := ro.Spec.Strategy.Canary
+	stableSvc := canary.StableService
+	canarySvc := canary.CanaryService
+	indexes, err := getRuleIndexes(canary.TrafficRouting.GatewayAPI, rules, stableSvc)
+	if err != nil {
+		return nil, err
+	}
+	backendRefs := []ruleBackendRefs{}
+	for _, i := range indexes {
+		rule, ok := rules[i].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("HTTPRoute rule %d is invalid", i)
+		}
+		refs := ruleBackendRefs{
+			stable: findServiceBackendRef(rule, stableSvc),
+			canary: findServiceBackendRef(rule, canarySvc),
+		}
+		if refs.stable == nil {
+			return nil, fmt.Errorf("Stable Service '%s' not found in HTTPRoute rule %d", stableSvc, i)
+		}
+		if refs.canary == nil {
+			return nil, fmt.Errorf("Canary Service '%s' not found in HTTPRoute rule %d", canarySvc, i)
+		}
+		backendRefs = append(backendRefs, refs)
+	}
+	return backendRefs, nil
+}
+
+// getRuleIndexes returns the indexes of the rules listed in the rollout, or the indexes of all the
+// rules referencing the stable service if the rollout does not list any
+func getRuleIndexes(gatewayAPI *v1alpha1.GatewayAPITrafficRouting, rules []interface{}, stableSvc string) ([]int, error) {
+	indexes := []int{}
+	if len(gatewayAPI.Rules) > 0 {
+		for _, i := range gatewayAPI.Rules {
+			if i < 0 || int(i) >= len(rules) {
+				return nil, fmt.Errorf("HTTPRoute rule %d is not found", i)
+			}
+			indexes = append(indexes, int(i))
+		}
+		return indexes, nil
+	}
+	for i := range rules {
+		rule, ok := rules[i].(map[string]interface{})
+		if ok && findServiceBackendRef(rule, stableSvc) != nil {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("Stable Service '%s' not found in HTTPRoute rules", stableSvc)
+	}
+	return indexes, nil
+}
+
+// findServiceBackendRef returns the backendRef of the rule referencing the service
+func findServiceBackendRef(rule map[string]interface{}, svc string) map[string]interface{} {
+	backendRefs, ok := rule["backendRefs"].([]interface{})
+	if !ok {
+		return nil
+	}
+	for _, backendRefI := range backendRefs {
+		backendRef, ok := backendRefI.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if kind, ok := backendRef["kind"].(string); ok && kind != "Service" {
+			continue
+		}
+		if group, ok := backendRef["group"].(string); ok && group != "" {
+			continue
+		}
+		if name, ok := backendRef["name"].(string); ok && name == svc {
+			return backendRef
+		}
+	}
+	return nil
+}
+
+// getWeight returns the weight of a backendRef, which defaults to 1
+func getWeight(backendRef map[string]interface{}) int64 {
+	switch w := backendRef["weight"].(type) {
+	case int64:
+		return w
+	case int:
+		return int64(w)
+	case float64:
+		return int64(w)
+	}
+	return 1
+}
+
+func getRules(route *unstructured.Unstructured) ([]interface{}, error) {
+	rules, found, err := unstructured.NestedSlice(route.Object, "spec", "rules")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf(".spec.rules is not defined")
+	}
+	return rules, nil
+}
+
+// ValidateHTTPRoute ensures that the rules of the HTTPRoute managed by the rollout exist and reference
+// both the stable and the canary service
+func ValidateHTTPRoute(ro *v1alpha1.Rollout, route *unstructured.Unstructured) error {
+	rules, err := getRules(route)
+	if err != nil {
+		return err
+	}
+	_, err = getBackendRefs(ro, rules)
+	return err
+}
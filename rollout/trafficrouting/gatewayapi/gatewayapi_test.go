@@ -0,0 +1,214 @@
+package gatewayapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	unstructuredutil "github.com/argoproj/argo-rollouts/utils/unstructured"
+)
+
+const httpRoute = `
+apiVersion: gateway.networking.k8s.io/v1beta1
+kind: HTTPRoute
+metadata:
+  name: httproute
+  namespace: default
+spec:
+  parentRefs:
+  - name: gateway
+  rules:
+  - matches:
+    - path:
+        type: PathPrefix
+        value: /api
+    backendRefs:
+    - name: stable
+      port: 80
+      weight: 100
+    - name: canary
+      port: 80
+      weight: 0
+  - matches:
+    - path:
+        type: PathPrefix
+        value: /
+    backendRefs:
+    - name: stable
+      port: 80
+    - name: canary
+      port: 80
+      weight: 0
+  - matches:
+    - path:
+        type: PathPrefix
+        value: /other
+    backendRefs:
+    - name: other
+      port: 80`
+
+func rollout(rules ...int32) *v1alpha1.Rollout {
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rollout",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					StableService: "stable",
+					CanaryService: "canary",
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+						GatewayAPI: &v1alpha1.GatewayAPITrafficRouting{
+							HTTPRoute: "httproute",
+							Rules:     rules,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newReconciler(client *fake.FakeDynamicClient, ro *v1alpha1.Rollout) *Reconciler {
+	return NewReconciler(ReconcilerConfig{
+		Rollout:  ro,
+		Client:   client,
+		Recorder: &record.FakeRecorder{},
+	})
+}
+
+func backendRefWeights(t *testing.T, client *fake.FakeDynamicClient) [][]int64 {
+	route, err := client.Resource(GetHTTPRouteGVR()).Namespace("default").Get(context.TODO(), "httproute", metav1.GetOptions{})
+	assert.NoError(t, err)
+	rules, err := getRules(route)
+	assert.NoError(t, err)
+	weights := [][]int64{}
+	for _, ruleI := range rules {
+		rule := ruleI.(map[string]interface{})
+		ruleWeights := []int64{}
+		for _, backendRef := range rule["backendRefs"].([]interface{}) {
+			ruleWeights = append(ruleWeights, getWeight(backendRef.(map[string]interface{})))
+		}
+		weights = append(weights, ruleWeights)
+	}
+	return weights
+}
+
+func TestType(t *testing.T) {
+	r := newReconciler(fake.NewSimpleDynamicClient(runtime.NewScheme()), rollout())
+	assert.Equal(t, Type, r.Type())
+}
+
+func TestSetWeight(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), unstructuredutil.StrToUnstructuredUnsafe(httpRoute))
+	r := newReconciler(client, rollout())
+
+	verified, err := r.VerifyWeight(30)
+	assert.NoError(t, err)
+	assert.False(t, verified)
+
+	err = r.SetWeight(30)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int64{{70, 30}, {70, 30}, {1}}, backendRefWeights(t, client))
+
+	verified, err = r.VerifyWeight(30)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+
+	// no update when the HTTPRoute has the desired weights
+	actions := len(client.Actions())
+	err = r.SetWeight(30)
+	assert.NoError(t, err)
+	assert.Len(t, client.Actions(), actions+1)
+	assert.Equal(t, "get", client.Actions()[actions].GetVerb())
+}
+
+func TestSetWeightRules(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), unstructuredutil.StrToUnstructuredUnsafe(httpRoute))
+	r := newReconciler(client, rollout(1))
+
+	err := r.SetWeight(10)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int64{{100, 0}, {90, 10}, {1}}, backendRefWeights(t, client))
+
+	verified, err := r.VerifyWeight(10)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+}
+
+func TestSetWeightInvalidRules(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), unstructuredutil.StrToUnstructuredUnsafe(httpRoute))
+
+	r := newReconciler(client, rollout(3))
+	err := r.SetWeight(10)
+	assert.EqualError(t, err, "HTTPRoute rule 3 is not found")
+
+	r = newReconciler(client, rollout(2))
+	err = r.SetWeight(10)
+	assert.EqualError(t, err, "Stable Service 'stable' not found in HTTPRoute rule 2")
+	_, err = r.VerifyWeight(10)
+	assert.EqualError(t, err, "Stable Service 'stable' not found in HTTPRoute rule 2")
+
+	ro := rollout()
+	ro.Spec.Strategy.Canary.CanaryService = "not-canary"
+	r = newReconciler(client, ro)
+	err = r.SetWeight(10)
+	assert.EqualError(t, err, "Canary Service 'not-canary' not found in HTTPRoute rule 0")
+
+	ro = rollout()
+	ro.Spec.Strategy.Canary.StableService = "not-stable"
+	r = newReconciler(client, ro)
+	err = r.SetWeight(10)
+	assert.EqualError(t, err, "Stable Service 'not-stable' not found in HTTPRoute rules")
+}
+
+func TestSetWeightHTTPRouteNotFound(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	recorder := &record.FakeRecorder{Events: make(chan string, 1)}
+	r := newReconciler(client, rollout())
+	r.cfg.Recorder = recorder
+
+	err := r.SetWeight(10)
+	assert.True(t, k8serrors.IsNotFound(err))
+	assert.Contains(t, <-recorder.Events, "HTTPRouteNotFound")
+}
+
+func TestFindServiceBackendRef(t *testing.T) {
+	rule := map[string]interface{}{
+		"backendRefs": []interface{}{
+			map[string]interface{}{"name": "stable", "group": "example.com", "kind": "Backend"},
+			map[string]interface{}{"name": "canary", "group": "", "kind": "Service"},
+		},
+	}
+	assert.Nil(t, findServiceBackendRef(rule, "stable"))
+	assert.NotNil(t, findServiceBackendRef(rule, "canary"))
+	assert.Nil(t, findServiceBackendRef(map[string]interface{}{}, "canary"))
+}
+
+func TestValidateHTTPRoute(t *testing.T) {
+	route := unstructuredutil.StrToUnstructuredUnsafe(httpRoute)
+	assert.NoError(t, ValidateHTTPRoute(rollout(), route))
+	assert.NoError(t, ValidateHTTPRoute(rollout(0, 1), route))
+	assert.EqualError(t, ValidateHTTPRoute(rollout(2), route), "Stable Service 'stable' not found in HTTPRoute rule 2")
+
+	unstructured.RemoveNestedField(route.Object, "spec", "rules")
+	assert.EqualError(t, ValidateHTTPRoute(rollout(), route), ".spec.rules is not defined")
+}
+
+func TestManagedRoutesNoOp(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	r := newReconciler(client, rollout())
+	assert.NoError(t, r.SetHeaderRoute(&v1alpha1.SetHeaderRoute{}))
+	assert.NoError(t, r.SetMirrorRoute(&v1alpha1.SetMirrorRoute{}))
+	assert.NoError(t, r.RemoveManagedRoutes())
+	assert.Len(t, client.Actions(), 0)
+}
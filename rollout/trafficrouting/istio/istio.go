@@ -1,15 +1,19 @@
 package istio
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamiclister"
 	"k8s.io/client-go/tools/record"
@@ -59,6 +63,8 @@ type Reconciler struct {
 	recorder                  record.EventRecorder
 	defaultAPIVersion         string
 	istioVirtualServiceLister dynamiclister.Lister
+	// virtualService is the VirtualService as last updated by the reconciler
+	virtualService *unstructured.Unstructured
 }
 
 type virtualServicePatch struct {
@@ -102,11 +108,14 @@ func (r *Reconciler) generateVirtualServicePatches(httpRoutes []HttpRoute, desir
 	for _, r := range r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Routes {
 		routes[r] = true
 	}
+	managedRoutes := r.managedRouteNames()
 
 	patches := virtualServicePatches{}
 	for i := range httpRoutes {
 		route := httpRoutes[i]
-		if !routes[route.Name] {
+		// mirror routes split the requests like the routes of the rollout
+		isMirrorRoute := managedRoutes[route.Name] && route.Mirror != nil
+		if !routes[route.Name] && !isMirrorRoute {
 			continue
 		}
 		for j := range route.Route {
@@ -187,23 +196,49 @@ func (r *Reconciler) Type() string {
 	return Type
 }
 
-// SetWeight modifies Istio resources to reach desired state
-func (r *Reconciler) SetWeight(desiredWeight int32) error {
-	ctx := context.TODO()
+// getVirtualService returns the VirtualService of the rollout. After the reconciler updated the
+// VirtualService, the updated object is returned to avoid update conflicts with a stale lister.
+func (r *Reconciler) getVirtualService(ctx context.Context) (*unstructured.Unstructured, error) {
+	if r.virtualService != nil {
+		return r.virtualService, nil
+	}
 	var vsvc *unstructured.Unstructured
 	var err error
 	vsvcName := r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Name
-	client := r.client.Resource(istioutil.GetIstioGVR(r.defaultAPIVersion)).Namespace(r.rollout.Namespace)
 	if r.istioVirtualServiceLister != nil {
 		vsvc, err = r.istioVirtualServiceLister.Namespace(r.rollout.Namespace).Get(vsvcName)
 	} else {
-		vsvc, err = client.Get(ctx, vsvcName, metav1.GetOptions{})
+		vsvc, err = r.virtualServiceClient().Get(ctx, vsvcName, metav1.GetOptions{})
 	}
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			msg := fmt.Sprintf("Virtual Service `%s` not found", vsvcName)
 			r.recorder.Event(r.rollout, corev1.EventTypeWarning, "VirtualServiceNotFound", msg)
 		}
+		return nil, err
+	}
+	return vsvc, nil
+}
+
+// updateVirtualService updates the VirtualService and keeps the result for the following reconciliations
+func (r *Reconciler) updateVirtualService(ctx context.Context, vsvc *unstructured.Unstructured) error {
+	updated, err := r.virtualServiceClient().Update(ctx, vsvc, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	r.virtualService = updated
+	return nil
+}
+
+func (r *Reconciler) virtualServiceClient() dynamic.ResourceInterface {
+	return r.client.Resource(istioutil.GetIstioGVR(r.defaultAPIVersion)).Namespace(r.rollout.Namespace)
+}
+
+// SetWeight modifies Istio resources to reach desired state
+func (r *Reconciler) SetWeight(desiredWeight int32) error {
+	ctx := context.TODO()
+	vsvc, err := r.getVirtualService(ctx)
+	if err != nil {
 		return err
 	}
 	modifiedVsvc, modified, err := r.reconcileVirtualService(vsvc, desiredWeight)
@@ -213,11 +248,302 @@ func (r *Reconciler) SetWeight(desiredWeight int32) error {
 	if !modified {
 		return nil
 	}
-	msg := fmt.Sprintf("Updating VirtualService `%s` to desiredWeight '%d'", vsvcName, desiredWeight)
+	msg := fmt.Sprintf("Updating VirtualService `%s` to desiredWeight '%d'", vsvc.GetName(), desiredWeight)
 	r.log.Info(msg)
 	r.recorder.Event(r.rollout, corev1.EventTypeNormal, "UpdatingVirtualService", msg)
-	_, err = client.Update(ctx, modifiedVsvc, metav1.UpdateOptions{})
-	return err
+	return r.updateVirtualService(ctx, modifiedVsvc)
+}
+
+// SetHeaderRoute adds or updates the managed route of the step ahead of the other routes of the
+// VirtualService, or removes the route when the step has no match conditions
+func (r *Reconciler) SetHeaderRoute(headerRoute *v1alpha1.SetHeaderRoute) error {
+	ctx := context.TODO()
+	vsvc, err := r.getVirtualService(ctx)
+	if err != nil {
+		return err
+	}
+	httpRoutesI, err := GetHttpRoutesI(vsvc)
+	if err != nil {
+		return err
+	}
+	var route map[string]interface{}
+	if len(headerRoute.Match) > 0 {
+		route, err = r.newHeaderRoute(httpRoutesI, headerRoute)
+		if err != nil {
+			return err
+		}
+	}
+	return r.reconcileManagedRoute(ctx, vsvc, httpRoutesI, headerRoute.Name, route)
+}
+
+// SetMirrorRoute adds or updates the managed route of the step ahead of the other routes of the
+// VirtualService, or removes the route when the step has no match conditions. The route keeps
+// sending the matching requests to the destinations of the routes of the rollout and mirrors them
+// to the canary.
+func (r *Reconciler) SetMirrorRoute(mirrorRoute *v1alpha1.SetMirrorRoute) error {
+	ctx := context.TODO()
+	vsvc, err := r.getVirtualService(ctx)
+	if err != nil {
+		return err
+	}
+	httpRoutesI, err := GetHttpRoutesI(vsvc)
+	if err != nil {
+		return err
+	}
+	var route map[string]interface{}
+	if len(mirrorRoute.Match) > 0 {
+		route, err = r.newMirrorRoute(httpRoutesI, mirrorRoute)
+		if err != nil {
+			return err
+		}
+	}
+	return r.reconcileManagedRoute(ctx, vsvc, httpRoutesI, mirrorRoute.Name, route)
+}
+
+// reconcileManagedRoute sets the managed route of the given name to route, or removes it if route is
+// nil, and updates the VirtualService if the routes changed
+func (r *Reconciler) reconcileManagedRoute(ctx context.Context, vsvc *unstructured.Unstructured, httpRoutesI []interface{}, name string, route map[string]interface{}) error {
+	newHttpRoutesI := r.setManagedRoute(httpRoutesI, name, route)
+	equal, err := routesEqual(httpRoutesI, newHttpRoutesI)
+	if err != nil || equal {
+		return err
+	}
+	msg := fmt.Sprintf("Updating managed route `%s` of VirtualService `%s`", name, vsvc.GetName())
+	if route == nil {
+		msg = fmt.Sprintf("Removing managed route `%s` of VirtualService `%s`", name, vsvc.GetName())
+	}
+	return r.updateHttpRoutes(ctx, vsvc, newHttpRoutesI, msg)
+}
+
+// routesEqual compares the JSON representation of the routes, since numbers read from the API
+// server are int64 while the controller sets float64 values
+func routesEqual(a, b []interface{}) (bool, error) {
+	aBytes, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	bBytes, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(aBytes, bBytes), nil
+}
+
+// RemoveManagedRoutes removes all the managed routes from the VirtualService
+func (r *Reconciler) RemoveManagedRoutes() error {
+	if len(r.rollout.Spec.Strategy.Canary.TrafficRouting.ManagedRoutes) == 0 {
+		return nil
+	}
+	ctx := context.TODO()
+	vsvc, err := r.getVirtualService(ctx)
+	if err != nil {
+		return err
+	}
+	httpRoutesI, err := GetHttpRoutesI(vsvc)
+	if err != nil {
+		return err
+	}
+	managedRoutes := r.managedRouteNames()
+	newHttpRoutesI := []interface{}{}
+	for _, routeI := range httpRoutesI {
+		if !managedRoutes[getRouteName(routeI)] {
+			newHttpRoutesI = append(newHttpRoutesI, routeI)
+		}
+	}
+	if len(newHttpRoutesI) == len(httpRoutesI) {
+		return nil
+	}
+	msg := fmt.Sprintf("Removing managed routes of VirtualService `%s`", vsvc.GetName())
+	return r.updateHttpRoutes(ctx, vsvc, newHttpRoutesI, msg)
+}
+
+func (r *Reconciler) updateHttpRoutes(ctx context.Context, vsvc *unstructured.Unstructured, httpRoutesI []interface{}, msg string) error {
+	newVsvc := vsvc.DeepCopy()
+	err := unstructured.SetNestedSlice(newVsvc.Object, httpRoutesI, "spec", "http")
+	if err != nil {
+		return err
+	}
+	r.log.Info(msg)
+	r.recorder.Event(r.rollout, corev1.EventTypeNormal, "UpdatingVirtualService", msg)
+	return r.updateVirtualService(ctx, newVsvc)
+}
+
+func (r *Reconciler) managedRouteNames() map[string]bool {
+	names := map[string]bool{}
+	for _, route := range r.rollout.Spec.Strategy.Canary.TrafficRouting.ManagedRoutes {
+		names[route.Name] = true
+	}
+	return names
+}
+
+func getRouteName(routeI interface{}) string {
+	route, ok := routeI.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	name, _ := route["name"].(string)
+	return name
+}
+
+// setManagedRoute returns the http routes with the managed route of the given name set to route, or
+// removed if route is nil. Managed routes are kept ahead of the other routes in the order of the
+// managedRoutes of the rollout.
+func (r *Reconciler) setManagedRoute(httpRoutesI []interface{}, name string, route map[string]interface{}) []interface{} {
+	managedRoutes := r.managedRouteNames()
+	current := map[string]interface{}{}
+	otherRoutes := []interface{}{}
+	for _, routeI := range httpRoutesI {
+		routeName := getRouteName(routeI)
+		if managedRoutes[routeName] {
+			current[routeName] = routeI
+		} else {
+			otherRoutes = append(otherRoutes, routeI)
+		}
+	}
+	if route != nil {
+		current[name] = route
+	} else {
+		delete(current, name)
+	}
+	newHttpRoutesI := []interface{}{}
+	for _, managedRoute := range r.rollout.Spec.Strategy.Canary.TrafficRouting.ManagedRoutes {
+		if routeI, ok := current[managedRoute.Name]; ok {
+			newHttpRoutesI = append(newHttpRoutesI, routeI)
+		}
+	}
+	return append(newHttpRoutesI, otherRoutes...)
+}
+
+// newHeaderRoute returns a route sending the requests matching any of the conditions of the header
+// route to the canary destination of the routes of the rollout
+func (r *Reconciler) newHeaderRoute(httpRoutesI []interface{}, headerRoute *v1alpha1.SetHeaderRoute) (map[string]interface{}, error) {
+	destination, err := r.getCanaryDestination(httpRoutesI)
+	if err != nil {
+		return nil, err
+	}
+	matches := []interface{}{}
+	for _, match := range headerRoute.Match {
+		var name string
+		var value map[string]interface{}
+		if match.CookieName != "" {
+			name = "cookie"
+			value = map[string]interface{}{"regex": cookieRegex(match.CookieName, match.Value)}
+		} else {
+			// Istio requires header names to be lowercase
+			name = strings.ToLower(match.HeaderName)
+			value = stringMatch(match.Value)
+		}
+		matches = append(matches, map[string]interface{}{
+			"headers": map[string]interface{}{name: value},
+		})
+	}
+	return map[string]interface{}{
+		"name":  headerRoute.Name,
+		"match": matches,
+		"route": []interface{}{
+			map[string]interface{}{"destination": destination},
+		},
+	}, nil
+}
+
+// newMirrorRoute returns a route sending the requests matching any of the conditions of the mirror
+// route to the destinations of the first route of the rollout and mirroring them to the canary
+func (r *Reconciler) newMirrorRoute(httpRoutesI []interface{}, mirrorRoute *v1alpha1.SetMirrorRoute) (map[string]interface{}, error) {
+	destination, err := r.getCanaryDestination(httpRoutesI)
+	if err != nil {
+		return nil, err
+	}
+	routeName := r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Routes[0]
+	var destinations []interface{}
+	for _, routeI := range httpRoutesI {
+		if getRouteName(routeI) == routeName {
+			destinations, _ = routeI.(map[string]interface{})["route"].([]interface{})
+		}
+	}
+	matches := []interface{}{}
+	for _, match := range mirrorRoute.Match {
+		m := map[string]interface{}{}
+		if match.Method != nil {
+			m["method"] = stringMatch(*match.Method)
+		}
+		if match.Path != nil {
+			m["uri"] = stringMatch(*match.Path)
+		}
+		if len(match.Headers) > 0 {
+			headers := map[string]interface{}{}
+			for name, value := range match.Headers {
+				// Istio requires header names to be lowercase
+				headers[strings.ToLower(name)] = stringMatch(value)
+			}
+			m["headers"] = headers
+		}
+		matches = append(matches, m)
+	}
+	percentage := int32(100)
+	if mirrorRoute.Percentage != nil {
+		percentage = *mirrorRoute.Percentage
+	}
+	return map[string]interface{}{
+		"name":             mirrorRoute.Name,
+		"match":            matches,
+		"route":            runtime.DeepCopyJSONValue(destinations),
+		"mirror":           destination,
+		"mirrorPercentage": map[string]interface{}{"value": float64(percentage)},
+	}, nil
+}
+
+// getCanaryDestination returns a copy of the destination of the canary service in the first route of the rollout
+func (r *Reconciler) getCanaryDestination(httpRoutesI []interface{}) (map[string]interface{}, error) {
+	routeName := r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Routes[0]
+	canarySvc := r.rollout.Spec.Strategy.Canary.CanaryService
+	for _, routeI := range httpRoutesI {
+		if getRouteName(routeI) != routeName {
+			continue
+		}
+		route := routeI.(map[string]interface{})
+		destinations, ok := route["route"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf(invalidCasting, "http[].route", "[]interface")
+		}
+		for _, destinationI := range destinations {
+			destination, ok := destinationI.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf(invalidCasting, "http[].route[]", "map[string]interface")
+			}
+			dest, ok := destination["destination"].(map[string]interface{})
+			if ok && dest["host"] == canarySvc {
+				return runtime.DeepCopyJSON(dest), nil
+			}
+		}
+		return nil, fmt.Errorf("Canary Service '%s' not found in route", canarySvc)
+	}
+	return nil, fmt.Errorf("Route '%s' is not found", routeName)
+}
+
+func stringMatch(match v1alpha1.StringMatch) map[string]interface{} {
+	switch {
+	case match.Exact != "":
+		return map[string]interface{}{"exact": match.Exact}
+	case match.Prefix != "":
+		return map[string]interface{}{"prefix": match.Prefix}
+	default:
+		return map[string]interface{}{"regex": match.Regex}
+	}
+}
+
+// cookieRegex returns a regular expression matching the cookie header of requests which have a
+// cookie with the given name and a value matching the string match
+func cookieRegex(name string, match v1alpha1.StringMatch) string {
+	var value string
+	switch {
+	case match.Exact != "":
+		value = regexp.QuoteMeta(match.Exact)
+	case match.Prefix != "":
+		value = regexp.QuoteMeta(match.Prefix) + "[^;]*"
+	default:
+		value = "(?:" + match.Regex + ")"
+	}
+	return fmt.Sprintf("^(.*?;\\s*)?(%s=%s)(;.*)?$", regexp.QuoteMeta(name), value)
 }
 
 func (r *Reconciler) VerifyWeight(desiredWeight int32) (bool, error) {
@@ -297,6 +623,7 @@ type route struct {
 
 // httpRoute fields within the HTTP struct of the Virtual Service that the controller modifies
 type HttpRoute struct {
-	Name  string  `json:"name,omitempty"`
-	Route []route `json:"route,omitempty"`
+	Name   string       `json:"name,omitempty"`
+	Route  []route      `json:"route,omitempty"`
+	Mirror *destination `json:"mirror,omitempty"`
 }
@@ -1,7 +1,9 @@
 package istio
 
 import (
+	"context"
 	"fmt"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -19,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 )
@@ -330,3 +333,214 @@ func TestGetRolloutVirtualServiceKeys(t *testing.T) {
 	assert.Len(t, keys, 1)
 	assert.Equal(t, keys[0], "default/test")
 }
+
+func rolloutWithManagedRoutes(managedRoutes ...string) *v1alpha1.Rollout {
+	ro := rollout("stable", "canary", "vsvc", []string{"primary"})
+	for _, name := range managedRoutes {
+		ro.Spec.Strategy.Canary.TrafficRouting.ManagedRoutes = append(ro.Spec.Strategy.Canary.TrafficRouting.ManagedRoutes, v1alpha1.ManagedRoute{Name: name})
+	}
+	return ro
+}
+
+func getHttpRoutes(t *testing.T, client *fake.FakeDynamicClient) []interface{} {
+	vsvc, err := client.Resource(istioutil.GetIstioGVR("v1alpha3")).Namespace("default").Get(context.TODO(), "vsvc", metav1.GetOptions{})
+	assert.NoError(t, err)
+	routes, _, err := unstructured.NestedSlice(vsvc.Object, "spec", "http")
+	assert.NoError(t, err)
+	return routes
+}
+
+func TestSetHeaderRoute(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(regularVsvc))
+	ro := rolloutWithManagedRoutes("header-route")
+	r := NewReconciler(ro, client, &record.FakeRecorder{}, "v1alpha3", nil)
+	headerRoute := &v1alpha1.SetHeaderRoute{
+		Name: "header-route",
+		Match: []v1alpha1.HeaderRoutingMatch{
+			{HeaderName: "X-Canary", Value: v1alpha1.StringMatch{Exact: "true"}},
+			{HeaderName: "agent", Value: v1alpha1.StringMatch{Prefix: "Mozilla"}},
+		},
+	}
+	err := r.SetHeaderRoute(headerRoute)
+	assert.NoError(t, err)
+
+	routes := getHttpRoutes(t, client)
+	assert.Len(t, routes, 3)
+	route := routes[0].(map[string]interface{})
+	assert.Equal(t, "header-route", route["name"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"headers": map[string]interface{}{"x-canary": map[string]interface{}{"exact": "true"}}},
+		map[string]interface{}{"headers": map[string]interface{}{"agent": map[string]interface{}{"prefix": "Mozilla"}}},
+	}, route["match"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"destination": map[string]interface{}{"host": "canary"}},
+	}, route["route"])
+	assert.Equal(t, "primary", routes[1].(map[string]interface{})["name"])
+
+	// setting the same route again does not update the VirtualService
+	client.ClearActions()
+	err = r.SetHeaderRoute(headerRoute)
+	assert.NoError(t, err)
+	assert.Len(t, client.Actions(), 0)
+
+	// a header route without match conditions removes the route
+	err = r.SetHeaderRoute(&v1alpha1.SetHeaderRoute{Name: "header-route"})
+	assert.NoError(t, err)
+	routes = getHttpRoutes(t, client)
+	assert.Len(t, routes, 2)
+	assert.Equal(t, "primary", routes[0].(map[string]interface{})["name"])
+}
+
+func TestSetHeaderRouteKeepsManagedRoutesOrder(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(regularVsvc))
+	ro := rolloutWithManagedRoutes("first", "second")
+	r := NewReconciler(ro, client, &record.FakeRecorder{}, "v1alpha3", nil)
+	match := []v1alpha1.HeaderRoutingMatch{{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Exact: "true"}}}
+
+	assert.NoError(t, r.SetHeaderRoute(&v1alpha1.SetHeaderRoute{Name: "second", Match: match}))
+	assert.NoError(t, r.SetHeaderRoute(&v1alpha1.SetHeaderRoute{Name: "first", Match: match}))
+
+	routes := getHttpRoutes(t, client)
+	var names []string
+	for _, route := range routes {
+		names = append(names, route.(map[string]interface{})["name"].(string))
+	}
+	assert.Equal(t, []string{"first", "second", "primary", "secondary"}, names)
+}
+
+func TestSetHeaderRouteCookie(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(regularVsvc))
+	ro := rolloutWithManagedRoutes("header-route")
+	r := NewReconciler(ro, client, &record.FakeRecorder{}, "v1alpha3", nil)
+	err := r.SetHeaderRoute(&v1alpha1.SetHeaderRoute{
+		Name:  "header-route",
+		Match: []v1alpha1.HeaderRoutingMatch{{CookieName: "beta", Value: v1alpha1.StringMatch{Exact: "yes"}}},
+	})
+	assert.NoError(t, err)
+
+	routes := getHttpRoutes(t, client)
+	match := routes[0].(map[string]interface{})["match"].([]interface{})[0].(map[string]interface{})
+	regex := match["headers"].(map[string]interface{})["cookie"].(map[string]interface{})["regex"].(string)
+	re := regexp.MustCompile(regex)
+	assert.True(t, re.MatchString("beta=yes"))
+	assert.True(t, re.MatchString("session=abc; beta=yes; theme=dark"))
+	assert.False(t, re.MatchString("beta=no"))
+	assert.False(t, re.MatchString("notbeta=yes"))
+}
+
+func TestSetHeaderRouteCanaryNotFound(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(regularVsvc))
+	ro := rolloutWithManagedRoutes("header-route")
+	ro.Spec.Strategy.Canary.CanaryService = "does-not-exist"
+	r := NewReconciler(ro, client, &record.FakeRecorder{}, "v1alpha3", nil)
+	err := r.SetHeaderRoute(&v1alpha1.SetHeaderRoute{
+		Name:  "header-route",
+		Match: []v1alpha1.HeaderRoutingMatch{{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Exact: "true"}}},
+	})
+	assert.EqualError(t, err, "Canary Service 'does-not-exist' not found in route")
+}
+
+func TestRemoveManagedRoutes(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(regularVsvc))
+	ro := rolloutWithManagedRoutes("header-route")
+	r := NewReconciler(ro, client, &record.FakeRecorder{}, "v1alpha3", nil)
+	err := r.SetHeaderRoute(&v1alpha1.SetHeaderRoute{
+		Name:  "header-route",
+		Match: []v1alpha1.HeaderRoutingMatch{{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Regex: "tr.*"}}},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, getHttpRoutes(t, client), 3)
+
+	err = r.RemoveManagedRoutes()
+	assert.NoError(t, err)
+	assert.Len(t, getHttpRoutes(t, client), 2)
+
+	// nothing left to remove
+	client.ClearActions()
+	err = r.RemoveManagedRoutes()
+	assert.NoError(t, err)
+	for _, action := range client.Actions() {
+		assert.NotEqual(t, "update", action.GetVerb())
+	}
+}
+
+func TestSetMirrorRoute(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(regularVsvc))
+	ro := rolloutWithManagedRoutes("mirror-route")
+	r := NewReconciler(ro, client, &record.FakeRecorder{}, "v1alpha3", nil)
+	mirrorRoute := &v1alpha1.SetMirrorRoute{
+		Name: "mirror-route",
+		Match: []v1alpha1.RouteMatch{{
+			Method:  &v1alpha1.StringMatch{Exact: "GET"},
+			Path:    &v1alpha1.StringMatch{Prefix: "/api"},
+			Headers: map[string]v1alpha1.StringMatch{"X-Tenant": {Exact: "internal"}},
+		}},
+		Percentage: pointer.Int32Ptr(20),
+	}
+	err := r.SetMirrorRoute(mirrorRoute)
+	assert.NoError(t, err)
+
+	routes := getHttpRoutes(t, client)
+	assert.Len(t, routes, 3)
+	route := routes[0].(map[string]interface{})
+	assert.Equal(t, "mirror-route", route["name"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"method":  map[string]interface{}{"exact": "GET"},
+			"uri":     map[string]interface{}{"prefix": "/api"},
+			"headers": map[string]interface{}{"x-tenant": map[string]interface{}{"exact": "internal"}},
+		},
+	}, route["match"])
+	assert.Equal(t, map[string]interface{}{"host": "canary"}, route["mirror"])
+	assert.Equal(t, map[string]interface{}{"value": float64(20)}, route["mirrorPercentage"])
+	// the mirrored requests are still served like the requests of the primary route
+	checkDestination(t, route, "stable", 100)
+	checkDestination(t, route, "canary", 0)
+
+	// setting the same route again does not update the VirtualService
+	client.ClearActions()
+	err = r.SetMirrorRoute(mirrorRoute)
+	assert.NoError(t, err)
+	assert.Len(t, client.Actions(), 0)
+
+	// the weights of the mirror route follow the weights of the routes of the rollout
+	err = r.SetWeight(10)
+	assert.NoError(t, err)
+	routes = getHttpRoutes(t, client)
+	checkDestination(t, routes[0].(map[string]interface{}), "stable", 90)
+	checkDestination(t, routes[0].(map[string]interface{}), "canary", 10)
+	checkDestination(t, routes[1].(map[string]interface{}), "canary", 10)
+
+	// a mirror route without match conditions removes the route
+	err = r.SetMirrorRoute(&v1alpha1.SetMirrorRoute{Name: "mirror-route"})
+	assert.NoError(t, err)
+	routes = getHttpRoutes(t, client)
+	assert.Len(t, routes, 2)
+	assert.Equal(t, "primary", routes[0].(map[string]interface{})["name"])
+}
+
+func TestSetMirrorRouteDefaultPercentage(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(regularVsvc))
+	ro := rolloutWithManagedRoutes("mirror-route")
+	r := NewReconciler(ro, client, &record.FakeRecorder{}, "v1alpha3", nil)
+	err := r.SetMirrorRoute(&v1alpha1.SetMirrorRoute{
+		Name:  "mirror-route",
+		Match: []v1alpha1.RouteMatch{{Path: &v1alpha1.StringMatch{Regex: "/.*"}}},
+	})
+	assert.NoError(t, err)
+	route := getHttpRoutes(t, client)[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"value": float64(100)}, route["mirrorPercentage"])
+}
+
+func TestRoutesEqual(t *testing.T) {
+	a := []interface{}{map[string]interface{}{"weight": int64(10)}}
+	b := []interface{}{map[string]interface{}{"weight": float64(10)}}
+	equal, err := routesEqual(a, b)
+	assert.NoError(t, err)
+	assert.True(t, equal)
+
+	b = []interface{}{map[string]interface{}{"weight": float64(20)}}
+	equal, err = routesEqual(a, b)
+	assert.NoError(t, err)
+	assert.False(t, equal)
+}
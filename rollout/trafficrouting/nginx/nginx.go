@@ -230,3 +230,18 @@ func (r *Reconciler) SetWeight(desiredWeight int32) error {
 func (r *Reconciler) VerifyWeight(desiredWeight int32) (bool, error) {
 	return true, nil
 }
+
+// SetHeaderRoute is a no-op since header routes are not supported by the nginx ingress controller
+func (r *Reconciler) SetHeaderRoute(headerRoute *v1alpha1.SetHeaderRoute) error {
+	return nil
+}
+
+// SetMirrorRoute is a no-op since mirror routes are not supported by the nginx ingress controller
+func (r *Reconciler) SetMirrorRoute(mirrorRoute *v1alpha1.SetMirrorRoute) error {
+	return nil
+}
+
+// RemoveManagedRoutes is a no-op since managed routes are not supported by the nginx ingress controller
+func (r *Reconciler) RemoveManagedRoutes() error {
+	return nil
+}
@@ -0,0 +1,126 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+	pluginutil "github.com/argoproj/argo-rollouts/utils/plugin"
+)
+
+// Type holds this controller type
+const Type = "Plugin"
+
+// callTimeout is the maximum duration of a call to a plugin
+const callTimeout = 30 * time.Second
+
+var registry = pluginutil.NewRegistry()
+
+// RegisterPlugins dials the traffic router plugins of name=address pairs and registers them with
+// the controller
+func RegisterPlugins(pairs []string) error {
+	return registry.RegisterAddresses(pairs)
+}
+
+// RegisterPlugin registers the connection to the traffic router plugin with the name
+func RegisterPlugin(name string, conn grpc.ClientConnInterface) {
+	registry.Register(name, conn)
+}
+
+// NewClient returns a client of the registered traffic router plugin with the name
+func NewClient(name string) (TrafficRouterClient, error) {
+	conn, err := registry.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewTrafficRouterClient(conn), nil
+}
+
+// ReconcilerConfig describes static configuration data for the plugin reconciler
+type ReconcilerConfig struct {
+	Rollout  *v1alpha1.Rollout
+	Client   TrafficRouterClient
+	Recorder record.EventRecorder
+}
+
+// Reconciler holds required fields to reconcile traffic routing through a plugin
+type Reconciler struct {
+	cfg ReconcilerConfig
+	log *logrus.Entry
+}
+
+// NewReconciler returns a reconciler struct that delegates the traffic routing to a plugin
+func NewReconciler(cfg ReconcilerConfig) *Reconciler {
+	return &Reconciler{
+		cfg: cfg,
+		log: logutil.WithRollout(cfg.Rollout),
+	}
+}
+
+func (r *Reconciler) pluginConfig() *v1alpha1.PluginTrafficRouting {
+	return r.cfg.Rollout.Spec.Strategy.Canary.TrafficRouting.Plugin
+}
+
+// Type returns the type of the reconciler. The type is only used for logging on every reconcile,
+// so it does not call the plugin, which could stall the reconcile when the plugin is unreachable.
+func (r *Reconciler) Type() string {
+	return Type
+}
+
+// SetWeight asks the plugin to route the desired weight of the traffic to the canary
+func (r *Reconciler) SetWeight(desiredWeight int32) error {
+	ctx, cancel := context.WithTimeout(context.TODO(), callTimeout)
+	defer cancel()
+	plugin := r.pluginConfig()
+	msg := fmt.Sprintf("Setting the weight of plugin '%s' to desiredWeight '%d'", plugin.Name, desiredWeight)
+	r.log.Info(msg)
+	_, err := r.cfg.Client.SetWeight(ctx, &SetWeightRequest{
+		Rollout:       r.cfg.Rollout,
+		Config:        plugin.Config,
+		DesiredWeight: desiredWeight,
+	})
+	if err != nil {
+		msg = fmt.Sprintf("Plugin '%s' failed to set the weight: %v", plugin.Name, err)
+		r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeWarning, "PluginSetWeightFailed", msg)
+		return err
+	}
+	return nil
+}
+
+// VerifyWeight asks the plugin whether the canary receives the desired weight of the traffic
+func (r *Reconciler) VerifyWeight(desiredWeight int32) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.TODO(), callTimeout)
+	defer cancel()
+	plugin := r.pluginConfig()
+	resp, err := r.cfg.Client.VerifyWeight(ctx, &VerifyWeightRequest{
+		Rollout:       r.cfg.Rollout,
+		Config:        plugin.Config,
+		DesiredWeight: desiredWeight,
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.Verified, nil
+}
+
+// SetHeaderRoute is a no-op since header routes are not supported by the plugin reconciler
+func (r *Reconciler) SetHeaderRoute(headerRoute *v1alpha1.SetHeaderRoute) error {
+	return nil
+}
+
+// SetMirrorRoute is a no-op since mirror routes are not supported by the plugin reconciler
+func (r *Reconciler) SetMirrorRoute(mirrorRoute *v1alpha1.SetMirrorRoute) error {
+	return nil
+}
+
+// RemoveManagedRoutes is a no-op since managed routes are not supported by the plugin reconciler
+func (r *Reconciler) RemoveManagedRoutes() error {
+	return nil
+}
@@ -0,0 +1,159 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+// fakePlugin is an in-process traffic router plugin which keeps the weight in memory
+type fakePlugin struct {
+	weight   int32
+	requests []*SetWeightRequest
+	err      error
+}
+
+func (p *fakePlugin) SetWeight(ctx context.Context, req *SetWeightRequest) (*SetWeightResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.requests = append(p.requests, req)
+	p.weight = req.DesiredWeight
+	return &SetWeightResponse{}, nil
+}
+
+func (p *fakePlugin) VerifyWeight(ctx context.Context, req *VerifyWeightRequest) (*VerifyWeightResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &VerifyWeightResponse{Verified: p.weight == req.DesiredWeight}, nil
+}
+
+// startFakePlugin serves the fake plugin over an in-memory listener and returns a connection to
+// it, along with a function which stops the plugin
+func startFakePlugin(t *testing.T, plugin *fakePlugin) (*grpc.ClientConn, func()) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	RegisterTrafficRouterServer(server, plugin)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	assert.NoError(t, err)
+	return conn, func() {
+		_ = conn.Close()
+		server.Stop()
+	}
+}
+
+func rollout() *v1alpha1.Rollout {
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rollout",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					StableService: "stable",
+					CanaryService: "canary",
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+						Plugin: &v1alpha1.PluginTrafficRouting{
+							Name:   "fake",
+							Config: `{"route": "my-route"}`,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newReconciler(t *testing.T, plugin *fakePlugin) (*Reconciler, func()) {
+	conn, stop := startFakePlugin(t, plugin)
+	return NewReconciler(ReconcilerConfig{
+		Rollout:  rollout(),
+		Client:   NewTrafficRouterClient(conn),
+		Recorder: &record.FakeRecorder{},
+	}), stop
+}
+
+func TestType(t *testing.T) {
+	// the plugin is not called, even when it fails
+	r, stop := newReconciler(t, &fakePlugin{err: fmt.Errorf("intentional error")})
+	defer stop()
+	assert.Equal(t, Type, r.Type())
+}
+
+func TestSetWeight(t *testing.T) {
+	plugin := &fakePlugin{}
+	r, stop := newReconciler(t, plugin)
+	defer stop()
+
+	verified, err := r.VerifyWeight(30)
+	assert.NoError(t, err)
+	assert.False(t, verified)
+
+	err = r.SetWeight(30)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(30), plugin.weight)
+	assert.Len(t, plugin.requests, 1)
+	assert.Equal(t, `{"route": "my-route"}`, plugin.requests[0].Config)
+	assert.Equal(t, "rollout", plugin.requests[0].Rollout.Name)
+
+	verified, err = r.VerifyWeight(30)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+}
+
+func TestSetWeightError(t *testing.T) {
+	recorder := &record.FakeRecorder{Events: make(chan string, 1)}
+	r, stop := newReconciler(t, &fakePlugin{err: fmt.Errorf("intentional error")})
+	defer stop()
+	r.cfg.Recorder = recorder
+
+	err := r.SetWeight(30)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "intentional error")
+	assert.Contains(t, <-recorder.Events, "PluginSetWeightFailed")
+
+	_, err = r.VerifyWeight(30)
+	assert.Error(t, err)
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient("not-registered")
+	assert.EqualError(t, err, "plugin 'not-registered' is not registered")
+
+	plugin := &fakePlugin{}
+	conn, stop := startFakePlugin(t, plugin)
+	defer stop()
+	RegisterPlugin("registered", conn)
+	client, err := NewClient("registered")
+	assert.NoError(t, err)
+	_, err = client.SetWeight(context.TODO(), &SetWeightRequest{DesiredWeight: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(10), plugin.weight)
+}
+
+func TestManagedRoutesNoOp(t *testing.T) {
+	plugin := &fakePlugin{}
+	r, stop := newReconciler(t, plugin)
+	defer stop()
+	assert.NoError(t, r.SetHeaderRoute(&v1alpha1.SetHeaderRoute{}))
+	assert.NoError(t, r.SetMirrorRoute(&v1alpha1.SetMirrorRoute{}))
+	assert.NoError(t, r.RemoveManagedRoutes())
+	assert.Len(t, plugin.requests, 0)
+}
@@ -0,0 +1,109 @@
+package plugin
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	pluginutil "github.com/argoproj/argo-rollouts/utils/plugin"
+)
+
+// ServiceName is the name of the gRPC service traffic router plugins implement
+const ServiceName = "argoproj.rollouts.TrafficRouterPlugin"
+
+// SetWeightRequest asks the plugin to route the desired weight of the traffic to the canary
+type SetWeightRequest struct {
+	Rollout       *v1alpha1.Rollout `json:"rollout"`
+	Config        string            `json:"config,omitempty"`
+	DesiredWeight int32             `json:"desiredWeight"`
+}
+
+// SetWeightResponse is the response of the plugin to a SetWeightRequest
+type SetWeightResponse struct{}
+
+// VerifyWeightRequest asks the plugin whether the canary receives the desired weight of the traffic
+type VerifyWeightRequest struct {
+	Rollout       *v1alpha1.Rollout `json:"rollout"`
+	Config        string            `json:"config,omitempty"`
+	DesiredWeight int32             `json:"desiredWeight"`
+}
+
+// VerifyWeightResponse is the response of the plugin to a VerifyWeightRequest
+type VerifyWeightResponse struct {
+	Verified bool `json:"verified"`
+}
+
+// TrafficRouterClient is the client API of a traffic router plugin
+type TrafficRouterClient interface {
+	SetWeight(ctx context.Context, in *SetWeightRequest, opts ...grpc.CallOption) (*SetWeightResponse, error)
+	VerifyWeight(ctx context.Context, in *VerifyWeightRequest, opts ...grpc.CallOption) (*VerifyWeightResponse, error)
+}
+
+type trafficRouterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewTrafficRouterClient returns a client of the traffic router plugin on the connection
+func NewTrafficRouterClient(cc grpc.ClientConnInterface) TrafficRouterClient {
+	return &trafficRouterClient{cc: cc}
+}
+
+func (c *trafficRouterClient) SetWeight(ctx context.Context, in *SetWeightRequest, opts ...grpc.CallOption) (*SetWeightResponse, error) {
+	out := new(SetWeightResponse)
+	if err := pluginutil.Invoke(ctx, c.cc, "/"+ServiceName+"/SetWeight", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trafficRouterClient) VerifyWeight(ctx context.Context, in *VerifyWeightRequest, opts ...grpc.CallOption) (*VerifyWeightResponse, error) {
+	out := new(VerifyWeightResponse)
+	if err := pluginutil.Invoke(ctx, c.cc, "/"+ServiceName+"/VerifyWeight", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TrafficRouterServer is the API a traffic router plugin implements
+type TrafficRouterServer interface {
+	SetWeight(context.Context, *SetWeightRequest) (*SetWeightResponse, error)
+	VerifyWeight(context.Context, *VerifyWeightRequest) (*VerifyWeightResponse, error)
+}
+
+// RegisterTrafficRouterServer registers the traffic router plugin implementation with the gRPC server
+func RegisterTrafficRouterServer(s *grpc.Server, srv TrafficRouterServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*TrafficRouterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SetWeight",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(SetWeightRequest)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return pluginutil.HandleUnary(ctx, srv, "/"+ServiceName+"/SetWeight", in, interceptor, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(TrafficRouterServer).SetWeight(ctx, req.(*SetWeightRequest))
+				})
+			},
+		},
+		{
+			MethodName: "VerifyWeight",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(VerifyWeightRequest)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return pluginutil.HandleUnary(ctx, srv, "/"+ServiceName+"/VerifyWeight", in, interceptor, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(TrafficRouterServer).VerifyWeight(ctx, req.(*VerifyWeightRequest))
+				})
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
@@ -316,3 +316,18 @@ func trafficSplitV1Alpha3(ro *v1alpha1.Rollout, objectMeta metav1.ObjectMeta, ro
 		},
 	}
 }
+
+// SetHeaderRoute is a no-op since header routes are not supported by SMI TrafficSplits
+func (r *Reconciler) SetHeaderRoute(headerRoute *v1alpha1.SetHeaderRoute) error {
+	return nil
+}
+
+// SetMirrorRoute is a no-op since mirror routes are not supported by SMI TrafficSplits
+func (r *Reconciler) SetMirrorRoute(mirrorRoute *v1alpha1.SetMirrorRoute) error {
+	return nil
+}
+
+// RemoveManagedRoutes is a no-op since managed routes are not supported by SMI TrafficSplits
+func (r *Reconciler) RemoveManagedRoutes() error {
+	return nil
+}
@@ -0,0 +1,196 @@
+package traefik
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+// Type holds this controller type
+const Type = "Traefik"
+
+// GetTraefikServiceGVR returns the GroupVersionResource of the TraefikServices
+func GetTraefikServiceGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "traefik.containo.us",
+		Version:  "v1alpha1",
+		Resource: "traefikservices",
+	}
+}
+
+// ReconcilerConfig describes static configuration data for the Traefik reconciler
+type ReconcilerConfig struct {
+	Rollout  *v1alpha1.Rollout
+	Client   dynamic.Interface
+	Recorder record.EventRecorder
+}
+
+// Reconciler holds required fields to reconcile Traefik resources
+type Reconciler struct {
+	cfg ReconcilerConfig
+	log *logrus.Entry
+}
+
+// NewReconciler returns a reconciler struct that brings the TraefikService into the desired state
+func NewReconciler(cfg ReconcilerConfig) *Reconciler {
+	return &Reconciler{
+		cfg: cfg,
+		log: logutil.WithRollout(cfg.Rollout),
+	}
+}
+
+// Type indicates this reconciler is a Traefik reconciler
+func (r *Reconciler) Type() string {
+	return Type
+}
+
+func (r *Reconciler) traefikServiceClient() dynamic.ResourceInterface {
+	return r.cfg.Client.Resource(GetTraefikServiceGVR()).Namespace(r.cfg.Rollout.Namespace)
+}
+
+func (r *Reconciler) getTraefikService(ctx context.Context) (*unstructured.Unstructured, error) {
+	name := r.cfg.Rollout.Spec.Strategy.Canary.TrafficRouting.Traefik.WeightedTraefikServiceName
+	traefikService, err := r.traefikServiceClient().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			msg := fmt.Sprintf("TraefikService `%s` not found", name)
+			r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeWarning, "TraefikServiceNotFound", msg)
+		}
+		return nil, err
+	}
+	return traefikService, nil
+}
+
+// SetWeight sets the weight of the canary service of the weighted TraefikService to the desired
+// weight, and the weight of the stable service to the remaining weight
+func (r *Reconciler) SetWeight(desiredWeight int32) error {
+	ctx := context.TODO()
+	traefikService, err := r.getTraefikService(ctx)
+	if err != nil {
+		return err
+	}
+	services, err := getWeightedServices(traefikService)
+	if err != nil {
+		return err
+	}
+	stableService, canaryService, err := r.getStableAndCanaryServices(services)
+	if err != nil {
+		return err
+	}
+	if getWeight(canaryService) == int64(desiredWeight) && getWeight(stableService) == int64(100-desiredWeight) {
+		return nil
+	}
+	canaryService["weight"] = int64(desiredWeight)
+	stableService["weight"] = int64(100 - desiredWeight)
+	traefikService = traefikService.DeepCopy()
+	err = unstructured.SetNestedSlice(traefikService.Object, services, "spec", "weighted", "services")
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("Updating TraefikService `%s` to desiredWeight '%d'", traefikService.GetName(), desiredWeight)
+	r.log.Info(msg)
+	r.cfg.Recorder.Event(r.cfg.Rollout, corev1.EventTypeNormal, "UpdatingTraefikService", msg)
+	_, err = r.traefikServiceClient().Update(ctx, traefikService, metav1.UpdateOptions{})
+	return err
+}
+
+// VerifyWeight returns true if the services of the weighted TraefikService have the desired weights
+func (r *Reconciler) VerifyWeight(desiredWeight int32) (bool, error) {
+	traefikService, err := r.getTraefikService(context.TODO())
+	if err != nil {
+		return false, err
+	}
+	services, err := getWeightedServices(traefikService)
+	if err != nil {
+		return false, err
+	}
+	stableService, canaryService, err := r.getStableAndCanaryServices(services)
+	if err != nil {
+		return false, err
+	}
+	return getWeight(canaryService) == int64(desiredWeight) && getWeight(stableService) == int64(100-desiredWeight), nil
+}
+
+// SetHeaderRoute is a no-op since header routes are not supported by the Traefik reconciler
+func (r *Reconciler) SetHeaderRoute(headerRoute *v1alpha1.SetHeaderRoute) error {
+	return nil
+}
+
+// SetMirrorRoute is a no-op since mirror routes are not supported by the Traefik reconciler
+func (r *Reconciler) SetMirrorRoute(mirrorRoute *v1alpha1.SetMirrorRoute) error {
+	return nil
+}
+
+// RemoveManagedRoutes is a no-op since managed routes are not supported by the Traefik reconciler
+func (r *Reconciler) RemoveManagedRoutes() error {
+	return nil
+}
+
+// getStableAndCanaryServices returns the stable and canary services of the weighted services. The
+// returned maps belong to the services so they can be modified in place.
+func (r *Reconciler) getStableAndCanaryServices(services []interface{}) (map[string]interface{}, map[string]interface{}, error) {
+	stableSvc := r.cfg.Rollout.Spec.Strategy.Canary.StableService
+	canarySvc := r.cfg.Rollout.Spec.Strategy.Canary.CanaryService
+	stableService := findService(services, stableSvc)
+	if stableService == nil {
+		return nil, nil, fmt.Errorf("Stable Service '%s' not found in TraefikService", stableSvc)
+	}
+	canaryService := findService(services, canarySvc)
+	if canaryService == nil {
+		return nil, nil, fmt.Errorf("Canary Service '%s' not found in TraefikService", canarySvc)
+	}
+	return stableService, canaryService, nil
+}
+
+func getWeightedServices(traefikService *unstructured.Unstructured) ([]interface{}, error) {
+	services, found, err := unstructured.NestedSlice(traefikService.Object, "spec", "weighted", "services")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf(".spec.weighted.services is not defined")
+	}
+	return services, nil
+}
+
+// findService returns the Kubernetes service with the name among the weighted services, skipping
+// the services which refer to other TraefikServices
+func findService(services []interface{}, name string) map[string]interface{} {
+	for _, serviceI := range services {
+		service, ok := serviceI.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if kind, ok := service["kind"].(string); ok && kind != "Service" {
+			continue
+		}
+		if serviceName, ok := service["name"].(string); ok && serviceName == name {
+			return service
+		}
+	}
+	return nil
+}
+
+// getWeight returns the weight of a weighted service, which defaults to 1
+func getWeight(service map[string]interface{}) int64 {
+	switch w := service["weight"].(type) {
+	case int64:
+		return w
+	case int:
+		return int64(w)
+	case float64:
+		return int64(w)
+	}
+	return 1
+}
@@ -0,0 +1,152 @@
+package traefik
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	unstructuredutil "github.com/argoproj/argo-rollouts/utils/unstructured"
+)
+
+const traefikService = `
+apiVersion: traefik.containo.us/v1alpha1
+kind: TraefikService
+metadata:
+  name: traefik-service
+  namespace: default
+spec:
+  weighted:
+    services:
+    - name: stable
+      port: 80
+    - name: canary
+      port: 80
+      weight: 0
+    - name: canary
+      kind: TraefikService
+      weight: 10`
+
+func rollout(stableSvc, canarySvc string) *v1alpha1.Rollout {
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rollout",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					StableService: stableSvc,
+					CanaryService: canarySvc,
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+						Traefik: &v1alpha1.TraefikTrafficRouting{
+							WeightedTraefikServiceName: "traefik-service",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newReconciler(client *fake.FakeDynamicClient, ro *v1alpha1.Rollout) *Reconciler {
+	return NewReconciler(ReconcilerConfig{
+		Rollout:  ro,
+		Client:   client,
+		Recorder: &record.FakeRecorder{},
+	})
+}
+
+func serviceWeights(t *testing.T, client *fake.FakeDynamicClient) []int64 {
+	obj, err := client.Resource(GetTraefikServiceGVR()).Namespace("default").Get(context.TODO(), "traefik-service", metav1.GetOptions{})
+	assert.NoError(t, err)
+	services, err := getWeightedServices(obj)
+	assert.NoError(t, err)
+	weights := []int64{}
+	for _, service := range services {
+		weights = append(weights, getWeight(service.(map[string]interface{})))
+	}
+	return weights
+}
+
+func TestType(t *testing.T) {
+	r := newReconciler(fake.NewSimpleDynamicClient(runtime.NewScheme()), rollout("stable", "canary"))
+	assert.Equal(t, Type, r.Type())
+}
+
+func TestSetWeight(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), unstructuredutil.StrToUnstructuredUnsafe(traefikService))
+	r := newReconciler(client, rollout("stable", "canary"))
+
+	verified, err := r.VerifyWeight(30)
+	assert.NoError(t, err)
+	assert.False(t, verified)
+
+	err = r.SetWeight(30)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{70, 30, 10}, serviceWeights(t, client))
+
+	verified, err = r.VerifyWeight(30)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+
+	// no update when the TraefikService has the desired weights
+	actions := len(client.Actions())
+	err = r.SetWeight(30)
+	assert.NoError(t, err)
+	assert.Len(t, client.Actions(), actions+1)
+	assert.Equal(t, "get", client.Actions()[actions].GetVerb())
+
+	err = r.SetWeight(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{100, 0, 10}, serviceWeights(t, client))
+}
+
+func TestSetWeightServiceNotFound(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), unstructuredutil.StrToUnstructuredUnsafe(traefikService))
+
+	r := newReconciler(client, rollout("not-stable", "canary"))
+	err := r.SetWeight(10)
+	assert.EqualError(t, err, "Stable Service 'not-stable' not found in TraefikService")
+
+	r = newReconciler(client, rollout("stable", "not-canary"))
+	_, err = r.VerifyWeight(10)
+	assert.EqualError(t, err, "Canary Service 'not-canary' not found in TraefikService")
+}
+
+func TestSetWeightWithoutWeightedServices(t *testing.T) {
+	obj := unstructuredutil.StrToUnstructuredUnsafe(traefikService)
+	unstructured.RemoveNestedField(obj.Object, "spec", "weighted")
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), obj)
+	r := newReconciler(client, rollout("stable", "canary"))
+
+	err := r.SetWeight(10)
+	assert.EqualError(t, err, ".spec.weighted.services is not defined")
+}
+
+func TestSetWeightTraefikServiceNotFound(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	recorder := &record.FakeRecorder{Events: make(chan string, 1)}
+	r := newReconciler(client, rollout("stable", "canary"))
+	r.cfg.Recorder = recorder
+
+	err := r.SetWeight(10)
+	assert.True(t, k8serrors.IsNotFound(err))
+	assert.Contains(t, <-recorder.Events, "TraefikServiceNotFound")
+}
+
+func TestManagedRoutesNoOp(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	r := newReconciler(client, rollout("stable", "canary"))
+	assert.NoError(t, r.SetHeaderRoute(&v1alpha1.SetHeaderRoute{}))
+	assert.NoError(t, r.SetMirrorRoute(&v1alpha1.SetMirrorRoute{}))
+	assert.NoError(t, r.RemoveManagedRoutes())
+	assert.Len(t, client.Actions(), 0)
+}
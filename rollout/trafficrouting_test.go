@@ -1,12 +1,17 @@
 package rollout
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -18,19 +23,46 @@ import (
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/rollout/mocks"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/alb"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/ambassador"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/appmesh"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/gatewayapi"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/istio"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/nginx"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/plugin"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/smi"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/traefik"
 	"github.com/argoproj/argo-rollouts/utils/conditions"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
+	unstructuredutil "github.com/argoproj/argo-rollouts/utils/unstructured"
 )
 
+const istioVirtualService = `apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: istio-vsvc
+  namespace: default
+spec:
+  hosts:
+  - istio-rollout.dev.argoproj.io
+  http:
+  - name: primary
+    route:
+    - destination:
+        host: stable
+      weight: 100
+    - destination:
+        host: canary
+      weight: 0`
+
 // newFakeTrafficRoutingReconciler returns a fake TrafficRoutingReconciler with mocked success return values
 func newFakeTrafficRoutingReconciler() *mocks.TrafficRoutingReconciler {
 	r := mocks.TrafficRoutingReconciler{}
 	r.On("Type").Return("fake")
 	r.On("SetWeight", mock.Anything).Return(nil)
 	r.On("VerifyWeight", mock.Anything).Return(true, nil)
+	r.On("SetHeaderRoute", mock.Anything).Return(nil)
+	r.On("SetMirrorRoute", mock.Anything).Return(nil)
+	r.On("RemoveManagedRoutes").Return(nil)
 	return &r
 }
 
@@ -249,6 +281,442 @@ func TestRolloutSetWeightToZeroWhenFullyRolledOut(t *testing.T) {
 	f.run(getKey(r1, t))
 }
 
+// verify the traffic is only shifted back to the stable as its replicas become available when aborting
+// with dynamic stable scaling
+func TestRolloutDynamicStableScaleAbort(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{
+		{
+			SetWeight: pointer.Int32Ptr(50),
+		},
+		{
+			Pause: &v1alpha1.RolloutPause{},
+		},
+	}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+	r2 := bumpVersion(r1)
+	r2.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{}
+	r2.Spec.Strategy.Canary.CanaryService = "canary"
+	r2.Spec.Strategy.Canary.StableService = "stable"
+	r2.Spec.Strategy.Canary.DynamicStableScale = true
+
+	rs1 := newReplicaSetWithStatus(r1, 5, 5)
+	rs2 := newReplicaSetWithStatus(r2, 5, 5)
+
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	rs2PodHash := rs2.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	canarySelector := map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs2PodHash}
+	stableSelector := map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs1PodHash}
+	canarySvc := newService("canary", 80, canarySelector, r2)
+	stableSvc := newService("stable", 80, stableSelector, r2)
+
+	f.kubeobjects = append(f.kubeobjects, rs1, rs2, canarySvc, stableSvc)
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2)
+
+	r2 = updateCanaryRolloutStatus(r2, rs1PodHash, 10, 5, 10, false)
+	r2.Status.Abort = true
+	now := metav1.Now()
+	r2.Status.AbortedAt = &now
+	r2.Status.Canary.Weights = &v1alpha1.TrafficWeights{
+		Canary: v1alpha1.WeightDestination{Weight: 50, ServiceName: "canary", PodTemplateHash: rs2PodHash},
+		Stable: v1alpha1.WeightDestination{Weight: 50, ServiceName: "stable", PodTemplateHash: rs1PodHash},
+	}
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.objects = append(f.objects, r2)
+
+	// the stable is scaled up first while the canary keeps receiving half of the traffic
+	updatedStableIndex := f.expectUpdateReplicaSetAction(rs1)
+	f.expectPatchRolloutAction(r2)
+
+	f.fakeTrafficRouting = newUnmockedFakeTrafficRoutingReconciler()
+	f.fakeTrafficRouting.On("SetWeight", mock.Anything).Return(func(desiredWeight int32) error {
+		assert.Equal(t, int32(50), desiredWeight)
+		return nil
+	})
+	f.fakeTrafficRouting.On("VerifyWeight", mock.Anything).Return(true, nil)
+	f.run(getKey(r2, t))
+
+	updatedStable := f.getUpdatedReplicaSet(updatedStableIndex)
+	assert.Equal(t, int32(10), *updatedStable.Spec.Replicas)
+}
+
+// verify the traffic is kept on the canary until the stable is switched to the new RS when promoting
+// with dynamic stable scaling, by reconciling the rollout from its update to its promotion
+func TestRolloutDynamicStableScalePromotion(t *testing.T) {
+	steps := []v1alpha1.CanaryStep{
+		{
+			SetWeight: pointer.Int32Ptr(50),
+		},
+		{
+			Pause: &v1alpha1.RolloutPause{},
+		},
+	}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(2), intstr.FromInt(1), intstr.FromInt(0))
+	r1.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{}
+	r1.Spec.Strategy.Canary.CanaryService = "canary"
+	r1.Spec.Strategy.Canary.StableService = "stable"
+	r1.Spec.Strategy.Canary.DynamicStableScale = true
+	r2 := bumpVersion(r1)
+
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	rs2 := newReplicaSet(r2, 0)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	rs2PodHash := rs2.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	stableSelector := map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs1PodHash}
+
+	rollout := updateCanaryRolloutStatus(r2, rs1PodHash, 10, 0, 10, false)
+	rollout.Status.CurrentStepIndex = pointer.Int32Ptr(0)
+	replicaSets := []*appsv1.ReplicaSet{rs1}
+	services := []*corev1.Service{newService("canary", 80, stableSelector, r2), newService("stable", 80, stableSelector, r2)}
+
+	reconciles := []struct {
+		name           string
+		expectActions  func(f *fixture)
+		weight         int32
+		stepIndex      int32
+		stableRS       string
+		stableReplicas int32
+		canaryReplicas int32
+	}{
+		{
+			name: "canary created",
+			expectActions: func(f *fixture) {
+				f.expectUpdateRolloutStatusAction(rollout)
+				f.expectPatchRolloutAction(rollout)
+				f.expectCreateReplicaSetAction(rs2)
+				f.expectPatchServiceAction(services[0], rs2PodHash)
+			},
+			weight:         0,
+			stepIndex:      0,
+			stableRS:       rs1PodHash,
+			stableReplicas: 10,
+			canaryReplicas: 5,
+		},
+		{
+			name: "traffic shifted to the canary",
+			expectActions: func(f *fixture) {
+				f.expectPatchRolloutAction(rollout)
+			},
+			weight:         50,
+			stepIndex:      1,
+			stableRS:       rs1PodHash,
+			stableReplicas: 10,
+			canaryReplicas: 5,
+		},
+		{
+			name: "stable scaled down",
+			expectActions: func(f *fixture) {
+				f.expectUpdateReplicaSetAction(rs1)
+			},
+			weight:         50,
+			stepIndex:      1,
+			stableRS:       rs1PodHash,
+			stableReplicas: 5,
+			canaryReplicas: 5,
+		},
+		{
+			name: "paused",
+			expectActions: func(f *fixture) {
+				f.expectPatchRolloutAction(rollout)
+			},
+			weight:         50,
+			stepIndex:      1,
+			stableRS:       rs1PodHash,
+			stableReplicas: 5,
+			canaryReplicas: 5,
+		},
+		{
+			name: "canary scaled up after the promotion",
+			expectActions: func(f *fixture) {
+				f.expectUpdateReplicaSetAction(rs2)
+			},
+			weight:         50,
+			stepIndex:      2,
+			stableRS:       rs1PodHash,
+			stableReplicas: 5,
+			canaryReplicas: 10,
+		},
+		{
+			name: "traffic shifted to the canary before the stable is switched",
+			expectActions: func(f *fixture) {
+				f.expectPatchRolloutAction(rollout)
+			},
+			weight:         100,
+			stepIndex:      2,
+			stableRS:       rs2PodHash,
+			stableReplicas: 5,
+			canaryReplicas: 10,
+		},
+		{
+			name: "traffic shifted to the switched stable",
+			expectActions: func(f *fixture) {
+				f.expectPatchRolloutAction(rollout)
+				f.expectPatchServiceAction(services[1], rs2PodHash)
+				f.expectUpdateReplicaSetAction(rs1)
+			},
+			weight:         0,
+			stepIndex:      2,
+			stableRS:       rs2PodHash,
+			stableReplicas: 0,
+			canaryReplicas: 10,
+		},
+	}
+	for _, reconcile := range reconciles {
+		f := newFixture(t)
+		f.rolloutLister = append(f.rolloutLister, rollout)
+		f.objects = append(f.objects, rollout)
+		for _, rs := range replicaSets {
+			f.replicaSetLister = append(f.replicaSetLister, rs)
+			f.kubeobjects = append(f.kubeobjects, rs)
+		}
+		for _, svc := range services {
+			f.serviceLister = append(f.serviceLister, svc)
+			f.kubeobjects = append(f.kubeobjects, svc)
+		}
+		reconcile.expectActions(f)
+
+		weight := int32(-1)
+		f.fakeTrafficRouting = newUnmockedFakeTrafficRoutingReconciler()
+		f.fakeTrafficRouting.On("SetWeight", mock.Anything).Return(func(desiredWeight int32) error {
+			weight = desiredWeight
+			return nil
+		})
+		f.fakeTrafficRouting.On("VerifyWeight", mock.Anything).Return(true, nil)
+		f.run(getKey(rollout, t))
+		f.Close()
+
+		// carry the objects updated by the reconcile over to the next one, with all the replicas
+		// of the ReplicaSets available
+		var err error
+		rollout, err = f.client.ArgoprojV1alpha1().Rollouts(rollout.Namespace).Get(context.TODO(), rollout.Name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		rsList, err := f.kubeclient.AppsV1().ReplicaSets(rollout.Namespace).List(context.TODO(), metav1.ListOptions{})
+		assert.NoError(t, err)
+		replicaSets = nil
+		replicaCounts := map[string]int32{}
+		for i := range rsList.Items {
+			rs := rsList.Items[i].DeepCopy()
+			rs.Status.Replicas = *rs.Spec.Replicas
+			rs.Status.AvailableReplicas = *rs.Spec.Replicas
+			rs.Status.ReadyReplicas = *rs.Spec.Replicas
+			replicaSets = append(replicaSets, rs)
+			replicaCounts[rs.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]] = *rs.Spec.Replicas
+		}
+		svcList, err := f.kubeclient.CoreV1().Services(rollout.Namespace).List(context.TODO(), metav1.ListOptions{})
+		assert.NoError(t, err)
+		services = nil
+		for i := range svcList.Items {
+			services = append(services, svcList.Items[i].DeepCopy())
+		}
+
+		assert.Equal(t, reconcile.weight, weight, reconcile.name)
+		assert.Equal(t, reconcile.stepIndex, *rollout.Status.CurrentStepIndex, reconcile.name)
+		assert.Equal(t, reconcile.stableRS, rollout.Status.StableRS, reconcile.name)
+		assert.Equal(t, reconcile.stableReplicas, replicaCounts[rs1PodHash], reconcile.name)
+		assert.Equal(t, reconcile.canaryReplicas, replicaCounts[rs2PodHash], reconcile.name)
+		// the stable must always have enough replicas for the traffic it receives, each of the 10
+		// replicas handling 10% of it
+		assert.Equal(t, reconcile.weight, rollout.Status.Canary.Weights.Canary.Weight, reconcile.name)
+		if rollout.Status.Canary.Weights.Stable.PodTemplateHash == rs1PodHash {
+			assert.GreaterOrEqual(t, replicaCounts[rs1PodHash]*10, 100-reconcile.weight, reconcile.name)
+		}
+
+		if len(rollout.Status.PauseConditions) > 0 {
+			// promote the rollout past the pause step
+			rollout.Status.PauseConditions = nil
+			rollout.Status.ControllerPause = false
+			*rollout.Status.CurrentStepIndex++
+		}
+	}
+}
+
+func TestRolloutSetHeaderRoute(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	headerRoute := &v1alpha1.SetHeaderRoute{
+		Name: "header-route",
+		Match: []v1alpha1.HeaderRoutingMatch{{
+			HeaderName: "x-canary",
+			Value:      v1alpha1.StringMatch{Exact: "true"},
+		}},
+	}
+	steps := []v1alpha1.CanaryStep{
+		{
+			SetWeight: pointer.Int32Ptr(10),
+		},
+		{
+			SetHeaderRoute: headerRoute,
+		},
+		{
+			Pause: &v1alpha1.RolloutPause{},
+		},
+	}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+	r2 := bumpVersion(r1)
+	r2.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+		Istio: &v1alpha1.IstioTrafficRouting{
+			VirtualService: v1alpha1.IstioVirtualService{Name: "istio-vsvc", Routes: []string{"primary"}},
+		},
+		ManagedRoutes: []v1alpha1.ManagedRoute{{Name: "header-route"}},
+	}
+	r2.Spec.Strategy.Canary.CanaryService = "canary"
+	r2.Spec.Strategy.Canary.StableService = "stable"
+
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	rs2 := newReplicaSetWithStatus(r2, 1, 1)
+
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	rs2PodHash := rs2.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	canarySelector := map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs2PodHash}
+	stableSelector := map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs1PodHash}
+	canarySvc := newService("canary", 80, canarySelector, r2)
+	stableSvc := newService("stable", 80, stableSelector, r2)
+
+	f.kubeobjects = append(f.kubeobjects, rs1, rs2, canarySvc, stableSvc)
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2)
+
+	r2 = updateCanaryRolloutStatus(r2, rs1PodHash, 10, 0, 10, false)
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.objects = append(f.objects, r2)
+
+	patchIndex := f.expectPatchRolloutAction(r2)
+
+	f.fakeTrafficRouting = newUnmockedFakeTrafficRoutingReconciler()
+	f.fakeTrafficRouting.On("SetHeaderRoute", headerRoute).Return(nil)
+	f.fakeTrafficRouting.On("SetWeight", mock.Anything).Return(func(desiredWeight int32) error {
+		// the weight of the previous setWeight step is kept
+		assert.Equal(t, int32(10), desiredWeight)
+		return nil
+	})
+	c, i, k8sI := f.newController(noResyncPeriodFunc)
+	c.dynamicclientset = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), unstructuredutil.StrToUnstructuredUnsafe(istioVirtualService))
+	f.runController(getKey(r2, t), true, false, c, i, k8sI)
+
+	f.fakeTrafficRouting.AssertCalled(t, "SetHeaderRoute", headerRoute)
+	// the step completes as soon as the header route is set
+	patchedRollout := f.getPatchedRolloutAsObject(patchIndex)
+	assert.Equal(t, int32(2), *patchedRollout.Status.CurrentStepIndex)
+}
+
+func TestRolloutSetMirrorRoute(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	mirrorRoute := &v1alpha1.SetMirrorRoute{
+		Name: "mirror-route",
+		Match: []v1alpha1.RouteMatch{{
+			Method: &v1alpha1.StringMatch{Exact: "GET"},
+		}},
+		Percentage: pointer.Int32Ptr(50),
+	}
+	steps := []v1alpha1.CanaryStep{
+		{
+			SetCanaryScale: &v1alpha1.SetCanaryScale{Replicas: pointer.Int32Ptr(1)},
+		},
+		{
+			SetMirrorRoute: mirrorRoute,
+		},
+		{
+			Pause: &v1alpha1.RolloutPause{},
+		},
+	}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+	r2 := bumpVersion(r1)
+	r2.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+		Istio: &v1alpha1.IstioTrafficRouting{
+			VirtualService: v1alpha1.IstioVirtualService{Name: "istio-vsvc", Routes: []string{"primary"}},
+		},
+		ManagedRoutes: []v1alpha1.ManagedRoute{{Name: "mirror-route"}},
+	}
+	r2.Spec.Strategy.Canary.CanaryService = "canary"
+	r2.Spec.Strategy.Canary.StableService = "stable"
+
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	rs2 := newReplicaSetWithStatus(r2, 1, 1)
+
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	rs2PodHash := rs2.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	canarySelector := map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs2PodHash}
+	stableSelector := map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs1PodHash}
+	canarySvc := newService("canary", 80, canarySelector, r2)
+	stableSvc := newService("stable", 80, stableSelector, r2)
+
+	f.kubeobjects = append(f.kubeobjects, rs1, rs2, canarySvc, stableSvc)
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2)
+
+	r2 = updateCanaryRolloutStatus(r2, rs1PodHash, 10, 0, 10, false)
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.objects = append(f.objects, r2)
+
+	patchIndex := f.expectPatchRolloutAction(r2)
+
+	f.fakeTrafficRouting = newUnmockedFakeTrafficRoutingReconciler()
+	f.fakeTrafficRouting.On("SetMirrorRoute", mirrorRoute).Return(nil)
+	f.fakeTrafficRouting.On("SetWeight", mock.Anything).Return(func(desiredWeight int32) error {
+		// mirrored requests are not served by the canary
+		assert.Equal(t, int32(0), desiredWeight)
+		return nil
+	})
+	c, i, k8sI := f.newController(noResyncPeriodFunc)
+	c.dynamicclientset = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), unstructuredutil.StrToUnstructuredUnsafe(istioVirtualService))
+	f.runController(getKey(r2, t), true, false, c, i, k8sI)
+
+	f.fakeTrafficRouting.AssertCalled(t, "SetMirrorRoute", mirrorRoute)
+	f.fakeTrafficRouting.AssertNotCalled(t, "SetHeaderRoute", mock.Anything)
+	patchedRollout := f.getPatchedRolloutAsObject(patchIndex)
+	assert.Equal(t, int32(2), *patchedRollout.Status.CurrentStepIndex)
+}
+
+func TestRolloutRemoveManagedRoutesWhenFullyRolledOut(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{
+		{
+			SetHeaderRoute: &v1alpha1.SetHeaderRoute{Name: "header-route"},
+		},
+	}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+	r1.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+		Istio: &v1alpha1.IstioTrafficRouting{
+			VirtualService: v1alpha1.IstioVirtualService{Name: "istio-vsvc", Routes: []string{"primary"}},
+		},
+		ManagedRoutes: []v1alpha1.ManagedRoute{{Name: "header-route"}},
+	}
+	r1.Spec.Strategy.Canary.CanaryService = "canary"
+	r1.Spec.Strategy.Canary.StableService = "stable"
+
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	canarySelector := map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs1PodHash}
+	stableSelector := map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs1PodHash}
+	canarySvc := newService("canary", 80, canarySelector, r1)
+	stableSvc := newService("stable", 80, stableSelector, r1)
+
+	f.kubeobjects = append(f.kubeobjects, rs1, canarySvc, stableSvc)
+	f.replicaSetLister = append(f.replicaSetLister, rs1)
+
+	r1 = updateCanaryRolloutStatus(r1, rs1PodHash, 10, 0, 10, false)
+	f.rolloutLister = append(f.rolloutLister, r1)
+	f.objects = append(f.objects, r1)
+
+	f.expectPatchRolloutAction(r1)
+	f.fakeTrafficRouting = newUnmockedFakeTrafficRoutingReconciler()
+	f.fakeTrafficRouting.On("RemoveManagedRoutes").Return(nil)
+	f.fakeTrafficRouting.On("SetWeight", mock.Anything).Return(nil)
+	f.fakeTrafficRouting.On("VerifyWeight", mock.Anything).Return(true, nil)
+	c, i, k8sI := f.newController(noResyncPeriodFunc)
+	c.dynamicclientset = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), unstructuredutil.StrToUnstructuredUnsafe(istioVirtualService))
+	f.runController(getKey(r1, t), true, false, c, i, k8sI)
+
+	f.fakeTrafficRouting.AssertCalled(t, "RemoveManagedRoutes")
+	f.fakeTrafficRouting.AssertNotCalled(t, "SetHeaderRoute", mock.Anything)
+}
+
 func TestNewTrafficRoutingReconciler(t *testing.T) {
 	rc := Controller{}
 	gvk := schema.ParseGroupResource("virtualservices.networking.istio.io").WithVersion("v1alpha3")
@@ -374,4 +842,116 @@ func TestNewTrafficRoutingReconciler(t *testing.T) {
 		assert.NotNil(t, networkReconciler)
 		assert.Equal(t, smi.Type, networkReconciler.Type())
 	}
+	{
+		r := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+		r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			Ambassador: &v1alpha1.AmbassadorTrafficRouting{},
+		}
+		roCtx := &rolloutContext{
+			rollout: r,
+			log:     logutil.WithRollout(r),
+		}
+		networkReconciler, err := rc.NewTrafficRoutingReconciler(roCtx)
+		assert.Nil(t, err)
+		assert.NotNil(t, networkReconciler)
+		assert.Equal(t, ambassador.Type, networkReconciler.Type())
+	}
+	{
+		r := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+		r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			GatewayAPI: &v1alpha1.GatewayAPITrafficRouting{},
+		}
+		roCtx := &rolloutContext{
+			rollout: r,
+			log:     logutil.WithRollout(r),
+		}
+		networkReconciler, err := rc.NewTrafficRoutingReconciler(roCtx)
+		assert.Nil(t, err)
+		assert.NotNil(t, networkReconciler)
+		assert.Equal(t, gatewayapi.Type, networkReconciler.Type())
+	}
+	{
+		r := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+		r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			Traefik: &v1alpha1.TraefikTrafficRouting{},
+		}
+		roCtx := &rolloutContext{
+			rollout: r,
+			log:     logutil.WithRollout(r),
+		}
+		networkReconciler, err := rc.NewTrafficRoutingReconciler(roCtx)
+		assert.Nil(t, err)
+		assert.NotNil(t, networkReconciler)
+		assert.Equal(t, traefik.Type, networkReconciler.Type())
+	}
+	{
+		r := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+		r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			AppMesh: &v1alpha1.AppMeshTrafficRouting{},
+		}
+		roCtx := &rolloutContext{
+			rollout: r,
+			log:     logutil.WithRollout(r),
+			newRS:   newReplicaSet(r, 1),
+		}
+		networkReconciler, err := rc.NewTrafficRoutingReconciler(roCtx)
+		assert.Nil(t, err)
+		assert.NotNil(t, networkReconciler)
+		assert.Equal(t, appmesh.Type, networkReconciler.Type())
+	}
+	{
+		r := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+		r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			Plugin: &v1alpha1.PluginTrafficRouting{Name: "not-registered"},
+		}
+		roCtx := &rolloutContext{
+			rollout: r,
+			log:     logutil.WithRollout(r),
+		}
+		networkReconciler, err := rc.NewTrafficRoutingReconciler(roCtx)
+		assert.EqualError(t, err, "plugin 'not-registered' is not registered")
+		assert.Nil(t, networkReconciler)
+
+		plugin.RegisterPlugin("fake", fakePluginConn{})
+		r.Spec.Strategy.Canary.TrafficRouting.Plugin.Name = "fake"
+		networkReconciler, err = rc.NewTrafficRoutingReconciler(roCtx)
+		assert.Nil(t, err)
+		assert.NotNil(t, networkReconciler)
+		assert.Equal(t, plugin.Type, networkReconciler.Type())
+	}
+	{
+		r := newBlueGreenRollout("foo", 10, nil, "active", "preview")
+		roCtx := &rolloutContext{
+			rollout: r,
+			log:     logutil.WithRollout(r),
+		}
+		networkReconciler, err := rc.NewTrafficRoutingReconciler(roCtx)
+		assert.Nil(t, err)
+		assert.Nil(t, networkReconciler)
+	}
+	{
+		r := newBlueGreenRollout("foo", 10, nil, "active", "preview")
+		r.Spec.Strategy.BlueGreen.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			Nginx: &v1alpha1.NginxTrafficRouting{},
+		}
+		roCtx := &rolloutContext{
+			rollout: r,
+			log:     logutil.WithRollout(r),
+		}
+		networkReconciler, err := rc.NewTrafficRoutingReconciler(roCtx)
+		assert.Nil(t, err)
+		assert.NotNil(t, networkReconciler)
+		assert.Equal(t, nginx.Type, networkReconciler.Type())
+	}
+}
+
+// fakePluginConn is a connection to a traffic router plugin
+type fakePluginConn struct{}
+
+func (fakePluginConn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	return nil
+}
+
+func (fakePluginConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("streams are not supported")
 }
@@ -0,0 +1,109 @@
+package rollout
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	patchtypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+)
+
+const (
+	scaleDownWorkloadPatch = `{"spec":{"replicas":0}}`
+)
+
+func workloadRefKey(namespace, kind, name string) string {
+	return fmt.Sprintf("%s/%s/%s", namespace, kind, name)
+}
+
+// enqueueWorkloadRef enqueues the rollouts which reference the Deployment or PodTemplate
+func (c *Controller) enqueueWorkloadRef(obj interface{}) {
+	acc, err := meta.Accessor(obj)
+	if err != nil {
+		log.Errorf("Error processing workload from watch: %v: %v", err, obj)
+		return
+	}
+	kind := replicasetutil.DeploymentKind
+	if _, ok := obj.(*corev1.PodTemplate); ok {
+		kind = replicasetutil.PodTemplateKind
+	}
+	rollouts, err := c.rolloutsIndexer.ByIndex(workloadRefIndexName, workloadRefKey(acc.GetNamespace(), kind, acc.GetName()))
+	if err != nil {
+		log.Errorf("Cannot process indexer: %s", err.Error())
+		return
+	}
+	for i := range rollouts {
+		c.enqueueRollout(rollouts[i])
+	}
+}
+
+// resolveWorkloadRef returns the pod template of the workload referenced by the rollout. The resolved
+// template is never persisted, so it has to be resolved on every reconciliation.
+func (c *Controller) resolveWorkloadRef(ro *v1alpha1.Rollout) (*corev1.PodTemplateSpec, error) {
+	return replicasetutil.ResolveWorkloadRef(ro, c.deploymentLister, c.podTemplateLister)
+}
+
+// scaleDownWorkload scales the referenced Deployment to zero once the rollout is healthy, so the pods
+// of the Deployment are replaced by the pods of the rollout
+func (c *rolloutContext) scaleDownWorkload() error {
+	workloadRef := c.rollout.Spec.WorkloadRef
+	if workloadRef == nil || !workloadRef.ScaleDown || workloadRef.Kind != replicasetutil.DeploymentKind {
+		return nil
+	}
+	ro := c.rollout
+	if c.newRollout != nil {
+		ro = c.newRollout
+	}
+	if !isRolloutHealthy(ro) {
+		return nil
+	}
+	deployment, err := c.deploymentLister.Deployments(c.rollout.Namespace).Get(workloadRef.Name)
+	if err != nil {
+		return err
+	}
+	if deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == 0 {
+		return nil
+	}
+	ctx := context.TODO()
+	_, err = c.kubeclientset.AppsV1().Deployments(deployment.Namespace).Patch(ctx, deployment.Name, patchtypes.MergePatchType, []byte(scaleDownWorkloadPatch), metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("Scaled down Deployment %s to 0", deployment.Name)
+	c.log.Info(msg)
+	c.recorder.Event(c.rollout, corev1.EventTypeNormal, "ScalingWorkload", msg)
+	return nil
+}
+
+// isRolloutHealthy returns true if the rollout completed its update and all of its replicas are available
+func isRolloutHealthy(ro *v1alpha1.Rollout) bool {
+	if conditions.GetRolloutCondition(ro.Status, v1alpha1.InvalidSpec) != nil {
+		return false
+	}
+	progressing := conditions.GetRolloutCondition(ro.Status, v1alpha1.RolloutProgressing)
+	if progressing == nil || progressing.Reason != conditions.NewRSAvailableReason {
+		return false
+	}
+	return ro.Status.StableRS != "" && ro.Status.StableRS == ro.Status.CurrentPodHash &&
+		ro.Status.AvailableReplicas >= defaults.GetReplicasOrDefault(ro.Spec.Replicas)
+}
+
+// persistedRollout returns the rollout being reconciled without the template resolved from its
+// workload, to be sent to the API server
+func (c *rolloutContext) persistedRollout() *v1alpha1.Rollout {
+	return replicasetutil.RolloutWithoutTemplate(c.rollout)
+}
+
+// setUpdatedRollout sets the rollout returned by the API server as the rollout being reconciled, with
+// the template resolved from its workload
+func (c *rolloutContext) setUpdatedRollout(updatedRollout *v1alpha1.Rollout) {
+	c.rollout = replicasetutil.RolloutWithTemplate(updatedRollout, c.resolvedTemplate)
+}
@@ -0,0 +1,194 @@
+package rollout
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+)
+
+// newWorkloadRefRollout returns a canary rollout referencing a Deployment with the template of the rollout
+func newWorkloadRefRollout(scaleDown bool) (*v1alpha1.Rollout, *appsv1.Deployment) {
+	r := newCanaryRollout("foo", 1, nil, nil, nil, intstr.FromInt(1), intstr.FromInt(0))
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo-deployment",
+			Namespace: r.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32Ptr(1),
+			Selector: r.Spec.Selector.DeepCopy(),
+			Template: *r.Spec.Template.DeepCopy(),
+		},
+	}
+	r.Spec.Template = corev1.PodTemplateSpec{}
+	r.Spec.WorkloadRef = &v1alpha1.WorkloadRef{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       deployment.Name,
+		ScaleDown:  scaleDown,
+	}
+	return r, deployment
+}
+
+func TestResolveWorkloadRefDeployment(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r, deployment := newWorkloadRefRollout(false)
+	r.Status.CurrentPodHash = ""
+	f.rolloutLister = append(f.rolloutLister, r)
+	f.objects = append(f.objects, r)
+	f.deploymentLister = append(f.deploymentLister, deployment)
+	f.kubeobjects = append(f.kubeobjects, deployment)
+
+	resolved := r.DeepCopy()
+	resolved.Spec.Template = deployment.Spec.Template
+	rs := newReplicaSet(resolved, 1)
+
+	createRSIndex := f.expectCreateReplicaSetAction(rs)
+	updateIndex := f.expectUpdateRolloutStatusAction(r)
+	f.expectPatchRolloutAction(r)
+	f.run(getKey(r, t))
+
+	createdRS := f.getCreatedReplicaSet(createRSIndex)
+	assert.Equal(t, rs.Name, createdRS.Name)
+	assert.Equal(t, deployment.Spec.Template.Spec.Containers[0].Image, createdRS.Spec.Template.Spec.Containers[0].Image)
+	// the resolved template is never persisted
+	updatedRollout := f.getUpdatedRollout(updateIndex)
+	assert.True(t, updatedRollout.Spec.EmptyTemplate())
+}
+
+func TestResolveWorkloadRefNotFound(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r, _ := newWorkloadRefRollout(false)
+	f.rolloutLister = append(f.rolloutLister, r)
+	f.objects = append(f.objects, r)
+
+	patchIndex := f.expectPatchRolloutAction(r)
+	f.run(getKey(r, t))
+
+	patchedRollout := f.getPatchedRolloutAsObject(patchIndex)
+	cond := conditions.GetRolloutCondition(patchedRollout.Status, v1alpha1.InvalidSpec)
+	assert.NotNil(t, cond)
+	assert.Contains(t, cond.Message, "spec.workloadRef.name: Invalid value: \"foo-deployment\"")
+}
+
+func TestResolveWorkloadRefWithTemplate(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r, deployment := newWorkloadRefRollout(false)
+	r.Spec.Template = deployment.Spec.Template
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	template, err := c.resolveWorkloadRef(r)
+	assert.Nil(t, template)
+	assert.EqualError(t, err, "spec.template: Invalid value: \"<template>\": Template must be empty when workloadRef is set")
+}
+
+func TestResolveWorkloadRefPodTemplate(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r, deployment := newWorkloadRefRollout(false)
+	r.Spec.WorkloadRef = &v1alpha1.WorkloadRef{APIVersion: "v1", Kind: "PodTemplate", Name: "foo-template"}
+	f.podTemplateLister = append(f.podTemplateLister, &corev1.PodTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo-template", Namespace: r.Namespace},
+		Template:   deployment.Spec.Template,
+	})
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	template, err := c.resolveWorkloadRef(r)
+	assert.NoError(t, err)
+	assert.Equal(t, deployment.Spec.Template, *template)
+	assert.True(t, r.Spec.EmptyTemplate())
+}
+
+func TestPersistedRolloutWithoutResolvedTemplate(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r, deployment := newWorkloadRefRollout(false)
+	resolved := r.DeepCopy()
+	resolved.Spec.Template = deployment.Spec.Template
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	roCtx, err := c.newRolloutContext(resolved)
+	assert.NoError(t, err)
+	roCtx.resolvedTemplate = &deployment.Spec.Template
+
+	persisted := roCtx.persistedRollout()
+	assert.True(t, persisted.Spec.EmptyTemplate())
+	assert.Equal(t, deployment.Spec.Template, roCtx.rollout.Spec.Template)
+
+	roCtx.setUpdatedRollout(persisted)
+	assert.Equal(t, deployment.Spec.Template, roCtx.rollout.Spec.Template)
+	assert.True(t, persisted.Spec.EmptyTemplate())
+}
+
+func newHealthyWorkloadRefRollout(scaleDown bool) (*v1alpha1.Rollout, *appsv1.Deployment) {
+	r, deployment := newWorkloadRefRollout(scaleDown)
+	r.Spec.Template = deployment.Spec.Template
+	rs := newReplicaSetWithStatus(r, 1, 1)
+	r = updateCanaryRolloutStatus(r, rs.Labels[v1alpha1.DefaultRolloutUniqueLabelKey], 1, 1, 1, false)
+	progressing, _ := newProgressingCondition(conditions.NewRSAvailableReason, rs, "")
+	conditions.SetRolloutCondition(&r.Status, progressing)
+	return r, deployment
+}
+
+func TestScaleDownWorkload(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r, deployment := newHealthyWorkloadRefRollout(true)
+	f.deploymentLister = append(f.deploymentLister, deployment)
+	f.kubeobjects = append(f.kubeobjects, deployment)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	roCtx, err := c.newRolloutContext(r)
+	assert.NoError(t, err)
+
+	assert.NoError(t, roCtx.scaleDownWorkload())
+	actions := filterInformerActions(f.kubeclient.Actions())
+	assert.Len(t, actions, 1)
+	patchAction, ok := actions[0].(core.PatchAction)
+	assert.True(t, ok)
+	assert.Equal(t, "deployments", patchAction.GetResource().Resource)
+	assert.Equal(t, scaleDownWorkloadPatch, string(patchAction.GetPatch()))
+}
+
+func TestScaleDownWorkloadSkipped(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	// scaleDown is not enabled
+	r, deployment := newHealthyWorkloadRefRollout(false)
+	f.deploymentLister = append(f.deploymentLister, deployment)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	roCtx, err := c.newRolloutContext(r)
+	assert.NoError(t, err)
+	assert.NoError(t, roCtx.scaleDownWorkload())
+
+	// the rollout is still progressing
+	roCtx.rollout.Spec.WorkloadRef.ScaleDown = true
+	progressing, _ := newProgressingCondition(conditions.ReplicaSetUpdatedReason, r, "")
+	conditions.SetRolloutCondition(&roCtx.rollout.Status, progressing)
+	assert.NoError(t, roCtx.scaleDownWorkload())
+
+	// the deployment is already scaled down
+	deployment.Spec.Replicas = pointer.Int32Ptr(0)
+	newR, _ := newHealthyWorkloadRefRollout(true)
+	roCtx.rollout = newR
+	assert.NoError(t, roCtx.scaleDownWorkload())
+
+	assert.Empty(t, filterInformerActions(f.kubeclient.Actions()))
+}
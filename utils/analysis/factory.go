@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	templateutil "github.com/argoproj/argo-rollouts/utils/template"
 
@@ -227,11 +228,55 @@ func ValidateMetric(metric v1alpha1.Metric) error {
 	if metric.Provider.NewRelic != nil {
 		numProviders++
 	}
+	if metric.Provider.Plugin != nil {
+		numProviders++
+	}
+	if metric.Provider.CloudWatch != nil {
+		numProviders++
+	}
+	if metric.Provider.Graphite != nil {
+		numProviders++
+	}
+	if metric.Provider.Influxdb != nil {
+		numProviders++
+	}
+	if metric.Provider.Elasticsearch != nil {
+		numProviders++
+	}
+	if metric.Provider.Loki != nil {
+		numProviders++
+	}
+	if metric.Provider.Stackdriver != nil {
+		numProviders++
+	}
+	if metric.Provider.Dynatrace != nil {
+		numProviders++
+	}
+	if metric.Provider.SQL != nil {
+		numProviders++
+	}
+	if metric.Provider.Resource != nil {
+		numProviders++
+	}
 	if numProviders == 0 {
 		return fmt.Errorf("no provider specified")
 	}
 	if numProviders > 1 {
 		return fmt.Errorf("multiple providers specified")
 	}
+	// args are templated into the metric as strings, so they must reach the query as bind parameters
+	if metric.Provider.SQL != nil && strings.Contains(metric.Provider.SQL.Query, "{{") {
+		return fmt.Errorf("sql query must not reference args, pass them in sql args instead")
+	}
+	if metric.Provider.Resource != nil {
+		// without a completed condition, an object ending in the state of a missing condition would
+		// never be considered completed
+		if metric.Provider.Resource.CompletedCondition == "" && (metric.SuccessCondition == "" || metric.FailureCondition == "") {
+			return fmt.Errorf("resource metric must have a completedCondition, or both a successCondition and a failureCondition")
+		}
+		if _, _, err := ParseMetricResource(metric); err != nil {
+			return err
+		}
+	}
 	return nil
 }
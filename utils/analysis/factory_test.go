@@ -372,13 +372,23 @@ func TestValidateMetrics(t *testing.T) {
 				{
 					Name: "success-rate",
 					Provider: v1alpha1.MetricProvider{
-						Prometheus: &v1alpha1.PrometheusMetric{},
-						Job:        &v1alpha1.JobMetric{},
-						Wavefront:  &v1alpha1.WavefrontMetric{},
-						Kayenta:    &v1alpha1.KayentaMetric{},
-						Web:        &v1alpha1.WebMetric{},
-						Datadog:    &v1alpha1.DatadogMetric{},
-						NewRelic:   &v1alpha1.NewRelicMetric{},
+						Prometheus:    &v1alpha1.PrometheusMetric{},
+						Job:           &v1alpha1.JobMetric{},
+						Wavefront:     &v1alpha1.WavefrontMetric{},
+						Kayenta:       &v1alpha1.KayentaMetric{},
+						Web:           &v1alpha1.WebMetric{},
+						Datadog:       &v1alpha1.DatadogMetric{},
+						NewRelic:      &v1alpha1.NewRelicMetric{},
+						Plugin:        &v1alpha1.PluginMetric{},
+						CloudWatch:    &v1alpha1.CloudWatchMetric{},
+						Graphite:      &v1alpha1.GraphiteMetric{},
+						Influxdb:      &v1alpha1.InfluxdbMetric{},
+						Elasticsearch: &v1alpha1.ElasticsearchMetric{},
+						Loki:          &v1alpha1.LokiMetric{},
+						Stackdriver:   &v1alpha1.StackdriverMetric{},
+						Dynatrace:     &v1alpha1.DynatraceMetric{},
+						SQL:           &v1alpha1.SQLMetric{},
+						Resource:      &v1alpha1.ResourceMetric{},
 					},
 				},
 			},
@@ -386,6 +396,84 @@ func TestValidateMetrics(t *testing.T) {
 		err := ValidateMetrics(spec.Metrics)
 		assert.EqualError(t, err, "metrics[0]: multiple providers specified")
 	})
+	t.Run("Ensure sql query does not reference args", func(t *testing.T) {
+		spec := v1alpha1.AnalysisTemplateSpec{
+			Metrics: []v1alpha1.Metric{
+				{
+					Name: "error-rate",
+					Provider: v1alpha1.MetricProvider{
+						SQL: &v1alpha1.SQLMetric{
+							Query: "SELECT count(*) FROM requests WHERE version = '{{args.version}}'",
+						},
+					},
+				},
+			},
+		}
+		err := ValidateMetrics(spec.Metrics)
+		assert.EqualError(t, err, "metrics[0]: sql query must not reference args, pass them in sql args instead")
+
+		spec.Metrics[0].Provider.SQL.Query = "SELECT count(*) FROM requests WHERE version = $1"
+		spec.Metrics[0].Provider.SQL.Args = []string{"{{args.version}}"}
+		assert.NoError(t, ValidateMetrics(spec.Metrics))
+	})
+	t.Run("Ensure resource metric has a completed condition", func(t *testing.T) {
+		SetAllowedMetricResources([]string{"workflows.argoproj.io"})
+		defer SetAllowedMetricResources(nil)
+		spec := v1alpha1.AnalysisTemplateSpec{
+			Metrics: []v1alpha1.Metric{
+				{
+					Name:             "integration-tests",
+					SuccessCondition: `result.phase == "Succeeded"`,
+					Provider: v1alpha1.MetricProvider{
+						Resource: &v1alpha1.ResourceMetric{
+							Manifest: "apiVersion: argoproj.io/v1alpha1\nkind: Workflow",
+						},
+					},
+				},
+			},
+		}
+		err := ValidateMetrics(spec.Metrics)
+		assert.EqualError(t, err, "metrics[0]: resource metric must have a completedCondition, or both a successCondition and a failureCondition")
+
+		spec.Metrics[0].FailureCondition = `result.phase != "Succeeded"`
+		assert.NoError(t, ValidateMetrics(spec.Metrics))
+
+		spec.Metrics[0].FailureCondition = ""
+		spec.Metrics[0].Provider.Resource.CompletedCondition = `result.phase in ["Succeeded", "Failed", "Error"]`
+		assert.NoError(t, ValidateMetrics(spec.Metrics))
+	})
+	t.Run("Ensure resource metric resource is allowed", func(t *testing.T) {
+		SetAllowedMetricResources([]string{"workflows.argoproj.io"})
+		defer SetAllowedMetricResources(nil)
+		spec := v1alpha1.AnalysisTemplateSpec{
+			Metrics: []v1alpha1.Metric{
+				{
+					Name: "ingress",
+					Provider: v1alpha1.MetricProvider{
+						Resource: &v1alpha1.ResourceMetric{
+							Manifest:           "apiVersion: networking.k8s.io/v1\nkind: Ingress",
+							CompletedCondition: "true",
+						},
+					},
+				},
+			},
+		}
+		err := ValidateMetrics(spec.Metrics)
+		assert.EqualError(t, err, "metrics[0]: resource ingresses.networking.k8s.io is not allowed for resource metrics")
+
+		// the resource of the metric overrides the one guessed from the kind
+		spec.Metrics[0].Provider.Resource.Manifest = "apiVersion: argoproj.io/v1alpha1\nkind: Workflow"
+		spec.Metrics[0].Provider.Resource.Resource = "ingresses"
+		err = ValidateMetrics(spec.Metrics)
+		assert.EqualError(t, err, "metrics[0]: resource ingresses.argoproj.io is not allowed for resource metrics")
+
+		spec.Metrics[0].Provider.Resource.Resource = ""
+		assert.NoError(t, ValidateMetrics(spec.Metrics))
+
+		SetAllowedMetricResources(nil)
+		err = ValidateMetrics(spec.Metrics)
+		assert.EqualError(t, err, "metrics[0]: resource workflows.argoproj.io is not allowed for resource metrics")
+	})
 }
 
 // TestResolveMetricArgs verifies that metric arguments are resolved
@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+var (
+	allowedMetricResourcesLock sync.RWMutex
+	// allowedMetricResources are the resources the objects of resource metrics may be created with.
+	// None are allowed by default, since the objects are created with the privileges of the
+	// controller.
+	allowedMetricResources = map[schema.GroupResource]bool{}
+)
+
+// SetAllowedMetricResources sets the resources the objects of resource metrics may be created with,
+// in the resource.group format, e.g. workflows.argoproj.io
+func SetAllowedMetricResources(resources []string) {
+	allowed := make(map[schema.GroupResource]bool, len(resources))
+	for _, resource := range resources {
+		allowed[schema.ParseGroupResource(resource)] = true
+	}
+	allowedMetricResourcesLock.Lock()
+	defer allowedMetricResourcesLock.Unlock()
+	allowedMetricResources = allowed
+}
+
+func isMetricResourceAllowed(resource schema.GroupResource) bool {
+	allowedMetricResourcesLock.RLock()
+	defer allowedMetricResourcesLock.RUnlock()
+	return allowedMetricResources[resource]
+}
+
+// ParseMetricResource parses the manifest of a resource metric, and returns its object along with the
+// resource to create it with. It fails when the resource is not allowed.
+func ParseMetricResource(metric v1alpha1.Metric) (*unstructured.Unstructured, schema.GroupVersionResource, error) {
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(metric.Provider.Resource.Manifest), &obj.Object); err != nil {
+		return nil, schema.GroupVersionResource{}, fmt.Errorf("failed to parse resource manifest: %v", err)
+	}
+	gvk := obj.GroupVersionKind()
+	if gvk.Version == "" || gvk.Kind == "" {
+		return nil, schema.GroupVersionResource{}, errors.New("resource manifest must have an apiVersion and a kind")
+	}
+	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+	if metric.Provider.Resource.Resource != "" {
+		gvr.Resource = metric.Provider.Resource.Resource
+	}
+	if !isMetricResourceAllowed(gvr.GroupResource()) {
+		return nil, schema.GroupVersionResource{}, fmt.Errorf("resource %s is not allowed for resource metrics", gvr.GroupResource())
+	}
+	return obj, gvr, nil
+}
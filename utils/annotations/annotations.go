@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/utils/defaults"
@@ -21,6 +23,9 @@ const (
 	RevisionAnnotation = RolloutLabel + "/revision"
 	// RevisionHistoryAnnotation maintains the history of all old revisions that a replica set has served for a rollout.
 	RevisionHistoryAnnotation = RolloutLabel + "/revision-history"
+	// RevisionTimestampAnnotation records when the revision of a replica set was last bumped by rolling
+	// back to it. A replica set without it got its revision when it was created.
+	RevisionTimestampAnnotation = RolloutLabel + "/revision-timestamp"
 	// DesiredReplicasAnnotation is the desired replicas for a rollout recorded as an annotation
 	// in its replica sets. Helps in separating scaling events from the rollout process and for
 	// determining if the new replica set for a rollout is really saturated.
@@ -120,6 +125,9 @@ func SetNewReplicaSetAnnotations(rollout *v1alpha1.Rollout, newRS *appsv1.Replic
 	}
 	if oldRevisionInt < newRevisionInt {
 		newRS.Annotations[RevisionAnnotation] = newRevision
+		if ok {
+			newRS.Annotations[RevisionTimestampAnnotation] = metav1.Now().UTC().Format(time.RFC3339)
+		}
 		annotationChanged = true
 		logCtx.Infof("Updating replica set '%s' revision from %d to %d", newRS.Name, oldRevisionInt, newRevisionInt)
 	}
@@ -148,6 +156,7 @@ var annotationsToSkip = map[string]bool{
 	corev1.LastAppliedConfigAnnotation: true,
 	RevisionAnnotation:                 true,
 	RevisionHistoryAnnotation:          true,
+	RevisionTimestampAnnotation:        true,
 	DesiredReplicasAnnotation:          true,
 }
 
@@ -177,6 +186,19 @@ func copyRolloutAnnotationsToReplicaSet(rollouts *v1alpha1.Rollout, rs *appsv1.R
 	return rsAnnotationsChanged
 }
 
+// GetRevisionTimestamp returns when the replica set got its current revision, which is the last
+// time it was rolled back to, or its creation.
+func GetRevisionTimestamp(rs *appsv1.ReplicaSet) metav1.Time {
+	if value, ok := rs.Annotations[RevisionTimestampAnnotation]; ok {
+		timestamp, err := time.Parse(time.RFC3339, value)
+		if err == nil {
+			return metav1.NewTime(timestamp)
+		}
+		log.Warnf("Cannot parse the value %q with annotation key %q for the replica set %q", value, RevisionTimestampAnnotation, rs.Name)
+	}
+	return rs.CreationTimestamp
+}
+
 // IsSaturated checks if the new replica set is saturated by comparing its size with its rollout size.
 // Both the rollout and the replica set have to believe this replica set can own all of the desired
 // replicas in the rollout and the annotation helps in achieving that. All pods of the ReplicaSet
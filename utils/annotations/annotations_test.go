@@ -5,6 +5,7 @@ import (
 	"math/rand"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
@@ -356,3 +357,32 @@ func TestReplicasAnnotationsNeedUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestRevisionTimestamp(t *testing.T) {
+	rollout := &v1alpha1.Rollout{}
+	created := metav1.NewTime(metav1.Now().Add(-time.Hour).Truncate(time.Second))
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "hello",
+			CreationTimestamp: created,
+			Annotations:       map[string]string{RevisionAnnotation: "1"},
+		},
+	}
+	// a replica set got its revision when it was created
+	assert.Equal(t, created, GetRevisionTimestamp(rs))
+
+	// rolling back to the replica set bumps its revision
+	before := metav1.Now().Truncate(time.Second)
+	assert.True(t, SetNewReplicaSetAnnotations(rollout, rs, "3", true))
+	bumped := GetRevisionTimestamp(rs)
+	assert.False(t, bumped.Time.Before(before))
+
+	// the timestamp is kept when the revision is not bumped
+	rs.Annotations[RevisionTimestampAnnotation] = created.UTC().Format(time.RFC3339)
+	SetNewReplicaSetAnnotations(rollout, rs, "3", true)
+	kept := GetRevisionTimestamp(rs)
+	assert.True(t, created.Equal(&kept))
+
+	rs.Annotations[RevisionTimestampAnnotation] = "invalid"
+	assert.Equal(t, created, GetRevisionTimestamp(rs))
+}
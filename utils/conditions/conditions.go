@@ -86,6 +86,9 @@ const (
 	// ReplicaSetTimeOutMessage is added in a rollout when its newest replica set fails to show any progress
 	// within the given deadline (progressDeadlineSeconds).
 	ReplicaSetTimeOutMessage = "ReplicaSet %q has timed out progressing."
+	// RolloutAbortedTimeOutMessage is added in a rollout when the update is aborted because the
+	// progress deadline is exceeded and progressDeadlineAbort is set
+	RolloutAbortedTimeOutMessage = "Rollout aborted update since progress deadline exceeded: %s"
 
 	// RolloutCompletedMessage is added when the rollout is completed
 	RolloutCompletedMessage = "Rollout %q has successfully progressed."
@@ -1,6 +1,7 @@
 package defaults
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -196,3 +197,9 @@ func TestGetConsecutiveErrorLimitOrDefault(t *testing.T) {
 	metricDefaultValue := &v1alpha1.Metric{}
 	assert.Equal(t, DefaultConsecutiveErrorLimit, GetConsecutiveErrorLimitOrDefault(metricDefaultValue))
 }
+
+func TestNamespace(t *testing.T) {
+	os.Setenv("POD_NAMESPACE", "test-namespace")
+	defer os.Unsetenv("POD_NAMESPACE")
+	assert.Equal(t, "test-namespace", Namespace())
+}
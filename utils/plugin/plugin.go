@@ -0,0 +1,126 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+const (
+	// CodecName is the name of the gRPC codec the controller and the plugins exchange messages
+	// with. The messages are encoded as JSON, so plugins do not need generated protobuf code.
+	CodecName = "json"
+
+	unixPrefix = "unix://"
+)
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec is a gRPC codec which encodes messages as JSON
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return CodecName
+}
+
+// Registry holds the connections to the plugins registered with the controller
+type Registry struct {
+	mutex sync.RWMutex
+	conns map[string]grpc.ClientConnInterface
+}
+
+// NewRegistry returns an empty plugin registry
+func NewRegistry() *Registry {
+	return &Registry{
+		conns: map[string]grpc.ClientConnInterface{},
+	}
+}
+
+// Register adds the connection to the plugin with the name to the registry
+func (r *Registry) Register(name string, conn grpc.ClientConnInterface) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.conns[name] = conn
+}
+
+// Get returns the connection to the plugin with the name
+func (r *Registry) Get(name string) (grpc.ClientConnInterface, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	conn, ok := r.conns[name]
+	if !ok {
+		return nil, fmt.Errorf("plugin '%s' is not registered", name)
+	}
+	return conn, nil
+}
+
+// RegisterAddresses dials the plugins of name=address pairs, such as given to the controller
+// flags, and registers their connections. The address is either host:port or
+// unix:///path/to/socket.
+func (r *Registry) RegisterAddresses(pairs []string) error {
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("plugin '%s' is not in the name=address format", pair)
+		}
+		conn, err := Dial(parts[1])
+		if err != nil {
+			return err
+		}
+		r.Register(parts[0], conn)
+	}
+	return nil
+}
+
+// Dial returns a connection to the plugin listening on the address. Connecting to the plugin
+// happens in the background, so a plugin which is not up yet does not fail the dial.
+func Dial(address string) (*grpc.ClientConn, error) {
+	opts := []grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(CodecName)),
+	}
+	if strings.HasPrefix(address, unixPrefix) {
+		path := strings.TrimPrefix(address, unixPrefix)
+		opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		}))
+		address = "passthrough:///" + path
+	}
+	return grpc.Dial(address, opts...)
+}
+
+// Invoke calls the unary method of the plugin on the connection, encoding the messages as JSON
+func Invoke(ctx context.Context, cc grpc.ClientConnInterface, method string, in, out interface{}, opts ...grpc.CallOption) error {
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+	return cc.Invoke(ctx, method, in, out, opts...)
+}
+
+// HandleUnary calls the handler of the unary method of a plugin, through the interceptor of the
+// server if it has one
+func HandleUnary(ctx context.Context, srv interface{}, method string, in interface{}, interceptor grpc.UnaryServerInterceptor, handler grpc.UnaryHandler) (interface{}, error) {
+	if interceptor == nil {
+		return handler(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: method,
+	}
+	return interceptor(ctx, in, info, handler)
+}
@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+type echo struct {
+	Message string `json:"message"`
+}
+
+// echoServiceDesc describes a service which echoes the request back, encoded with the JSON codec
+var echoServiceDesc = grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Echo",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(echo)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			return in, nil
+		},
+	}},
+}
+
+func TestRegisterAddresses(t *testing.T) {
+	r := NewRegistry()
+	assert.EqualError(t, r.RegisterAddresses([]string{"no-address"}), "plugin 'no-address' is not in the name=address format")
+	assert.EqualError(t, r.RegisterAddresses([]string{"=localhost:8080"}), "plugin '=localhost:8080' is not in the name=address format")
+
+	assert.NoError(t, r.RegisterAddresses([]string{"tcp=localhost:8080", "unix=unix:///tmp/plugin.sock"}))
+	_, err := r.Get("tcp")
+	assert.NoError(t, err)
+	_, err = r.Get("unix")
+	assert.NoError(t, err)
+	_, err = r.Get("other")
+	assert.EqualError(t, err, "plugin 'other' is not registered")
+}
+
+func TestDialUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "plugin.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	server.RegisterService(&echoServiceDesc, struct{}{})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	conn, err := Dial("unix://" + socket)
+	assert.NoError(t, err)
+	defer conn.Close()
+	out := new(echo)
+	err = conn.Invoke(context.TODO(), "/test.Echo/Echo", &echo{Message: "hello"}, out)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", out.Message)
+}
@@ -23,7 +23,15 @@ func AtDesiredReplicaCountsForCanary(rollout *v1alpha1.Rollout, newRS, stableRS
 	if newRS == nil || desiredNewRSReplicaCount != *newRS.Spec.Replicas || desiredNewRSReplicaCount != newRS.Status.AvailableReplicas {
 		return false
 	}
-	if stableRS == nil || desiredStableRSReplicaCount != *stableRS.Spec.Replicas || desiredStableRSReplicaCount != stableRS.Status.AvailableReplicas {
+	if useDynamicStableScale(rollout) {
+		// With dynamic stable scaling, the stable is only scaled down after the traffic was shifted
+		// away from it. It only needs enough available replicas to handle the desired weight.
+		rolloutSpecReplica := defaults.GetReplicasOrDefault(rollout.Spec.Replicas)
+		minStableRSReplicaCount := trafficWeightToReplicas(rolloutSpecReplica, 100-desiredTrafficWeight(rollout))
+		if stableRS == nil || stableRS.Status.AvailableReplicas < minStableRSReplicaCount {
+			return false
+		}
+	} else if stableRS == nil || desiredStableRSReplicaCount != *stableRS.Spec.Replicas || desiredStableRSReplicaCount != stableRS.Status.AvailableReplicas {
 		return false
 	}
 	if GetAvailableReplicaCountForReplicaSets(olderRSs) != int32(0) {
@@ -55,8 +63,10 @@ func DesiredReplicaCountsForCanary(rollout *v1alpha1.Rollout, newRS, stableRS *a
 	}
 	// Unlike the ReplicaSet based weighted canary, a service mesh/ingress
 	// based canary leaves the stable as 100% scaled until the rollout completes.
-	if rollout.Spec.Strategy.Canary.TrafficRouting != nil {
+	if rollout.Spec.Strategy.Canary.TrafficRouting != nil && !useDynamicStableScale(rollout) {
 		desiredStableRSReplicaCount = rolloutSpecReplica
+	} else if useDynamicStableScale(rollout) && CheckStableRSExists(newRS, stableRS) {
+		desiredNewRSReplicaCount, desiredStableRSReplicaCount = dynamicStableScaleReplicaCounts(rollout, replicas, weight)
 	}
 
 	return desiredNewRSReplicaCount, desiredStableRSReplicaCount
@@ -100,6 +110,9 @@ func DesiredReplicaCountsForCanary(rollout *v1alpha1.Rollout, newRS, stableRS *a
 func CalculateReplicaCountsForCanary(rollout *v1alpha1.Rollout, newRS *appsv1.ReplicaSet, stableRS *appsv1.ReplicaSet, oldRSs []*appsv1.ReplicaSet) (int32, int32) {
 	rolloutSpecReplica := defaults.GetReplicasOrDefault(rollout.Spec.Replicas)
 	replicas, weight := GetCanaryReplicasOrWeight(rollout)
+	if useDynamicStableScale(rollout) && CheckStableRSExists(newRS, stableRS) {
+		return dynamicStableScaleReplicaCounts(rollout, replicas, weight)
+	}
 	if replicas != nil {
 		return *replicas, rolloutSpecReplica
 	}
@@ -215,6 +228,64 @@ func CalculateReplicaCountsForCanary(rollout *v1alpha1.Rollout, newRS *appsv1.Re
 	return newRSReplicaCount, stableRSReplicaCount
 }
 
+// useDynamicStableScale returns true if the stable of the traffic routed canary is scaled according
+// to the traffic weight it receives
+func useDynamicStableScale(rollout *v1alpha1.Rollout) bool {
+	canary := rollout.Spec.Strategy.Canary
+	return canary != nil && canary.TrafficRouting != nil && canary.DynamicStableScale
+}
+
+// dynamicStableScaleReplicaCounts calculates the replica counts of the new and the stable
+// ReplicaSets of a traffic routed canary with dynamic stable scaling. Each ReplicaSet is kept
+// scaled for the higher of the desired weight and the weight it currently receives according to
+// the weights last set by the traffic routing:
+//
+// * When the canary weight increases, the stable is scaled down only after the traffic was shifted
+//   to the canary.
+// * When the canary weight decreases (e.g. when aborting), the stable is scaled up first, and the
+//   canary is scaled down only after the traffic was shifted back to the stable.
+//
+// If the traffic routing has not set any weight for the current stable yet, the stable is assumed
+// to receive all traffic.
+func dynamicStableScaleReplicaCounts(rollout *v1alpha1.Rollout, canaryReplicas *int32, weight int32) (int32, int32) {
+	rolloutSpecReplica := defaults.GetReplicasOrDefault(rollout.Spec.Replicas)
+	desiredNewRSReplicaCount := trafficWeightToReplicas(rolloutSpecReplica, weight)
+	if canaryReplicas != nil {
+		desiredNewRSReplicaCount = *canaryReplicas
+	}
+	desiredStableRSReplicaCount := trafficWeightToReplicas(rolloutSpecReplica, 100-desiredTrafficWeight(rollout))
+	actualStableWeight, actualCanaryWeight := int32(100), int32(0)
+	if weights := rollout.Status.Canary.Weights; weights != nil && weights.Stable.PodTemplateHash == rollout.Status.StableRS {
+		actualStableWeight, actualCanaryWeight = weights.Stable.Weight, weights.Canary.Weight
+	}
+	desiredStableRSReplicaCount = maxInt32(desiredStableRSReplicaCount, trafficWeightToReplicas(rolloutSpecReplica, actualStableWeight))
+	desiredNewRSReplicaCount = maxInt32(desiredNewRSReplicaCount, trafficWeightToReplicas(rolloutSpecReplica, actualCanaryWeight))
+	return desiredNewRSReplicaCount, desiredStableRSReplicaCount
+}
+
+// desiredTrafficWeight returns the canary weight the traffic routing should set for the current
+// step. Unlike GetCanaryReplicasOrWeight, it ignores setCanaryScale since it only changes the scale
+// of the canary and not the traffic sent to it.
+func desiredTrafficWeight(rollout *v1alpha1.Rollout) int32 {
+	if rollout.Status.PromoteFull {
+		return 100
+	}
+	return GetCurrentSetWeight(rollout)
+}
+
+// trafficWeightToReplicas returns the number of replicas needed to receive the given weight of
+// the traffic
+func trafficWeightToReplicas(replicas, weight int32) int32 {
+	return int32(math.Ceil(float64(replicas) * float64(weight) / 100))
+}
+
+func maxInt32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 // BeforeStartingStep checks if canary rollout is at the starting step
 func BeforeStartingStep(rollout *v1alpha1.Rollout) bool {
 	if rollout.Spec.Strategy.Canary == nil || rollout.Spec.Strategy.Canary.Analysis == nil || rollout.Spec.Strategy.Canary.Analysis.StartingStep == nil {
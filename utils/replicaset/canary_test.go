@@ -596,6 +596,97 @@ func TestCalculateReplicaCountsForCanaryTrafficRouting(t *testing.T) {
 	assert.Equal(t, int32(10), stableRSReplicaCount)
 }
 
+func TestCalculateReplicaCountsForCanaryDynamicStableScale(t *testing.T) {
+	weights := func(canaryWeight int32) *v1alpha1.TrafficWeights {
+		return &v1alpha1.TrafficWeights{
+			Canary: v1alpha1.WeightDestination{Weight: canaryWeight, PodTemplateHash: "canary"},
+			Stable: v1alpha1.WeightDestination{Weight: 100 - canaryWeight, PodTemplateHash: "stable"},
+		}
+	}
+	tests := []struct {
+		name           string
+		setWeight      int32
+		weights        *v1alpha1.TrafficWeights
+		abort          bool
+		expectedCanary int32
+		expectedStable int32
+	}{
+		{
+			name:           "stable is fully scaled before any weight was set",
+			setWeight:      30,
+			expectedCanary: 3,
+			expectedStable: 10,
+		},
+		{
+			name:           "stable is not scaled down before the traffic is shifted",
+			setWeight:      30,
+			weights:        weights(10),
+			expectedCanary: 3,
+			expectedStable: 9,
+		},
+		{
+			name:           "stable is scaled down after the traffic is shifted",
+			setWeight:      30,
+			weights:        weights(30),
+			expectedCanary: 3,
+			expectedStable: 7,
+		},
+		{
+			name:           "stable is scaled up before the traffic is shifted back",
+			setWeight:      20,
+			weights:        weights(50),
+			expectedCanary: 5,
+			expectedStable: 8,
+		},
+		{
+			name:           "canary is not scaled down on abort before the traffic is shifted back",
+			setWeight:      50,
+			weights:        weights(50),
+			abort:          true,
+			expectedCanary: 5,
+			expectedStable: 10,
+		},
+		{
+			name:           "canary is scaled down on abort after the traffic is shifted back",
+			setWeight:      50,
+			weights:        weights(0),
+			abort:          true,
+			expectedCanary: 0,
+			expectedStable: 10,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rollout := newRollout(10, test.setWeight, intstr.FromInt(1), intstr.FromInt(0), "canary", "stable", nil, &v1alpha1.RolloutTrafficRouting{})
+			rollout.Spec.Strategy.Canary.DynamicStableScale = true
+			rollout.Status.Canary.Weights = test.weights
+			rollout.Status.Abort = test.abort
+			stableRS := newRS("stable", 10, 10)
+			canaryRS := newRS("canary", 0, 0)
+			newRSReplicaCount, stableRSReplicaCount := CalculateReplicaCountsForCanary(rollout, canaryRS, stableRS, nil)
+			assert.Equal(t, test.expectedCanary, newRSReplicaCount)
+			assert.Equal(t, test.expectedStable, stableRSReplicaCount)
+			newRSReplicaCount, stableRSReplicaCount = DesiredReplicaCountsForCanary(rollout, canaryRS, stableRS)
+			assert.Equal(t, test.expectedCanary, newRSReplicaCount)
+			assert.Equal(t, test.expectedStable, stableRSReplicaCount)
+		})
+	}
+}
+
+func TestAtDesiredReplicaCountsForCanaryDynamicStableScale(t *testing.T) {
+	rollout := newRollout(10, 50, intstr.FromInt(1), intstr.FromInt(0), "canary", "stable", nil, &v1alpha1.RolloutTrafficRouting{})
+	rollout.Spec.Strategy.Canary.DynamicStableScale = true
+	rollout.Status.Canary.Weights = &v1alpha1.TrafficWeights{
+		Canary: v1alpha1.WeightDestination{Weight: 10, PodTemplateHash: "canary"},
+		Stable: v1alpha1.WeightDestination{Weight: 90, PodTemplateHash: "stable"},
+	}
+	// the stable does not need to be scaled down before the traffic is shifted to the canary
+	assert.True(t, AtDesiredReplicaCountsForCanary(rollout, newRS("canary", 5, 5), newRS("stable", 9, 9), nil))
+	// the stable must be able to handle its desired weight before the traffic is shifted
+	assert.False(t, AtDesiredReplicaCountsForCanary(rollout, newRS("canary", 5, 5), newRS("stable", 9, 4), nil))
+	assert.False(t, AtDesiredReplicaCountsForCanary(rollout, newRS("canary", 5, 3), newRS("stable", 9, 9), nil))
+}
+
 func TestCalculateReplicaCountsForCanaryStableRSdEdgeCases(t *testing.T) {
 	rollout := newRollout(10, 10, intstr.FromInt(0), intstr.FromInt(1), "", "", nil, nil)
 	newRS := newRS("stable", 9, 9)
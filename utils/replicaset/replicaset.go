@@ -27,7 +27,8 @@ import (
 )
 
 // FindNewReplicaSet returns the new RS this given rollout targets from the given list.
-// Returns nil if the ReplicaSet does not exist in the list.
+// Returns nil if the ReplicaSet does not exist in the list. The template of a rollout referencing a
+// workload must be resolved first, see RolloutWithTemplate.
 func FindNewReplicaSet(rollout *v1alpha1.Rollout, rsList []*appsv1.ReplicaSet) *appsv1.ReplicaSet {
 	var newRSList []*appsv1.ReplicaSet
 	for _, rs := range rsList {
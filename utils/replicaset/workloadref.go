@@ -0,0 +1,72 @@
+package replicaset
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/validation"
+)
+
+const (
+	// DeploymentKind is the kind of a Deployment referenced by a rollout
+	DeploymentKind = "Deployment"
+	// PodTemplateKind is the kind of a PodTemplate referenced by a rollout
+	PodTemplateKind = "PodTemplate"
+)
+
+// ResolveWorkloadRef returns the pod template of the Deployment or PodTemplate referenced by the
+// rollout, or nil when the rollout does not reference a workload
+func ResolveWorkloadRef(ro *v1alpha1.Rollout, deploymentLister appslisters.DeploymentLister, podTemplateLister corelisters.PodTemplateLister) (*corev1.PodTemplateSpec, error) {
+	workloadRef := ro.Spec.WorkloadRef
+	if workloadRef == nil {
+		return nil, nil
+	}
+	fldPath := field.NewPath("spec", "workloadRef")
+	if !ro.Spec.EmptyTemplate() {
+		return nil, field.Invalid(field.NewPath("spec", "template"), "<template>", validation.InvalidWorkloadRefTemplateMessage)
+	}
+	switch workloadRef.Kind {
+	case DeploymentKind:
+		deployment, err := deploymentLister.Deployments(ro.Namespace).Get(workloadRef.Name)
+		if err != nil {
+			return nil, field.Invalid(fldPath.Child("name"), workloadRef.Name, err.Error())
+		}
+		return deployment.Spec.Template.DeepCopy(), nil
+	case PodTemplateKind:
+		podTemplate, err := podTemplateLister.PodTemplates(ro.Namespace).Get(workloadRef.Name)
+		if err != nil {
+			return nil, field.Invalid(fldPath.Child("name"), workloadRef.Name, err.Error())
+		}
+		return podTemplate.Template.DeepCopy(), nil
+	default:
+		return nil, field.Invalid(fldPath.Child("kind"), workloadRef.Kind, fmt.Sprintf("workloadRef kind '%s' is not supported", workloadRef.Kind))
+	}
+}
+
+// RolloutWithTemplate returns a copy of the rollout with the template resolved from its workload
+// reference, so that the hashes and the ReplicaSets are computed from the template of the workload.
+// The copy must never be persisted, since the template of the rollout is owned by the workload.
+func RolloutWithTemplate(ro *v1alpha1.Rollout, template *corev1.PodTemplateSpec) *v1alpha1.Rollout {
+	if template == nil {
+		return ro
+	}
+	ro = ro.DeepCopy()
+	ro.Spec.Template = *template.DeepCopy()
+	return ro
+}
+
+// RolloutWithoutTemplate returns a copy of a rollout referencing a workload without the template
+// resolved from the workload, as it has to be persisted
+func RolloutWithoutTemplate(ro *v1alpha1.Rollout) *v1alpha1.Rollout {
+	if ro.Spec.WorkloadRef == nil {
+		return ro
+	}
+	ro = ro.DeepCopy()
+	ro.Spec.Template = corev1.PodTemplateSpec{}
+	return ro
+}
@@ -0,0 +1,76 @@
+package replicaset
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	extensionsobj "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiservervalidation "k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+// validateRolloutSchema validates the rollout, as sent to the API server, against the schema of the
+// Rollout CRD
+func validateRolloutSchema(t *testing.T, ro *v1alpha1.Rollout) field.ErrorList {
+	crdBytes, err := ioutil.ReadFile("../../manifests/crds/rollout-crd.yaml")
+	assert.NoError(t, err)
+	var crd extensionsobj.CustomResourceDefinition
+	assert.NoError(t, yaml.Unmarshal(crdBytes, &crd))
+	var crdValidation apiextensions.CustomResourceValidation
+	err = extensionsobj.Convert_v1beta1_CustomResourceValidation_To_apiextensions_CustomResourceValidation(crd.Spec.Validation, &crdValidation, nil)
+	assert.NoError(t, err)
+	validator, _, err := apiservervalidation.NewSchemaValidator(&crdValidation)
+	assert.NoError(t, err)
+
+	roBytes, err := json.Marshal(ro)
+	assert.NoError(t, err)
+	var obj map[string]interface{}
+	assert.NoError(t, json.Unmarshal(roBytes, &obj))
+	return apiservervalidation.ValidateCustomResource(nil, obj, validator)
+}
+
+func TestRolloutWithoutTemplateMatchesSchema(t *testing.T) {
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"app": "foo"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "foo", Image: "foo:v1"}},
+		},
+	}
+	ro := &v1alpha1.Rollout{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "Rollout",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: v1alpha1.RolloutSpec{
+			Selector:    &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}},
+			WorkloadRef: &v1alpha1.WorkloadRef{APIVersion: "apps/v1", Kind: DeploymentKind, Name: "foo"},
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{},
+			},
+		},
+	}
+	assert.Empty(t, validateRolloutSchema(t, ro))
+
+	resolved := RolloutWithTemplate(ro, &template)
+	assert.Equal(t, template, resolved.Spec.Template)
+	assert.True(t, ro.Spec.EmptyTemplate())
+
+	// the rollout sent to the API server by the controller and the CLI
+	persisted := RolloutWithoutTemplate(resolved)
+	assert.True(t, persisted.Spec.EmptyTemplate())
+	assert.Empty(t, validateRolloutSchema(t, persisted))
+}
@@ -0,0 +1,33 @@
+package trafficrouting
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+// GetTrafficRoutingRollout returns the rollout in the shape expected by the traffic routers. A
+// blue-green rollout is returned as a copy in the shape of a canary rollout, where the preview service
+// receives the canary weight and the active service the stable weight.
+func GetTrafficRoutingRollout(rollout *v1alpha1.Rollout) *v1alpha1.Rollout {
+	blueGreen := rollout.Spec.Strategy.BlueGreen
+	if blueGreen == nil {
+		return rollout
+	}
+	rollout = rollout.DeepCopy()
+	rollout.Spec.Strategy.Canary = &v1alpha1.CanaryStrategy{
+		CanaryService:  blueGreen.PreviewService,
+		StableService:  blueGreen.ActiveService,
+		TrafficRouting: blueGreen.TrafficRouting,
+	}
+	rollout.Spec.Strategy.BlueGreen = nil
+	return rollout
+}
+
+// GetTrafficRoutingFieldPath returns the field path of the traffic routing of the rollout's strategy
+func GetTrafficRoutingFieldPath(rollout *v1alpha1.Rollout) *field.Path {
+	if rollout.Spec.Strategy.BlueGreen != nil {
+		return field.NewPath("spec", "strategy", "blueGreen", "trafficRouting")
+	}
+	return field.NewPath("spec", "strategy", "canary", "trafficRouting")
+}
@@ -0,0 +1,42 @@
+package trafficrouting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func TestGetTrafficRoutingRollout(t *testing.T) {
+	canary := &v1alpha1.Rollout{
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{SMI: &v1alpha1.SMITrafficRouting{}},
+				},
+			},
+		},
+	}
+	assert.Same(t, canary, GetTrafficRoutingRollout(canary))
+	assert.Equal(t, "spec.strategy.canary.trafficRouting", GetTrafficRoutingFieldPath(canary).String())
+
+	blueGreen := &v1alpha1.Rollout{
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				BlueGreen: &v1alpha1.BlueGreenStrategy{
+					ActiveService:  "active",
+					PreviewService: "preview",
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{SMI: &v1alpha1.SMITrafficRouting{}},
+				},
+			},
+		},
+	}
+	ro := GetTrafficRoutingRollout(blueGreen)
+	assert.Nil(t, ro.Spec.Strategy.BlueGreen)
+	assert.Equal(t, "preview", ro.Spec.Strategy.Canary.CanaryService)
+	assert.Equal(t, "active", ro.Spec.Strategy.Canary.StableService)
+	assert.Equal(t, blueGreen.Spec.Strategy.BlueGreen.TrafficRouting, ro.Spec.Strategy.Canary.TrafficRouting)
+	assert.Nil(t, blueGreen.Spec.Strategy.Canary)
+	assert.Equal(t, "spec.strategy.blueGreen.trafficRouting", GetTrafficRoutingFieldPath(blueGreen).String())
+}
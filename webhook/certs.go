@@ -0,0 +1,214 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultServiceName is the default name of the Service in front of the webhook server
+	DefaultServiceName = "argo-rollouts-webhook"
+	// DefaultSecretName is the default name of the Secret storing the certificates of the webhook
+	DefaultSecretName = "argo-rollouts-webhook-certs"
+	// DefaultWebhookConfigurationName is the default name of the ValidatingWebhookConfiguration
+	DefaultWebhookConfigurationName = "argo-rollouts-webhook"
+
+	// certificateValidity is how long the generated certificates are valid
+	certificateValidity = 10 * 365 * 24 * time.Hour
+	// certificateRenewBefore is how long before the expiry of the certificates new ones are generated
+	certificateRenewBefore = 30 * 24 * time.Hour
+)
+
+// CertificateConfig describes where the certificates of the webhook are stored and what they are for
+type CertificateConfig struct {
+	KubeClientSet kubernetes.Interface
+	// Namespace is the namespace of the Secret and the Service of the webhook
+	Namespace                string
+	SecretName               string
+	ServiceName              string
+	WebhookConfigurationName string
+}
+
+// EnsureCertificate returns the serving certificate of the webhook. A self-signed CA and serving
+// certificate are generated and stored in a Secret when they do not exist yet or expire soon, so the
+// webhook works without an external certificate manager. The CA bundle of the webhook configuration is
+// updated to trust the CA.
+func EnsureCertificate(cfg CertificateConfig) (*tls.Certificate, error) {
+	ctx := context.TODO()
+	secrets := cfg.KubeClientSet.CoreV1().Secrets(cfg.Namespace)
+	dnsNames := serviceDNSNames(cfg.ServiceName, cfg.Namespace)
+	secret, err := secrets.Get(ctx, cfg.SecretName, metav1.GetOptions{})
+	exists := err == nil
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+	if !exists || !certificateValid(secret, dnsNames, time.Now()) {
+		caPEM, certPEM, keyPEM, err := generateCertificates(dnsNames, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		data := map[string][]byte{
+			corev1.ServiceAccountRootCAKey: caPEM,
+			corev1.TLSCertKey:              certPEM,
+			corev1.TLSPrivateKeyKey:        keyPEM,
+		}
+		if exists {
+			secret = secret.DeepCopy()
+			secret.Data = data
+			secret, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		} else {
+			secret, err = secrets.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: cfg.SecretName, Namespace: cfg.Namespace},
+				Type:       corev1.SecretTypeTLS,
+				Data:       data,
+			}, metav1.CreateOptions{})
+			if k8serrors.IsAlreadyExists(err) {
+				// another replica generated the certificates first
+				secret, err = secrets.Get(ctx, cfg.SecretName, metav1.GetOptions{})
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("Generated webhook certificates in secret '%s'", cfg.SecretName)
+	}
+	cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, err
+	}
+	if err := updateCABundle(cfg.KubeClientSet, cfg.WebhookConfigurationName, secret.Data[corev1.ServiceAccountRootCAKey]); err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// updateCABundle sets the CA bundle of all the webhooks of the webhook configuration
+func updateCABundle(kubeclientset kubernetes.Interface, name string, caPEM []byte) error {
+	ctx := context.TODO()
+	webhookConfigs := kubeclientset.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+	webhookConfig, err := webhookConfigs.Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		log.Warnf("ValidatingWebhookConfiguration '%s' not found, skipping CA bundle update", name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	modified := false
+	webhookConfig = webhookConfig.DeepCopy()
+	for i := range webhookConfig.Webhooks {
+		if !bytes.Equal(webhookConfig.Webhooks[i].ClientConfig.CABundle, caPEM) {
+			webhookConfig.Webhooks[i].ClientConfig.CABundle = caPEM
+			modified = true
+		}
+	}
+	if !modified {
+		return nil
+	}
+	_, err = webhookConfigs.Update(ctx, webhookConfig, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	log.Infof("Updated CA bundle of ValidatingWebhookConfiguration '%s'", name)
+	return nil
+}
+
+func serviceDNSNames(service, namespace string) []string {
+	return []string{
+		service,
+		fmt.Sprintf("%s.%s", service, namespace),
+		fmt.Sprintf("%s.%s.svc", service, namespace),
+	}
+}
+
+// certificateValid returns true if the secret holds a serving certificate for all the DNS names
+// which does not expire soon
+func certificateValid(secret *corev1.Secret, dnsNames []string, now time.Time) bool {
+	if len(secret.Data[corev1.ServiceAccountRootCAKey]) == 0 || len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+		return false
+	}
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	if now.Add(certificateRenewBefore).After(cert.NotAfter) {
+		return false
+	}
+	for _, name := range dnsNames {
+		if cert.VerifyHostname(name) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// generateCertificates returns a self-signed CA and a serving certificate and key signed by the CA
+func generateCertificates(dnsNames []string, now time.Time) ([]byte, []byte, []byte, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "argo-rollouts-webhook-ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(certificateValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: dnsNames[len(dnsNames)-1]},
+		DNSNames:     dnsNames,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(certificateValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return caPEM, certPEM, keyPEM, nil
+}
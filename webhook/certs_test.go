@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newCertificateConfig(client *k8sfake.Clientset) CertificateConfig {
+	return CertificateConfig{
+		KubeClientSet:            client,
+		Namespace:                "argo-rollouts",
+		SecretName:               DefaultSecretName,
+		ServiceName:              DefaultServiceName,
+		WebhookConfigurationName: DefaultWebhookConfigurationName,
+	}
+}
+
+func TestEnsureCertificate(t *testing.T) {
+	webhookConfig := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultWebhookConfigurationName},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{Name: "rollouts.argoproj.io"},
+			{Name: "analysistemplates.argoproj.io"},
+		},
+	}
+	client := k8sfake.NewSimpleClientset(webhookConfig)
+	cfg := newCertificateConfig(client)
+
+	cert, err := EnsureCertificate(cfg)
+	assert.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	assert.NoError(t, leaf.VerifyHostname("argo-rollouts-webhook.argo-rollouts.svc"))
+
+	secret, err := client.CoreV1().Secrets("argo-rollouts").Get(context.TODO(), DefaultSecretName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	caPEM := secret.Data[corev1.ServiceAccountRootCAKey]
+	roots := x509.NewCertPool()
+	assert.True(t, roots.AppendCertsFromPEM(caPEM))
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: roots, DNSName: "argo-rollouts-webhook.argo-rollouts.svc"})
+	assert.NoError(t, err)
+
+	updated, err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(context.TODO(), DefaultWebhookConfigurationName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	for _, webhook := range updated.Webhooks {
+		assert.Equal(t, caPEM, webhook.ClientConfig.CABundle)
+	}
+
+	// the certificates stored in the secret are reused
+	again, err := EnsureCertificate(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, cert.Certificate, again.Certificate)
+}
+
+func TestEnsureCertificateRegeneratesInvalidCertificate(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultSecretName, Namespace: "argo-rollouts"},
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("not a certificate")},
+	}
+	client := k8sfake.NewSimpleClientset(secret)
+	cert, err := EnsureCertificate(newCertificateConfig(client))
+	assert.NoError(t, err)
+	assert.NotNil(t, cert)
+
+	secret, err = client.CoreV1().Secrets("argo-rollouts").Get(context.TODO(), DefaultSecretName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, secret.Data[corev1.ServiceAccountRootCAKey])
+}
+
+func TestCertificateValid(t *testing.T) {
+	dnsNames := serviceDNSNames(DefaultServiceName, "argo-rollouts")
+	now := time.Now()
+	caPEM, certPEM, keyPEM, err := generateCertificates(dnsNames, now)
+	assert.NoError(t, err)
+	secret := &corev1.Secret{
+		Data: map[string][]byte{
+			corev1.ServiceAccountRootCAKey: caPEM,
+			corev1.TLSCertKey:              certPEM,
+			corev1.TLSPrivateKeyKey:        keyPEM,
+		},
+	}
+	assert.True(t, certificateValid(secret, dnsNames, now))
+	// expires soon
+	assert.False(t, certificateValid(secret, dnsNames, now.Add(certificateValidity-certificateRenewBefore/2)))
+	// issued for another service
+	assert.False(t, certificateValid(secret, serviceDNSNames("other", "argo-rollouts"), now))
+}
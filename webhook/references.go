@@ -0,0 +1,208 @@
+package webhook
+
+import (
+	"context"
+
+	"k8s.io/api/extensions/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/validation"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/gatewayapi"
+	istioutil "github.com/argoproj/argo-rollouts/utils/istio"
+	trafficroutingutil "github.com/argoproj/argo-rollouts/utils/trafficrouting"
+)
+
+// getReferencedResources returns the resources referenced by the rollout which already exist.
+// Unlike the controller, the webhook admits rollouts referencing missing resources, since
+// they are commonly applied together with the rollout in no particular order.
+func (v *validator) getReferencedResources(ro *v1alpha1.Rollout) (*validation.ReferencedResources, error) {
+	refResources := validation.ReferencedResources{}
+	services, err := v.getReferencedServices(ro)
+	if err != nil {
+		return nil, err
+	}
+	refResources.ServiceWithType = services
+
+	analysisTemplates, err := v.getReferencedRolloutAnalyses(ro)
+	if err != nil {
+		return nil, err
+	}
+	refResources.AnalysisTemplateWithType = analysisTemplates
+
+	ingresses, err := v.getReferencedIngresses(ro)
+	if err != nil {
+		return nil, err
+	}
+	refResources.Ingresses = ingresses
+
+	virtualServices, err := v.getReferencedVirtualServices(ro)
+	if err != nil {
+		return nil, err
+	}
+	refResources.VirtualServices = virtualServices
+
+	httpRoutes, err := v.getReferencedHTTPRoutes(ro)
+	if err != nil {
+		return nil, err
+	}
+	refResources.HTTPRoutes = httpRoutes
+	return &refResources, nil
+}
+
+func (v *validator) getReferencedServices(ro *v1alpha1.Rollout) ([]validation.ServiceWithType, error) {
+	serviceNames := map[validation.ServiceType]string{}
+	if ro.Spec.Strategy.BlueGreen != nil {
+		serviceNames[validation.ActiveService] = ro.Spec.Strategy.BlueGreen.ActiveService
+		serviceNames[validation.PreviewService] = ro.Spec.Strategy.BlueGreen.PreviewService
+	} else if ro.Spec.Strategy.Canary != nil {
+		serviceNames[validation.StableService] = ro.Spec.Strategy.Canary.StableService
+		serviceNames[validation.CanaryService] = ro.Spec.Strategy.Canary.CanaryService
+	}
+	services := []validation.ServiceWithType{}
+	for _, serviceType := range []validation.ServiceType{validation.ActiveService, validation.PreviewService, validation.StableService, validation.CanaryService} {
+		name := serviceNames[serviceType]
+		if name == "" {
+			continue
+		}
+		svc, err := v.servicesLister.Services(ro.Namespace).Get(name)
+		if k8serrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, validation.ServiceWithType{Service: svc, Type: serviceType})
+	}
+	return services, nil
+}
+
+func (v *validator) getReferencedRolloutAnalyses(ro *v1alpha1.Rollout) ([]validation.AnalysisTemplateWithType, error) {
+	analysisTemplates := []validation.AnalysisTemplateWithType{}
+	add := func(rolloutAnalysis *v1alpha1.RolloutAnalysis, templateType validation.AnalysisTemplateType, canaryStepIndex int) error {
+		if rolloutAnalysis == nil {
+			return nil
+		}
+		for i, template := range rolloutAnalysis.Templates {
+			analysisTemplate, err := v.getReferencedAnalysisTemplate(ro, template, templateType, i, canaryStepIndex)
+			if err != nil {
+				return err
+			}
+			if analysisTemplate != nil {
+				analysisTemplates = append(analysisTemplates, *analysisTemplate)
+			}
+		}
+		return nil
+	}
+	if blueGreen := ro.Spec.Strategy.BlueGreen; blueGreen != nil {
+		if err := add(blueGreen.PrePromotionAnalysis, validation.PrePromotionAnalysis, 0); err != nil {
+			return nil, err
+		}
+		if err := add(blueGreen.PostPromotionAnalysis, validation.PostPromotionAnalysis, 0); err != nil {
+			return nil, err
+		}
+	} else if canary := ro.Spec.Strategy.Canary; canary != nil {
+		for i, step := range canary.Steps {
+			if err := add(step.Analysis, validation.InlineAnalysis, i); err != nil {
+				return nil, err
+			}
+		}
+		if canary.Analysis != nil {
+			if err := add(&canary.Analysis.RolloutAnalysis, validation.BackgroundAnalysis, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return analysisTemplates, nil
+}
+
+func (v *validator) getReferencedAnalysisTemplate(ro *v1alpha1.Rollout, template v1alpha1.RolloutAnalysisTemplate, templateType validation.AnalysisTemplateType, analysisIndex int, canaryStepIndex int) (*validation.AnalysisTemplateWithType, error) {
+	if template.ClusterScope {
+		clusterAnalysisTemplate, err := v.clusterAnalysisTemplateLister.Get(template.TemplateName)
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &validation.AnalysisTemplateWithType{
+			ClusterAnalysisTemplate: clusterAnalysisTemplate,
+			TemplateType:            templateType,
+			AnalysisIndex:           analysisIndex,
+			CanaryStepIndex:         canaryStepIndex,
+		}, nil
+	}
+	analysisTemplate, err := v.analysisTemplateLister.AnalysisTemplates(ro.Namespace).Get(template.TemplateName)
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &validation.AnalysisTemplateWithType{
+		AnalysisTemplate: analysisTemplate,
+		TemplateType:     templateType,
+		AnalysisIndex:    analysisIndex,
+		CanaryStepIndex:  canaryStepIndex,
+	}, nil
+}
+
+func (v *validator) getReferencedIngresses(ro *v1alpha1.Rollout) ([]v1beta1.Ingress, error) {
+	ingresses := []v1beta1.Ingress{}
+	canary := trafficroutingutil.GetTrafficRoutingRollout(ro).Spec.Strategy.Canary
+	if canary == nil || canary.TrafficRouting == nil {
+		return ingresses, nil
+	}
+	var name string
+	if canary.TrafficRouting.ALB != nil {
+		name = canary.TrafficRouting.ALB.Ingress
+	} else if canary.TrafficRouting.Nginx != nil {
+		name = canary.TrafficRouting.Nginx.StableIngress
+	} else {
+		return ingresses, nil
+	}
+	ingress, err := v.ingressesLister.Ingresses(ro.Namespace).Get(name)
+	if k8serrors.IsNotFound(err) {
+		return ingresses, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(ingresses, *ingress), nil
+}
+
+func (v *validator) getReferencedVirtualServices(ro *v1alpha1.Rollout) ([]unstructured.Unstructured, error) {
+	virtualServices := []unstructured.Unstructured{}
+	canary := trafficroutingutil.GetTrafficRoutingRollout(ro).Spec.Strategy.Canary
+	if canary == nil || canary.TrafficRouting == nil || canary.TrafficRouting.Istio == nil {
+		return virtualServices, nil
+	}
+	vsvcName := canary.TrafficRouting.Istio.VirtualService.Name
+	vsvc, err := v.dynamicclientset.Resource(istioutil.GetIstioGVR(v.defaultIstioVersion)).Namespace(ro.Namespace).Get(context.TODO(), vsvcName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return virtualServices, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(virtualServices, *vsvc), nil
+}
+
+func (v *validator) getReferencedHTTPRoutes(ro *v1alpha1.Rollout) ([]unstructured.Unstructured, error) {
+	httpRoutes := []unstructured.Unstructured{}
+	canary := trafficroutingutil.GetTrafficRoutingRollout(ro).Spec.Strategy.Canary
+	if canary == nil || canary.TrafficRouting == nil || canary.TrafficRouting.GatewayAPI == nil {
+		return httpRoutes, nil
+	}
+	routeName := canary.TrafficRouting.GatewayAPI.HTTPRoute
+	route, err := v.dynamicclientset.Resource(gatewayapi.GetHTTPRouteGVR()).Namespace(ro.Namespace).Get(context.TODO(), routeName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return httpRoutes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(httpRoutes, *route), nil
+}
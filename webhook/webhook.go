@@ -0,0 +1,225 @@
+package webhook
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+
+	log "github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/dynamic"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	extensionslisters "k8s.io/client-go/listers/extensions/v1beta1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts"
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/validation"
+	listers "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+)
+
+const (
+	// ValidatePath is the endpoint the API server sends admission reviews to
+	ValidatePath = "/validate"
+	// DefaultPort is the default port the webhook server listens on
+	DefaultPort = 8443
+)
+
+// ServerConfig describes the data required to instantiate a new webhook Server
+type ServerConfig struct {
+	Addr                          string
+	Certificate                   *tls.Certificate
+	DynamicClientSet              dynamic.Interface
+	ServicesLister                corelisters.ServiceLister
+	IngressesLister               extensionslisters.IngressLister
+	AnalysisTemplateLister        listers.AnalysisTemplateLister
+	ClusterAnalysisTemplateLister listers.ClusterAnalysisTemplateLister
+	DefaultIstioVersion           string
+}
+
+// Server is a validating admission webhook which rejects invalid Rollouts, AnalysisTemplates,
+// ClusterAnalysisTemplates and Experiments before they are persisted
+type Server struct {
+	*http.Server
+	validator *validator
+}
+
+// NewServer returns a new webhook server
+func NewServer(cfg ServerConfig) *Server {
+	v := &validator{
+		dynamicclientset:              cfg.DynamicClientSet,
+		servicesLister:                cfg.ServicesLister,
+		ingressesLister:               cfg.IngressesLister,
+		analysisTemplateLister:        cfg.AnalysisTemplateLister,
+		clusterAnalysisTemplateLister: cfg.ClusterAnalysisTemplateLister,
+		defaultIstioVersion:           cfg.DefaultIstioVersion,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(ValidatePath, v.serveValidate)
+	return &Server{
+		Server: &http.Server{
+			Addr:    cfg.Addr,
+			Handler: mux,
+			TLSConfig: &tls.Config{
+				Certificates: []tls.Certificate{*cfg.Certificate},
+			},
+		},
+		validator: v,
+	}
+}
+
+// ListenAndServe serves the webhook over TLS with the configured certificate
+func (s *Server) ListenAndServe() error {
+	return s.Server.ListenAndServeTLS("", "")
+}
+
+type validator struct {
+	dynamicclientset              dynamic.Interface
+	servicesLister                corelisters.ServiceLister
+	ingressesLister               extensionslisters.IngressLister
+	analysisTemplateLister        listers.AnalysisTemplateLister
+	clusterAnalysisTemplateLister listers.ClusterAnalysisTemplateLister
+	defaultIstioVersion           string
+}
+
+func (v *validator) serveValidate(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var review admissionv1.AdmissionReview
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, "failed to decode admission review", http.StatusBadRequest)
+		return
+	}
+	review.Response = v.review(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+	resp, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resp)
+}
+
+// review decides whether the object of an admission request is admitted
+func (v *validator) review(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	logCtx := log.WithField("namespace", req.Namespace).WithField("name", req.Name).WithField("kind", req.Kind.Kind)
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return allowed()
+	}
+	if req.Kind.Group != rollouts.Group {
+		return allowed()
+	}
+	allErrs, err := v.validate(req)
+	if err != nil {
+		logCtx.Warnf("Failed to validate object: %v", err)
+		return denied(fmt.Sprintf("failed to decode %s: %v", req.Kind.Kind, err))
+	}
+	if len(allErrs) > 0 {
+		logCtx.Infof("Rejected invalid %s: %v", req.Kind.Kind, allErrs.ToAggregate())
+		return denied(fmt.Sprintf("%s \"%s\" is invalid: %s", req.Kind.Kind, req.Name, allErrs.ToAggregate().Error()))
+	}
+	return allowed()
+}
+
+func (v *validator) validate(req *admissionv1.AdmissionRequest) (field.ErrorList, error) {
+	switch req.Kind.Kind {
+	case rollouts.RolloutKind:
+		var ro, oldRo v1alpha1.Rollout
+		if skip, err := decode(req, &ro, &oldRo, &ro.ObjectMeta, &ro.Spec, &oldRo.Spec); skip || err != nil {
+			return nil, err
+		}
+		return v.validateRollout(&ro), nil
+	case rollouts.AnalysisTemplateKind:
+		var at, oldAt v1alpha1.AnalysisTemplate
+		if skip, err := decode(req, &at, &oldAt, &at.ObjectMeta, &at.Spec, &oldAt.Spec); skip || err != nil {
+			return nil, err
+		}
+		return validateMetrics(at.Spec.Metrics), nil
+	case rollouts.ClusterAnalysisTemplateKind:
+		var cat, oldCat v1alpha1.ClusterAnalysisTemplate
+		if skip, err := decode(req, &cat, &oldCat, &cat.ObjectMeta, &cat.Spec, &oldCat.Spec); skip || err != nil {
+			return nil, err
+		}
+		return validateMetrics(cat.Spec.Metrics), nil
+	case rollouts.ExperimentKind:
+		var ex, oldEx v1alpha1.Experiment
+		if skip, err := decode(req, &ex, &oldEx, &ex.ObjectMeta, &ex.Spec, &oldEx.Spec); skip || err != nil {
+			return nil, err
+		}
+		return validateExperiment(&ex), nil
+	}
+	return nil, nil
+}
+
+// decode unmarshals the object of the request. Validation is skipped for objects which are being
+// deleted and for updates which do not change the spec, so the controller can always update the
+// metadata of existing objects and remove their finalizers.
+func decode(req *admissionv1.AdmissionRequest, obj, oldObj interface{}, meta *metav1.ObjectMeta, spec, oldSpec interface{}) (bool, error) {
+	if err := json.Unmarshal(req.Object.Raw, obj); err != nil {
+		return false, err
+	}
+	if meta.DeletionTimestamp != nil {
+		return true, nil
+	}
+	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
+		if err := json.Unmarshal(req.OldObject.Raw, oldObj); err != nil {
+			return false, err
+		}
+		if reflect.DeepEqual(spec, oldSpec) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (v *validator) validateRollout(ro *v1alpha1.Rollout) field.ErrorList {
+	allErrs := validation.ValidateRollout(ro)
+	refResources, err := v.getReferencedResources(ro)
+	if err != nil {
+		return append(allErrs, field.InternalError(field.NewPath("spec"), err))
+	}
+	return append(allErrs, validation.ValidateRolloutReferencedResources(ro, *refResources)...)
+}
+
+func validateMetrics(metrics []v1alpha1.Metric) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if err := analysisutil.ValidateMetrics(metrics); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "metrics"), "<metrics>", err.Error()))
+	}
+	return allErrs
+}
+
+func validateExperiment(ex *v1alpha1.Experiment) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if cond := conditions.VerifyExperimentSpec(ex, nil); cond != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec"), "<spec>", cond.Message))
+	}
+	return allErrs
+}
+
+func allowed() *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+func denied(message string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: message,
+			Code:    http.StatusUnprocessableEntity,
+		},
+	}
+}
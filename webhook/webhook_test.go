@@ -0,0 +1,291 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
+	informers "github.com/argoproj/argo-rollouts/pkg/client/informers/externalversions"
+)
+
+func newValidator(objects ...runtime.Object) *validator {
+	k8sI := kubeinformers.NewSharedInformerFactory(k8sfake.NewSimpleClientset(), 0)
+	i := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case *corev1.Service:
+			_ = k8sI.Core().V1().Services().Informer().GetIndexer().Add(o)
+		case *v1alpha1.AnalysisTemplate:
+			_ = i.Argoproj().V1alpha1().AnalysisTemplates().Informer().GetIndexer().Add(o)
+		}
+	}
+	return &validator{
+		dynamicclientset:              dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+		servicesLister:                k8sI.Core().V1().Services().Lister(),
+		ingressesLister:               k8sI.Extensions().V1beta1().Ingresses().Lister(),
+		analysisTemplateLister:        i.Argoproj().V1alpha1().AnalysisTemplates().Lister(),
+		clusterAnalysisTemplateLister: i.Argoproj().V1alpha1().ClusterAnalysisTemplates().Lister(),
+		defaultIstioVersion:           "v1alpha3",
+	}
+}
+
+func newRollout() *v1alpha1.Rollout {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "guestbook"}}
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "guestbook", Namespace: metav1.NamespaceDefault},
+		Spec: v1alpha1.RolloutSpec{
+			Selector: selector,
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					StableService: "stable",
+					CanaryService: "canary",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: selector.MatchLabels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "guestbook", Image: "argoproj/rollouts-demo:blue"}},
+				},
+			},
+		},
+	}
+}
+
+func newRequest(operation admissionv1.Operation, kind string, obj, oldObj interface{}) *admissionv1.AdmissionRequest {
+	req := &admissionv1.AdmissionRequest{
+		UID:       types.UID("uid"),
+		Kind:      metav1.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: kind},
+		Name:      "guestbook",
+		Namespace: metav1.NamespaceDefault,
+		Operation: operation,
+	}
+	req.Object.Raw, _ = json.Marshal(obj)
+	if oldObj != nil {
+		req.OldObject.Raw, _ = json.Marshal(oldObj)
+	}
+	return req
+}
+
+func TestReviewRollout(t *testing.T) {
+	v := newValidator()
+	ro := newRollout()
+	resp := v.review(newRequest(admissionv1.Create, "Rollout", ro, nil))
+	assert.True(t, resp.Allowed)
+
+	ro.Spec.Selector = nil
+	resp = v.review(newRequest(admissionv1.Create, "Rollout", ro, nil))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, "Rollout \"guestbook\" is invalid")
+	assert.Contains(t, resp.Result.Message, "spec.selector")
+}
+
+func TestReviewRolloutReferencedResources(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "stable",
+			Namespace:   metav1.NamespaceDefault,
+			Annotations: map[string]string{v1alpha1.ManagedByRolloutsKey: "another-rollout"},
+		},
+	}
+	v := newValidator(svc)
+	resp := v.review(newRequest(admissionv1.Create, "Rollout", newRollout(), nil))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, "spec.strategy.canary.stableService")
+
+	// a missing service is allowed since it might be applied after the rollout
+	v = newValidator()
+	resp = v.review(newRequest(admissionv1.Create, "Rollout", newRollout(), nil))
+	assert.True(t, resp.Allowed)
+}
+
+func TestReviewRolloutHTTPRoute(t *testing.T) {
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"backendRefs": []interface{}{
+						map[string]interface{}{"name": "stable", "port": int64(80)},
+					},
+				},
+			},
+		},
+	}}
+	route.SetAPIVersion("gateway.networking.k8s.io/v1beta1")
+	route.SetKind("HTTPRoute")
+	route.SetName("httproute")
+	route.SetNamespace(metav1.NamespaceDefault)
+	ro := newRollout()
+	ro.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+		GatewayAPI: &v1alpha1.GatewayAPITrafficRouting{HTTPRoute: "httproute"},
+	}
+
+	v := newValidator()
+	resp := v.review(newRequest(admissionv1.Create, "Rollout", ro, nil))
+	assert.True(t, resp.Allowed)
+
+	v.dynamicclientset = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), route)
+	resp = v.review(newRequest(admissionv1.Create, "Rollout", ro, nil))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, "spec.strategy.canary.trafficRouting.gatewayAPI.httpRoute")
+	assert.Contains(t, resp.Result.Message, "Canary Service 'canary' not found in HTTPRoute rule 0")
+}
+
+func TestReviewBlueGreenRolloutHTTPRoute(t *testing.T) {
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"backendRefs": []interface{}{
+						map[string]interface{}{"name": "active", "port": int64(80)},
+					},
+				},
+			},
+		},
+	}}
+	route.SetAPIVersion("gateway.networking.k8s.io/v1beta1")
+	route.SetKind("HTTPRoute")
+	route.SetName("httproute")
+	route.SetNamespace(metav1.NamespaceDefault)
+	ro := newRollout()
+	ro.Spec.Strategy.Canary = nil
+	ro.Spec.Strategy.BlueGreen = &v1alpha1.BlueGreenStrategy{
+		ActiveService:  "active",
+		PreviewService: "preview",
+		TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+			GatewayAPI: &v1alpha1.GatewayAPITrafficRouting{HTTPRoute: "httproute"},
+		},
+	}
+
+	v := newValidator()
+	v.dynamicclientset = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), route)
+	resp := v.review(newRequest(admissionv1.Create, "Rollout", ro, nil))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, "spec.strategy.blueGreen.trafficRouting.gatewayAPI.httpRoute")
+	assert.Contains(t, resp.Result.Message, "Canary Service 'preview' not found in HTTPRoute rule 0")
+}
+
+func TestReviewRolloutAnalysisTemplate(t *testing.T) {
+	at := &v1alpha1.AnalysisTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "success-rate", Namespace: metav1.NamespaceDefault},
+		Spec: v1alpha1.AnalysisTemplateSpec{
+			Metrics: []v1alpha1.Metric{{Name: "success-rate", Interval: "5m"}},
+		},
+	}
+	v := newValidator(at)
+	ro := newRollout()
+	ro.Spec.Strategy.Canary.Steps = []v1alpha1.CanaryStep{{
+		Analysis: &v1alpha1.RolloutAnalysis{
+			Templates: []v1alpha1.RolloutAnalysisTemplate{{TemplateName: "success-rate"}},
+		},
+	}}
+	resp := v.review(newRequest(admissionv1.Create, "Rollout", ro, nil))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, "runs indefinitely")
+}
+
+func TestReviewSkipsUnchangedSpec(t *testing.T) {
+	v := newValidator()
+	ro := newRollout()
+	ro.Spec.Selector = nil
+	updated := ro.DeepCopy()
+	updated.Annotations = map[string]string{"rollout.argoproj.io/revision": "2"}
+	resp := v.review(newRequest(admissionv1.Update, "Rollout", updated, ro))
+	assert.True(t, resp.Allowed)
+
+	updated.Spec.Replicas = nil
+	updated.Spec.MinReadySeconds = 10
+	resp = v.review(newRequest(admissionv1.Update, "Rollout", updated, ro))
+	assert.False(t, resp.Allowed)
+
+	resp = v.review(newRequest(admissionv1.Delete, "Rollout", nil, ro))
+	assert.True(t, resp.Allowed)
+}
+
+func TestReviewAnalysisTemplate(t *testing.T) {
+	v := newValidator()
+	at := &v1alpha1.AnalysisTemplate{
+		Spec: v1alpha1.AnalysisTemplateSpec{
+			Metrics: []v1alpha1.Metric{{
+				Name:     "success-rate",
+				Count:    intstrPtr(1),
+				Provider: v1alpha1.MetricProvider{Prometheus: &v1alpha1.PrometheusMetric{}},
+			}},
+		},
+	}
+	resp := v.review(newRequest(admissionv1.Create, "AnalysisTemplate", at, nil))
+	assert.True(t, resp.Allowed)
+
+	at.Spec.Metrics = nil
+	resp = v.review(newRequest(admissionv1.Create, "ClusterAnalysisTemplate", at, nil))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, "no metrics specified")
+}
+
+func TestReviewExperiment(t *testing.T) {
+	v := newValidator()
+	ex := &v1alpha1.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "guestbook"},
+		Spec: v1alpha1.ExperimentSpec{
+			Templates: []v1alpha1.TemplateSpec{
+				{Name: "baseline", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "baseline"}}},
+				{Name: "baseline", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "canary"}}},
+			},
+		},
+	}
+	resp := v.review(newRequest(admissionv1.Create, "Experiment", ex, nil))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, "baseline")
+
+	ex.Spec.Templates[1].Name = "canary"
+	resp = v.review(newRequest(admissionv1.Create, "Experiment", ex, nil))
+	assert.True(t, resp.Allowed)
+}
+
+func TestServeValidate(t *testing.T) {
+	v := newValidator()
+	server := httptest.NewServer(http.HandlerFunc(v.serveValidate))
+	defer server.Close()
+
+	ro := newRollout()
+	ro.Spec.Selector = nil
+	review := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request:  newRequest(admissionv1.Create, "Rollout", ro, nil),
+	}
+	body, _ := json.Marshal(review)
+	resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result admissionv1.AdmissionReview
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "AdmissionReview", result.Kind)
+	assert.Equal(t, types.UID("uid"), result.Response.UID)
+	assert.False(t, result.Response.Allowed)
+
+	resp, err = http.Post(server.URL, "application/json", bytes.NewReader([]byte("{")))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func intstrPtr(i int) *intstr.IntOrString {
+	v := intstr.FromInt(i)
+	return &v
+}